@@ -0,0 +1,24 @@
+#!/bin/sh
+# Runs the datastore benchmarks and writes CPU and memory profiles.
+# Usage: ./bench.sh [output-dir] [benchmark-regexp]
+set -e
+# datastore has no go.mod, so build it in GOPATH mode
+export GO111MODULE=off
+
+out=${1:-bench-out}
+pattern=${2:-.}
+mkdir -p "$out"
+
+cd "$(dirname "$0")/datastore"
+case "$out" in
+/*) ;;
+*) out="$OLDPWD/$out" ;;
+esac
+
+go test -run '^$' -bench "$pattern" -benchmem \
+	-cpuprofile "$out/cpu.prof" \
+	-memprofile "$out/mem.prof" \
+	-o "$out/datastore.test" \
+	. | tee "$out/bench.txt"
+
+echo "profiles written to $out (inspect with: go tool pprof $out/datastore.test $out/cpu.prof)"
@@ -0,0 +1,94 @@
+package datastore
+
+import (
+	"fmt"
+	"path"
+	"sync"
+)
+
+var ErrForbidden = fmt.Errorf("access denied")
+
+type Permission int
+
+const (
+	READ_PERMISSION Permission = 1 << iota
+	WRITE_PERMISSION
+	DELETE_PERMISSION
+)
+
+type aclRule struct {
+	namespace string
+	pattern   string
+	perm      Permission
+}
+
+type ACL struct {
+	mu    sync.RWMutex
+	rules []aclRule
+}
+
+func NewACL() *ACL {
+	return &ACL{}
+}
+
+// Allow grants perm on keys matching pattern (path.Match syntax) to namespace.
+// A malformed pattern matches no keys.
+func (acl *ACL) Allow(namespace, pattern string, perm Permission) *ACL {
+	acl.mu.Lock()
+	defer acl.mu.Unlock()
+	acl.rules = append(acl.rules, aclRule{namespace, pattern, perm})
+	return acl
+}
+
+func (acl *ACL) Allowed(namespace, key string, perm Permission) bool {
+	acl.mu.RLock()
+	defer acl.mu.RUnlock()
+	//права з кількох правил для одного ключа додаються
+	var granted Permission
+	for _, rule := range acl.rules {
+		if rule.namespace != namespace {
+			continue
+		}
+		if ok, err := path.Match(rule.pattern, key); ok && err == nil {
+			granted |= rule.perm
+		}
+	}
+	return granted&perm == perm
+}
+
+type ACLStore struct {
+	store Store
+	acl   *ACL
+}
+
+func NewACLStore(store Store, acl *ACL) *ACLStore {
+	return &ACLStore{store: store, acl: acl}
+}
+
+func (s *ACLStore) Get(namespace, key string) (string, error) {
+	if !s.acl.Allowed(namespace, key, READ_PERMISSION) {
+		return "", ErrForbidden
+	}
+	return s.store.Get(key)
+}
+
+func (s *ACLStore) Put(namespace, key, value string) error {
+	if !s.acl.Allowed(namespace, key, WRITE_PERMISSION) {
+		return ErrForbidden
+	}
+	return s.store.Put(key, value)
+}
+
+func (s *ACLStore) GetInt64(namespace, key string) (int64, error) {
+	if !s.acl.Allowed(namespace, key, READ_PERMISSION) {
+		return 0, ErrForbidden
+	}
+	return s.store.GetInt64(key)
+}
+
+func (s *ACLStore) PutInt64(namespace, key string, value int64) error {
+	if !s.acl.Allowed(namespace, key, WRITE_PERMISSION) {
+		return ErrForbidden
+	}
+	return s.store.PutInt64(key, value)
+}
@@ -0,0 +1,47 @@
+package datastore
+
+import (
+	"testing"
+)
+
+func TestACLStore(t *testing.T) {
+	store := &mockStore{}
+	if err := store.Put("user:1", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	acl := NewACL().
+		Allow("alice", "user:*", READ_PERMISSION).
+		Allow("admin", "*", READ_PERMISSION|WRITE_PERMISSION).
+		Allow("bob", "[bad", READ_PERMISSION)
+	s := NewACLStore(store, acl)
+
+	if value, err := s.Get("alice", "user:1"); err != nil || value != "alice" {
+		t.Errorf("Expected read to succeed, got %q, %v", value, err)
+	}
+	if err := s.Put("alice", "user:1", "mallory"); err != ErrForbidden {
+		t.Errorf("Expected ErrForbidden for write, got %v", err)
+	}
+	if _, err := s.GetInt64("alice", "order:1"); err != ErrForbidden {
+		t.Errorf("Expected ErrForbidden outside the pattern, got %v", err)
+	}
+	if _, err := s.Get("bob", "[bad"); err != ErrForbidden {
+		t.Errorf("Expected malformed pattern to match nothing, got %v", err)
+	}
+	if _, err := s.Get("carol", "user:1"); err != ErrForbidden {
+		t.Errorf("Expected ErrForbidden for unknown namespace, got %v", err)
+	}
+	if err := s.PutInt64("admin", "order:1", 5); err != nil {
+		t.Errorf("Expected admin write to succeed, got %v", err)
+	}
+	if store.calls != 3 {
+		t.Errorf("Expected denied operations not to reach the store, got %d calls", store.calls)
+	}
+
+	if acl.Allowed("alice", "user:1", READ_PERMISSION|WRITE_PERMISSION) {
+		t.Error("Expected combined permission to require every bit")
+	}
+	acl.Allow("alice", "user:1", WRITE_PERMISSION)
+	if !acl.Allowed("alice", "user:1", READ_PERMISSION|WRITE_PERMISSION) {
+		t.Error("Expected permissions from several rules to add up")
+	}
+}
@@ -0,0 +1,118 @@
+package datastore
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type AggregateResult struct {
+	Sum   int64
+	Min   int64
+	Max   int64
+	Avg   float64
+	Count int
+}
+
+func (r *AggregateResult) add(n int64) {
+	if r.Count == 0 || n < r.Min {
+		r.Min = n
+	}
+	if r.Count == 0 || n > r.Max {
+		r.Max = n
+	}
+	r.Sum += n
+	r.Count++
+	r.Avg = float64(r.Sum) / float64(r.Count)
+}
+
+func Aggregate(db *Db, pattern string) (*AggregateResult, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	entries, err := db.entries()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &AggregateResult{}
+	for _, e := range entries {
+		if e.valueType != INT64_TYPE {
+			continue
+		}
+		if ok, _ := path.Match(pattern, e.key); !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(e.value, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		res.add(n)
+	}
+	return res, nil
+}
+
+func GroupBy(db *Db, separator string) (map[string]*AggregateResult, error) {
+	if separator == "" {
+		return nil, fmt.Errorf("empty separator")
+	}
+	entries, err := db.entries()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]*AggregateResult)
+	for _, e := range entries {
+		if e.valueType != INT64_TYPE {
+			continue
+		}
+		n, err := strconv.ParseInt(e.value, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		prefix, _, _ := strings.Cut(e.key, separator)
+		group, ok := res[prefix]
+		if !ok {
+			group = &AggregateResult{}
+			res[prefix] = group
+		}
+		group.add(n)
+	}
+	return res, nil
+}
+
+func Distinct(db *Db, valueType string) ([]string, error) {
+	t := ToByte(valueType)
+	if ToType(t) != valueType {
+		return nil, fmt.Errorf("unknown value type %q", valueType)
+	}
+	entries, err := db.entries()
+	if err != nil {
+		return nil, err
+	}
+
+	unique := make(map[string]struct{})
+	for _, e := range entries {
+		if e.valueType != t {
+			continue
+		}
+		value := e.value
+		if t == INT64_TYPE {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			value = strconv.FormatInt(n, 10)
+		}
+		unique[value] = struct{}{}
+	}
+
+	res := make([]string, 0, len(unique))
+	for value := range unique {
+		res = append(res, value)
+	}
+	sort.Strings(res)
+	return res, nil
+}
@@ -0,0 +1,148 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestAggregate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for i := 1; i <= 1000; i++ {
+		if err := db.PutInt64(fmt.Sprintf("score:%d", i), int64(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Put("score:name", "not a number"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.PutInt64("other", 1000000); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Aggregate(db, "score:*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := AggregateResult{Sum: 500500, Min: 1, Max: 1000, Avg: 500.5, Count: 1000}
+	if *res != expected {
+		t.Errorf("Bad aggregate: expected %+v, got %+v", expected, *res)
+	}
+
+	res, err = Aggregate(db, "missing*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Count != 0 {
+		t.Errorf("Expected no matches, got %+v", *res)
+	}
+
+	if _, err := Aggregate(db, "[bad"); err == nil {
+		t.Error("Expected error for malformed pattern")
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	prefixes := []string{"user", "order", "item"}
+	for _, prefix := range prefixes {
+		for i := 0; i < 100; i++ {
+			if err := db.PutInt64(fmt.Sprintf("%s:%d:score", prefix, i), int64(i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	groups, err := GroupBy(db, ":")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 3 {
+		t.Errorf("Expected 3 groups, got %d", len(groups))
+	}
+	for _, prefix := range prefixes {
+		group, ok := groups[prefix]
+		if !ok {
+			t.Errorf("Missing group %s", prefix)
+			continue
+		}
+		if group.Count != 100 || group.Sum != 4950 {
+			t.Errorf("Bad aggregate for %s: %+v", prefix, *group)
+		}
+	}
+
+	if _, err := GroupBy(db, ""); err == nil {
+		t.Error("Expected error for empty separator")
+	}
+}
+
+func TestDistinct(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for i := 0; i < 500; i++ {
+		if err := db.Put(fmt.Sprintf("s%d", i), fmt.Sprintf("value%02d", i%50)); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.PutInt64(fmt.Sprintf("i%d", i), int64(i%50)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	values, err := Distinct(db, "string")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 50 {
+		t.Errorf("Expected 50 distinct strings, got %d", len(values))
+	}
+	for i := 1; i < len(values); i++ {
+		if values[i-1] >= values[i] {
+			t.Errorf("Values are not sorted: %s >= %s", values[i-1], values[i])
+		}
+	}
+
+	values, err = Distinct(db, "int64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 50 {
+		t.Errorf("Expected 50 distinct int64 values, got %d", len(values))
+	}
+
+	if _, err := Distinct(db, "float"); err == nil {
+		t.Error("Expected error for unknown type")
+	}
+}
@@ -0,0 +1,97 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Анотації зберігаються після значення як пари [uvarint довжина][ім'я][uvarint довжина][значення],
+// а останні 4 байти запису містять довжину всієї секції.
+
+type annotation struct {
+	name, value string
+}
+
+func (e *Entry) Annotate(name, value string) {
+	pairs, _ := parseAnnotations(e.annotations)
+	replaced := false
+	for i := range pairs {
+		if pairs[i].name == name {
+			pairs[i].value = value
+			replaced = true
+		}
+	}
+	if !replaced {
+		pairs = append(pairs, annotation{name, value})
+	}
+
+	var buf []byte
+	for _, a := range pairs {
+		buf = binary.AppendUvarint(buf, uint64(len(a.name)))
+		buf = append(buf, a.name...)
+		buf = binary.AppendUvarint(buf, uint64(len(a.value)))
+		buf = append(buf, a.value...)
+	}
+	e.annotations = string(buf)
+}
+
+func (e *Entry) Annotation(name string) (string, bool) {
+	pairs, _ := parseAnnotations(e.annotations)
+	for _, a := range pairs {
+		if a.name == name {
+			return a.value, true
+		}
+	}
+	return "", false
+}
+
+func (e *Entry) Annotations() map[string]string {
+	pairs, _ := parseAnnotations(e.annotations)
+	res := make(map[string]string, len(pairs))
+	for _, a := range pairs {
+		res[a.name] = a.value
+	}
+	return res
+}
+
+func parseAnnotations(section string) ([]annotation, error) {
+	var res []annotation
+	readString := func() (string, error) {
+		n, size := binary.Uvarint([]byte(section))
+		if size <= 0 || n > uint64(len(section)-size) {
+			return "", fmt.Errorf("corrupted annotation")
+		}
+		s := section[size : size+int(n)]
+		section = section[size+int(n):]
+		return s, nil
+	}
+	for len(section) > 0 {
+		name, err := readString()
+		if err != nil {
+			return nil, err
+		}
+		value, err := readString()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, annotation{name, value})
+	}
+	return res, nil
+}
+
+func appendAnnotations(dst []byte, start int, e *Entry) []byte {
+	if e.annotations == "" {
+		return dst
+	}
+	dst = append(dst, e.annotations...)
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(len(e.annotations)))
+	binary.LittleEndian.PutUint32(dst[start:], uint32(len(dst)-start))
+	dst[start+8+len(e.key)] |= ANNOTATED_FLAG
+	return dst
+}
+
+func annotationSection(input []byte) []byte {
+	end := binary.LittleEndian.Uint32(input) - 4
+	n := binary.LittleEndian.Uint32(input[end:])
+	return input[end-n : end]
+}
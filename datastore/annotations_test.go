@@ -0,0 +1,72 @@
+package datastore
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEntry_Annotations(t *testing.T) {
+	e := Entry{key: "key", valueType: ToByte("string"), value: "value"}
+	e.Annotate("user", "alice")
+	e.Annotate("timestamp", "2024-01-01T00:00:00Z")
+
+	var decoded Entry
+	decoded.Decode(e.Encode())
+	if decoded != e {
+		t.Errorf("Bad round-trip: expected %v, got %v", e, decoded)
+	}
+	if user, ok := decoded.Annotation("user"); !ok || user != "alice" {
+		t.Errorf("Bad user annotation: %q, %v", user, ok)
+	}
+	if ts, ok := decoded.Annotation("timestamp"); !ok || ts != "2024-01-01T00:00:00Z" {
+		t.Errorf("Bad timestamp annotation: %q, %v", ts, ok)
+	}
+	if _, ok := decoded.Annotation("missing"); ok {
+		t.Error("Unexpected annotation found")
+	}
+	if len(decoded.Annotations()) != 2 {
+		t.Errorf("Expected 2 annotations, got %v", decoded.Annotations())
+	}
+
+	e.Annotate("user", "bob")
+	if user, _ := e.Annotation("user"); user != "bob" || len(e.Annotations()) != 2 {
+		t.Errorf("Expected annotation to be replaced, got %v", e.Annotations())
+	}
+}
+
+func TestEntry_AnnotationsEncodings(t *testing.T) {
+	e := Entry{key: "key", valueType: ToByte("int64"), value: "-12"}
+	e.Annotate("trace", "abc")
+
+	prefix := []byte("prefix")
+	dst, err := EncodeInto(&e, append([]byte(nil), prefix...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dst[len(prefix):], e.Encode()) {
+		t.Error("EncodeInto differs from Encode")
+	}
+	if !bytes.Equal(e.EncodeBuffer(bytes.Repeat([]byte{0xff}, 64)), e.Encode()) {
+		t.Error("EncodeBuffer differs from Encode")
+	}
+
+	var unsafeDecoded Entry
+	UnsafeDecode(e.Encode(), &unsafeDecoded)
+	if unsafeDecoded != e {
+		t.Errorf("Bad unsafe round-trip: expected %v, got %v", e, unsafeDecoded)
+	}
+
+	entries, err := DecodeMany(append(e.Encode(), e.Encode()...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || *entries[1] != e {
+		t.Errorf("Bad DecodeMany result: %v", entries)
+	}
+
+	data := e.Encode()
+	data[len(data)-4] = 0xff
+	if _, err := DecodeMany(data); err == nil {
+		t.Error("Expected error for corrupted annotations")
+	}
+}
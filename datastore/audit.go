@@ -0,0 +1,197 @@
+package datastore
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+type Op int
+
+const (
+	READ_OP Op = iota
+	WRITE_OP
+	DELETE_OP
+)
+
+func (op Op) String() string {
+	switch op {
+	case READ_OP:
+		return "read"
+	case WRITE_OP:
+		return "write"
+	case DELETE_OP:
+		return "delete"
+	}
+	return fmt.Sprintf("op(%d)", int(op))
+}
+
+type AuditLog interface {
+	Log(op Op, key string, namespace string, outcome error)
+	Close() error
+}
+
+type AuditRecord struct {
+	Time      time.Time `json:"time"`
+	Op        string    `json:"op"`
+	Key       string    `json:"key"`
+	Namespace string    `json:"namespace"`
+	Success   bool      `json:"success"`
+	Error     string    `json:"error,omitempty"`
+	// Hash is the SHA-256 of the previous record's hash and this record
+	// without Hash, so editing or dropping a record breaks every later hash.
+	Hash string `json:"hash"`
+}
+
+func (r *AuditRecord) chainHash(prev string) (string, error) {
+	unsigned := *r
+	unsigned.Hash = ""
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(prev), data...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+type fileAuditLog struct {
+	mu   sync.Mutex
+	file *os.File
+	last string
+	err  error
+}
+
+// FileAuditLog appends hash-chained JSON records to path, continuing the chain
+// of an existing file. Write errors are reported by Close.
+func FileAuditLog(path string) (AuditLog, error) {
+	records, err := ReadAuditLog(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	l := &fileAuditLog{file: f}
+	if len(records) != 0 {
+		l.last = records[len(records)-1].Hash
+	}
+	return l, nil
+}
+
+func (l *fileAuditLog) Log(op Op, key string, namespace string, outcome error) {
+	record := AuditRecord{
+		Time:      timeNow().UTC(),
+		Op:        op.String(),
+		Key:       key,
+		Namespace: namespace,
+		Success:   outcome == nil,
+	}
+	if outcome != nil {
+		record.Error = outcome.Error()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return
+	}
+	record.Hash, l.err = record.chainHash(l.last)
+	if l.err != nil {
+		return
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		l.err = err
+		return
+	}
+	//пишемо рядок одним викликом, щоб запис не розірвався
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		l.err = err
+		return
+	}
+	l.last = record.Hash
+}
+
+func (l *fileAuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.file.Sync(); err != nil && l.err == nil {
+		l.err = err
+	}
+	if err := l.file.Close(); err != nil && l.err == nil {
+		l.err = err
+	}
+	return l.err
+}
+
+// ReadAuditLog reads the records written by FileAuditLog and verifies the hash chain.
+func ReadAuditLog(path string) ([]AuditRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var res []AuditRecord
+	prev := ""
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("corrupted audit record %d: %v", len(res)+1, err)
+		}
+		hash, err := record.chainHash(prev)
+		if err != nil {
+			return nil, err
+		}
+		if hash != record.Hash {
+			return nil, fmt.Errorf("audit record %d was tampered with", len(res)+1)
+		}
+		res = append(res, record)
+		prev = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+type AuditStore struct {
+	store Store
+	log   AuditLog
+}
+
+func NewAuditStore(store Store, log AuditLog) *AuditStore {
+	return &AuditStore{store: store, log: log}
+}
+
+func (s *AuditStore) Get(namespace, key string) (string, error) {
+	val, err := s.store.Get(key)
+	s.log.Log(READ_OP, key, namespace, err)
+	return val, err
+}
+
+func (s *AuditStore) Put(namespace, key, value string) error {
+	err := s.store.Put(key, value)
+	s.log.Log(WRITE_OP, key, namespace, err)
+	return err
+}
+
+func (s *AuditStore) GetInt64(namespace, key string) (int64, error) {
+	val, err := s.store.GetInt64(key)
+	s.log.Log(READ_OP, key, namespace, err)
+	return val, err
+}
+
+func (s *AuditStore) PutInt64(namespace, key string, value int64) error {
+	err := s.store.PutInt64(key, value)
+	s.log.Log(WRITE_OP, key, namespace, err)
+	return err
+}
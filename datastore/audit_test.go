@@ -0,0 +1,76 @@
+package datastore
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuditStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	log, err := FileAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewAuditStore(&mockStore{}, log)
+	if _, err := s.Get("alice", "user:1"); err != ErrNotFound {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	if err := s.Put("alice", "user:1", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := ReadAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 audit records, got %d", len(records))
+	}
+	read, write := records[0], records[1]
+	if read.Op != "read" || read.Key != "user:1" || read.Namespace != "alice" || read.Success || read.Error != ErrNotFound.Error() {
+		t.Errorf("Bad read record: %+v", read)
+	}
+	if write.Op != "write" || !write.Success || write.Error != "" {
+		t.Errorf("Bad write record: %+v", write)
+	}
+
+	// новий лог продовжує ланцюжок існуючого файлу
+	log, err = FileAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	NewAuditStore(&mockStore{}, log).PutInt64("bob", "counter", 1)
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if records, err := ReadAuditLog(path); err != nil || len(records) != 3 {
+		t.Errorf("Expected 3 verified records, got %d, %v", len(records), err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := bytes.Replace(data, []byte(`"namespace":"alice"`), []byte(`"namespace":"mallo"`), 1)
+	if err := ioutil.WriteFile(path, tampered, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadAuditLog(path); err == nil {
+		t.Error("Expected error for tampered audit log")
+	}
+	if _, err := FileAuditLog(path); err == nil {
+		t.Error("Expected FileAuditLog to refuse a tampered log")
+	}
+}
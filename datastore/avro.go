@@ -0,0 +1,99 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const AvroSchema = `{
+	"type": "record",
+	"name": "Entry",
+	"namespace": "datastore",
+	"fields": [
+		{"name": "key", "type": "string"},
+		{"name": "value_type", "type": {"type": "enum", "name": "ValueType", "symbols": ["string", "int64"]}},
+		{"name": "value", "type": "bytes"}
+	]
+}`
+
+const avroMagicByte byte = 0
+
+// порядок має збігатися з symbols у AvroSchema
+var avroValueTypes = []byte{STRING_TYPE, INT64_TYPE}
+
+func AvroEncode(e *Entry, schemaID int) ([]byte, error) {
+	symbol := -1
+	for i, t := range avroValueTypes {
+		if t == e.valueType {
+			symbol = i
+		}
+	}
+	if symbol < 0 {
+		return nil, fmt.Errorf("unsupported value type %d", e.valueType)
+	}
+	if schemaID < 0 || int64(schemaID) > 0xffffffff {
+		return nil, fmt.Errorf("schema id %d out of range", schemaID)
+	}
+
+	res := make([]byte, 5, 5+len(e.key)+len(e.value)+2*binary.MaxVarintLen64+1)
+	res[0] = avroMagicByte
+	binary.BigEndian.PutUint32(res[1:], uint32(schemaID))
+
+	res = binary.AppendVarint(res, int64(len(e.key)))
+	res = append(res, e.key...)
+	res = binary.AppendVarint(res, int64(symbol))
+	res = binary.AppendVarint(res, int64(len(e.value)))
+	res = append(res, e.value...)
+	return res, nil
+}
+
+func avroReadBytes(input []byte) ([]byte, []byte, error) {
+	l, n := binary.Varint(input)
+	if n <= 0 {
+		return nil, nil, fmt.Errorf("corrupted avro length")
+	}
+	input = input[n:]
+	if l < 0 || l > int64(len(input)) {
+		return nil, nil, fmt.Errorf("avro length %d out of range", l)
+	}
+	return input[:l], input[l:], nil
+}
+
+func AvroDecode(input []byte) (*Entry, error) {
+	if len(input) < 5 || input[0] != avroMagicByte {
+		return nil, fmt.Errorf("missing avro magic header")
+	}
+	input = input[5:]
+
+	key, input, err := avroReadBytes(input)
+	if err != nil {
+		return nil, err
+	}
+	symbol, n := binary.Varint(input)
+	if n <= 0 {
+		return nil, fmt.Errorf("corrupted avro enum")
+	}
+	if symbol < 0 || symbol >= int64(len(avroValueTypes)) {
+		return nil, fmt.Errorf("unknown avro value_type symbol %d", symbol)
+	}
+	value, input, err := avroReadBytes(input[n:])
+	if err != nil {
+		return nil, err
+	}
+	if len(input) != 0 {
+		return nil, fmt.Errorf("unexpected %d trailing bytes", len(input))
+	}
+
+	return &Entry{
+		key:       string(key),
+		valueType: avroValueTypes[symbol],
+		value:     string(value),
+	}, nil
+}
+
+func AvroSchemaID(input []byte) (int, error) {
+	if len(input) < 5 || input[0] != avroMagicByte {
+		return 0, fmt.Errorf("missing avro magic header")
+	}
+	return int(binary.BigEndian.Uint32(input[1:5])), nil
+}
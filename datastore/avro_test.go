@@ -0,0 +1,65 @@
+package datastore
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestAvroSchema(t *testing.T) {
+	var schema struct {
+		Fields []struct {
+			Name string `json:"name"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(AvroSchema), &schema); err != nil {
+		t.Fatal(err)
+	}
+	if len(schema.Fields) != 3 {
+		t.Errorf("Expected 3 fields in schema, got %d", len(schema.Fields))
+	}
+}
+
+func TestAvroEncode(t *testing.T) {
+	e := Entry{key: "key", valueType: ToByte("int64"), value: "-12"}
+	data, err := AvroEncode(&e, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{0x00, 0x00, 0x00, 0x00, 0x2a, 0x06, 'k', 'e', 'y', 0x02, 0x06, '-', '1', '2'}
+	if !bytes.Equal(data, expected) {
+		t.Errorf("Bad encoding: expected %x, got %x", expected, data)
+	}
+
+	id, err := AvroSchemaID(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 42 {
+		t.Errorf("Bad schema id: expected 42, got %d", id)
+	}
+
+	decoded, err := AvroDecode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *decoded != e {
+		t.Errorf("Bad entry decoded: expected %v, got %v", e, *decoded)
+	}
+}
+
+func TestAvroDecode_Errors(t *testing.T) {
+	inputs := [][]byte{
+		{},
+		{0x01, 0x00, 0x00, 0x00, 0x01},
+		{0x00, 0x00, 0x00, 0x00, 0x01, 0x06, 'k'},
+		{0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x08, 0x00},
+		{0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00},
+	}
+	for _, input := range inputs {
+		if _, err := AvroDecode(input); err == nil {
+			t.Errorf("Expected error for input %x", input)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package datastore
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// розміри записів для набору бенчмарків; *.prof пише скрипт bench.sh
+var benchSizes = []struct {
+	name string
+	size int
+}{
+	{"10B", 10},
+	{"1KB", 1 << 10},
+	{"1MB", 1 << 20},
+}
+
+// benchEntry повертає запис заданого типу розміром приблизно size байт;
+// у int64 значення фіксоване, тому розмір набирається ключем
+func benchEntry(valueType byte, size int) *Entry {
+	switch valueType {
+	case INT64_TYPE:
+		return &Entry{key: "k" + strings.Repeat("x", max(size-1-TYPE_SIZE-12-8, 0)), valueType: INT64_TYPE, value: strconv.FormatInt(1<<40, 10)}
+	default:
+		return &Entry{key: "key", valueType: STRING_TYPE, value: strings.Repeat("v", max(size-4-TYPE_SIZE-12, 0))}
+	}
+}
+
+func benchmarkEncode(b *testing.B, valueType byte) {
+	for _, s := range benchSizes {
+		b.Run(s.name, func(b *testing.B) {
+			e := benchEntry(valueType, s.size)
+			buf, _ := EncodeInto(e, nil)
+			b.SetBytes(int64(len(buf)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				buf, _ = EncodeInto(e, buf[:0])
+			}
+		})
+	}
+}
+
+func benchmarkDecode(b *testing.B, valueType byte) {
+	for _, s := range benchSizes {
+		b.Run(s.name, func(b *testing.B) {
+			data := benchEntry(valueType, s.size).Encode()
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			var e Entry
+			for i := 0; i < b.N; i++ {
+				e.Decode(data)
+			}
+		})
+	}
+}
+
+func BenchmarkEncodeString(b *testing.B) { benchmarkEncode(b, STRING_TYPE) }
+
+func BenchmarkEncodeInt64(b *testing.B) { benchmarkEncode(b, INT64_TYPE) }
+
+func BenchmarkDecodeString(b *testing.B) { benchmarkDecode(b, STRING_TYPE) }
+
+func BenchmarkDecodeInt64(b *testing.B) { benchmarkDecode(b, INT64_TYPE) }
+
+func BenchmarkRoundTrip(b *testing.B) {
+	for _, valueType := range []byte{STRING_TYPE, INT64_TYPE} {
+		for _, s := range benchSizes {
+			b.Run(ToType(valueType)+"/"+s.name, func(b *testing.B) {
+				e := benchEntry(valueType, s.size)
+				buf, _ := EncodeInto(e, nil)
+				b.SetBytes(int64(len(buf)))
+				b.ReportAllocs()
+				var decoded Entry
+				for i := 0; i < b.N; i++ {
+					buf, _ = EncodeInto(e, buf[:0])
+					decoded.Decode(buf)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkReadEntryFromBuffer(b *testing.B) {
+	for _, valueType := range []byte{STRING_TYPE, INT64_TYPE} {
+		for _, s := range benchSizes {
+			b.Run(ToType(valueType)+"/"+s.name, func(b *testing.B) {
+				data := benchEntry(valueType, s.size).Encode()
+				b.SetBytes(int64(len(data)))
+				b.ReportAllocs()
+				r := bytes.NewReader(data)
+				in := bufio.NewReaderSize(r, bufSize)
+				for i := 0; i < b.N; i++ {
+					r.Reset(data)
+					in.Reset(r)
+					if _, err := readValue(in); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// benchSegment пише в сегмент записи загальним обсягом близько 4 МБ
+func benchSegment(b *testing.B, size int) (string, int) {
+	dir := b.TempDir()
+	e := benchEntry(STRING_TYPE, size)
+	count := max(1, min(4<<20/size, 10000))
+	var buf bytes.Buffer
+	for i := 0; i < count; i++ {
+		e.key = fmt.Sprintf("key%06d", i)
+		buf.Write(e.Encode())
+	}
+	if err := os.WriteFile(filepath.Join(dir, outFileName+"1"), buf.Bytes(), 0o600); err != nil {
+		b.Fatal(err)
+	}
+	return dir, buf.Len()
+}
+
+func BenchmarkIndexBuild(b *testing.B) {
+	for _, s := range benchSizes {
+		b.Run(s.name, func(b *testing.B) {
+			dir, total := benchSegment(b, s.size)
+			bl, err := openBlock(dir, outFileName+"1", true, 0, make(hashIndex))
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer bl.close()
+			b.SetBytes(int64(total))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				bl.index = make(hashIndex)
+				bl.outOffset = 0
+				if err := bl.recover(); err != nil && err != io.EOF {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParallel(b *testing.B) {
+	for _, valueType := range []byte{STRING_TYPE, INT64_TYPE} {
+		for _, s := range benchSizes {
+			b.Run(ToType(valueType)+"/"+s.name, func(b *testing.B) {
+				e := benchEntry(valueType, s.size)
+				data := e.Encode()
+				b.SetBytes(int64(len(data)))
+				b.ReportAllocs()
+				b.RunParallel(func(pb *testing.PB) {
+					var buf []byte
+					var decoded Entry
+					for pb.Next() {
+						buf, _ = EncodeInto(e, buf[:0])
+						decoded.Decode(buf)
+					}
+				})
+			})
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// Бітовий набір зберігається як [кількість бітів u32][байти], біт i лежить
+// у байті i/8 на позиції i%8; зайві біти останнього байта завжди нульові.
+
+func parseBitset(data string) (int, string, error) {
+	if len(data) < 4 {
+		return 0, "", fmt.Errorf("corrupted bitset length")
+	}
+	n := int(binary.LittleEndian.Uint32([]byte(data[:4])))
+	body := data[4:]
+	if len(body) != (n+7)/8 {
+		return 0, "", fmt.Errorf("corrupted bitset length")
+	}
+	if n%8 != 0 && body[len(body)-1]>>(n%8) != 0 {
+		return 0, "", fmt.Errorf("bitset has bits past its length")
+	}
+	return n, body, nil
+}
+
+func validateBitset(data string) error {
+	_, _, err := parseBitset(data)
+	return err
+}
+
+func (e *Entry) bitset() (int, string, error) {
+	if e.valueType != BITSET_TYPE {
+		return 0, "", fmt.Errorf("wrong type of value")
+	}
+	return parseBitset(e.value)
+}
+
+// NewBitsetEntry returns a bitset of size bits, all cleared.
+func NewBitsetEntry(key string, size int) *Entry {
+	value := binary.LittleEndian.AppendUint32(nil, uint32(size))
+	return &Entry{key: key, valueType: BITSET_TYPE, value: string(value) + strings.Repeat("\x00", (size+7)/8)}
+}
+
+func (e *Entry) SetBit(i int, v bool) error {
+	n, _, err := e.bitset()
+	if err != nil {
+		return err
+	}
+	if i < 0 || i >= n {
+		return fmt.Errorf("bit %d out of range [0, %d)", i, n)
+	}
+	data := []byte(e.value)
+	if v {
+		data[4+i/8] |= 1 << (i % 8)
+	} else {
+		data[4+i/8] &^= 1 << (i % 8)
+	}
+	e.value = string(data)
+	return nil
+}
+
+func (e *Entry) GetBit(i int) (bool, error) {
+	n, body, err := e.bitset()
+	if err != nil {
+		return false, err
+	}
+	if i < 0 || i >= n {
+		return false, fmt.Errorf("bit %d out of range [0, %d)", i, n)
+	}
+	return body[i/8]&(1<<(i%8)) != 0, nil
+}
+
+// BitCount returns the number of set bits.
+func (e *Entry) BitCount() (int, error) {
+	_, body, err := e.bitset()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for i := 0; i < len(body); i++ {
+		count += bits.OnesCount8(body[i])
+	}
+	return count, nil
+}
+
+func bitwise(a, b *Entry, op func(x, y byte) byte) (*Entry, error) {
+	n, x, err := a.bitset()
+	if err != nil {
+		return nil, err
+	}
+	m, y, err := b.bitset()
+	if err != nil {
+		return nil, err
+	}
+	if n != m {
+		return nil, fmt.Errorf("bitset sizes differ: %d and %d", n, m)
+	}
+	res := binary.LittleEndian.AppendUint32(nil, uint32(n))
+	for i := 0; i < len(x); i++ {
+		res = append(res, op(x[i], y[i]))
+	}
+	return &Entry{key: a.key, valueType: BITSET_TYPE, value: string(res)}, nil
+}
+
+// BitwiseAnd, BitwiseOr and BitwiseXor combine two bitsets of the same
+// size; the result keeps the key of a.
+func BitwiseAnd(a, b *Entry) (*Entry, error) {
+	return bitwise(a, b, func(x, y byte) byte { return x & y })
+}
+
+func BitwiseOr(a, b *Entry) (*Entry, error) {
+	return bitwise(a, b, func(x, y byte) byte { return x | y })
+}
+
+func BitwiseXor(a, b *Entry) (*Entry, error) {
+	return bitwise(a, b, func(x, y byte) byte { return x ^ y })
+}
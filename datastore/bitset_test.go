@@ -0,0 +1,64 @@
+package datastore
+
+import "testing"
+
+func TestBitset(t *testing.T) {
+	e := NewBitsetEntry("flags", 1000)
+	for i := 0; i < 1000; i += 3 {
+		if err := e.SetBit(i, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.SetBit(999, false); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Entry
+	decoded.Decode(e.Encode())
+	for i := 0; i < 1000; i++ {
+		v, err := decoded.GetBit(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != (i%3 == 0 && i != 999) {
+			t.Errorf("Bad bit %d: %v", i, v)
+		}
+	}
+	if n, err := decoded.BitCount(); err != nil || n != 333 {
+		t.Errorf("Expected 333 set bits, got %d, %v", n, err)
+	}
+	if err := e.SetBit(1000, true); err == nil {
+		t.Errorf("Expected an error for a bit out of range")
+	}
+	if _, err := e.GetBit(-1); err == nil {
+		t.Errorf("Expected an error for a negative bit")
+	}
+
+	evens := NewBitsetEntry("evens", 1000)
+	for i := 0; i < 1000; i += 2 {
+		evens.SetBit(i, true)
+	}
+	for _, c := range []struct {
+		name  string
+		op    func(a, b *Entry) (*Entry, error)
+		count int
+	}{
+		//кратні 6 - 167, кратні 3 - 333 (без 999), парні - 500
+		{"and", BitwiseAnd, 167},
+		{"or", BitwiseOr, 333 + 500 - 167},
+		{"xor", BitwiseXor, 333 + 500 - 2*167},
+	} {
+		res, err := c.op(e, evens)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n, _ := res.BitCount(); n != c.count {
+			t.Errorf("Bad %s count: expected %d, got %d", c.name, c.count, n)
+		}
+	}
+	if _, err := BitwiseOr(e, NewBitsetEntry("small", 8)); err == nil {
+		t.Errorf("Expected an error for bitsets of different sizes")
+	}
+	if _, err := EncodeInto(&Entry{key: "k", valueType: BITSET_TYPE, value: "\x04\x00\x00\x00\xff"}, nil); err == nil {
+		t.Errorf("Expected an error for bits past the length")
+	}
+}
@@ -6,17 +6,25 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"sync"
 )
 
 var ErrNotFound = fmt.Errorf("record does not exist")
+var ErrReadOnly = fmt.Errorf("database is opened read-only")
+var ErrClosed = fmt.Errorf("database is closed")
 
-type hashIndex map[string]int64
+// errDeleted означає, що найновіша версія ключа видалена або прострочена,
+// тож старіші версії показувати не можна
+var errDeleted = fmt.Errorf("record is deleted")
+
+// errTornRecord означає, що останній запис сегмента дописаний не до кінця
+var errTornRecord = fmt.Errorf("torn record at the end of segment")
 
 type block struct {
-	index   hashIndex
+	index   Index
 	segment *os.File
 
 	outPath   string
@@ -26,26 +34,46 @@ type block struct {
 	writeCh chan writeArgument
 
 	cancel context.CancelFunc
+
+	cache      *PageCache
+	bufferSize int
+
+	//блок зі знімку чекпоінту: файл не належить базі й не видаляється при мерджі
+	snapshot bool
 }
 
-func newBlock(dir string, outFileName string) (*block, error) {
+func newBlock(dir string, outFileName string, index Index) (*block, error) {
+	return openBlock(dir, outFileName, false, 0, index)
+}
+
+func openBlock(dir string, outFileName string, readOnly bool, from int64, index Index) (*block, error) {
 	outputPath := filepath.Join(dir, outFileName)
-	f, err := os.OpenFile(outputPath, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o600)
+	flag := os.O_APPEND | os.O_WRONLY | os.O_CREATE
+	if readOnly {
+		flag = os.O_RDONLY
+	}
+	f, err := os.OpenFile(outputPath, flag, 0o600)
 	if err != nil {
 		return nil, err
 	}
 	bl := &block{
-		index:   make(hashIndex),
+		index:   index,
 		segment: f,
 
-		outPath: outputPath,
-		writeCh: make(chan writeArgument),
+		outPath:   outputPath,
+		outOffset: from,
+		writeCh:   make(chan writeArgument),
 	}
 	ctx, cancel := context.WithCancel(context.Background())
 	bl.cancel = cancel
 	go bl.write(ctx)
 	err = bl.recover()
-	if err != nil && err != io.EOF {
+	if err == errTornRecord && !readOnly {
+		//обірваний запис ніколи не підтверджувався, тож просто відкидаємо його
+		err = bl.segment.Truncate(bl.outOffset)
+	}
+	if err != nil && err != io.EOF && err != errTornRecord {
+		bl.close()
 		return nil, err
 	}
 	return bl, nil
@@ -54,11 +82,17 @@ func newBlock(dir string, outFileName string) (*block, error) {
 const bufSize = 8192
 
 func (b *block) recover() error {
+	if err := failpoint("block.recover"); err != nil {
+		return err
+	}
 	input, err := os.Open(b.outPath)
 	if err != nil {
 		return err
 	}
 	defer input.Close()
+	if _, err := input.Seek(b.outOffset, io.SeekStart); err != nil {
+		return err
+	}
 
 	var buf [bufSize]byte
 	in := bufio.NewReaderSize(input, bufSize)
@@ -72,6 +106,9 @@ func (b *block) recover() error {
 			if len(header) == 0 {
 				return err
 			}
+			if len(header) < 4 {
+				return errTornRecord
+			}
 		} else if err != nil {
 			return err
 		}
@@ -82,7 +119,10 @@ func (b *block) recover() error {
 		} else {
 			data = make([]byte, size)
 		}
-		n, err = in.Read(data)
+		n, err = io.ReadFull(in, data)
+		if err == io.ErrUnexpectedEOF {
+			return errTornRecord
+		}
 
 		if err == nil {
 			if n != int(size) {
@@ -91,7 +131,7 @@ func (b *block) recover() error {
 
 			var e Entry
 			e.Decode(data)
-			b.index[e.key] = b.outOffset
+			b.index.Insert(e.key, b.outOffset)
 			b.outOffset += int64(n)
 		}
 	}
@@ -106,52 +146,112 @@ func (b *block) close() error {
 
 func (b *block) get(key string) (string, string, error) {
 	b.mu.RLock()
-	position, ok := b.index[key]
+	position, ok := b.index.Find(key)
 	b.mu.RUnlock()
 	if !ok {
 		return "", "", ErrNotFound
 	}
 
+	value, valueType, _, err := b.readAt(position)
+	return value, valueType, err
+}
+
+// readAt повертає значення запису за зміщенням і довжину ланцюжка диффів до нього
+func (b *block) readAt(position int64) (string, string, int, error) {
+	if b.cache != nil {
+		f := &cachedFile{cache: b.cache, path: b.outPath}
+		defer f.Close()
+		return b.readValueAt(f, position)
+	}
 	file, err := os.Open(b.outPath)
 	if err != nil {
-		return "", "", err
+		return "", "", 0, err
 	}
 	defer file.Close()
+	return b.readValueAt(file, position)
+}
 
-	_, err = file.Seek(position, 0)
+func (b *block) readValueAt(f io.ReaderAt, position int64) (string, string, int, error) {
+	var reader *bufio.Reader
+	src := io.NewSectionReader(f, position, math.MaxInt64-position)
+	if b.bufferSize > 0 {
+		reader = bufio.NewReaderSize(src, b.bufferSize)
+	} else {
+		reader = bufio.NewReader(src)
+	}
+	valueType, err := recordType(reader, f, position)
 	if err != nil {
-		return "", "", err
+		return "", "", 0, err
+	}
+	if valueType&ANNOTATED_FLAG != 0 {
+		e, err := readRecordAt(f, position)
+		if err != nil {
+			return "", "", 0, err
+		}
+		if e.IsHardExpired() || e.valueType == SOFT_DELETED_TYPE {
+			return "", "", 0, errDeleted
+		}
+		return e.value, e.Type(), 0, nil
 	}
 
-	reader := bufio.NewReader(file)
 	pair, err := readValue(reader)
 	if err != nil {
-		return "", "", err
+		return "", "", 0, err
+	}
+	if pair.valueType == tombstoneTypeName {
+		return "", "", 0, errDeleted
+	}
+	if pair.valueType != diffTypeName {
+		return pair.value, pair.valueType, 0, nil
 	}
 
-	return pair.value, pair.valueType, nil
+	base, ops, err := parseDiff(pair.value)
+	if err != nil {
+		return "", "", 0, err
+	}
+	if base >= position {
+		return "", "", 0, fmt.Errorf("corrupted diff base offset %d", base)
+	}
+	baseValue, baseType, depth, err := b.readValueAt(f, base)
+	if err != nil {
+		return "", "", 0, err
+	}
+	if baseType != "string" {
+		return "", "", 0, fmt.Errorf("diff base has wrong type of value")
+	}
+	value, err := applyDiff(baseValue, ops)
+	if err != nil {
+		return "", "", 0, err
+	}
+	return value, "string", depth + 1, nil
 }
 
-func (b *block) put(key, valueType, value string) error {
-	e := Entry{
-		key:       key,
-		valueType: ToByte(valueType),
-		value:     value,
+func (b *block) putEntry(e *Entry) error {
+	data, err := EncodeInto(e, nil)
+	if err != nil {
+		return err
 	}
-
 	resultCh := make(chan writeResult)
-	b.writeCh <- writeArgument{resultCh, e.Encode()}
+	b.writeCh <- writeArgument{resultCh, data}
 	result := <-resultCh
 	close(resultCh)
 
-	if result.err == nil {
-		b.mu.Lock()
-		b.index[key] = b.outOffset
-		b.outOffset += int64(result.n)
-		b.mu.Unlock()
+	if result.err != nil {
+		//відкочуємо частково записаний запис, щоб наступний не ліг після сміття
+		err := failpoint("block.truncate")
+		if err == nil {
+			err = b.segment.Truncate(b.outOffset)
+		}
+		if err != nil {
+			return fmt.Errorf("%v; rollback failed: %v", result.err, err)
+		}
+		return result.err
 	}
-
-	return result.err
+	b.mu.Lock()
+	b.index.Insert(e.key, b.outOffset)
+	b.outOffset += int64(result.n)
+	b.mu.Unlock()
+	return nil
 }
 
 type writeArgument struct {
@@ -170,7 +270,11 @@ func (b *block) write(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case arg := <-b.writeCh:
-			n, err := b.segment.Write(arg.data)
+			size, err := tornWrite("block.write", len(arg.data))
+			n, writeErr := b.segment.Write(arg.data[:size])
+			if err == nil {
+				err = writeErr
+			}
 			arg.resultCh <- writeResult{n, err}
 		}
 	}
@@ -185,41 +289,131 @@ func (b *block) size() (int64, error) {
 	return currentSize, nil
 }
 
-func mergeAll(blocks []*block) (*block, error) {
+func recordType(reader *bufio.Reader, f io.ReaderAt, position int64) (byte, error) {
+	header, err := reader.Peek(8)
+	if err != nil {
+		return 0, err
+	}
+	kl := int(binary.LittleEndian.Uint32(header[4:]))
+	if data, err := reader.Peek(8 + kl + TYPE_SIZE); err == nil {
+		return data[8+kl], nil
+	}
+	//ключ не вмістився в буфер читача
+	var t [TYPE_SIZE]byte
+	if _, err := f.ReadAt(t[:], position+8+int64(kl)); err != nil {
+		return 0, err
+	}
+	return t[0], nil
+}
+
+func readRecordAt(f io.ReaderAt, position int64) (*Entry, error) {
+	var header [4]byte
+	if _, err := f.ReadAt(header[:], position); err != nil {
+		return nil, err
+	}
+	data := make([]byte, binary.LittleEndian.Uint32(header[:]))
+	if _, err := f.ReadAt(data, position); err != nil {
+		return nil, err
+	}
+	return decodeRecord(data)
+}
+
+// getEntry повертає запис разом з анотаціями; диффи розгортаються в повне значення
+func (b *block) getEntry(key string) (*Entry, error) {
+	b.mu.RLock()
+	position, ok := b.index.Find(key)
+	b.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	var f io.ReaderAt
+	if b.cache != nil {
+		cached := &cachedFile{cache: b.cache, path: b.outPath}
+		defer cached.Close()
+		f = cached
+	} else {
+		file, err := os.Open(b.outPath)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		f = file
+	}
+
+	e, err := readRecordAt(f, position)
+	if err != nil {
+		return nil, err
+	}
+	if e.valueType == DIFF_STRING_TYPE {
+		value, _, _, err := b.readValueAt(f, position)
+		if err != nil {
+			return nil, err
+		}
+		e.value = value
+		e.valueType = STRING_TYPE
+	}
+	if e.IsHardExpired() || e.valueType == TOMBSTONE_TYPE {
+		return nil, errDeleted
+	}
+	return e, nil
+}
+
+func mergeAll(blocks []*block, index Index) (*block, error) {
 	if len(blocks) == 0 {
 		return nil, fmt.Errorf("empty array of blocks")
 	}
-	newBlock, err := newBlock(blocks[0].outPath+"-temp", "")
+	//перший блок може бути знімком чекпоінту поза директорією бази
+	newBlock, err := newBlock(blocks[len(blocks)-1].outPath+"-temp", "", index)
 	if err != nil {
 		return nil, err
 	}
+	expired := make(map[string]bool)
 	for j := len(blocks) - 1; j >= 0; j = j - 1 {
-		err = mergePair(newBlock, blocks[j])
+		err = mergePair(newBlock, blocks[j], expired)
 		if err != nil {
+			newBlock.close()
+			os.Remove(newBlock.outPath)
 			return nil, err
 		}
 	}
 	return newBlock, nil
 }
 
-func mergePair(destBlock, srcBlock *block) error {
-	for key := range srcBlock.index {
-		_, ok := destBlock.index[key]
-		if !ok {
-			val, valueType, err := srcBlock.get(key)
+func mergePair(destBlock, srcBlock *block, expired map[string]bool) error {
+	for _, key := range srcBlock.keys() {
+		_, ok := destBlock.index.Find(key)
+		if !ok && !expired[key] {
+			e, err := srcBlock.getEntry(key)
+			if err == errDeleted {
+				//старіші версії цього ключа теж не переносимо
+				expired[key] = true
+				continue
+			}
 			if err != nil {
 				return err
 			}
-			destBlock.put(key, valueType, val)
+			if err := failpoint("block.merge"); err != nil {
+				return err
+			}
+			if err := destBlock.putEntry(e); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
 func (b *block) delete() error {
-	err := os.Remove(b.segment.Name())
+	if b.snapshot {
+		return b.close()
+	}
+	err := os.Remove(b.outPath)
 	if err != nil {
 		return err
 	}
+	if b.cache != nil {
+		b.cache.invalidateFile(b.outPath)
+	}
 	return nil
 }
@@ -0,0 +1,132 @@
+package datastore
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = fmt.Errorf("circuit breaker is open")
+
+var _ Store = (*CircuitBreaker)(nil)
+
+type CircuitState int
+
+const (
+	CIRCUIT_CLOSED CircuitState = iota
+	CIRCUIT_OPEN
+	CIRCUIT_HALF_OPEN
+)
+
+type CircuitBreaker struct {
+	store            Store
+	ErrorThreshold   int
+	CooldownDuration time.Duration
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+	now      func() time.Time
+}
+
+func NewCircuitBreaker(store Store, errorThreshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		store:            store,
+		ErrorThreshold:   errorThreshold,
+		CooldownDuration: cooldown,
+		now:              time.Now,
+	}
+}
+
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.state == CIRCUIT_OPEN && cb.now().Sub(cb.openedAt) >= cb.CooldownDuration {
+		return CIRCUIT_HALF_OPEN
+	}
+	return cb.state
+}
+
+func (cb *CircuitBreaker) Reset() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.state = CIRCUIT_CLOSED
+	cb.failures = 0
+	cb.probing = false
+	return nil
+}
+
+func (cb *CircuitBreaker) allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	switch cb.state {
+	case CIRCUIT_OPEN:
+		if cb.now().Sub(cb.openedAt) < cb.CooldownDuration {
+			return ErrCircuitOpen
+		}
+		cb.state = CIRCUIT_HALF_OPEN
+		cb.probing = true
+	case CIRCUIT_HALF_OPEN:
+		//у напіввідкритому стані пропускаємо лише одну пробу
+		if cb.probing {
+			return ErrCircuitOpen
+		}
+		cb.probing = true
+	}
+	return nil
+}
+
+func (cb *CircuitBreaker) done(err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.probing = false
+	//відсутній ключ — не збій сховища
+	if err == nil || err == ErrNotFound {
+		cb.state = CIRCUIT_CLOSED
+		cb.failures = 0
+		return
+	}
+	cb.failures++
+	if cb.state == CIRCUIT_HALF_OPEN || cb.failures >= cb.ErrorThreshold {
+		cb.state = CIRCUIT_OPEN
+		cb.openedAt = cb.now()
+	}
+}
+
+func (cb *CircuitBreaker) Get(key string) (string, error) {
+	if err := cb.allow(); err != nil {
+		return "", err
+	}
+	val, err := cb.store.Get(key)
+	cb.done(err)
+	return val, err
+}
+
+func (cb *CircuitBreaker) Put(key, value string) error {
+	if err := cb.allow(); err != nil {
+		return err
+	}
+	err := cb.store.Put(key, value)
+	cb.done(err)
+	return err
+}
+
+func (cb *CircuitBreaker) GetInt64(key string) (int64, error) {
+	if err := cb.allow(); err != nil {
+		return 0, err
+	}
+	val, err := cb.store.GetInt64(key)
+	cb.done(err)
+	return val, err
+}
+
+func (cb *CircuitBreaker) PutInt64(key string, value int64) error {
+	if err := cb.allow(); err != nil {
+		return err
+	}
+	err := cb.store.PutInt64(key, value)
+	cb.done(err)
+	return err
+}
@@ -0,0 +1,123 @@
+package datastore
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+type mockStore struct {
+	calls int
+	fail  func(call int) error
+	data  map[string]string
+}
+
+func (m *mockStore) call() error {
+	m.calls++
+	if m.fail != nil {
+		return m.fail(m.calls)
+	}
+	return nil
+}
+
+func (m *mockStore) Get(key string) (string, error) {
+	if err := m.call(); err != nil {
+		return "", err
+	}
+	val, ok := m.data[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return val, nil
+}
+
+func (m *mockStore) Put(key, value string) error {
+	if err := m.call(); err != nil {
+		return err
+	}
+	if m.data == nil {
+		m.data = make(map[string]string)
+	}
+	m.data[key] = value
+	return nil
+}
+
+func (m *mockStore) GetInt64(key string) (int64, error) {
+	return 0, m.call()
+}
+
+func (m *mockStore) PutInt64(key string, value int64) error {
+	return m.call()
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	broken := fmt.Errorf("storage is down")
+	store := &mockStore{fail: func(int) error { return broken }}
+	now := time.Unix(0, 0)
+	cb := NewCircuitBreaker(store, 3, time.Minute)
+	cb.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if err := cb.Put("key", "value"); err != broken {
+			t.Errorf("Expected backend error, got %v", err)
+		}
+	}
+	if cb.State() != CIRCUIT_OPEN {
+		t.Fatalf("Expected open circuit, got %v", cb.State())
+	}
+
+	for i := 0; i < 10; i++ {
+		if _, err := cb.Get("key"); err != ErrCircuitOpen {
+			t.Errorf("Expected ErrCircuitOpen, got %v", err)
+		}
+	}
+	if store.calls != 3 {
+		t.Errorf("Expected backend to be called 3 times, got %d", store.calls)
+	}
+
+	now = now.Add(time.Minute)
+	if cb.State() != CIRCUIT_HALF_OPEN {
+		t.Errorf("Expected half-open circuit after cooldown, got %v", cb.State())
+	}
+	if err := cb.PutInt64("key", 1); err != broken {
+		t.Errorf("Expected probe to reach the backend, got %v", err)
+	}
+	if cb.State() != CIRCUIT_OPEN || store.calls != 4 {
+		t.Errorf("Expected failed probe to reopen the circuit, got %v after %d calls", cb.State(), store.calls)
+	}
+
+	now = now.Add(time.Minute)
+	store.fail = nil
+	if err := cb.Put("key", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if cb.State() != CIRCUIT_CLOSED {
+		t.Errorf("Expected successful probe to close the circuit, got %v", cb.State())
+	}
+}
+
+func TestCircuitBreaker_NotFoundAndReset(t *testing.T) {
+	store := &mockStore{}
+	cb := NewCircuitBreaker(store, 1, time.Hour)
+	for i := 0; i < 5; i++ {
+		if _, err := cb.Get("missing"); err != ErrNotFound {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	}
+	if cb.State() != CIRCUIT_CLOSED {
+		t.Errorf("Missing keys must not open the circuit, got %v", cb.State())
+	}
+
+	store.fail = func(int) error { return fmt.Errorf("down") }
+	cb.Put("key", "value")
+	if cb.State() != CIRCUIT_OPEN {
+		t.Fatalf("Expected open circuit, got %v", cb.State())
+	}
+	if err := cb.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	store.fail = nil
+	if err := cb.Put("key", "value"); err != nil {
+		t.Errorf("Expected closed circuit after reset, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package datastore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+// has повідомляє, чи key можна прочитати; видалений ключ вважається відсутнім
+func (db *Db) has(key string) (bool, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	e, err := db.latestEntry(key)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return e.valueType != SOFT_DELETED_TYPE, nil
+}
+
+func canonicalValue(value, valueType string) (string, error) {
+	switch valueType {
+	case "string":
+		return value, nil
+	case "int64":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(n, 10), nil
+	}
+	return "", fmt.Errorf("unknown value type %q", valueType)
+}
+
+func (db *Db) ContentAddressedWrite(value string, valueType string) (string, error) {
+	value, err := canonicalValue(value, valueType)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(value))
+	key := hex.EncodeToString(sum[:])
+	//перевірка й запис ідуть через compareAndSwap, тож з паралельних записів
+	//того самого вмісту пише лише один, а решта бачать уже наявний запис
+	_, err = db.updateEntry(key, func(cur *Entry) (*Entry, error) {
+		if cur == nil || cur.valueType == SOFT_DELETED_TYPE {
+			return &Entry{valueType: ToByte(valueType), value: value}, nil
+		}
+		//ключ залежить лише від значення, тож "1" у різних типах має той самий ключ
+		if cur.valueType != ToByte(valueType) {
+			return nil, fmt.Errorf("content %s is already stored as %s, not %s", key, cur.Type(), valueType)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+func (db *Db) BatchContentAddressedWrite(values []string, valueType string) ([]string, error) {
+	keys := make([]string, 0, len(values))
+	for _, value := range values {
+		key, err := db.ContentAddressedWrite(value, valueType)
+		if err != nil {
+			return keys, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (db *Db) ContentAddressedRead(key string) (*Entry, error) {
+	val, vType, err := db.getType(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Entry{key: key, valueType: ToByte(vType), value: val}, nil
+}
@@ -0,0 +1,148 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestContentAddressedWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var key string
+	for i := 0; i < 100; i++ {
+		k, err := db.ContentAddressedWrite("hello", "string")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i > 0 && k != key {
+			t.Errorf("Key changed between writes: %s vs %s", key, k)
+		}
+		key = k
+	}
+	if key != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Errorf("Unexpected key %s", key)
+	}
+
+	entries, err := db.entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected 1 entry, got %d", len(entries))
+	}
+	size, err := db.blocks[0].size()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := int64(len((&Entry{key: key, valueType: STRING_TYPE, value: "hello"}).Encode())); size != expected {
+		t.Errorf("Expected file of %d bytes, got %d", expected, size)
+	}
+
+	e, err := db.ContentAddressedRead(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Value() != "hello" || e.Type() != "string" {
+		t.Errorf("Bad entry read: %v", *e)
+	}
+
+	keys, err := db.BatchContentAddressedWrite([]string{"1", "01", "2"}, "int64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 3 || keys[0] != keys[1] || keys[0] == keys[2] {
+		t.Errorf("Expected equal int64 values to share a key, got %v", keys)
+	}
+
+	if _, err := db.ContentAddressedWrite("x", "int64"); err == nil {
+		t.Error("Expected error for malformed int64 value")
+	}
+	if _, err := db.ContentAddressedRead("missing"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	//після видалення той самий вміст записується знову
+	if err := db.putTombstone(key); err != nil {
+		t.Fatal(err)
+	}
+	if k, err := db.ContentAddressedWrite("hello", "string"); err != nil || k != key {
+		t.Fatalf("Bad rewrite: %s, %v", k, err)
+	}
+	if e, err := db.ContentAddressedRead(key); err != nil || e.Value() != "hello" {
+		t.Errorf("Expected the rewritten value to be readable, got %v", err)
+	}
+}
+
+func TestContentAddressedWriteConcurrent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var writes int32
+	db.Watch(func(e *Entry) { atomic.AddInt32(&writes, 1) })
+	//поки база зайнята, усі записувачі встигають дійти до перевірки ключа
+	db.mu.Lock()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := db.ContentAddressedWrite("hello", "string"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	db.mu.Unlock()
+	wg.Wait()
+	if n := atomic.LoadInt32(&writes); n != 1 {
+		t.Errorf("Expected exactly 1 write of the same content, got %d", n)
+	}
+}
+
+func TestContentAddressedWriteTypeCollision(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	key, err := db.ContentAddressedWrite("1", "string")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k, err := db.ContentAddressedWrite("1", "int64"); err == nil {
+		t.Errorf("Expected an error for the same content of another type, got key %s", k)
+	}
+	if e, err := db.ContentAddressedRead(key); err != nil || e.Type() != "string" || e.Value() != "1" {
+		t.Errorf("Expected the string entry to stay, got %v, %v", e, err)
+	}
+}
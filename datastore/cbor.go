@@ -0,0 +1,240 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+)
+
+const (
+	cborUint   byte = 0
+	cborNegInt byte = 1
+	cborBytes  byte = 2
+	cborText   byte = 3
+	cborArray  byte = 4
+	cborMap    byte = 5
+	cborSimple byte = 7
+)
+
+const (
+	cborKeyField   = 1
+	cborTypeField  = 2
+	cborValueField = 3
+)
+
+func cborHead(dst []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(dst, major<<5|byte(n))
+	case n <= 0xff:
+		return append(dst, major<<5|24, byte(n))
+	case n <= 0xffff:
+		dst = append(dst, major<<5|25)
+		return binary.BigEndian.AppendUint16(dst, uint16(n))
+	case n <= 0xffffffff:
+		dst = append(dst, major<<5|26)
+		return binary.BigEndian.AppendUint32(dst, uint32(n))
+	default:
+		dst = append(dst, major<<5|27)
+		return binary.BigEndian.AppendUint64(dst, n)
+	}
+}
+
+func cborInt(dst []byte, v int64) []byte {
+	if v >= 0 {
+		return cborHead(dst, cborUint, uint64(v))
+	}
+	return cborHead(dst, cborNegInt, uint64(-1-v))
+}
+
+func cborString(dst []byte, s string) []byte {
+	dst = cborHead(dst, cborText, uint64(len(s)))
+	return append(dst, s...)
+}
+
+func CBOREncode(e *Entry) ([]byte, error) {
+	res := cborHead(nil, cborMap, 3)
+	res = cborHead(res, cborUint, cborKeyField)
+	res = cborString(res, e.key)
+	res = cborHead(res, cborUint, cborTypeField)
+	res = cborHead(res, cborUint, uint64(e.valueType))
+	res = cborHead(res, cborUint, cborValueField)
+
+	switch e.valueType {
+	case STRING_TYPE:
+		res = cborString(res, e.value)
+	case INT64_TYPE:
+		i, err := strconv.ParseInt(e.value, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		res = cborInt(res, i)
+	default:
+		return nil, fmt.Errorf("unsupported value type %d", e.valueType)
+	}
+	return res, nil
+}
+
+type cborReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *cborReader) head() (byte, uint64, error) {
+	if r.pos >= len(r.data) {
+		return 0, 0, fmt.Errorf("unexpected end of cbor data")
+	}
+	b := r.data[r.pos]
+	r.pos++
+	major, info := b>>5, b&0x1f
+
+	var size int
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, 0, fmt.Errorf("unsupported cbor additional info %d", info)
+	}
+	if r.pos+size > len(r.data) {
+		return 0, 0, fmt.Errorf("unexpected end of cbor data")
+	}
+	var n uint64
+	for _, c := range r.data[r.pos : r.pos+size] {
+		n = n<<8 | uint64(c)
+	}
+	r.pos += size
+	return major, n, nil
+}
+
+func (r *cborReader) uint() (uint64, error) {
+	major, n, err := r.head()
+	if err != nil {
+		return 0, err
+	}
+	if major != cborUint {
+		return 0, fmt.Errorf("expected cbor unsigned integer, got major type %d", major)
+	}
+	return n, nil
+}
+
+func (r *cborReader) text(major byte, n uint64) (string, error) {
+	if major != cborText {
+		return "", fmt.Errorf("expected cbor text string, got major type %d", major)
+	}
+	if n > uint64(len(r.data)-r.pos) {
+		return "", fmt.Errorf("unexpected end of cbor data")
+	}
+	s := string(r.data[r.pos : r.pos+int(n)])
+	r.pos += int(n)
+	return s, nil
+}
+
+func (r *cborReader) int(major byte, n uint64) (int64, error) {
+	switch major {
+	case cborUint:
+		if n > 1<<63-1 {
+			return 0, fmt.Errorf("cbor integer overflows int64")
+		}
+		return int64(n), nil
+	case cborNegInt:
+		if n > 1<<63-1 {
+			return 0, fmt.Errorf("cbor integer overflows int64")
+		}
+		return -1 - int64(n), nil
+	}
+	return 0, fmt.Errorf("expected cbor integer, got major type %d", major)
+}
+
+func CBORDecode(input []byte) (*Entry, error) {
+	r := &cborReader{data: input}
+	major, pairs, err := r.head()
+	if err != nil {
+		return nil, err
+	}
+	if major != cborMap {
+		return nil, fmt.Errorf("expected cbor map, got major type %d", major)
+	}
+
+	var (
+		e                         Entry
+		valueMajor                byte
+		valueArg                  uint64
+		valueStart                int
+		hasKey, hasType, hasValue bool
+	)
+	for i := uint64(0); i < pairs; i++ {
+		field, err := r.uint()
+		if err != nil {
+			return nil, err
+		}
+		switch field {
+		case cborKeyField:
+			major, n, err := r.head()
+			if err != nil {
+				return nil, err
+			}
+			e.key, err = r.text(major, n)
+			if err != nil {
+				return nil, err
+			}
+			hasKey = true
+		case cborTypeField:
+			t, err := r.uint()
+			if err != nil {
+				return nil, err
+			}
+			if t > 0xff {
+				return nil, fmt.Errorf("unknown value type %d", t)
+			}
+			if _, ok := operators[byte(t)]; !ok {
+				return nil, fmt.Errorf("unknown value type %d", t)
+			}
+			e.valueType = byte(t)
+			hasType = true
+		case cborValueField:
+			valueMajor, valueArg, err = r.head()
+			if err != nil {
+				return nil, err
+			}
+			valueStart = r.pos
+			if valueMajor == cborText {
+				if valueArg > uint64(len(r.data)-r.pos) {
+					return nil, fmt.Errorf("unexpected end of cbor data")
+				}
+				r.pos += int(valueArg)
+			}
+			hasValue = true
+		default:
+			return nil, fmt.Errorf("unknown cbor entry field %d", field)
+		}
+	}
+	if !hasKey || !hasType || !hasValue {
+		return nil, fmt.Errorf("incomplete cbor entry")
+	}
+
+	vr := &cborReader{data: input, pos: valueStart}
+	switch e.valueType {
+	case STRING_TYPE:
+		e.value, err = vr.text(valueMajor, valueArg)
+		if err != nil {
+			return nil, err
+		}
+	case INT64_TYPE:
+		i, err := vr.int(valueMajor, valueArg)
+		if err != nil {
+			return nil, err
+		}
+		e.value = strconv.FormatInt(i, 10)
+	default:
+		return nil, fmt.Errorf("unsupported value type %d", e.valueType)
+	}
+	return &e, nil
+}
@@ -0,0 +1,69 @@
+package datastore
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCBOREncode_Int64Golden(t *testing.T) {
+	// {1: "key", 2: 1, 3: -12} in canonical CBOR, the form cbor2.loads expects
+	golden := []byte{0xa3, 0x01, 0x63, 'k', 'e', 'y', 0x02, 0x01, 0x03, 0x2b}
+
+	e := Entry{key: "key", valueType: ToByte("int64"), value: "-12"}
+	data, err := CBOREncode(&e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, golden) {
+		t.Errorf("Bad encoding: expected %x, got %x", golden, data)
+	}
+
+	decoded, err := CBORDecode(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *decoded != e {
+		t.Errorf("Bad entry decoded: expected %v, got %v", e, *decoded)
+	}
+}
+
+func TestCBOR_RoundTrip(t *testing.T) {
+	entries := []Entry{
+		{key: "key", valueType: ToByte("string"), value: "value"},
+		{key: "", valueType: ToByte("string"), value: ""},
+		{key: "long", valueType: ToByte("string"), value: string(bytes.Repeat([]byte("x"), 70000))},
+		{key: "zero", valueType: ToByte("int64"), value: "0"},
+		{key: "small", valueType: ToByte("int64"), value: "23"},
+		{key: "max", valueType: ToByte("int64"), value: "9223372036854775807"},
+		{key: "min", valueType: ToByte("int64"), value: "-9223372036854775808"},
+	}
+	for _, e := range entries {
+		data, err := CBOREncode(&e)
+		if err != nil {
+			t.Fatalf("Cannot encode %s: %s", e.key, err)
+		}
+		decoded, err := CBORDecode(data)
+		if err != nil {
+			t.Fatalf("Cannot decode %s: %s", e.key, err)
+		}
+		if *decoded != e {
+			t.Errorf("Bad entry decoded for %s: got type %d, value of length %d", e.key, decoded.valueType, len(decoded.value))
+		}
+	}
+}
+
+func TestCBORDecode_Errors(t *testing.T) {
+	inputs := [][]byte{
+		{},
+		{0x01},
+		{0xa1, 0x01, 0x63, 'k'},
+		{0xa2, 0x01, 0x63, 'k', 'e', 'y', 0x02, 0x01},
+		{0xa3, 0x01, 0x60, 0x02, 0x00, 0x03, 0x01},
+		{0xa3, 0x01, 0x60, 0x02, 0x09, 0x03, 0x01},
+	}
+	for _, input := range inputs {
+		if _, err := CBORDecode(input); err == nil {
+			t.Errorf("Expected error for input %x", input)
+		}
+	}
+}
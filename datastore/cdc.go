@@ -0,0 +1,172 @@
+package datastore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CDCEvent is the JSON body CDCWebhookEmitter posts for every write. Delete
+// events carry only the key; binary values are sent in value_base64 as in
+// ExportJSON.
+type CDCEvent struct {
+	Op          string `json:"op"`
+	Key         string `json:"key"`
+	Type        string `json:"type,omitempty"`
+	Value       string `json:"value,omitempty"`
+	ValueBase64 string `json:"value_base64,omitempty"`
+	Timestamp   int64  `json:"timestamp"`
+}
+
+type CDCOptions struct {
+	// MaxRetries is how many times a failed POST is repeated before the
+	// event is dropped.
+	MaxRetries int
+	// InitialDelay is the wait before the first retry; it doubles after
+	// every attempt. Defaults to 100ms.
+	InitialDelay time.Duration
+	// BufferSize is how many events wait for delivery before new ones are
+	// dropped. Defaults to 1024.
+	BufferSize int
+	Client     *http.Client
+}
+
+// CDCWebhookEmitter posts the writes of a Db to a webhook from a background
+// goroutine, so a slow endpoint doesn't hold up writers.
+type CDCWebhookEmitter struct {
+	url     string
+	opts    CDCOptions
+	db      *Db
+	events  chan CDCEvent
+	unwatch func()
+	done    chan struct{}
+
+	//mu не дає enqueue писати в закритий канал: runWatchers може викликати
+	//його вже після unwatch
+	mu      sync.Mutex
+	closed  bool
+	dropped int64
+	sleep   func(time.Duration)
+}
+
+// CDCWebhook starts posting every write to db to webhookURL until Close.
+func CDCWebhook(db *Db, webhookURL string, opts CDCOptions) (*CDCWebhookEmitter, error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported webhook url scheme %q", u.Scheme)
+	}
+	if opts.InitialDelay <= 0 {
+		opts.InitialDelay = 100 * time.Millisecond
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 1024
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	w := &CDCWebhookEmitter{
+		url:    webhookURL,
+		opts:   opts,
+		db:     db,
+		events: make(chan CDCEvent, opts.BufferSize),
+		done:   make(chan struct{}),
+		sleep:  time.Sleep,
+	}
+	w.unwatch = db.Watch(w.enqueue)
+	go w.run()
+	return w, nil
+}
+
+func newCDCEvent(e *Entry) CDCEvent {
+	ev := CDCEvent{Op: "delete", Key: e.key, Timestamp: timeNow().Unix()}
+	if e.valueType != TOMBSTONE_TYPE && e.valueType != SOFT_DELETED_TYPE {
+		record := newExportRecord(e)
+		ev.Op, ev.Type, ev.Value, ev.ValueBase64 = "set", record.Type, record.Value, record.ValueBase64
+	}
+	return ev
+}
+
+// enqueue працює на горутині запису, тож не чекає: при повному буфері
+// подія відкидається
+func (w *CDCWebhookEmitter) enqueue(e *Entry) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	select {
+	case w.events <- newCDCEvent(e):
+	default:
+		atomic.AddInt64(&w.dropped, 1)
+		if w.db.logger != nil {
+			w.db.logger.Warn("cdc buffer is full, dropping event", "key", e.key)
+		}
+	}
+}
+
+// Dropped returns how many events were lost to a full buffer or to running
+// out of retries.
+func (w *CDCWebhookEmitter) Dropped() int64 {
+	return atomic.LoadInt64(&w.dropped)
+}
+
+func (w *CDCWebhookEmitter) run() {
+	defer close(w.done)
+	for ev := range w.events {
+		if err := w.deliver(ev); err != nil {
+			atomic.AddInt64(&w.dropped, 1)
+			if w.db.logger != nil {
+				w.db.logger.Error("cdc webhook failed", "key", ev.Key, "error", err)
+			}
+		}
+	}
+}
+
+func (w *CDCWebhookEmitter) deliver(ev CDCEvent) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	delay := w.opts.InitialDelay
+	for retry := 0; ; retry++ {
+		err = w.post(body)
+		if err == nil || retry >= w.opts.MaxRetries {
+			return err
+		}
+		w.sleep(delay)
+		delay *= 2
+	}
+}
+
+func (w *CDCWebhookEmitter) post(body []byte) error {
+	resp, err := w.opts.Client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("cdc webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+// Close stops watching db and waits until the buffered events are delivered.
+func (w *CDCWebhookEmitter) Close() error {
+	w.unwatch()
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.events)
+	}
+	w.mu.Unlock()
+	<-w.done
+	return nil
+}
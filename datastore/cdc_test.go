@@ -0,0 +1,80 @@
+package datastore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCDCWebhook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var mu sync.Mutex
+	var received []CDCEvent
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		//кожен третій запит падає, щоб перевірити повтори
+		requests++
+		if requests%3 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var ev CDCEvent
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Error(err)
+		}
+		received = append(received, ev)
+	}))
+	defer server.Close()
+
+	if _, err := CDCWebhook(db, "ftp://example.com", CDCOptions{}); err == nil {
+		t.Errorf("Expected an error for an unsupported scheme")
+	}
+	cdc, err := CDCWebhook(db, server.URL, CDCOptions{MaxRetries: 3, InitialDelay: time.Millisecond, BufferSize: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 99; i++ {
+		if err := db.Put("key"+strconv.Itoa(i), "value"+strconv.Itoa(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.SoftDelete("key0", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	cdc.Close()
+	if err := db.Put("after", "close"); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 100 || cdc.Dropped() != 0 {
+		t.Fatalf("Expected 100 events, got %d (%d dropped)", len(received), cdc.Dropped())
+	}
+	for i, ev := range received[:99] {
+		if ev.Op != "set" || ev.Key != "key"+strconv.Itoa(i) || ev.Type != "string" || ev.Value != "value"+strconv.Itoa(i) || ev.Timestamp == 0 {
+			t.Errorf("Bad event %d: %+v", i, ev)
+		}
+	}
+	if ev := received[99]; ev.Op != "delete" || ev.Key != "key0" {
+		t.Errorf("Bad delete event: %+v", ev)
+	}
+}
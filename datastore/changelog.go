@@ -0,0 +1,228 @@
+package datastore
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	ENTRY_WRITTEN_EVENT = "datastore.entry.written"
+	ENTRY_DELETED_EVENT = "datastore.entry.deleted"
+)
+
+// ChangelogEmitter receives every entry a Db writes, after the write is on
+// disk. Emit runs on the writing goroutine, so it should not block; its
+// errors are logged, since the write has already succeeded. An emitter that
+// implements io.Closer is closed by Db.Close.
+type ChangelogEmitter interface {
+	Emit(source string, e *Entry) error
+}
+
+// Watch calls fn with every entry db writes, after the write is on disk and
+// before the changelog emitter sees it, until the returned function is
+// called. fn runs on the writing goroutine and must not block.
+func (db *Db) Watch(fn func(e *Entry)) (unwatch func()) {
+	db.hooksMu.Lock()
+	defer db.hooksMu.Unlock()
+	if db.watchers == nil {
+		db.watchers = make(map[int]func(e *Entry))
+	}
+	id := db.nextWatcher
+	db.nextWatcher++
+	db.watchers[id] = fn
+	return func() {
+		db.hooksMu.Lock()
+		defer db.hooksMu.Unlock()
+		delete(db.watchers, id)
+	}
+}
+
+func (db *Db) runWatchers(e *Entry) {
+	db.hooksMu.Lock()
+	if len(db.watchers) == 0 {
+		db.hooksMu.Unlock()
+		return
+	}
+	watchers := make([]func(e *Entry), 0, len(db.watchers))
+	for _, fn := range db.watchers {
+		watchers = append(watchers, fn)
+	}
+	db.hooksMu.Unlock()
+	for _, fn := range watchers {
+		fn(e)
+	}
+}
+
+// CloudEvent is a CloudEvents v1.0 event in the structured JSON format.
+type CloudEvent struct {
+	SpecVersion     string    `json:"specversion"`
+	ID              string    `json:"id"`
+	Source          string    `json:"source"`
+	Type            string    `json:"type"`
+	Subject         string    `json:"subject,omitempty"`
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype,omitempty"`
+	DataBase64      []byte    `json:"data_base64"`
+}
+
+func NewCloudEvent(source string, e *Entry) (*CloudEvent, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	data, err := EncodeInto(e, nil)
+	if err != nil {
+		return nil, err
+	}
+	eventType := ENTRY_WRITTEN_EVENT
+	if e.valueType == TOMBSTONE_TYPE || e.valueType == SOFT_DELETED_TYPE {
+		eventType = ENTRY_DELETED_EVENT
+	}
+	return &CloudEvent{
+		SpecVersion:     "1.0",
+		ID:              hex.EncodeToString(id),
+		Source:          source,
+		Type:            eventType,
+		Subject:         e.key,
+		Time:            timeNow().UTC(),
+		DataContentType: "application/octet-stream",
+		DataBase64:      data,
+	}, nil
+}
+
+// Entry decodes the entry carried by the event.
+func (ev *CloudEvent) Entry() (*Entry, error) {
+	entries, err := DecodeMany(ev.DataBase64)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) != 1 {
+		return nil, fmt.Errorf("expected 1 entry in event data, got %d", len(entries))
+	}
+	return entries[0], nil
+}
+
+const (
+	cloudEventsBufferSize = 1024
+	cloudEventsMaxRetries = 3
+)
+
+// cloudEventsHTTPSink шле події з окремої горутини, як CDCWebhookEmitter:
+// повільний приймач не затримує записи, а збій доставки не робить уже
+// зафіксований запис невдалим
+type cloudEventsHTTPSink struct {
+	url    string
+	client *http.Client
+	logger *slog.Logger
+	events chan []byte
+	done   chan struct{}
+
+	//mu не дає Emit писати в закритий канал
+	mu      sync.Mutex
+	closed  bool
+	dropped int64
+	sleep   func(time.Duration)
+}
+
+// CloudEventsHTTPSink posts each event to sinkURL in structured content
+// mode. Events are sent from a background goroutine and retried with
+// backoff; failed deliveries are logged and dropped. Db.Close closes the
+// sink, which waits for the buffered events.
+func CloudEventsHTTPSink(sinkURL string) (ChangelogEmitter, error) {
+	u, err := url.Parse(sinkURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported sink url scheme %q", u.Scheme)
+	}
+	s := &cloudEventsHTTPSink{
+		url:    sinkURL,
+		client: &http.Client{Timeout: 10 * time.Second},
+		logger: slog.Default(),
+		events: make(chan []byte, cloudEventsBufferSize),
+		done:   make(chan struct{}),
+		sleep:  time.Sleep,
+	}
+	go s.run()
+	return s, nil
+}
+
+// Emit лише ставить подію в чергу; помилка означає, що її не буде надіслано
+func (s *cloudEventsHTTPSink) Emit(source string, e *Entry) error {
+	ev, err := NewCloudEvent(source, e)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return fmt.Errorf("cloudevents sink is closed")
+	}
+	select {
+	case s.events <- body:
+		return nil
+	default:
+		atomic.AddInt64(&s.dropped, 1)
+		return fmt.Errorf("cloudevents sink buffer is full, dropping event for %s", e.key)
+	}
+}
+
+func (s *cloudEventsHTTPSink) run() {
+	defer close(s.done)
+	for body := range s.events {
+		if err := s.deliver(body); err != nil {
+			atomic.AddInt64(&s.dropped, 1)
+			s.logger.Error("cloudevents sink failed", "url", s.url, "error", err)
+		}
+	}
+}
+
+func (s *cloudEventsHTTPSink) deliver(body []byte) error {
+	delay := 100 * time.Millisecond
+	for retry := 0; ; retry++ {
+		err := s.post(body)
+		if err == nil || retry >= cloudEventsMaxRetries {
+			return err
+		}
+		s.sleep(delay)
+		delay *= 2
+	}
+}
+
+func (s *cloudEventsHTTPSink) post(body []byte) error {
+	resp, err := s.client.Post(s.url, "application/cloudevents+json; charset=utf-8", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("cloudevents sink responded %s", resp.Status)
+	}
+	return nil
+}
+
+// Close waits until the buffered events are delivered or dropped.
+func (s *cloudEventsHTTPSink) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.events)
+	}
+	s.mu.Unlock()
+	<-s.done
+	return nil
+}
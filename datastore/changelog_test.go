@@ -0,0 +1,156 @@
+package datastore
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCloudEventsHTTPSink(t *testing.T) {
+	var events []CloudEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/cloudevents+json; charset=utf-8" {
+			t.Errorf("Bad content type %q", ct)
+		}
+		var ev CloudEvent
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Error(err)
+		}
+		events = append(events, ev)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sink, err := CloudEventsHTTPSink(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir, WithChangelog(sink))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := db.Put("key", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.PutInt64("counter", 42); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SoftDelete("key", 0); err != nil {
+		t.Fatal(err)
+	}
+	//Close дочікується доставки з черги
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		eventType, key, value string
+	}{
+		{ENTRY_WRITTEN_EVENT, "key", "value"},
+		{ENTRY_WRITTEN_EVENT, "counter", "42"},
+		{ENTRY_DELETED_EVENT, "key", "value"},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d", len(expected), len(events))
+	}
+	ids := make(map[string]bool)
+	for i, ev := range events {
+		if ev.SpecVersion != "1.0" || ev.Source != dir || ev.Type != expected[i].eventType || ev.Subject != expected[i].key {
+			t.Errorf("Bad event %d: %+v", i, ev)
+		}
+		if ev.ID == "" || ids[ev.ID] {
+			t.Errorf("Expected unique event id, got %q", ev.ID)
+		}
+		ids[ev.ID] = true
+		e, err := ev.Entry()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Key() != expected[i].key || e.Value() != expected[i].value {
+			t.Errorf("Bad entry in event %d: %v", i, e)
+		}
+	}
+
+	if _, err := CloudEventsHTTPSink("ftp://example.com"); err == nil {
+		t.Error("Expected error for unsupported scheme")
+	}
+}
+
+func TestCloudEventsHTTPSink_Rejected(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	emitter, err := CloudEventsHTTPSink(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := emitter.(*cloudEventsHTTPSink)
+	sink.sleep = func(time.Duration) {}
+	sink.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir, WithChangelog(sink))
+	if err != nil {
+		t.Fatal(err)
+	}
+	//відмова приймача не робить записаний ключ невдалим
+	if err := db.Put("key", "value"); err != nil {
+		t.Errorf("Expected the write to succeed, got %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&attempts); n != 1+cloudEventsMaxRetries {
+		t.Errorf("Expected %d attempts, got %d", 1+cloudEventsMaxRetries, n)
+	}
+	if n := atomic.LoadInt64(&sink.dropped); n != 1 {
+		t.Errorf("Expected 1 dropped event, got %d", n)
+	}
+	if err := sink.Emit("source", &Entry{key: "key", valueType: STRING_TYPE}); err == nil {
+		t.Errorf("Expected an error emitting to a closed sink")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var seen []string
+	unwatch := db.Watch(func(e *Entry) { seen = append(seen, e.key+"="+e.value) })
+	db.Put("a", "1")
+	db.PutInt64("b", 2)
+	unwatch()
+	db.Put("c", "3")
+	if len(seen) != 2 || seen[0] != "a=1" || seen[1] != "b=2" {
+		t.Errorf("Bad watched writes: %v", seen)
+	}
+}
@@ -0,0 +1,124 @@
+//go:build failpoint
+
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var chaosFailpoints = []string{"block.write", "block.truncate", "block.recover", "block.merge", "db.merge.delete"}
+
+// TestChaos runs random writes with every failpoint firing at 1%. Any error
+// is treated as a crash: the database is reopened and every key must hold
+// either its last acknowledged value or the value of the failed write.
+func TestChaos(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for i, name := range chaosFailpoints {
+		enableFailpoint(name, 0.01, int64(i+2))
+	}
+	defer func() {
+		for _, name := range chaosFailpoints {
+			disableFailpoint(name)
+		}
+	}()
+
+	open := func() *Db {
+		//відмова під час відновлення індексу теж не має нічого зіпсувати
+		for attempt := 0; attempt < 1000; attempt++ {
+			db, err := NewDb(dir, WithMaxFileSize(4096))
+			if err == nil {
+				return db
+			}
+		}
+		t.Fatal("could not reopen the database")
+		return nil
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	model := make(map[string]string)
+	db := open()
+	crashes := 0
+	for op := 0; op < 10000; op++ {
+		key := fmt.Sprintf("key-%d", rnd.Intn(50))
+		value := strings.Repeat(fmt.Sprint(op), 1+rnd.Intn(8))
+		del := rnd.Intn(10) == 0
+		if del {
+			err = db.putTombstone(key)
+		} else {
+			err = db.Put(key, value)
+		}
+		if err == nil {
+			if del {
+				delete(model, key)
+			} else {
+				model[key] = value
+			}
+			continue
+		}
+
+		crashes++
+		db.Close()
+		db = open()
+		got, getErr := db.Get(key)
+		old, existed := model[key]
+		switch {
+		case getErr == ErrNotFound && (del || !existed):
+			delete(model, key)
+		case getErr == nil && !del && got == value:
+			model[key] = value
+		case getErr == nil && existed && got == old:
+		default:
+			t.Fatalf("op %d: after failed write of %q got %q, %v; last committed %q", op, key, got, getErr, old)
+		}
+	}
+	db.Close()
+
+	for _, name := range chaosFailpoints {
+		t.Logf("%s fired %d times", name, disableFailpoint(name))
+	}
+	t.Logf("%d crashes", crashes)
+	db, err = NewDb(dir, WithMaxFileSize(4096))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if crashes == 0 {
+		t.Errorf("Expected some failpoints to fire")
+	}
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		got, err := db.Get(key)
+		want, ok := model[key]
+		if ok && (err != nil || got != want) {
+			t.Errorf("Bad value for %s: got %q, %v; want %q", key, got, err, want)
+		}
+		if !ok && err != ErrNotFound {
+			t.Errorf("Expected %s to be missing, got %q, %v", key, got, err)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := DecodeMany(data); err != nil {
+			t.Errorf("Corrupt segment %s: %v", filepath.Base(path), err)
+		}
+	}
+}
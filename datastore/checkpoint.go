@@ -0,0 +1,191 @@
+package datastore
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func checkpointPath(db *Db) string {
+	return strings.TrimRight(db.dir, string(os.PathSeparator)) + ".ckpt"
+}
+
+// Checkpoint writes the latest version of every key to dst and records the
+// current end of each segment in a .ckpt file next to the database directory.
+// The next NewDb loads dst and replays only the records written after it.
+func Checkpoint(db *Db, dst string) error {
+	dst, err := filepath.Abs(dst)
+	if err != nil {
+		return err
+	}
+	dir, err := filepath.Abs(db.dir)
+	if err != nil {
+		return err
+	}
+	if filepath.Dir(dst) == dir {
+		return fmt.Errorf("checkpoint %s can't be stored in the database directory", dst)
+	}
+	//весь знімок під db.mu: мердж між ним і .ckpt переписав би сегменти
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if len(db.blocks) != 0 && db.blocks[0].snapshot && db.blocks[0].outPath == dst {
+		return fmt.Errorf("checkpoint %s is in use", dst)
+	}
+
+	//зміщення беремо до знімку: записи після них буде прочитано повторно
+	names := make([]string, 0, len(db.blocks))
+	offsets := make([]int64, 0, len(db.blocks))
+	for _, b := range db.blocks {
+		if b.snapshot {
+			continue
+		}
+		b.mu.RLock()
+		names = append(names, filepath.Base(b.outPath))
+		offsets = append(offsets, b.outOffset)
+		b.mu.RUnlock()
+	}
+
+	//знімок - повні записи з анотаціями, інакше TTL і мітки не переживуть його
+	err = writeFileAtomic(dst, func(w *bufio.Writer) error {
+		var buf []byte
+		return db.scanLatest(func(key string, e *Entry) error {
+			if e == nil {
+				return nil
+			}
+			var err error
+			if buf, err = EncodeInto(e, buf[:0]); err != nil {
+				return err
+			}
+			_, err = w.Write(buf)
+			return err
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(checkpointPath(db), func(w *bufio.Writer) error {
+		if err := writeString(w, dst); err != nil {
+			return err
+		}
+		var n [8]byte
+		binary.LittleEndian.PutUint32(n[:], uint32(len(names)))
+		if _, err := w.Write(n[:4]); err != nil {
+			return err
+		}
+		for i, name := range names {
+			if err := writeString(w, name); err != nil {
+				return err
+			}
+			binary.LittleEndian.PutUint64(n[:], uint64(offsets[i]))
+			if _, err := w.Write(n[:]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func writeFileAtomic(path string, write func(w *bufio.Writer) error) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	err = write(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadCheckpoint відкриває знімок як найстаріший блок і повертає, з якого
+// зміщення треба відновлювати кожен сегмент. Застарілий чекпоінт ігнорується.
+func (db *Db) loadCheckpoint(filesNames []string) (map[string]int64, error) {
+	f, err := os.Open(checkpointPath(db))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	snapshotPath, err := readString(r)
+	if err != nil {
+		return nil, fmt.Errorf("corrupted checkpoint: %v", err)
+	}
+	var n [8]byte
+	if _, err := io.ReadFull(r, n[:4]); err != nil {
+		return nil, fmt.Errorf("corrupted checkpoint: %v", err)
+	}
+	count := binary.LittleEndian.Uint32(n[:4])
+	offsets := make(map[string]int64, count)
+	for i := uint32(0); i < count; i++ {
+		name, err := readString(r)
+		if err != nil {
+			return nil, fmt.Errorf("corrupted checkpoint: %v", err)
+		}
+		if _, err := io.ReadFull(r, n[:]); err != nil {
+			return nil, fmt.Errorf("corrupted checkpoint: %v", err)
+		}
+		offsets[name] = int64(binary.LittleEndian.Uint64(n[:]))
+	}
+
+	present := make(map[string]bool, len(filesNames))
+	for _, name := range filesNames {
+		present[name] = true
+	}
+	for name, offset := range offsets {
+		if !present[name] {
+			return nil, nil
+		}
+		info, err := os.Stat(filepath.Join(db.dir, name))
+		if err != nil {
+			return nil, err
+		}
+		if info.Size() < offset {
+			return nil, nil
+		}
+	}
+
+	b, err := openBlock(filepath.Dir(snapshotPath), filepath.Base(snapshotPath), true, 0, db.newIndex())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	b.snapshot = true
+	b.cache = db.pageCache
+	b.bufferSize = db.bufferSize
+	db.blocks = append(db.blocks, b)
+	if db.logger != nil {
+		db.logger.Debug("loaded checkpoint", "path", snapshotPath)
+	}
+	return offsets, nil
+}
+
+func removeCheckpoint(db *Db) error {
+	err := os.Remove(checkpointPath(db))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dbDir := filepath.Join(dir, "db")
+	snapshot := filepath.Join(dir, "snapshot")
+
+	db, err := NewDb(dbDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if err := db.Put(fmt.Sprintf("key%d", i), "old"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.PutInt64("counter", 42); err != nil {
+		t.Fatal(err)
+	}
+	if err := Checkpoint(db, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if err := Checkpoint(db, filepath.Join(dbDir, "snapshot")); err == nil {
+		t.Error("Expected error for checkpoint inside the database directory")
+	}
+
+	// половина нових записів перезаписує ключі зі знімку
+	for i := 50; i < 150; i++ {
+		if err := db.Put(fmt.Sprintf("key%d", i), "new"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	db, err = NewDb(dbDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if len(db.blocks) != 2 || !db.blocks[0].snapshot {
+		t.Fatalf("Expected snapshot block to be loaded, got %d blocks", len(db.blocks))
+	}
+	if replayed := db.blocks[1].index.Len(); replayed != 100 {
+		t.Errorf("Expected 100 replayed keys, got %d", replayed)
+	}
+	for i := 0; i < 150; i++ {
+		expected := "old"
+		if i >= 50 {
+			expected = "new"
+		}
+		value, err := db.Get(fmt.Sprintf("key%d", i))
+		if err != nil || value != expected {
+			t.Errorf("Bad value for key%d: %q, %v", i, value, err)
+		}
+	}
+	if n, err := db.GetInt64("counter"); err != nil || n != 42 {
+		t.Errorf("Bad counter: %d, %v", n, err)
+	}
+	if err := Checkpoint(db, snapshot); err == nil {
+		t.Error("Expected error for overwriting the loaded checkpoint")
+	}
+}
+
+func TestCheckpoint_Merge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dbDir := filepath.Join(dir, "db")
+
+	db, err := NewDb(dbDir, WithMaxFileSize(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put("first", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Checkpoint(db, filepath.Join(dir, "snapshot")); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		if err := db.Put(fmt.Sprintf("key%d", i), "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	if _, err := os.Stat(checkpointPath(db)); !os.IsNotExist(err) {
+		t.Errorf("Expected merge to drop the checkpoint, got %v", err)
+	}
+	db, err = NewDb(dbDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, key := range []string{"first", "key0", "key49"} {
+		if value, err := db.Get(key); err != nil || value != "value" {
+			t.Errorf("Bad value for %s: %q, %v", key, value, err)
+		}
+	}
+}
+
+func TestCheckpointConcurrentWrites(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(filepath.Join(dir, "db"), WithMaxFileSize(200))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	//дрібні сегменти: записи постійно відкривають нові блоки й запускають мердж
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 300; i++ {
+			if err := db.Put(fmt.Sprintf("key%d", i%40), strconv.Itoa(i)); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		if err := Checkpoint(db, filepath.Join(dir, "snapshot")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+}
+
+func TestCheckpointKeepsAnnotations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dbDir := filepath.Join(dir, "db")
+	snapshot := filepath.Join(dir, "snapshot")
+
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	db, err := NewDb(dbDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.PutWithTTL("session", "token", 0, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put("trash", "kept"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SoftDelete("trash", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	annotated := &Entry{key: "annotated", valueType: STRING_TYPE, value: "v"}
+	annotated.Annotate("owner", "billing")
+	if err := db.putEntry(annotated); err != nil {
+		t.Fatal(err)
+	}
+	if err := Checkpoint(db, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	db, err = NewDb(dbDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if len(db.blocks) == 0 || !db.blocks[0].snapshot {
+		t.Fatal("Expected the snapshot block to be loaded")
+	}
+	e, err := db.getEntry("annotated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner, ok := e.Annotation("owner"); !ok || owner != "billing" {
+		t.Errorf("Expected the annotation to survive the checkpoint, got %v", e.Annotations())
+	}
+
+	//ключ з TTL після чекпоінту має так само застаріти
+	if v, err := db.Get("session"); err != nil || v != "token" {
+		t.Errorf("Expected the TTL key before its expiry, got %q, %v", v, err)
+	}
+
+	//м'яко видалений ключ лишається доступним до кінця вікна зберігання
+	if _, err := db.Get("trash"); err != ErrNotFound {
+		t.Errorf("Expected the soft-deleted key to stay hidden, got %v", err)
+	}
+	if e, err := db.LookupIncludingDeleted("trash"); err != nil || !e.IsSoftDeleted() || e.Value() != "kept" {
+		t.Errorf("Expected the soft-deleted key within its retain window, got %v, %v", e, err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err := db.Get("session"); err != ErrNotFound {
+		t.Errorf("Expected the TTL key to expire after the checkpoint, got %v", err)
+	}
+	if err := Prune(db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.LookupIncludingDeleted("trash"); err != ErrNotFound {
+		t.Errorf("Expected Prune to remove the soft-deleted key, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultCircuitThreshold = 5
+	defaultCircuitCooldown  = 30 * time.Second
+)
+
+var circuitStateNames = map[CircuitState]string{
+	CIRCUIT_CLOSED:    "closed",
+	CIRCUIT_OPEN:      "open",
+	CIRCUIT_HALF_OPEN: "half-open",
+}
+
+func (s CircuitState) String() string {
+	if name, ok := circuitStateNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// storedCircuit - стан запобіжника, що переживає перезапуск процесу;
+// розмітка: [стан з префіксом довжини][збої i64][останній збій UnixNano i64][проби i64]
+type storedCircuit struct {
+	state            CircuitState
+	failureCount     int64
+	lastFailureNs    int64
+	halfOpenAttempts int64
+}
+
+func (c *storedCircuit) encode() string {
+	res := appendLengthPrefixed(nil, c.state.String())
+	for _, n := range []int64{c.failureCount, c.lastFailureNs, c.halfOpenAttempts} {
+		res = binary.LittleEndian.AppendUint64(res, uint64(n))
+	}
+	return string(res)
+}
+
+func parseStoredCircuit(data string) (*storedCircuit, error) {
+	name, data, err := readLengthPrefixed(data)
+	if err != nil {
+		return nil, err
+	}
+	c := &storedCircuit{state: -1}
+	for s, n := range circuitStateNames {
+		if n == name {
+			c.state = s
+		}
+	}
+	if c.state < 0 || len(data) != 24 {
+		return nil, fmt.Errorf("corrupted circuit state")
+	}
+	raw := []byte(data)
+	c.failureCount = int64(binary.LittleEndian.Uint64(raw))
+	c.lastFailureNs = int64(binary.LittleEndian.Uint64(raw[8:]))
+	c.halfOpenAttempts = int64(binary.LittleEndian.Uint64(raw[16:]))
+	return c, nil
+}
+
+func validateStoredCircuit(data string) error {
+	_, err := parseStoredCircuit(data)
+	return err
+}
+
+func (db *Db) circuitSettings() (int64, time.Duration) {
+	threshold, cooldown := int64(db.circuitThreshold), db.circuitCooldown
+	if threshold <= 0 {
+		threshold = defaultCircuitThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = defaultCircuitCooldown
+	}
+	return threshold, cooldown
+}
+
+// effectiveState - як і в CircuitBreaker.State, відкритий запобіжник після
+// охолодження вважається напіввідкритим без окремого запису
+func (c *storedCircuit) effectiveState(cooldown time.Duration) CircuitState {
+	if c.state == CIRCUIT_OPEN && timeNow().UnixNano()-c.lastFailureNs >= int64(cooldown) {
+		return CIRCUIT_HALF_OPEN
+	}
+	return c.state
+}
+
+func storedCircuitOf(e *Entry) (*storedCircuit, error) {
+	if e == nil {
+		return &storedCircuit{state: CIRCUIT_CLOSED}, nil
+	}
+	if e.valueType != CIRCUIT_STATE_TYPE {
+		return nil, fmt.Errorf("wrong type of value")
+	}
+	return parseStoredCircuit(e.value)
+}
+
+// GetCircuitState returns the state of the persisted circuit at key; a
+// missing key is a closed circuit.
+func GetCircuitState(db *Db, key string) (CircuitState, error) {
+	e, err := db.getEntry(key)
+	if err == ErrNotFound {
+		e, err = nil, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	c, err := storedCircuitOf(e)
+	if err != nil {
+		return 0, err
+	}
+	_, cooldown := db.circuitSettings()
+	return c.effectiveState(cooldown), nil
+}
+
+// RecordFailure counts a failure against the circuit at key. The circuit
+// opens once the failures reach the threshold set with
+// WithCircuitStateDefaults, and a failed probe in the half-open state opens
+// it again at once.
+func RecordFailure(db *Db, key string) error {
+	threshold, cooldown := db.circuitSettings()
+	_, err := db.updateEntry(key, func(cur *Entry) (*Entry, error) {
+		c, err := storedCircuitOf(cur)
+		if err != nil {
+			return nil, err
+		}
+		if c.effectiveState(cooldown) == CIRCUIT_HALF_OPEN {
+			c.halfOpenAttempts++
+			c.state = CIRCUIT_OPEN
+		}
+		c.failureCount++
+		c.lastFailureNs = timeNow().UnixNano()
+		if c.failureCount >= threshold {
+			c.state = CIRCUIT_OPEN
+		}
+		return &Entry{valueType: CIRCUIT_STATE_TYPE, value: c.encode()}, nil
+	})
+	return err
+}
+
+// RecordSuccess closes the circuit at key and clears its counters.
+func RecordSuccess(db *Db, key string) error {
+	_, err := db.updateEntry(key, func(cur *Entry) (*Entry, error) {
+		c, err := storedCircuitOf(cur)
+		if err != nil {
+			return nil, err
+		}
+		if cur == nil || *c == (storedCircuit{state: CIRCUIT_CLOSED}) {
+			return nil, nil
+		}
+		return &Entry{valueType: CIRCUIT_STATE_TYPE, value: (&storedCircuit{state: CIRCUIT_CLOSED}).encode()}, nil
+	})
+	return err
+}
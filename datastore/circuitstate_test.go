@@ -0,0 +1,76 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPersistedCircuitState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	now := time.Unix(1700000000, 0)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	opts := WithCircuitStateDefaults(3, time.Minute)
+	db, err := NewDb(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err := GetCircuitState(db, "payments"); err != nil || s != CIRCUIT_CLOSED {
+		t.Errorf("Expected a missing circuit to be closed, got %v, %v", s, err)
+	}
+	for i := 0; i < 3; i++ {
+		if s, _ := GetCircuitState(db, "payments"); s != CIRCUIT_CLOSED {
+			t.Errorf("Expected the circuit to be closed after %d failures, got %v", i, s)
+		}
+		if err := RecordFailure(db, "payments"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	//новий процес бачить той самий стан
+	db, err = NewDb(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if s, err := GetCircuitState(db, "payments"); err != nil || s != CIRCUIT_OPEN {
+		t.Errorf("Expected the circuit to stay open after reopening, got %v, %v", s, err)
+	}
+
+	now = now.Add(time.Minute)
+	if s, _ := GetCircuitState(db, "payments"); s != CIRCUIT_HALF_OPEN {
+		t.Errorf("Expected the circuit to be half-open after the cooldown, got %v", s)
+	}
+	if err := RecordFailure(db, "payments"); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := GetCircuitState(db, "payments"); s != CIRCUIT_OPEN {
+		t.Errorf("Expected a failed probe to reopen the circuit, got %v", s)
+	}
+	e, _ := db.getEntry("payments")
+	if c, err := storedCircuitOf(e); err != nil || c.failureCount != 4 || c.halfOpenAttempts != 1 {
+		t.Errorf("Bad stored circuit: %+v, %v", c, err)
+	}
+
+	now = now.Add(time.Minute)
+	if err := RecordSuccess(db, "payments"); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := GetCircuitState(db, "payments"); s != CIRCUIT_CLOSED {
+		t.Errorf("Expected a success to close the circuit, got %v", s)
+	}
+	if err := RecordFailure(db, "payments"); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := GetCircuitState(db, "payments"); s != CIRCUIT_CLOSED {
+		t.Errorf("Expected the failure count to restart after closing, got %v", s)
+	}
+}
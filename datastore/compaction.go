@@ -0,0 +1,60 @@
+package datastore
+
+import "path/filepath"
+
+// RegisterPostCompactionHook adds fn to the hooks called, in registration
+// order, after every merge of segments with the path of the merged segment.
+// Hook errors are logged and do not fail the write that caused the merge.
+func (db *Db) RegisterPostCompactionHook(fn func(compactedPath string) error) {
+	db.hooksMu.Lock()
+	defer db.hooksMu.Unlock()
+	db.compactionHooks = append(db.compactionHooks, fn)
+}
+
+// unlockWrite відпускає db.mu і вже поза блокуванням запускає хуки,
+// щоб вони могли читати базу
+func (db *Db) unlockWrite() {
+	compacted, quotaReached := db.compacted, db.quotaReached
+	db.compacted, db.quotaReached = false, 0
+	db.mu.Unlock()
+	if compacted {
+		db.runPostCompactionHooks(filepath.Join(db.dir, db.segmentName+"0"))
+	}
+	if quotaReached > 0 {
+		db.runQuotaHooks(quotaReached)
+	}
+}
+
+func (db *Db) runPostCompactionHooks(path string) {
+	db.hooksMu.Lock()
+	hooks := append([]func(string) error(nil), db.compactionHooks...)
+	db.hooksMu.Unlock()
+	for _, hook := range hooks {
+		if err := hook(path); err != nil && db.logger != nil {
+			db.logger.Warn("post-compaction hook failed", "path", path, "error", err)
+		}
+	}
+}
+
+// Compact seals the active segment and merges every segment into one,
+// dropping overwritten, deleted and expired versions.
+func (db *Db) Compact() error {
+	if db.readOnly {
+		return ErrReadOnly
+	}
+	db.mu.Lock()
+	defer db.unlockWrite()
+	size, err := db.blocks[len(db.blocks)-1].size()
+	if err != nil {
+		return err
+	}
+	if size > 0 {
+		if err := db.addNewBlockToDb(); err != nil {
+			return err
+		}
+	}
+	if len(db.blocks) < 2 {
+		return nil
+	}
+	return db.merge()
+}
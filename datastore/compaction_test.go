@@ -0,0 +1,93 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPostCompactionHook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for i := 0; i < 10; i++ {
+		if err := db.Put(fmt.Sprintf("key%d", i%3), fmt.Sprintf("word%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var order []string
+	var paths []string
+	var index *InvertedIndex
+	db.RegisterPostCompactionHook(func(path string) error {
+		order = append(order, "failing")
+		return fmt.Errorf("failed on purpose")
+	})
+	db.RegisterPostCompactionHook(func(path string) error {
+		order = append(order, "index")
+		paths = append(paths, path)
+		//хук читає базу, тож має виконуватись поза блокуванням запису
+		var err error
+		index, err = BuildInvertedIndex(db)
+		return err
+	})
+
+	if err := db.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0] != filepath.Join(dir, outFileName+"0") {
+		t.Fatalf("Expected one hook call with the merged segment, got %v", paths)
+	}
+	if len(order) != 2 || order[0] != "failing" || order[1] != "index" {
+		t.Errorf("Expected hooks in registration order despite error, got %v", order)
+	}
+	if keys := index.Search("word9"); len(keys) != 1 || keys[0] != "key0" {
+		t.Errorf("Expected rebuilt index to find key0, got %v", keys)
+	}
+	if val, err := db.Get("key1"); err != nil || val != "word7" {
+		t.Errorf("Expected latest value after compaction, got %q, %v", val, err)
+	}
+}
+
+func TestPostCompactionHook_AutomaticMerge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir, WithMaxFileSize(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	calls := 0
+	db.RegisterPostCompactionHook(func(path string) error {
+		calls++
+		return nil
+	})
+	merges := 0
+	for i := 0; i < 50; i++ {
+		// новий сегмент при двох наявних одразу запускає мердж
+		blocks, segment := len(db.blocks), db.segmentNumber
+		if err := db.Put("key", fmt.Sprintf("value%d", i)); err != nil {
+			t.Fatal(err)
+		}
+		if blocks == 2 && db.segmentNumber > segment {
+			merges++
+		}
+	}
+	if merges == 0 || calls != merges {
+		t.Errorf("Expected a hook call per merge, got %d calls for %d merges", calls, merges)
+	}
+}
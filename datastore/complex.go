@@ -0,0 +1,119 @@
+package datastore
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"strconv"
+)
+
+// complexOperator зберігає дійсну та уявну частини як два float64 (16 байт);
+// у Entry.value число лежить у вигляді strconv.FormatComplex: "(3+4i)"
+type complexOperator struct{}
+
+func formatComplex(c complex128) string {
+	return strconv.FormatComplex(c, 'g', -1, 128)
+}
+
+func parseComplex(s string) (complex128, error) {
+	c, err := strconv.ParseComplex(s, 128)
+	if err != nil {
+		return 0, fmt.Errorf("bad complex %q", s)
+	}
+	return c, nil
+}
+
+func (s complexOperator) Encode(e *Entry, dst []byte) []byte {
+	c, err := parseComplex(e.value)
+	if err != nil {
+		panic(err)
+	}
+	res, offset := encodeKeyInto(e, 12, dst)
+	res[offset] = COMPLEX_TYPE
+	binary.LittleEndian.PutUint64(res[offset+TYPE_SIZE:], math.Float64bits(real(c)))
+	binary.LittleEndian.PutUint64(res[offset+TYPE_SIZE+8:], math.Float64bits(imag(c)))
+	return res
+}
+
+func (s complexOperator) EncodeInto(e *Entry, dst []byte) ([]byte, error) {
+	c, err := parseComplex(e.value)
+	if err != nil {
+		return dst, err
+	}
+	dst = appendKey(dst, e, 12)
+	dst = append(dst, COMPLEX_TYPE)
+	dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(real(c)))
+	return binary.LittleEndian.AppendUint64(dst, math.Float64bits(imag(c))), nil
+}
+
+func decodeComplex(data []byte) string {
+	re := math.Float64frombits(binary.LittleEndian.Uint64(data))
+	im := math.Float64frombits(binary.LittleEndian.Uint64(data[8:]))
+	return formatComplex(complex(re, im))
+}
+
+func (s complexOperator) Decode(input []byte, e *Entry) {
+	kl := len(e.key)
+	e.value = decodeComplex(input[kl+TYPE_SIZE+8:])
+}
+
+func (s complexOperator) Read(in *bufio.Reader) (string, error) {
+	data, err := in.Peek(16)
+	if err != nil {
+		return "", err
+	}
+	return decodeComplex(data), nil
+}
+
+// NewComplexEntry keeps the exact bits of both parts, except NaN payloads,
+// which do not survive the text form.
+func NewComplexEntry(key string, c complex128) *Entry {
+	return &Entry{key: key, valueType: COMPLEX_TYPE, value: formatComplex(c)}
+}
+
+func (e *Entry) GetComplex() (complex128, error) {
+	if e.valueType != COMPLEX_TYPE {
+		return 0, fmt.Errorf("wrong type of value")
+	}
+	return parseComplex(e.value)
+}
+
+// ComplexAdd and ComplexMul combine two complex entries into a new one
+// under the key of a.
+func ComplexAdd(a, b *Entry) (*Entry, error) {
+	x, y, err := complexPair(a, b)
+	if err != nil {
+		return nil, err
+	}
+	return NewComplexEntry(a.key, x+y), nil
+}
+
+func ComplexMul(a, b *Entry) (*Entry, error) {
+	x, y, err := complexPair(a, b)
+	if err != nil {
+		return nil, err
+	}
+	return NewComplexEntry(a.key, x*y), nil
+}
+
+func ComplexAbs(e *Entry) (float64, error) {
+	c, err := e.GetComplex()
+	if err != nil {
+		return 0, err
+	}
+	return cmplx.Abs(c), nil
+}
+
+func complexPair(a, b *Entry) (complex128, complex128, error) {
+	x, err := a.GetComplex()
+	if err != nil {
+		return 0, 0, err
+	}
+	y, err := b.GetComplex()
+	if err != nil {
+		return 0, 0, err
+	}
+	return x, y, nil
+}
@@ -0,0 +1,49 @@
+package datastore
+
+import (
+	"math"
+	"testing"
+)
+
+func TestComplex(t *testing.T) {
+	e := NewComplexEntry("z", 3+4i)
+	if e.value != "(3+4i)" {
+		t.Errorf("Bad complex text: %s", e.value)
+	}
+	if abs, err := ComplexAbs(e); err != nil || abs != 5 {
+		t.Errorf("Expected |3+4i| = 5, got %v, %v", abs, err)
+	}
+
+	for _, c := range []complex128{3 + 4i, complex(math.Pi, -1e-300), complex(math.Copysign(0, -1), math.Inf(1)), complex(math.SmallestNonzeroFloat64, math.MaxFloat64)} {
+		var decoded Entry
+		decoded.Decode(NewComplexEntry("z", c).Encode())
+		got, err := decoded.GetComplex()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Float64bits(real(got)) != math.Float64bits(real(c)) || math.Float64bits(imag(got)) != math.Float64bits(imag(c)) {
+			t.Errorf("Bits changed in round trip: %v -> %v", c, got)
+		}
+	}
+
+	sum, err := ComplexAdd(e, NewComplexEntry("w", 1-1i))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := sum.GetComplex(); c != 4+3i || sum.key != "z" {
+		t.Errorf("Bad sum %s: %v", sum.key, c)
+	}
+	product, err := ComplexMul(e, NewComplexEntry("w", 1i))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := product.GetComplex(); c != -4+3i {
+		t.Errorf("Bad product: %v", c)
+	}
+	if _, err := EncodeInto(&Entry{key: "z", valueType: COMPLEX_TYPE, value: "3+"}, nil); err == nil {
+		t.Errorf("Expected an error for a bad complex")
+	}
+	if _, err := ComplexAbs(&Entry{key: "z", valueType: STRING_TYPE, value: "(3+4i)"}); err == nil {
+		t.Errorf("Expected an error for a wrong type")
+	}
+}
@@ -0,0 +1,107 @@
+package datastore
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Частини складеного ключа кодуються так, щоб байтовий порядок ключів
+// збігався з порядком кортежів: нульовий байт екранується як 00 ff, а
+// кожна частина закінчується на 00 01. Довжинний префікс тут не підходить:
+// з ним ("b") опинився б перед ("aa").
+const (
+	compositeEscape     = "\x00\xff"
+	compositeTerminator = "\x00\x01"
+)
+
+// CompositeKey is a tuple of strings stored as a single key whose byte order
+// matches tuple order, so ordered indexes can range over it.
+type CompositeKey struct {
+	parts []string
+}
+
+func NewCompositeKey(parts ...string) CompositeKey {
+	return CompositeKey{parts: append([]string(nil), parts...)}
+}
+
+func (k CompositeKey) Parts() []string {
+	return append([]string(nil), k.parts...)
+}
+
+func (k CompositeKey) Encode() string {
+	var sb strings.Builder
+	for _, part := range k.parts {
+		sb.WriteString(strings.ReplaceAll(part, "\x00", compositeEscape))
+		sb.WriteString(compositeTerminator)
+	}
+	return sb.String()
+}
+
+func ParseCompositeKey(encoded string) (CompositeKey, error) {
+	var (
+		k    CompositeKey
+		part strings.Builder
+	)
+	for i := 0; i < len(encoded); i++ {
+		if encoded[i] != 0 {
+			part.WriteByte(encoded[i])
+			continue
+		}
+		if i+1 == len(encoded) {
+			return CompositeKey{}, fmt.Errorf("truncated composite key")
+		}
+		i++
+		switch encoded[i] {
+		case 0xff:
+			part.WriteByte(0)
+		case 0x01:
+			k.parts = append(k.parts, part.String())
+			part.Reset()
+		default:
+			return CompositeKey{}, fmt.Errorf("bad escape 00 %02x in composite key", encoded[i])
+		}
+	}
+	if part.Len() > 0 {
+		return CompositeKey{}, fmt.Errorf("unterminated composite key part")
+	}
+	return k, nil
+}
+
+// Compare orders keys part by part; a key sorts before any key it is a prefix of.
+func (k CompositeKey) Compare(other CompositeKey) int {
+	for i := 0; i < len(k.parts) && i < len(other.parts); i++ {
+		if c := CompareKeys(k.parts[i], other.parts[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(k.parts) < len(other.parts):
+		return -1
+	case len(k.parts) > len(other.parts):
+		return 1
+	}
+	return 0
+}
+
+// PrefixRange returns the half-open range [lo, hi) of encoded keys that start
+// with all parts of k, e.g. every (user, timestamp) for one user. k must have
+// at least one part.
+func (k CompositeKey) PrefixRange() (string, string) {
+	lo := k.Encode()
+	//замінюємо останній термінатор 00 01 на 00 02: усі продовження менші за нього
+	return lo, lo[:len(lo)-1] + "\x02"
+}
+
+// RangeComposite calls fn for every key in s that extends prefix, in tuple order.
+func (s *SkipList) RangeComposite(prefix CompositeKey, fn func(key CompositeKey, offset int64) bool) error {
+	var err error
+	lo, hi := prefix.PrefixRange()
+	s.Range(lo, hi, func(encoded string, offset int64) bool {
+		var key CompositeKey
+		if key, err = ParseCompositeKey(encoded); err != nil {
+			return false
+		}
+		return fn(key, offset)
+	})
+	return err
+}
@@ -0,0 +1,93 @@
+package datastore
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCompositeKey_Order(t *testing.T) {
+	//очікуваний порядок кортежів
+	expected := []CompositeKey{
+		NewCompositeKey(),
+		NewCompositeKey(""),
+		NewCompositeKey("", "z"),
+		NewCompositeKey("a"),
+		NewCompositeKey("a", ""),
+		NewCompositeKey("a", "\x00"),
+		NewCompositeKey("a", "b"),
+		NewCompositeKey("a\x00", "a"),
+		NewCompositeKey("aa"),
+		NewCompositeKey("b"),
+	}
+	for i := 1; i < len(expected); i++ {
+		if c := expected[i-1].Compare(expected[i]); c != -1 {
+			t.Errorf("Expected %q < %q, Compare returned %d", expected[i-1].parts, expected[i].parts, c)
+		}
+		if expected[i-1].Encode() >= expected[i].Encode() {
+			t.Errorf("Encoding of %q does not sort before %q", expected[i-1].parts, expected[i].parts)
+		}
+	}
+
+	encoded := make([]string, len(expected))
+	for i, k := range expected {
+		encoded[i] = k.Encode()
+	}
+	rand.New(rand.NewSource(1)).Shuffle(len(encoded), func(i, j int) { encoded[i], encoded[j] = encoded[j], encoded[i] })
+	sort.Strings(encoded)
+	for i, e := range encoded {
+		k, err := ParseCompositeKey(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k.Compare(expected[i]) != 0 || !reflect.DeepEqual(k.Parts(), expected[i].Parts()) {
+			t.Errorf("Bad key at %d: expected %q, got %q", i, expected[i].parts, k.parts)
+		}
+	}
+}
+
+func TestParseCompositeKey_Errors(t *testing.T) {
+	for _, bad := range []string{"a", "a\x00", "a\x00\x02", NewCompositeKey("a").Encode() + "b"} {
+		if _, err := ParseCompositeKey(bad); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func TestSkipList_RangeComposite(t *testing.T) {
+	s := NewSkipList()
+	keys := []CompositeKey{
+		NewCompositeKey("user1", "2024-01-02"),
+		NewCompositeKey("user1", "2024-01-01"),
+		NewCompositeKey("user10", "2024-01-01"),
+		NewCompositeKey("user1"),
+		NewCompositeKey("user0", "2024-01-03"),
+		NewCompositeKey("user1", "\xff"),
+	}
+	for i, k := range keys {
+		s.Insert(k.Encode(), int64(i))
+	}
+
+	var got []int64
+	err := s.RangeComposite(NewCompositeKey("user1"), func(k CompositeKey, offset int64) bool {
+		got = append(got, offset)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, []int64{3, 1, 0, 5}) {
+		t.Errorf("Bad range for user1: %v", got)
+	}
+
+	lo, hi := NewCompositeKey("user1", "2024-01-01").Encode(), NewCompositeKey("user1", "2024-01-02").Encode()
+	got = nil
+	s.Range(lo, hi, func(key string, offset int64) bool {
+		got = append(got, offset)
+		return true
+	})
+	if !reflect.DeepEqual(got, []int64{1}) {
+		t.Errorf("Bad range between timestamps: %v", got)
+	}
+}
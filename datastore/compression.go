@@ -0,0 +1,67 @@
+package datastore
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"sync"
+)
+
+// CompressionCodec compresses cached pages. Decompress must accept the
+// output of Compress and nothing else needs to read it.
+type CompressionCodec interface {
+	Compress(src []byte) ([]byte, error)
+	Decompress(src []byte) ([]byte, error)
+}
+
+// FlateCodec compresses with compress/flate at the fastest level; it stands
+// in for LZ4, which the standard library does not have.
+type FlateCodec struct {
+	writers sync.Pool
+	readers sync.Pool
+}
+
+func (c *FlateCodec) Compress(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, _ := c.writers.Get().(*flate.Writer)
+	if w == nil {
+		var err error
+		if w, err = flate.NewWriter(&buf, flate.BestSpeed); err != nil {
+			return nil, err
+		}
+	} else {
+		w.Reset(&buf)
+	}
+	defer c.writers.Put(w)
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *FlateCodec) Decompress(src []byte) ([]byte, error) {
+	r, _ := c.readers.Get().(io.ReadCloser)
+	if r == nil {
+		r = flate.NewReader(bytes.NewReader(src))
+	} else if err := r.(flate.Resetter).Reset(bytes.NewReader(src), nil); err != nil {
+		return nil, err
+	}
+	defer c.readers.Put(r)
+	dst := make([]byte, 0, pageSize)
+	buf := bytes.NewBuffer(dst)
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// NewCompressedPageCache returns a page cache that keeps pages compressed with
+// codec, so capacity limits compressed bytes. Every hit pays for decompression.
+func NewCompressedPageCache(capacity int64, codec CompressionCodec) *PageCache {
+	c := NewPageCache(capacity)
+	c.codec = codec
+	return c
+}
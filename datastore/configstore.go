@@ -0,0 +1,151 @@
+package datastore
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// configCodec переводить значення параметра в запис і назад; decode
+// повертає interface{}, а ConfigStore сам перевіряє, що це T
+type configCodec struct {
+	encode func(key string, val interface{}) (*Entry, error)
+	decode func(e *Entry) (interface{}, error)
+}
+
+// ConfigStore keeps configuration values of type T in a Db, one key per
+// setting.
+type ConfigStore[T any] struct {
+	db    *Db
+	codec configCodec
+}
+
+type (
+	StringConfig  = ConfigStore[string]
+	Int64Config   = ConfigStore[int64]
+	BoolConfig    = ConfigStore[bool]
+	Float64Config = ConfigStore[float64]
+)
+
+var stringCodec = configCodec{
+	encode: func(key string, val interface{}) (*Entry, error) {
+		return &Entry{key: key, valueType: STRING_TYPE, value: val.(string)}, nil
+	},
+	decode: func(e *Entry) (interface{}, error) {
+		if e.valueType != STRING_TYPE {
+			return nil, fmt.Errorf("wrong type of value")
+		}
+		return e.value, nil
+	},
+}
+
+var int64Codec = configCodec{
+	encode: func(key string, val interface{}) (*Entry, error) {
+		return &Entry{key: key, valueType: INT64_TYPE, value: strconv.FormatInt(val.(int64), 10)}, nil
+	},
+	decode: func(e *Entry) (interface{}, error) {
+		if e.valueType != INT64_TYPE {
+			return nil, fmt.Errorf("wrong type of value")
+		}
+		return strconv.ParseInt(e.value, 10, 64)
+	},
+}
+
+// окремого типу для bool немає, тож зберігаємо рядок strconv.FormatBool
+var boolCodec = configCodec{
+	encode: func(key string, val interface{}) (*Entry, error) {
+		return &Entry{key: key, valueType: STRING_TYPE, value: strconv.FormatBool(val.(bool))}, nil
+	},
+	decode: func(e *Entry) (interface{}, error) {
+		if e.valueType != STRING_TYPE {
+			return nil, fmt.Errorf("wrong type of value")
+		}
+		return strconv.ParseBool(e.value)
+	},
+}
+
+var float64Codec = configCodec{
+	encode: func(key string, val interface{}) (*Entry, error) {
+		return NewFloat64Entry(key, val.(float64)), nil
+	},
+	decode: func(e *Entry) (interface{}, error) {
+		return e.GetFloat64()
+	},
+}
+
+func NewStringConfig(db *Db) *StringConfig {
+	return &StringConfig{db: db, codec: stringCodec}
+}
+
+func NewInt64Config(db *Db) *Int64Config {
+	return &Int64Config{db: db, codec: int64Codec}
+}
+
+func NewBoolConfig(db *Db) *BoolConfig {
+	return &BoolConfig{db: db, codec: boolCodec}
+}
+
+func NewFloat64Config(db *Db) *Float64Config {
+	return &Float64Config{db: db, codec: float64Codec}
+}
+
+func (c *ConfigStore[T]) decode(e *Entry) (T, error) {
+	var zero T
+	v, err := c.codec.decode(e)
+	if err != nil {
+		return zero, err
+	}
+	val, ok := v.(T)
+	if !ok {
+		return zero, fmt.Errorf("config %s holds %T, not %T", e.key, v, zero)
+	}
+	return val, nil
+}
+
+// Get returns the value of key, or defaultVal when key is missing or holds
+// a value of another type.
+func (c *ConfigStore[T]) Get(key string, defaultVal T) T {
+	e, err := c.db.getEntry(key)
+	if err != nil || e.valueType == SOFT_DELETED_TYPE {
+		return defaultVal
+	}
+	val, err := c.decode(e)
+	if err != nil {
+		return defaultVal
+	}
+	return val
+}
+
+func (c *ConfigStore[T]) Set(key string, val T) error {
+	e, err := c.codec.encode(key, val)
+	if err != nil {
+		return err
+	}
+	return c.db.putEntry(e)
+}
+
+// Watch calls fn with the previous and the new value after each write to
+// key. A deleted key reports the zero value; writes of another type are
+// skipped. fn runs on the writing goroutine, one call at a time.
+func (c *ConfigStore[T]) Watch(key string, fn func(old, new T)) (cancel func()) {
+	var mu sync.Mutex
+	var zero T
+	old := c.Get(key, zero)
+	return c.db.Watch(func(e *Entry) {
+		if e.key != key {
+			return
+		}
+		val := zero
+		if e.valueType != TOMBSTONE_TYPE && e.valueType != SOFT_DELETED_TYPE {
+			var err error
+			if val, err = c.decode(e); err != nil {
+				return
+			}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		prev := old
+		old = val
+		fn(prev, val)
+	})
+}
@@ -0,0 +1,91 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestConfigStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	strs := NewStringConfig(db)
+	if v := strs.Get("log.level", "info"); v != "info" {
+		t.Errorf("Expected the default for a missing key, got %q", v)
+	}
+	type change struct{ old, new string }
+	changes := make(chan change, 4)
+	cancel := strs.Watch("log.level", func(old, new string) {
+		changes <- change{old, new}
+	})
+	go func() {
+		if err := strs.Set("log.level", "debug"); err != nil {
+			t.Error(err)
+		}
+	}()
+	select {
+	case c := <-changes:
+		if c.old != "" || c.new != "debug" {
+			t.Errorf("Bad change: %+v", c)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the watcher to see the write")
+	}
+	if v := strs.Get("log.level", "info"); v != "debug" {
+		t.Errorf("Bad value: %q", v)
+	}
+
+	//запис іншого типу в той самий ключ watcher пропускає
+	if err := db.PutInt64("log.level", 3); err != nil {
+		t.Fatal(err)
+	}
+	if v := strs.Get("log.level", "info"); v != "info" {
+		t.Errorf("Expected the default for a value of another type, got %q", v)
+	}
+	if err := strs.Set("log.level", "warn"); err != nil {
+		t.Fatal(err)
+	}
+	if c := <-changes; c.old != "debug" || c.new != "warn" {
+		t.Errorf("Bad change: %+v", c)
+	}
+	cancel()
+	if err := strs.Set("log.level", "error"); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("Expected no calls after cancel")
+	}
+
+	ints, bools, floats := NewInt64Config(db), NewBoolConfig(db), NewFloat64Config(db)
+	if err := ints.Set("pool.size", -8); err != nil {
+		t.Fatal(err)
+	}
+	if err := bools.Set("feature.on", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := floats.Set("ratio", 0.25); err != nil {
+		t.Fatal(err)
+	}
+	if v := ints.Get("pool.size", 1); v != -8 {
+		t.Errorf("Bad int64: %d", v)
+	}
+	if v := bools.Get("feature.on", false); !v {
+		t.Errorf("Bad bool: %v", v)
+	}
+	if v := floats.Get("ratio", 1); v != 0.25 {
+		t.Errorf("Bad float64: %v", v)
+	}
+	if v := bools.Get("pool.size", true); !v {
+		t.Errorf("Expected the default for an int64 read as bool")
+	}
+}
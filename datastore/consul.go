@@ -0,0 +1,136 @@
+package datastore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ConsulClient talks to the KV endpoints of Consul's HTTP API.
+type ConsulClient struct {
+	address string
+	token   string
+	client  *http.Client
+}
+
+// NewConsulClient returns a client for the agent at address, for example
+// http://127.0.0.1:8500. An empty token sends no ACL token.
+func NewConsulClient(address, token string) (*ConsulClient, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported consul url scheme %q", u.Scheme)
+	}
+	return &ConsulClient{address: strings.TrimRight(address, "/"), token: token, client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+type consulKVPair struct {
+	Key   string
+	Value []byte
+}
+
+// kvURL екранує кожен сегмент ключа окремо, щоб / лишалися роздільниками
+func (c *ConsulClient) kvURL(key string, query string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	res := c.address + "/v1/kv/" + strings.Join(segments, "/")
+	if query != "" {
+		res += "?" + query
+	}
+	return res
+}
+
+func (c *ConsulClient) do(method, target string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+	return c.client.Do(req)
+}
+
+func (c *ConsulClient) list(prefix string) ([]consulKVPair, error) {
+	resp, err := c.do(http.MethodGet, c.kvURL(prefix, "recurse=true"), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	//без жодного ключа під префіксом Consul відповідає 404
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("consul responded %s", resp.Status)
+	}
+	var pairs []consulKVPair
+	if err := json.NewDecoder(resp.Body).Decode(&pairs); err != nil {
+		return nil, fmt.Errorf("bad consul kv listing: %v", err)
+	}
+	return pairs, nil
+}
+
+func (c *ConsulClient) put(key, value string) error {
+	resp, err := c.do(http.MethodPut, c.kvURL(key, ""), strings.NewReader(value))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("consul responded %s", resp.Status)
+	}
+	var ok bool
+	if err := json.NewDecoder(resp.Body).Decode(&ok); err != nil || !ok {
+		return fmt.Errorf("consul did not store %q", key)
+	}
+	return nil
+}
+
+// ImportConsulKV copies every Consul key under prefix into db as a string
+// entry with the same key. Folder markers (keys ending in / without a value)
+// are skipped.
+func ImportConsulKV(client *ConsulClient, prefix string, db *Db) error {
+	pairs, err := client.list(prefix)
+	if err != nil {
+		return err
+	}
+	for _, p := range pairs {
+		if strings.HasSuffix(p.Key, "/") && len(p.Value) == 0 {
+			continue
+		}
+		if !strings.HasPrefix(p.Key, prefix) {
+			return fmt.Errorf("consul returned key %q outside prefix %q", p.Key, prefix)
+		}
+		if err := db.Put(p.Key, string(p.Value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportConsulKV writes every key of db under prefix to Consul, one PUT per
+// key. Values of other types are sent as their text.
+func ExportConsulKV(db *Db, prefix string, client *ConsulClient) error {
+	entries, err := db.entries()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !strings.HasPrefix(e.key, prefix) {
+			continue
+		}
+		if err := client.put(e.key, e.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
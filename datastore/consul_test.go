@@ -0,0 +1,122 @@
+package datastore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// mockConsul відповідає на GET ?recurse і PUT як KV API агента Consul
+type mockConsul struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func (m *mockConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Consul-Token") != "secret" {
+		http.Error(w, "ACL not found", http.StatusForbidden)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		var pairs []consulKVPair
+		for k, v := range m.data {
+			if strings.HasPrefix(k, key) {
+				pairs = append(pairs, consulKVPair{Key: k, Value: v})
+			}
+		}
+		if len(pairs) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+		sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key < pairs[j].Key })
+		json.NewEncoder(w).Encode(pairs)
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		m.data[key] = body
+		w.Write([]byte("true"))
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func TestConsulKV(t *testing.T) {
+	consul := &mockConsul{data: map[string][]byte{"app/": nil, "other/key": []byte("x")}}
+	for i := 0; i < 100; i++ {
+		consul.data[fmt.Sprintf("app/key-%03d", i)] = []byte(fmt.Sprintf("value %d", i))
+	}
+	server := httptest.NewServer(consul)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := NewConsulClient("ftp://consul", ""); err == nil {
+		t.Errorf("Expected an error for a non-http address")
+	}
+	anonymous, _ := NewConsulClient(server.URL, "")
+	if err := ImportConsulKV(anonymous, "app/", db); err == nil {
+		t.Errorf("Expected an error without an ACL token")
+	}
+	client, err := NewConsulClient(server.URL, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportConsulKV(client, "app/", db); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := db.entries()
+	if len(entries) != 100 {
+		t.Errorf("Expected 100 imported keys, got %d", len(entries))
+	}
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("app/key-%03d", i)
+		if v, err := db.Get(key); err != nil || v != fmt.Sprintf("value %d", i) {
+			t.Errorf("Bad %s: %q, %v", key, v, err)
+		}
+	}
+	if err := ImportConsulKV(client, "missing/", db); err != nil {
+		t.Errorf("Expected an empty prefix to import nothing, got %v", err)
+	}
+
+	if err := db.Put("app/key-000", "changed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put("app/with space/and?query", "escaped"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put("local/only", "not exported"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportConsulKV(db, "app/", client); err != nil {
+		t.Fatal(err)
+	}
+	if v := string(consul.data["app/key-000"]); v != "changed" {
+		t.Errorf("Expected the change to reach Consul, got %q", v)
+	}
+	if v := string(consul.data["app/with space/and?query"]); v != "escaped" {
+		t.Errorf("Expected the key to be path-escaped, got %q", v)
+	}
+	if _, ok := consul.data["local/only"]; ok {
+		t.Errorf("Expected keys outside the prefix to stay local")
+	}
+}
@@ -0,0 +1,163 @@
+package datastore
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Вузол DAG зберігається як [хеш вмісту][батьків u32][хеші батьків][автор]
+// [повідомлення] - усе з префіксом довжини - і [час UnixNano i64] у кінці.
+// Ключ вузла - sha256 від "commit <довжина>\x00" і цього кодування, як у git,
+// тож він не перетинається з ключами вмісту з ContentAddressedWrite.
+
+// DagNode is a commit in a content-addressed DAG. Hash is its key.
+type DagNode struct {
+	Hash        string
+	ContentHash string
+	Parents     []string
+	Author      string
+	Message     string
+	Timestamp   int64
+}
+
+func (n *DagNode) encode() string {
+	res := appendLengthPrefixed(nil, n.ContentHash)
+	res = binary.LittleEndian.AppendUint32(res, uint32(len(n.Parents)))
+	for _, p := range n.Parents {
+		res = appendLengthPrefixed(res, p)
+	}
+	res = appendLengthPrefixed(res, n.Author)
+	res = appendLengthPrefixed(res, n.Message)
+	return string(binary.LittleEndian.AppendUint64(res, uint64(n.Timestamp)))
+}
+
+func dagHash(encoded string) string {
+	sum := sha256.Sum256([]byte("commit " + strconv.Itoa(len(encoded)) + "\x00" + encoded))
+	return hex.EncodeToString(sum[:])
+}
+
+func parseDagNode(data string) (*DagNode, error) {
+	n := &DagNode{}
+	var err error
+	if n.ContentHash, data, err = readLengthPrefixed(data); err != nil {
+		return nil, err
+	}
+	if len(data) < 4 {
+		return nil, fmt.Errorf("corrupted dag node")
+	}
+	count := int(binary.LittleEndian.Uint32([]byte(data[:4])))
+	if count > (len(data)-4)/4 {
+		return nil, fmt.Errorf("corrupted dag node")
+	}
+	data = data[4:]
+	for i := 0; i < count; i++ {
+		var p string
+		if p, data, err = readLengthPrefixed(data); err != nil {
+			return nil, err
+		}
+		n.Parents = append(n.Parents, p)
+	}
+	if n.Author, data, err = readLengthPrefixed(data); err != nil {
+		return nil, err
+	}
+	if n.Message, data, err = readLengthPrefixed(data); err != nil {
+		return nil, err
+	}
+	if len(data) != 8 {
+		return nil, fmt.Errorf("corrupted dag node")
+	}
+	n.Timestamp = int64(binary.LittleEndian.Uint64([]byte(data)))
+	return n, nil
+}
+
+func validateDagNode(data string) error {
+	_, err := parseDagNode(data)
+	return err
+}
+
+func getDagNode(db *Db, hash string) (*DagNode, error) {
+	e, err := db.getEntry(hash)
+	if err != nil {
+		return nil, err
+	}
+	if e.valueType != DAG_NODE_TYPE {
+		return nil, fmt.Errorf("%s is not a dag node", hash)
+	}
+	n, err := parseDagNode(e.value)
+	if err != nil {
+		return nil, err
+	}
+	n.Hash = hash
+	return n, nil
+}
+
+// DagCommit stores content with ContentAddressedWrite and a commit node
+// pointing at it and at parents, which must already exist. The returned hash
+// covers every field, the commit time included.
+func DagCommit(db *Db, content string, parents []string, author, message string) (string, error) {
+	for _, p := range parents {
+		if _, err := getDagNode(db, p); err != nil {
+			return "", fmt.Errorf("parent %s: %v", p, err)
+		}
+	}
+	contentHash, err := db.ContentAddressedWrite(content, "string")
+	if err != nil {
+		return "", err
+	}
+	n := &DagNode{ContentHash: contentHash, Parents: parents, Author: author, Message: message, Timestamp: timeNow().UnixNano()}
+	encoded := n.encode()
+	hash := dagHash(encoded)
+	if ok, err := db.has(hash); err != nil || ok {
+		return hash, err
+	}
+	return hash, db.putEntry(&Entry{key: hash, valueType: DAG_NODE_TYPE, value: encoded})
+}
+
+// DagLog returns the commits reachable from tipHash in topological order:
+// every commit comes before its parents. Among commits that are ready at
+// the same time the newest goes first.
+func DagLog(db *Db, tipHash string) ([]*DagNode, error) {
+	nodes := make(map[string]*DagNode)
+	children := make(map[string]int)
+	stack := []string{tipHash}
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if nodes[hash] != nil {
+			continue
+		}
+		n, err := getDagNode(db, hash)
+		if err != nil {
+			return nil, err
+		}
+		nodes[hash] = n
+		for _, p := range n.Parents {
+			children[p]++
+			stack = append(stack, p)
+		}
+	}
+
+	var res []*DagNode
+	ready := []*DagNode{nodes[tipHash]}
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool {
+			if ready[i].Timestamp != ready[j].Timestamp {
+				return ready[i].Timestamp > ready[j].Timestamp
+			}
+			return ready[i].Hash < ready[j].Hash
+		})
+		n := ready[0]
+		ready = ready[1:]
+		res = append(res, n)
+		for _, p := range n.Parents {
+			if children[p]--; children[p] == 0 {
+				ready = append(ready, nodes[p])
+			}
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,78 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDag(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Unix(1700000000, 0)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	commit := func(content string, parents ...string) string {
+		now = now.Add(time.Minute)
+		hash, err := DagCommit(db, content, parents, "alice", "commit "+content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+	//a <- b, a <- c, злиття d з батьками b і c, e над d
+	a := commit("a")
+	b := commit("b", a)
+	c := commit("c", a)
+	d := commit("d", b, c)
+	e := commit("e", d)
+
+	log, err := DagLog(db, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{e, d, c, b, a}
+	if len(log) != len(expected) {
+		t.Fatalf("Expected %d commits, got %d", len(expected), len(log))
+	}
+	position := make(map[string]int)
+	for i, n := range log {
+		if n.Hash != expected[i] {
+			t.Errorf("Bad commit %d: %s", i, n.Message)
+		}
+		position[n.Hash] = i
+	}
+	for _, n := range log {
+		for _, p := range n.Parents {
+			if position[p] <= position[n.Hash] {
+				t.Errorf("Parent of %q comes before it", n.Message)
+			}
+		}
+	}
+
+	content, err := db.Get(log[0].ContentHash)
+	if err != nil || content != "e" {
+		t.Errorf("Bad commit content: %q, %v", content, err)
+	}
+	if hash, err := DagCommit(db, "e", []string{d}, "alice", "commit e"); err != nil || hash != e {
+		t.Errorf("Expected the same commit to get the same hash, got %s, %v", hash, err)
+	}
+	if _, err := DagCommit(db, "x", []string{"missing"}, "alice", "bad"); err == nil {
+		t.Errorf("Expected an error for a missing parent")
+	}
+	if _, err := DagLog(db, log[0].ContentHash); err == nil {
+		t.Errorf("Expected an error for a non-commit tip")
+	}
+}
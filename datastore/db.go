@@ -2,11 +2,16 @@ package datastore
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 const outFileName = "segment-"
@@ -16,22 +21,80 @@ const outFileName = "segment-"
 const outFileSize int64 = 10000000
 
 type Db struct {
+	//захищає blocks: записи і мердж виконуються під Lock, читання під RLock
+	mu     sync.RWMutex
 	blocks []*block
 	//директорія, де зберігатимуться всі сегменти
 	dir           string
 	segmentName   string
 	segmentNumber int
 	segmentSize   int64
+	pageCache     *PageCache
+	hotKeys       *hotKeyTracker
+	readOnly      bool
+	bufferSize    int
+	logger        *slog.Logger
+	changelog     ChangelogEmitter
+
+	maxDiskBytes          int64
+	quotaWarningThreshold float64
+
+	circuitThreshold int
+	circuitCooldown  time.Duration
+
+	notifierMu sync.Mutex
+	notifier   *expiryNotifier
+
+	hooksMu         sync.Mutex
+	compactionHooks []func(compactedPath string) error
+	quotaHooks      []func(used, limit int64)
+	watchers        map[int]func(e *Entry)
+	nextWatcher     int
+	//змінюється під db.mu і hooksMu, читати можна під будь-яким з них
+	replica *replication
+	//мердж під db.mu лише позначає себе, хуки запускає unlockWrite
+	compacted bool
+	//розмір бази після запису, що перетнув поріг попередження
+	quotaReached int64
+	//flock від інших процесів; nil у режимі лише для читання
+	lockFile *os.File
+	//резервна копія: пише лише реплікація, захищено db.mu
+	standby bool
+	//після Close блоки закриті, і запис у них панікував би; захищено db.mu
+	closed bool
+	//nil - індекс сегмента на звичайній мапі
+	indexFactory func() Index
 }
 
-func NewDb(dir string) (*Db, error) {
+func NewDb(dir string, opts ...Option) (*Db, error) {
+	var options DbOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	db := &Db{
 		dir:         dir,
 		segmentName: outFileName,
 		segmentSize: outFileSize,
+		pageCache:   options.PageCache,
+		readOnly:    options.ReadOnly,
+		bufferSize:  options.BufferSize,
+		logger:      options.Logger,
+		changelog:   options.Changelog,
+
+		maxDiskBytes:          options.MaxDiskBytes,
+		quotaWarningThreshold: options.QuotaWarningThreshold,
+
+		circuitThreshold: options.CircuitErrorThreshold,
+		circuitCooldown:  options.CircuitCooldown,
+
+		indexFactory: options.NewIndex,
+	}
+	if options.MaxFileSize > 0 {
+		db.segmentSize = options.MaxFileSize
 	}
 
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) && !db.readOnly {
 		os.MkdirAll(dir, os.ModePerm)
 	}
 	f, err := os.Open(dir)
@@ -40,21 +103,35 @@ func NewDb(dir string) (*Db, error) {
 	}
 	defer f.Close()
 
-	filesNames, err := f.Readdirnames(0)
+	if !db.readOnly {
+		if err := db.Lock(); err != nil {
+			return nil, err
+		}
+	}
+	names, err := f.Readdirnames(0)
 	if err != nil {
+		db.Close()
 		return nil, err
 	}
+	filesNames := names[:0]
+	for _, name := range names {
+		if name != lockFileName {
+			filesNames = append(filesNames, name)
+		}
+	}
 
 	//якщо директорія не порожня -> викликаємо рекавер
 	if len(filesNames) != 0 {
 		err := db.recover(filesNames)
 		if err != nil {
+			db.Close()
 			return nil, err
 		}
-	} else {
+	} else if !db.readOnly {
 		// директорія порожня -> створюємо перший блок
 		err = db.addNewBlockToDb()
 		if err != nil {
+			db.Close()
 			return nil, err
 		}
 	}
@@ -65,27 +142,45 @@ func NewDb(dir string) (*Db, error) {
 func (db *Db) addNewBlockToDb() error {
 	db.segmentNumber++
 	b, err := newBlock(db.dir,
-		db.segmentName+strconv.Itoa((db.segmentNumber)))
+		db.segmentName+strconv.Itoa((db.segmentNumber)), db.newIndex())
 	if err != nil {
 		return err
 	}
+	b.cache = db.pageCache
+	b.bufferSize = db.bufferSize
 	db.blocks = append(db.blocks, b)
+	if db.logger != nil {
+		db.logger.Debug("created segment", "path", b.outPath)
+	}
 	return nil
 }
 
 func (db *Db) recover(filesNames []string) error {
 	//сортуємо за зростанням
 	sort.Strings(filesNames)
+	offsets, err := db.loadCheckpoint(filesNames)
+	if err != nil {
+		return err
+	}
 	//регексп для перевірки назв фалів
 	r, _ := regexp.Compile(db.segmentName + "[0-9]+")
 	for _, fileName := range filesNames {
+		if strings.HasSuffix(fileName, "-temp") {
+			//недороблений мердж: сегменти, з яких його збирали, ще на місці
+			if !db.readOnly {
+				os.Remove(filepath.Join(db.dir, fileName))
+			}
+			continue
+		}
 		match := r.MatchString(fileName)
 
 		if match {
-			b, err := newBlock(db.dir, fileName)
+			b, err := openBlock(db.dir, fileName, db.readOnly, offsets[fileName], db.newIndex())
 			if err != nil {
 				return err
 			}
+			b.cache = db.pageCache
+			b.bufferSize = db.bufferSize
 			db.blocks = append(db.blocks, b)
 			reg, _ := regexp.Compile("[0-9]+")
 			db.segmentNumber, err = strconv.Atoi(reg.FindString(fileName))
@@ -100,17 +195,66 @@ func (db *Db) recover(filesNames []string) error {
 }
 
 func (db *Db) Close() error {
+	db.StopExpiryNotifier()
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.closed {
+		return nil
+	}
+	db.closed = true
 	for _, block := range db.blocks {
 		block.close()
 	}
-	return nil
+	err := db.unlockFile()
+	//журнал змін дочікується подій, що ще в черзі
+	if closer, ok := db.changelog.(io.Closer); ok {
+		if cerr := closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// TryPut writes value under key like Put, but waits at most timeout for
+// the write lock held by other writes and compaction. It reports false,
+// without writing, if the lock did not come free in time.
+func (db *Db) TryPut(key, value string, timeout time.Duration) (bool, error) {
+	return db.tryPutEntry(&Entry{key: key, valueType: STRING_TYPE, value: value}, timeout)
+}
+
+func (db *Db) tryPutEntry(e *Entry, timeout time.Duration) (bool, error) {
+	if db.readOnly {
+		return false, ErrReadOnly
+	}
+	deadline := time.Now().Add(timeout)
+	for !db.mu.TryLock() {
+		if !time.Now().Before(deadline) {
+			return false, nil
+		}
+		time.Sleep(time.Millisecond)
+	}
+	err := db.appendEntry(e)
+	db.unlockWrite()
+	if err != nil {
+		return false, err
+	}
+	db.emit(e)
+	return true, nil
 }
 
 func (db *Db) getType(key string) (string, string, error) {
+	if db.hotKeys != nil {
+		db.hotKeys.record(key)
+	}
+	db.mu.RLock()
+	defer db.mu.RUnlock()
 	var val, vType string
-	var err error
+	var err error = ErrNotFound
 	for j := len(db.blocks) - 1; j >= 0; j = j - 1 {
 		val, vType, err = db.blocks[j].get(key)
+		if err == errDeleted {
+			return "", "", ErrNotFound
+		}
 		if err != nil && err != ErrNotFound {
 			return "", "", err
 		}
@@ -122,13 +266,124 @@ func (db *Db) getType(key string) (string, string, error) {
 }
 
 func (db *Db) putType(key, vType, value string) error {
+	return db.putEntry(&Entry{key: key, valueType: ToByte(vType), value: value})
+}
+
+func (db *Db) putEntry(e *Entry) error {
+	db.mu.Lock()
+	err := db.appendEntry(e)
+	db.unlockWrite()
+	if err != nil {
+		return err
+	}
+	db.emit(e)
+	return nil
+}
+
+// compareAndSwap writes e only if the latest entry for its key still equals
+// old; old == nil expects the key to be missing.
+func (db *Db) compareAndSwap(old, e *Entry) (bool, error) {
+	db.mu.Lock()
+	cur, err := db.latestEntry(e.key)
+	if err == ErrNotFound {
+		cur, err = nil, nil
+	}
+	if err != nil || (cur == nil) != (old == nil) || (cur != nil && *cur != *old) {
+		db.mu.Unlock()
+		return false, err
+	}
+	err = db.appendEntry(e)
+	db.unlockWrite()
+	if err != nil {
+		return false, err
+	}
+	db.emit(e)
+	return true, nil
+}
+
+// updateEntry applies fn to the latest entry for key (nil if missing) and
+// writes the result with compareAndSwap, retrying on conflicts. fn returning
+// nil leaves the key unchanged.
+func (db *Db) updateEntry(key string, fn func(cur *Entry) (*Entry, error)) (*Entry, error) {
+	for {
+		cur, err := db.getEntry(key)
+		if err == ErrNotFound {
+			cur = nil
+		} else if err != nil {
+			return nil, err
+		}
+		e, err := fn(cur)
+		if err != nil || e == nil {
+			return cur, err
+		}
+		e.key = key
+		swapped, err := db.compareAndSwap(cur, e)
+		if err != nil {
+			return nil, err
+		}
+		if swapped {
+			return e, nil
+		}
+	}
+}
+
+// emit повідомляє спостерігачів і журнал змін; запис уже зафіксовано, тож
+// помилку журналу лише логуємо, а не повертаємо автору запису
+func (db *Db) emit(e *Entry) {
+	db.runWatchers(e)
+	if db.changelog == nil {
+		return
+	}
+	if err := db.changelog.Emit(db.dir, e); err != nil && db.logger != nil {
+		db.logger.Error("changelog emit failed", "key", e.key, "error", err)
+	}
+}
+
+func (db *Db) appendEntry(e *Entry) error {
+	return db.appendStored(e, e)
+}
+
+// appendStored пише stored, а копії дістається e: дифф-запис має сенс лише
+// поряд зі своєю базою; викликається під db.mu
+func (db *Db) appendStored(stored, e *Entry) error {
+	if db.readOnly || db.standby {
+		return ErrReadOnly
+	}
+	if err := db.writeEntry(stored); err != nil {
+		return err
+	}
+	db.replicate(e)
+	return nil
+}
+
+// writeEntry пише e в активний блок; викликається під db.mu
+func (db *Db) writeEntry(e *Entry) error {
+	if db.closed {
+		return ErrClosed
+	}
+	quotaReached, err := db.checkQuota(e)
+	if err != nil {
+		return err
+	}
+	if err := db.putToActiveBlock(e); err != nil {
+		return err
+	}
+	if quotaReached > 0 {
+		db.quotaReached = quotaReached
+	}
+	return nil
+}
+
+// putToActiveBlock пише e в активний блок, відкриваючи новий і запускаючи
+// мердж, коли поточний заповнено; викликається під db.mu
+func (db *Db) putToActiveBlock(e *Entry) error {
 	actBlock := db.blocks[len(db.blocks)-1]
 	curSize, err := actBlock.size()
 	if err != nil {
 		return err
 	}
 	if curSize <= db.segmentSize {
-		err := actBlock.put(key, vType, value)
+		err := actBlock.putEntry(e)
 		if err != nil {
 			return err
 		}
@@ -140,7 +395,7 @@ func (db *Db) putType(key, vType, value string) error {
 	if err != nil {
 		return err
 	}
-	err = db.blocks[len(db.blocks)-1].put(key, vType, value)
+	err = db.blocks[len(db.blocks)-1].putEntry(e)
 	if err != nil {
 		return err
 	}
@@ -155,6 +410,38 @@ func (db *Db) putType(key, vType, value string) error {
 	return nil
 }
 
+func (db *Db) entries() ([]*Entry, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.latestValues()
+}
+
+// latestValues - entries без блокування; викликається під db.mu
+func (db *Db) latestValues() ([]*Entry, error) {
+	seen := make(map[string]bool)
+	var res []*Entry
+	//йдемо від найновішого блоку, щоб брати останні значення
+	for j := len(db.blocks) - 1; j >= 0; j = j - 1 {
+		b := db.blocks[j]
+		for _, key := range b.keys() {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			val, vType, err := b.get(key)
+			if err == errDeleted {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, &Entry{key: key, valueType: ToByte(vType), value: val})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].key < res[j].key })
+	return res, nil
+}
+
 func (db *Db) Get(key string) (string, error) {
 	val, vType, err := db.getType(key)
 	if err != nil {
@@ -197,30 +484,79 @@ func (db *Db) PutInt64(key string, value int64) error {
 	return nil
 }
 
+func (db *Db) GetValue(key string) (string, string, error) {
+	return db.getType(key)
+}
+
+// PutValue writes value as valueType, which may be a type added by RegisterType.
+func (db *Db) PutValue(key, valueType, value string) error {
+	if _, ok := typeToByte[valueType]; !ok {
+		return fmt.Errorf("unknown value type %q", valueType)
+	}
+	return db.putType(key, valueType, value)
+}
+
 func (db *Db) merge() error {
-	tempBlock, err := mergeAll(db.blocks[:len(db.blocks)-1])
+	//мердж переписує segment-0, тож зміщення з чекпоінту стають недійсними
+	if err := removeCheckpoint(db); err != nil {
+		return err
+	}
+	tempBlock, err := mergeAll(db.blocks[:len(db.blocks)-1], db.newIndex())
 	if err != nil {
 		return err
 	}
 
-	//додаємо блок до масиву блоків
-	db.blocks = append(db.blocks[:1], db.blocks[:]...)
-	db.blocks[0] = tempBlock
+	//спершу підміняємо segment-0: поки старі сегменти не видалені, з них
+	//читаються ті самі значення, тож падіння посеред мерджу нічого не губить
+	mergedPath := filepath.Join(db.dir, db.segmentName+"0")
+	err = os.Rename(tempBlock.outPath, mergedPath)
+	if err != nil {
+		tempBlock.close()
+		os.Remove(tempBlock.outPath)
+		return err
+	}
+
+	//segment.Name() лишається старим іменем, тому шлях виставляємо явно
+	tempBlock.outPath = mergedPath
+	oldBlocks := db.blocks[:len(db.blocks)-1]
+	db.blocks = []*block{tempBlock, db.blocks[len(db.blocks)-1]}
+	db.compacted = true
+	if db.pageCache != nil {
+		db.pageCache.invalidateFile(mergedPath)
+	}
+	tempBlock.cache = db.pageCache
+	tempBlock.bufferSize = db.bufferSize
 
-	//видаляємо вже непотрібні блоки
-	for _, block := range db.blocks[1 : len(db.blocks)-1] {
-		err := block.delete()
+	//видаляємо вже непотрібні блоки; недовидалені лише дублюють segment-0
+	for _, block := range oldBlocks {
+		if block.outPath == mergedPath {
+			block.close()
+			continue
+		}
+		err := failpoint("db.merge.delete")
+		if err == nil {
+			err = block.delete()
+		}
 		if err != nil {
 			return err
 		}
 	}
-
-	//видалимо рештки з масиву
-	db.blocks = append(db.blocks[:1], db.blocks[len(db.blocks)-1])
-	err = os.Rename(tempBlock.segment.Name(), filepath.Join(db.dir, db.segmentName+"0"))
-	tempBlock.outPath = tempBlock.segment.Name()
-	if err != nil {
-		return err
+	if db.logger != nil {
+		db.logger.Debug("merged segments", "path", mergedPath)
 	}
 	return nil
 }
+
+func (db *Db) SetPageCache(c *PageCache) {
+	db.pageCache = c
+	for _, b := range db.blocks {
+		b.cache = c
+	}
+}
+
+func (db *Db) PageCacheStats() PageCacheStats {
+	if db.pageCache == nil {
+		return PageCacheStats{}
+	}
+	return db.pageCache.Stats()
+}
@@ -1,11 +1,15 @@
 package datastore
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestDb_Put(t *testing.T) {
@@ -72,6 +76,8 @@ func TestDb_Put(t *testing.T) {
 	})
 
 	t.Run("new db process", func(t *testing.T) {
+		//старий процес мав завершитися й відпустити блокування
+		db.Close()
 		db, err = NewDb(dir)
 		if err != nil {
 			t.Fatal(err)
@@ -114,7 +120,8 @@ func TestDb_Put(t *testing.T) {
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
-		n := len(filesNames)
+		//без файлу блокування
+		n := len(filesNames) - 1
 		if n != 2 {
 			t.Errorf("Expected 2 files in the directory, got %v", n)
 		}
@@ -161,7 +168,8 @@ func TestDb_Put(t *testing.T) {
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
-		n := len(filesNames)
+		//без файлу блокування
+		n := len(filesNames) - 1
 		if n != 2 {
 			t.Errorf("Expected 2 files in the directory, got %v", n)
 		}
@@ -212,3 +220,157 @@ func TestDb_PutInt64(t *testing.T) {
 	})
 
 }
+
+func TestDb_LargeValue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value := strings.Repeat("v", 3*bufSize)
+	if err := db.Put("large", value); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := db.Get("large"); err != nil || got != value {
+		t.Errorf("Cannot get large value: %v", err)
+	}
+	db.Close()
+
+	db, err = NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if got, err := db.Get("large"); err != nil || got != value {
+		t.Errorf("Cannot get large value after reopening: %v", err)
+	}
+}
+
+func TestDb_TryPut(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if ok, err := db.TryPut("key", "value", time.Second); !ok || err != nil {
+		t.Fatalf("Expected the write to go through, got %v, %v", ok, err)
+	}
+	//блокування тримає інший запис
+	db.mu.Lock()
+	ok, err := db.TryPut("key", "other", 10*time.Millisecond)
+	db.mu.Unlock()
+	if ok || err != nil {
+		t.Errorf("Expected the attempt to time out, got %v, %v", ok, err)
+	}
+	if v, _ := db.Get("key"); v != "value" {
+		t.Errorf("Expected a timed-out TryPut to write nothing, got %q", v)
+	}
+
+	db.mu.Lock()
+	time.AfterFunc(20*time.Millisecond, db.mu.Unlock)
+	if ok, err := db.TryPut("key", "later", time.Second); !ok || err != nil {
+		t.Errorf("Expected TryPut to wait for the lock, got %v, %v", ok, err)
+	}
+	if v, _ := db.Get("key"); v != "later" {
+		t.Errorf("Bad value: %q", v)
+	}
+}
+
+func TestDb_ConcurrentReadersAndWriters(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	//малі сегменти, щоб мердж ішов паралельно з читанням
+	db, err := NewDb(dir, WithMaxFileSize(4096))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	//кожне значення - одна літера, повторена 100 разів; половинчасте видно одразу
+	valid := func(v string) bool {
+		return len(v) == 100 && strings.Count(v, v[:1]) == 100
+	}
+	keys := []string{"a", "b", "c", "d", "e"}
+	for _, key := range keys {
+		if err := db.Put(key, strings.Repeat("0", 100)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 1000)
+	for i := 0; i < 1000; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := keys[i%len(keys)]
+			if i%10 == 0 {
+				value := strings.Repeat(string(rune('a'+i%26)), 100)
+				if err := db.Put(key, value); err != nil {
+					errs <- err
+				}
+				return
+			}
+			if i%3 == 0 {
+				entries, err := db.entries()
+				if err != nil {
+					errs <- err
+					return
+				}
+				for _, e := range entries {
+					if !valid(e.value) {
+						errs <- fmt.Errorf("partial value of %q in scan: %q", e.key, e.value)
+					}
+				}
+				return
+			}
+			v, err := db.Get(key)
+			if err != nil {
+				errs <- err
+			} else if !valid(v) {
+				errs <- fmt.Errorf("partial value of %q: %q", key, v)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestDb_WriteAfterClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put("key", "value"); err != ErrClosed {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Errorf("Expected a second Close to be a no-op, got %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package datastore
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// DebeziumOp is the op field of a Debezium change event.
+type DebeziumOp string
+
+const (
+	DebeziumCreate DebeziumOp = "c"
+	DebeziumUpdate DebeziumOp = "u"
+	DebeziumDelete DebeziumOp = "d"
+)
+
+// DebeziumSource is the source block of an event: where the change was
+// written. File and Offset point at the latest version of the key when the
+// event was built.
+type DebeziumSource struct {
+	Version   string `json:"version"`
+	Connector string `json:"connector"`
+	Name      string `json:"name"`
+	TsMs      int64  `json:"ts_ms"`
+	File      string `json:"file,omitempty"`
+	Offset    int64  `json:"offset"`
+}
+
+// DebeziumEvent is the Debezium envelope as Kafka Connect writes it with the
+// JSON converter and schemas.enable=false. Rows are the records of
+// ExportJSON.
+type DebeziumEvent struct {
+	Before *exportRecord   `json:"before"`
+	After  *exportRecord   `json:"after"`
+	Source *DebeziumSource `json:"source"`
+	Op     DebeziumOp      `json:"op"`
+	TsMs   int64           `json:"ts_ms"`
+}
+
+// KafkaProducer is the part of a Kafka client DebeziumPublisher needs;
+// adapt the client of your choice to it.
+type KafkaProducer interface {
+	Produce(topic string, key, value []byte) error
+}
+
+// EncodeDebeziumEvent returns the Debezium event for writing e with op.
+// before is the previous version of the row or nil; for DebeziumDelete e
+// only supplies the key and after is null.
+func EncodeDebeziumEvent(e *Entry, op DebeziumOp, before *Entry) ([]byte, error) {
+	ts := timeNow().UnixMilli()
+	return encodeDebeziumEvent(e, op, before, &DebeziumSource{TsMs: ts})
+}
+
+func encodeDebeziumEvent(e *Entry, op DebeziumOp, before *Entry, source *DebeziumSource) ([]byte, error) {
+	if op != DebeziumCreate && op != DebeziumUpdate && op != DebeziumDelete {
+		return nil, fmt.Errorf("unsupported debezium op %q", op)
+	}
+	source.Version = "1.0"
+	source.Connector = "goland-db"
+	if source.Name == "" {
+		source.Name = "datastore"
+	}
+	ev := DebeziumEvent{Source: source, Op: op, TsMs: timeNow().UnixMilli()}
+	if before != nil {
+		record := newExportRecord(before)
+		ev.Before = &record
+	}
+	if op != DebeziumDelete {
+		record := newExportRecord(e)
+		ev.After = &record
+	}
+	return json.Marshal(ev)
+}
+
+// entryPosition повертає сегмент і зміщення останньої версії key
+func (db *Db) entryPosition(key string) (string, int64, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	for j := len(db.blocks) - 1; j >= 0; j-- {
+		b := db.blocks[j]
+		b.mu.RLock()
+		offset, ok := b.index.Find(key)
+		b.mu.RUnlock()
+		if ok {
+			return b.outPath, offset, true
+		}
+	}
+	return "", 0, false
+}
+
+// DebeziumPublisher produces a Debezium event to a Kafka topic for every
+// write to a Db. Like Postgres with the default replica identity it has no
+// before image of updates: before is null for "c" and "u" and holds only
+// the key for "d".
+type DebeziumPublisher struct {
+	db       *Db
+	producer KafkaProducer
+	topic    string
+	unwatch  func()
+
+	mu     sync.Mutex
+	closed bool
+	known  map[string]bool
+	events chan debeziumMessage
+	done   chan struct{}
+}
+
+type debeziumMessage struct {
+	key, value []byte
+}
+
+// NewDebeziumPublisher starts publishing the writes to db to topic until
+// Close. Publishing happens on a background goroutine; producer errors are
+// logged to the Db logger.
+func NewDebeziumPublisher(db *Db, producer KafkaProducer, topic string) (*DebeziumPublisher, error) {
+	entries, err := db.entries()
+	if err != nil {
+		return nil, err
+	}
+	p := &DebeziumPublisher{
+		db:       db,
+		producer: producer,
+		topic:    topic,
+		known:    make(map[string]bool, len(entries)),
+		events:   make(chan debeziumMessage, watchBufferSize),
+		done:     make(chan struct{}),
+	}
+	for _, e := range entries {
+		p.known[e.key] = true
+	}
+	p.unwatch = db.Watch(p.enqueue)
+	go p.run()
+	return p, nil
+}
+
+func (p *DebeziumPublisher) enqueue(e *Entry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	op, before := DebeziumCreate, (*Entry)(nil)
+	switch {
+	case e.valueType == TOMBSTONE_TYPE || e.valueType == SOFT_DELETED_TYPE:
+		if !p.known[e.key] {
+			return
+		}
+		op, before = DebeziumDelete, &Entry{key: e.key}
+		delete(p.known, e.key)
+	case p.known[e.key]:
+		op = DebeziumUpdate
+	default:
+		p.known[e.key] = true
+	}
+	source := &DebeziumSource{TsMs: timeNow().UnixMilli()}
+	source.File, source.Offset, _ = p.db.entryPosition(e.key)
+	value, err := encodeDebeziumEvent(e, op, before, source)
+	if err == nil {
+		var key []byte
+		key, err = json.Marshal(map[string]string{"key": e.key})
+		if err == nil {
+			select {
+			case p.events <- debeziumMessage{key, value}:
+				return
+			default:
+				err = fmt.Errorf("publish buffer is full")
+			}
+		}
+	}
+	if p.db.logger != nil {
+		p.db.logger.Error("debezium event dropped", "key", e.key, "error", err)
+	}
+}
+
+func (p *DebeziumPublisher) run() {
+	defer close(p.done)
+	for msg := range p.events {
+		if err := p.producer.Produce(p.topic, msg.key, msg.value); err != nil && p.db.logger != nil {
+			p.db.logger.Error("debezium produce failed", "topic", p.topic, "error", err)
+		}
+	}
+}
+
+// Close stops watching the Db and waits for the queued events.
+func (p *DebeziumPublisher) Close() error {
+	p.unwatch()
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.events)
+	}
+	p.mu.Unlock()
+	<-p.done
+	return nil
+}
@@ -0,0 +1,106 @@
+package datastore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeKafkaProducer struct {
+	mu       sync.Mutex
+	topics   []string
+	keys     []string
+	messages [][]byte
+}
+
+func (p *fakeKafkaProducer) Produce(topic string, key, value []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.topics = append(p.topics, topic)
+	p.keys = append(p.keys, string(key))
+	p.messages = append(p.messages, value)
+	return nil
+}
+
+func TestEncodeDebeziumEvent(t *testing.T) {
+	data, err := EncodeDebeziumEvent(&Entry{key: "user:1", valueType: STRING_TYPE, value: "alice"}, DebeziumCreate, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if string(raw["before"]) != "null" || string(raw["op"]) != `"c"` {
+		t.Errorf("Bad create event: %s", data)
+	}
+	var ev DebeziumEvent
+	if err := json.Unmarshal(data, &ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.After == nil || ev.After.Key != "user:1" || ev.After.Type != "string" || ev.After.Value != "alice" {
+		t.Errorf("Bad after: %+v", ev.After)
+	}
+	if ev.Source == nil || ev.Source.Connector != "goland-db" || ev.Source.TsMs == 0 || ev.TsMs == 0 {
+		t.Errorf("Bad source: %+v", ev.Source)
+	}
+	if _, err := EncodeDebeziumEvent(&Entry{key: "k"}, "x", nil); err == nil {
+		t.Errorf("Expected an error for an unknown op")
+	}
+}
+
+func TestDebeziumPublisher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Put("existing", "old"); err != nil {
+		t.Fatal(err)
+	}
+
+	producer := &fakeKafkaProducer{}
+	publisher, err := NewDebeziumPublisher(db, producer, "db.changes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pair := range [][]string{{"existing", "new"}, {"fresh", "1"}, {"fresh", "2"}} {
+		if err := db.Put(pair[0], pair[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.SoftDelete("fresh", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	publisher.Close()
+
+	expected := []DebeziumOp{DebeziumUpdate, DebeziumCreate, DebeziumUpdate, DebeziumDelete}
+	if len(producer.messages) != len(expected) {
+		t.Fatalf("Expected %d events, got %d", len(expected), len(producer.messages))
+	}
+	for i, data := range producer.messages {
+		var ev DebeziumEvent
+		if err := json.Unmarshal(data, &ev); err != nil {
+			t.Fatal(err)
+		}
+		if ev.Op != expected[i] || producer.topics[i] != "db.changes" {
+			t.Errorf("Expected op %q in event %d, got %s", expected[i], i, data)
+		}
+		if ev.Source.File == "" {
+			t.Errorf("Expected the segment in the source of event %d", i)
+		}
+	}
+	var del DebeziumEvent
+	json.Unmarshal(producer.messages[3], &del)
+	if del.After != nil || del.Before == nil || del.Before.Key != "fresh" || producer.keys[3] != `{"key":"fresh"}` {
+		t.Errorf("Bad delete event: %s", producer.messages[3])
+	}
+}
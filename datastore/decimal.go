@@ -0,0 +1,169 @@
+package datastore
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Десяткове число mantissa × 10^(-scale) зберігається як рівно 9 байт:
+// мантиса i64 і масштаб i8. У Entry.value воно лежить текстом: "123.45",
+// а для від'ємного масштабу — "5e2".
+
+func formatDecimal(mantissa int64, scale int8) string {
+	digits := strconv.FormatInt(mantissa, 10)
+	sign := ""
+	if mantissa < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	switch {
+	case scale < 0:
+		return sign + digits + "e" + strconv.Itoa(-int(scale))
+	case scale == 0:
+		return sign + digits
+	}
+	if len(digits) <= int(scale) {
+		digits = strings.Repeat("0", int(scale)-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-int(scale)] + "." + digits[len(digits)-int(scale):]
+}
+
+func parseDecimal(s string) (int64, int8, error) {
+	body, exp, hasExp := strings.Cut(s, "e")
+	whole, frac, hasFrac := strings.Cut(body, ".")
+	digits := strings.TrimPrefix(whole, "-") + frac
+	if digits == frac || (hasExp && hasFrac) || (hasFrac && frac == "") || strings.Trim(digits, "0123456789") != "" {
+		return 0, 0, fmt.Errorf("bad decimal %q", s)
+	}
+	mantissa, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("bad decimal %q: %v", s, err)
+	}
+	if !hasExp {
+		if len(frac) > math.MaxInt8 {
+			return 0, 0, fmt.Errorf("decimal %q has too many fraction digits", s)
+		}
+		return mantissa, int8(len(frac)), nil
+	}
+	n, err := strconv.Atoi(exp)
+	if err != nil || n <= 0 || n > -math.MinInt8 {
+		return 0, 0, fmt.Errorf("bad decimal exponent in %q", s)
+	}
+	return mantissa, int8(-n), nil
+}
+
+type decimalOperator struct{}
+
+func (s decimalOperator) Encode(e *Entry, dst []byte) []byte {
+	mantissa, scale, err := parseDecimal(e.value)
+	if err != nil {
+		panic(err)
+	}
+	res, offset := encodeKeyInto(e, 5, dst)
+	res[offset] = DECIMAL_TYPE
+	binary.LittleEndian.PutUint64(res[offset+TYPE_SIZE:], uint64(mantissa))
+	res[offset+TYPE_SIZE+8] = byte(scale)
+	return res
+}
+
+func (s decimalOperator) EncodeInto(e *Entry, dst []byte) ([]byte, error) {
+	mantissa, scale, err := parseDecimal(e.value)
+	if err != nil {
+		return dst, err
+	}
+	dst = appendKey(dst, e, 5)
+	dst = append(dst, DECIMAL_TYPE)
+	dst = binary.LittleEndian.AppendUint64(dst, uint64(mantissa))
+	return append(dst, byte(scale)), nil
+}
+
+func (s decimalOperator) Decode(input []byte, e *Entry) {
+	kl := len(e.key)
+	data := input[kl+TYPE_SIZE+8:]
+	e.value = formatDecimal(int64(binary.LittleEndian.Uint64(data)), int8(data[8]))
+}
+
+func (s decimalOperator) Read(in *bufio.Reader) (string, error) {
+	data, err := in.Peek(9)
+	if err != nil {
+		return "", err
+	}
+	return formatDecimal(int64(binary.LittleEndian.Uint64(data)), int8(data[8])), nil
+}
+
+func NewDecimalEntry(key string, mantissa int64, scale int8) *Entry {
+	return &Entry{key: key, valueType: DECIMAL_TYPE, value: formatDecimal(mantissa, scale)}
+}
+
+func (e *Entry) GetDecimal() (int64, int8, error) {
+	if e.valueType != DECIMAL_TYPE {
+		return 0, 0, fmt.Errorf("wrong type of value")
+	}
+	return parseDecimal(e.value)
+}
+
+func (db *Db) PutDecimal(key string, mantissa int64, scale int8) error {
+	return db.putEntry(NewDecimalEntry(key, mantissa, scale))
+}
+
+func (db *Db) GetDecimal(key string) (int64, int8, error) {
+	e, err := db.getEntry(key)
+	if err != nil {
+		return 0, 0, err
+	}
+	return e.GetDecimal()
+}
+
+// rescaleDecimal переводить мантису до більшого масштабу без втрати точності
+func rescaleDecimal(mantissa int64, from, to int8) (int64, error) {
+	for ; from < to; from++ {
+		if mantissa > math.MaxInt64/10 || mantissa < math.MinInt64/10 {
+			return 0, fmt.Errorf("decimal overflow")
+		}
+		mantissa *= 10
+	}
+	return mantissa, nil
+}
+
+// AddDecimal returns the exact sum of two decimals at the larger of their
+// scales, or an error if it does not fit in int64.
+func AddDecimal(m1 int64, s1 int8, m2 int64, s2 int8) (int64, int8, error) {
+	scale := s1
+	if s2 > scale {
+		scale = s2
+	}
+	a, err := rescaleDecimal(m1, s1, scale)
+	if err != nil {
+		return 0, 0, err
+	}
+	b, err := rescaleDecimal(m2, s2, scale)
+	if err != nil {
+		return 0, 0, err
+	}
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, 0, fmt.Errorf("decimal overflow")
+	}
+	return sum, scale, nil
+}
+
+// CompareDecimal compares two decimals by value, so 0.30 equals 0.3.
+func CompareDecimal(m1 int64, s1 int8, m2 int64, s2 int8) (int, error) {
+	if m2 == math.MinInt64 {
+		return 0, fmt.Errorf("decimal overflow")
+	}
+	diff, _, err := AddDecimal(m1, s1, -m2, s2)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case diff < 0:
+		return -1, nil
+	case diff > 0:
+		return 1, nil
+	}
+	return 0, nil
+}
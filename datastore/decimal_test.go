@@ -0,0 +1,99 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+)
+
+func TestDecimal_Format(t *testing.T) {
+	for _, tc := range []struct {
+		mantissa int64
+		scale    int8
+		text     string
+	}{
+		{12345, 2, "123.45"},
+		{-12345, 2, "-123.45"},
+		{5, 3, "0.005"},
+		{-5, 1, "-0.5"},
+		{0, 2, "0.00"},
+		{42, 0, "42"},
+		{5, -2, "5e2"},
+		{math.MinInt64, 18, "-9.223372036854775808"},
+	} {
+		e := NewDecimalEntry("price", tc.mantissa, tc.scale)
+		if e.value != tc.text {
+			t.Errorf("Bad text for %d, %d: %q", tc.mantissa, tc.scale, e.value)
+		}
+		var decoded Entry
+		decoded.Decode(e.Encode())
+		m, s, err := decoded.GetDecimal()
+		if err != nil || m != tc.mantissa || s != tc.scale {
+			t.Errorf("Bad round trip of %q: %d, %d, %v", tc.text, m, s, err)
+		}
+	}
+
+	for _, bad := range []string{"", "-", "1.", ".5", "1.2.3", "1e", "1.5e2", "1e0", "+5", "1,5", "99999999999999999999"} {
+		if _, err := EncodeInto(&Entry{key: "k", valueType: DECIMAL_TYPE, value: bad}, nil); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for key, m := range map[string]int64{"a": 1, "b": 2, "sum": 3} {
+		if err := db.PutDecimal(key, m, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	am, as, err := db.GetDecimal("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bm, bs, err := db.GetDecimal("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, s, err := AddDecimal(am, as, bm, bs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wm, ws, err := db.GetDecimal("sum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, err := CompareDecimal(m, s, wm, ws); err != nil || c != 0 || formatDecimal(m, s) != "0.3" {
+		t.Errorf("Expected 0.1 + 0.2 == 0.3, got %s", formatDecimal(m, s))
+	}
+
+	if c, err := CompareDecimal(30, 2, 3, 1); err != nil || c != 0 {
+		t.Errorf("Expected 0.30 == 0.3, got %d, %v", c, err)
+	}
+	if c, err := CompareDecimal(1, 3, 1, 2); err != nil || c != -1 {
+		t.Errorf("Expected 0.001 < 0.01, got %d, %v", c, err)
+	}
+	if _, _, err := AddDecimal(math.MaxInt64, 0, 1, 0); err == nil {
+		t.Errorf("Expected an overflow error")
+	}
+	if _, _, err := AddDecimal(math.MaxInt64/5, 0, 1, 1); err == nil {
+		t.Errorf("Expected an overflow error when rescaling")
+	}
+	if err := db.Put("s", "1.5"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := db.GetDecimal("s"); err == nil {
+		t.Errorf("Expected an error for a string value")
+	}
+}
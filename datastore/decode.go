@@ -0,0 +1,182 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+)
+
+func recordOffsets(src []byte) ([]int, error) {
+	var offsets []int
+	for offset := 0; offset < len(src); {
+		if len(src)-offset < 4 {
+			return nil, fmt.Errorf("corrupted record header at offset %d", offset)
+		}
+		size := int(binary.LittleEndian.Uint32(src[offset:]))
+		if size < 8 || size > len(src)-offset {
+			return nil, fmt.Errorf("corrupted record size %d at offset %d", size, offset)
+		}
+		offsets = append(offsets, offset)
+		offset += size
+	}
+	return offsets, nil
+}
+
+func decodeRecord(data []byte) (*Entry, error) {
+	kl := int(binary.LittleEndian.Uint32(data[4:]))
+	if kl > len(data)-8-TYPE_SIZE {
+		return nil, fmt.Errorf("corrupted key length %d", kl)
+	}
+	valueType := data[kl+8]
+	//значення перевіряємо без секції анотацій у кінці запису
+	value := data
+	if valueType&ANNOTATED_FLAG != 0 {
+		valueType &^= ANNOTATED_FLAG
+		end := len(data) - 4
+		if end < kl+8+TYPE_SIZE || int(binary.LittleEndian.Uint32(data[end:])) > end-kl-8-TYPE_SIZE {
+			return nil, fmt.Errorf("corrupted annotations length")
+		}
+		section := data[end-int(binary.LittleEndian.Uint32(data[end:])) : end]
+		if _, err := parseAnnotations(string(section)); err != nil {
+			return nil, err
+		}
+		value = data[:len(data)-4-len(section)]
+	}
+	operator, ok := operators[valueType]
+	if !ok {
+		return nil, fmt.Errorf("unknown value type %d", valueType)
+	}
+	switch operator.(type) {
+	case stringOperator, taggedStringOperator, codecOperator, validatedStringOperator:
+		start := kl + TYPE_SIZE + 12
+		if start > len(value) || int(binary.LittleEndian.Uint32(value[start-4:])) != len(value)-start {
+			return nil, fmt.Errorf("corrupted value length")
+		}
+		payload := value[start : start+int(binary.LittleEndian.Uint32(value[start-4:]))]
+		switch op := operator.(type) {
+		case codecOperator:
+			if _, err := op.codec.DecodeValue(payload); err != nil {
+				return nil, fmt.Errorf("corrupted %s value: %v", ToType(valueType), err)
+			}
+		case validatedStringOperator:
+			if err := op.validate(string(payload)); err != nil {
+				return nil, err
+			}
+		}
+	case int64Operator:
+		if len(value) != kl+8+TYPE_SIZE+12 {
+			return nil, fmt.Errorf("corrupted int64 value")
+		}
+	case lamportOperator, timestampOperator, durationOperator, subscriptionOperator:
+		if len(value) != kl+8+TYPE_SIZE+8 {
+			return nil, fmt.Errorf("corrupted %s value", ToType(valueType))
+		}
+	case dictStringOperator:
+		if len(value) != kl+8+TYPE_SIZE+2 {
+			return nil, fmt.Errorf("corrupted dictionary code")
+		}
+	case decimalOperator:
+		if len(value) != kl+8+TYPE_SIZE+9 {
+			return nil, fmt.Errorf("corrupted decimal value")
+		}
+	case uuidOperator, geoOperator, complexOperator:
+		if len(value) != kl+8+TYPE_SIZE+16 {
+			return nil, fmt.Errorf("corrupted %s value", ToType(valueType))
+		}
+	case rationalOperator:
+		if len(value) != kl+8+TYPE_SIZE+16 || binary.LittleEndian.Uint64(value[kl+8+TYPE_SIZE+8:]) == 0 {
+			return nil, fmt.Errorf("corrupted rational value")
+		}
+	case ipOperator:
+		payload := value[kl+8+TYPE_SIZE:]
+		if len(payload) == 0 || !(payload[0] == 4 && len(payload) == 5 || payload[0] == 6 && len(payload) == 17) {
+			return nil, fmt.Errorf("corrupted ipaddr value")
+		}
+	case matrixOperator:
+		payload := value[kl+8+TYPE_SIZE:]
+		if len(payload) < 4 {
+			return nil, fmt.Errorf("corrupted matrix value")
+		}
+		rows, cols := int(binary.LittleEndian.Uint16(payload)), int(binary.LittleEndian.Uint16(payload[2:]))
+		if checkMatrixSize(rows, cols) != nil || len(payload) != 4+8*rows*cols {
+			return nil, fmt.Errorf("corrupted matrix value")
+		}
+	case varintOperator:
+		if _, n := binary.Varint(value[kl+8+TYPE_SIZE:]); n <= 0 || n != len(value)-kl-8-TYPE_SIZE {
+			return nil, fmt.Errorf("corrupted varint value")
+		}
+	}
+
+	var e Entry
+	e.Decode(data)
+	return &e, nil
+}
+
+func DecodeMany(src []byte) ([]*Entry, error) {
+	offsets, err := recordOffsets(src)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*Entry, len(offsets))
+	for i, offset := range offsets {
+		size := int(binary.LittleEndian.Uint32(src[offset:]))
+		res[i], err = decodeRecord(src[offset : offset+size])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+const decodeBatchSize = 1024
+
+func ParallelDecodeMany(src []byte, workers int) ([]*Entry, error) {
+	if workers < 1 {
+		return nil, fmt.Errorf("workers must be positive, got %d", workers)
+	}
+	offsets, err := recordOffsets(src)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*Entry, len(offsets))
+	//воркери отримують не окремі записи, а пачки, щоб не платити за канал на кожен запис
+	jobs := make(chan int)
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range jobs {
+				end := start + decodeBatchSize
+				if end > len(offsets) {
+					end = len(offsets)
+				}
+				for i := start; i < end; i++ {
+					offset := offsets[i]
+					size := int(binary.LittleEndian.Uint32(src[offset:]))
+					e, err := decodeRecord(src[offset : offset+size])
+					if err != nil {
+						errOnce.Do(func() { firstErr = err })
+						break
+					}
+					res[i] = e
+				}
+			}
+		}()
+	}
+	for start := 0; start < len(offsets); start += decodeBatchSize {
+		jobs <- start
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return res, nil
+}
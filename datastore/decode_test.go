@@ -0,0 +1,82 @@
+package datastore
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+func encodeMany(n int) ([]byte, []Entry) {
+	var src []byte
+	var entries []Entry
+	for i := 0; i < n; i++ {
+		e := Entry{key: fmt.Sprintf("key%d", i), valueType: ToByte("string"), value: fmt.Sprintf("value%d", i)}
+		if i%2 == 1 {
+			e = Entry{key: fmt.Sprintf("key%d", i), valueType: ToByte("int64"), value: fmt.Sprintf("%d", -i)}
+		}
+		entries = append(entries, e)
+		src = append(src, e.Encode()...)
+	}
+	return src, entries
+}
+
+func TestDecodeMany(t *testing.T) {
+	src, entries := encodeMany(1000)
+	for _, workers := range []int{0, 1, 4} {
+		var (
+			res []*Entry
+			err error
+		)
+		if workers == 0 {
+			res, err = DecodeMany(src)
+		} else {
+			res, err = ParallelDecodeMany(src, workers)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res) != len(entries) {
+			t.Fatalf("Expected %d entries, got %d", len(entries), len(res))
+		}
+		for i := range entries {
+			if *res[i] != entries[i] {
+				t.Errorf("Workers %d: bad entry %d: expected %v, got %v", workers, i, entries[i], *res[i])
+			}
+		}
+	}
+}
+
+func TestDecodeMany_Corrupted(t *testing.T) {
+	src, _ := encodeMany(10)
+	if _, err := DecodeMany(src[:len(src)-1]); err == nil {
+		t.Error("Expected error for truncated input")
+	}
+
+	bad := append([]byte(nil), src...)
+	bad[8+len("key0")] = 42
+	if _, err := ParallelDecodeMany(bad, 2); err == nil {
+		t.Error("Expected error for unknown value type")
+	}
+	if _, err := ParallelDecodeMany(src, 0); err == nil {
+		t.Error("Expected error for zero workers")
+	}
+}
+
+func BenchmarkDecodeMany(b *testing.B) {
+	src, _ := encodeMany(500000)
+	b.SetBytes(int64(len(src)))
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := DecodeMany(src); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := ParallelDecodeMany(src, runtime.NumCPU()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package datastore
+
+import (
+	"hash/fnv"
+	"strconv"
+	"sync"
+)
+
+var _ Store = (*DeduplicatingStore)(nil)
+
+// DeduplicatingStore skips writes whose encoded entry matches the last one
+// written through it for the same key. Writes that bypass the wrapper are not
+// seen, so it must be the only writer of the wrapped store.
+type DeduplicatingStore struct {
+	store Store
+
+	//м'ютекс тримається під час запису, щоб порядок хешів збігався з порядком на диску
+	mu      sync.Mutex
+	hashes  map[string]uint64
+	buf     []byte
+	skipped int64
+	written int64
+}
+
+func NewDeduplicatingStore(store Store) *DeduplicatingStore {
+	return &DeduplicatingStore{
+		store:  store,
+		hashes: make(map[string]uint64),
+	}
+}
+
+func (d *DeduplicatingStore) DedupStats() (skipped, written int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.skipped, d.written
+}
+
+func (d *DeduplicatingStore) write(e *Entry, put func() error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.buf = e.EncodeBuffer(d.buf)
+	h := fnv.New64a()
+	h.Write(d.buf)
+	sum := h.Sum64()
+	if last, ok := d.hashes[e.key]; ok && last == sum {
+		d.skipped++
+		return nil
+	}
+
+	if err := put(); err != nil {
+		//після невдалого запису не знаємо, що лишилось на диску
+		delete(d.hashes, e.key)
+		return err
+	}
+	d.hashes[e.key] = sum
+	d.written++
+	return nil
+}
+
+func (d *DeduplicatingStore) Get(key string) (string, error) {
+	return d.store.Get(key)
+}
+
+func (d *DeduplicatingStore) Put(key, value string) error {
+	e := &Entry{key: key, valueType: STRING_TYPE, value: value}
+	return d.write(e, func() error {
+		return d.store.Put(key, value)
+	})
+}
+
+func (d *DeduplicatingStore) GetInt64(key string) (int64, error) {
+	return d.store.GetInt64(key)
+}
+
+func (d *DeduplicatingStore) PutInt64(key string, value int64) error {
+	e := &Entry{key: key, valueType: INT64_TYPE, value: strconv.FormatInt(value, 10)}
+	return d.write(e, func() error {
+		return d.store.PutInt64(key, value)
+	})
+}
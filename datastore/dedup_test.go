@@ -0,0 +1,77 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestDeduplicatingStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	d := NewDeduplicatingStore(db)
+	for i := 0; i < 1000; i++ {
+		if err := d.Put("heartbeat", "alive"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if skipped, written := d.DedupStats(); skipped != 999 || written != 1 {
+		t.Errorf("Expected 999 skipped and 1 written, got %d and %d", skipped, written)
+	}
+	size, err := db.blocks[0].size()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := int64(len((&Entry{key: "heartbeat", value: "alive"}).Encode())); size != expected {
+		t.Errorf("Expected a single record of %d bytes, got %d", expected, size)
+	}
+
+	// зміна значення або типу — це новий запис
+	if err := d.Put("heartbeat", "dead"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutInt64("heartbeat", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutInt64("heartbeat", 1); err != nil {
+		t.Fatal(err)
+	}
+	if skipped, written := d.DedupStats(); skipped != 1000 || written != 3 {
+		t.Errorf("Expected 1000 skipped and 3 written, got %d and %d", skipped, written)
+	}
+	if n, err := d.GetInt64("heartbeat"); err != nil || n != 1 {
+		t.Errorf("Bad value read: %d, %v", n, err)
+	}
+}
+
+func TestDeduplicatingStore_Error(t *testing.T) {
+	broken := fmt.Errorf("down")
+	store := &mockStore{fail: func(call int) error {
+		if call == 2 {
+			return broken
+		}
+		return nil
+	}}
+	d := NewDeduplicatingStore(store)
+	d.Put("key", "value")
+	if err := d.Put("key", "other"); err != broken {
+		t.Fatalf("Expected write error, got %v", err)
+	}
+	if err := d.Put("key", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if store.calls != 3 {
+		t.Errorf("Expected write after failure to reach the store, got %d calls", store.calls)
+	}
+}
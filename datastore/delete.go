@@ -0,0 +1,153 @@
+package datastore
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	deletedTypeAnnotation  = "deleted.type"
+	deletedUntilAnnotation = "deleted.until"
+)
+
+// внутрішня назва типу жорсткого видалення
+const tombstoneTypeName = "tombstone"
+
+func (db *Db) getEntry(key string) (*Entry, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.latestEntry(key)
+}
+
+// latestEntry читає без блокування; викликається під db.mu
+func (db *Db) latestEntry(key string) (*Entry, error) {
+	for j := len(db.blocks) - 1; j >= 0; j = j - 1 {
+		e, err := db.blocks[j].getEntry(key)
+		if err == ErrNotFound {
+			continue
+		}
+		if err == errDeleted {
+			return nil, ErrNotFound
+		}
+		return e, err
+	}
+	return nil, ErrNotFound
+}
+
+// SoftDelete hides key from reads but keeps its value readable through
+// LookupIncludingDeleted for retainFor. Prune removes it for good afterwards.
+func (db *Db) SoftDelete(key string, retainFor time.Duration) error {
+	e, err := db.getEntry(key)
+	if err != nil {
+		return err
+	}
+	if e.valueType == SOFT_DELETED_TYPE {
+		return ErrNotFound
+	}
+	deleted := &Entry{key: key, valueType: SOFT_DELETED_TYPE, value: e.value, annotations: e.annotations}
+	deleted.Annotate(deletedTypeAnnotation, e.Type())
+	deleted.Annotate(deletedUntilAnnotation, strconv.FormatInt(timeNow().Add(retainFor).UnixNano(), 10))
+	return db.putEntry(deleted)
+}
+
+// LookupIncludingDeleted returns the latest entry for key even if it was
+// soft-deleted; IsSoftDeleted reports which case it is.
+func (db *Db) LookupIncludingDeleted(key string) (*Entry, error) {
+	e, err := db.getEntry(key)
+	if err != nil {
+		return nil, err
+	}
+	if e.valueType == SOFT_DELETED_TYPE {
+		vType, _ := e.Annotation(deletedTypeAnnotation)
+		e.valueType = ToByte(vType)
+	}
+	return e, nil
+}
+
+func (e *Entry) IsSoftDeleted() bool {
+	_, ok := e.expiry(deletedUntilAnnotation)
+	return ok
+}
+
+func (e *Entry) DeletedUntil() (time.Time, bool) {
+	return e.expiry(deletedUntilAnnotation)
+}
+
+func (db *Db) putTombstone(key string) error {
+	return db.putEntry(&Entry{key: key, valueType: TOMBSTONE_TYPE})
+}
+
+// deleteExisting пише надгробок, лише якщо key можна прочитати, і каже, чи
+// він був; перевірка й запис ідуть через compareAndSwap, тож з двох
+// паралельних видалень ключ видаляє лише одне
+func (db *Db) deleteExisting(key string) (bool, error) {
+	e, err := db.updateEntry(key, func(cur *Entry) (*Entry, error) {
+		if cur == nil || cur.valueType == SOFT_DELETED_TYPE {
+			return nil, nil
+		}
+		return &Entry{valueType: TOMBSTONE_TYPE}, nil
+	})
+	return err == nil && e != nil && e.valueType == TOMBSTONE_TYPE, err
+}
+
+// Prune writes hard tombstones for soft-deleted keys past their retain window.
+func Prune(db *Db) error {
+	entries, err := db.scanEntries()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if until, ok := e.DeletedUntil(); ok && e.valueType == SOFT_DELETED_TYPE && !timeNow().Before(until) {
+			if err := db.putTombstone(e.key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// scanEntries повертає останню версію кожного ключа разом з анотаціями,
+// включно з м'яко видаленими; видалені остаточно пропускає
+func (db *Db) scanEntries() ([]*Entry, error) {
+	var res []*Entry
+	err := db.scanVersions(func(key string, e *Entry) error {
+		if e != nil {
+			res = append(res, e)
+		}
+		return nil
+	})
+	return res, err
+}
+
+// scanVersions викликає fn під db.mu для останньої версії кожного ключа;
+// для остаточно видаленого ключа e == nil
+func (db *Db) scanVersions(fn func(key string, e *Entry) error) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.scanLatest(fn)
+}
+
+// scanLatest - scanVersions без блокування; викликається під db.mu
+func (db *Db) scanLatest(fn func(key string, e *Entry) error) error {
+	seen := make(map[string]bool)
+	for j := len(db.blocks) - 1; j >= 0; j = j - 1 {
+		b := db.blocks[j]
+		for _, key := range b.keys() {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			e, err := b.getEntry(key)
+			if err == errDeleted {
+				e, err = nil, nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := fn(key, e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDb_SoftDelete(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Unix(1700000000, 0)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Put("key", "old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put("key", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.PutInt64("counter", 42); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SoftDelete("key", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SoftDelete("counter", 2*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SoftDelete("missing", time.Hour); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound for a missing key, got %v", err)
+	}
+
+	// у вікні зберігання ключ прихований, але відновлюваний
+	now = now.Add(30 * time.Minute)
+	if _, err := db.Get("key"); err != ErrNotFound {
+		t.Errorf("Expected soft-deleted key to be hidden, got %v", err)
+	}
+	if _, _, err := db.GetWithStale("key"); err != ErrNotFound {
+		t.Errorf("Expected GetWithStale to hide soft-deleted key, got %v", err)
+	}
+	if err := db.SoftDelete("key", time.Hour); err != ErrNotFound {
+		t.Errorf("Expected repeated soft delete to fail, got %v", err)
+	}
+	e, err := db.LookupIncludingDeleted("key")
+	if err != nil || e.Value() != "value" || e.Type() != "string" || !e.IsSoftDeleted() {
+		t.Errorf("Bad soft-deleted entry: %v, %v", e, err)
+	}
+	e, err = db.LookupIncludingDeleted("counter")
+	if err != nil || e.Value() != "42" || e.Type() != "int64" {
+		t.Errorf("Bad soft-deleted int64 entry: %v, %v", e, err)
+	}
+	if entries, err := db.entries(); err != nil || len(entries) != 0 {
+		t.Errorf("Expected no live entries, got %v, %v", entries, err)
+	}
+
+	if err := Prune(db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.LookupIncludingDeleted("key"); err != nil {
+		t.Errorf("Expected prune to keep entries inside the window, got %v", err)
+	}
+
+	// після вікна ключ видаляється назавжди, разом зі старими версіями
+	now = now.Add(time.Hour)
+	if err := Prune(db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.LookupIncludingDeleted("key"); err != ErrNotFound {
+		t.Errorf("Expected pruned key to be gone, got %v", err)
+	}
+	if _, err := db.LookupIncludingDeleted("counter"); err != nil {
+		t.Errorf("Expected counter to stay recoverable, got %v", err)
+	}
+
+	db.Close()
+	db, err = NewDb(dir, WithMaxFileSize(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.LookupIncludingDeleted("key"); err != ErrNotFound {
+		t.Errorf("Expected pruned key to stay gone after reopen, got %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := db.Put(fmt.Sprintf("filler%d", i), "value to roll segments"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.LookupIncludingDeleted("key"); err != ErrNotFound {
+		t.Errorf("Expected pruned key to stay gone after merge, got %v", err)
+	}
+	if e, err := db.LookupIncludingDeleted("counter"); err != nil || !e.IsSoftDeleted() {
+		t.Errorf("Expected soft delete to survive merge, got %v, %v", e, err)
+	}
+}
@@ -0,0 +1,251 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Дифф-запис має розмітку рядка, а його значення — це
+// [u64 зміщення базового запису в тому ж блоці][операції].
+// Операція: [тип][uvarint довжина][байти, лише для вставки].
+
+const (
+	diffCopy byte = iota
+	diffSkip
+	diffInsert
+)
+
+// внутрішня назва типу; для користувача дифф-запис завжди string
+const diffTypeName = "diff"
+
+// більші правки вигідніше записати повним значенням
+const maxDiffEdits = 256
+
+// обмежує глибину рекурсії при читанні
+const maxDiffChain = 32
+
+func parseDiff(payload string) (int64, string, error) {
+	if len(payload) < 8 {
+		return 0, "", fmt.Errorf("corrupted diff")
+	}
+	return int64(binary.LittleEndian.Uint64([]byte(payload[:8]))), payload[8:], nil
+}
+
+func applyDiff(base, ops string) (string, error) {
+	res := make([]byte, 0, len(base))
+	pos := 0
+	for len(ops) > 0 {
+		op := ops[0]
+		n, size := binary.Uvarint([]byte(ops[1:min(len(ops), 1+binary.MaxVarintLen64)]))
+		if size <= 0 {
+			return "", fmt.Errorf("corrupted diff")
+		}
+		ops = ops[1+size:]
+		switch op {
+		case diffCopy, diffSkip:
+			if n > uint64(len(base)-pos) {
+				return "", fmt.Errorf("corrupted diff")
+			}
+			if op == diffCopy {
+				res = append(res, base[pos:pos+int(n)]...)
+			}
+			pos += int(n)
+		case diffInsert:
+			if n > uint64(len(ops)) {
+				return "", fmt.Errorf("corrupted diff")
+			}
+			res = append(res, ops[:n]...)
+			ops = ops[n:]
+		default:
+			return "", fmt.Errorf("unknown diff operation %d", op)
+		}
+	}
+	return string(res), nil
+}
+
+// diffStrings будує скрипт правок алгоритмом Маєрса. Повертає false, якщо
+// правок більше за maxEdits.
+func diffStrings(a, b string, maxEdits int) ([]byte, bool) {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxEdits)
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+
+	found := -1
+	for d := 0; d <= limit && found < 0; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = d
+				break
+			}
+		}
+	}
+	if found < 0 {
+		return nil, false
+	}
+
+	//йдемо назад по збережених станах і збираємо правки з кінця
+	edits := make([]byte, 0, n+m)
+	x, y := n, m
+	for d := found; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			edits = append(edits, diffCopy)
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, diffInsert)
+			} else {
+				edits = append(edits, diffSkip)
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	var ops []byte
+	y = 0
+	for i := len(edits) - 1; i >= 0; {
+		op := edits[i]
+		j := i
+		for j >= 0 && edits[j] == op {
+			j--
+		}
+		count := i - j
+		ops = append(ops, op)
+		ops = binary.AppendUvarint(ops, uint64(count))
+		if op == diffInsert {
+			ops = append(ops, b[y:y+count]...)
+		}
+		if op != diffSkip {
+			y += count
+		}
+		i = j
+	}
+	return ops, true
+}
+
+// PutDiff writes value as a diff against the previous version of key when
+// that version is a string in the active segment and the diff is smaller.
+// Otherwise it writes the full value like Put.
+func (db *Db) PutDiff(key, value string) error {
+	e := &Entry{key: key, valueType: STRING_TYPE, value: value}
+	db.mu.Lock()
+	stored, err := db.diffEntry(key, value)
+	if err == nil {
+		if stored == nil {
+			stored = e
+		}
+		err = db.appendStored(stored, e)
+	}
+	db.unlockWrite()
+	if err != nil {
+		return err
+	}
+	//спостерігачі й копії отримують повне значення, а не дифф
+	db.emit(e)
+	return nil
+}
+
+// diffEntry будує дифф-запис value або повертає nil, якщо краще писати
+// повне значення; викликається під db.mu
+func (db *Db) diffEntry(key, value string) (*Entry, error) {
+	actBlock := db.blocks[len(db.blocks)-1]
+	curSize, err := actBlock.size()
+	if err != nil {
+		return nil, err
+	}
+	actBlock.mu.RLock()
+	position, ok := actBlock.index.Find(key)
+	actBlock.mu.RUnlock()
+
+	//база диффу має бути в тому ж блоці, куди піде запис
+	if !ok || curSize > db.segmentSize {
+		return nil, nil
+	}
+	base, vType, depth, err := actBlock.readAt(position)
+	if err == errDeleted {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if vType != "string" || depth >= maxDiffChain {
+		return nil, nil
+	}
+	if depth == 0 {
+		//анотований запис може стати простроченим, і дифф на нього буде нечитабельним
+		e, err := actBlock.getEntry(key)
+		if err != nil {
+			return nil, err
+		}
+		if e.annotations != "" {
+			return nil, nil
+		}
+	}
+	ops, ok := diffStrings(base, value, maxDiffEdits)
+	if !ok || len(ops)+8 >= len(value) {
+		return nil, nil
+	}
+	payload := binary.LittleEndian.AppendUint64(nil, uint64(position))
+	payload = append(payload, ops...)
+	return &Entry{key: key, valueType: DIFF_STRING_TYPE, value: string(payload)}, nil
+}
+
+// CompactDiffs replaces the diff chain of key with a single full-value record.
+func (db *Db) CompactDiffs(key string) error {
+	db.mu.Lock()
+	err := db.compactDiffs(key)
+	db.unlockWrite()
+	return err
+}
+
+// compactDiffs викликається під db.mu; значення не змінюється, тож подій немає
+func (db *Db) compactDiffs(key string) error {
+	for j := len(db.blocks) - 1; j >= 0; j = j - 1 {
+		b := db.blocks[j]
+		b.mu.RLock()
+		position, ok := b.index.Find(key)
+		b.mu.RUnlock()
+		if !ok {
+			continue
+		}
+
+		value, _, depth, err := b.readAt(position)
+		if err == errDeleted {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if depth == 0 {
+			return nil
+		}
+		return db.appendEntry(&Entry{key: key, valueType: STRING_TYPE, value: value})
+	}
+	return ErrNotFound
+}
@@ -0,0 +1,163 @@
+package datastore
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func TestDiffStrings(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	randomString := func(n int) string {
+		buf := make([]byte, n)
+		for i := range buf {
+			buf[i] = "abc"[rnd.Intn(3)]
+		}
+		return string(buf)
+	}
+	for i := 0; i < 200; i++ {
+		a, b := randomString(rnd.Intn(40)), randomString(rnd.Intn(40))
+		ops, ok := diffStrings(a, b, 100)
+		if !ok {
+			t.Fatalf("Expected diff of %q and %q", a, b)
+		}
+		if res, err := applyDiff(a, string(ops)); err != nil || res != b {
+			t.Errorf("Bad diff of %q -> %q: got %q, %v", a, b, res, err)
+		}
+	}
+	if _, ok := diffStrings("aaaa", "bbbb", 4); ok {
+		t.Error("Expected diff to exceed the edit limit")
+	}
+	if _, err := applyDiff("abc", string([]byte{diffCopy, 10})); err == nil {
+		t.Error("Expected error for copy past the end of the base")
+	}
+}
+
+func TestDb_PutDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := bytes.Repeat([]byte(`{"field": "value"}`), 57)[:1024]
+	if err := db.PutDiff("doc", string(doc)); err != nil {
+		t.Fatal(err)
+	}
+	fullSize, err := db.blocks[0].size()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		pos := i * 100
+		copy(doc[pos:], fmt.Sprintf("edit%d", i))
+		if err := db.PutDiff("doc", string(doc)); err != nil {
+			t.Fatal(err)
+		}
+		if value, err := db.Get("doc"); err != nil || value != string(doc) {
+			t.Fatalf("Bad value after edit %d: %v", i, err)
+		}
+	}
+
+	size, err := db.blocks[0].size()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size > 2*fullSize {
+		t.Errorf("Expected diffs to be small: %d bytes for 10 edits of a %d byte record", size-fullSize, fullSize)
+	}
+	_, _, depth, err := db.blocks[0].readAt(indexOffset(db.blocks[0], "doc"))
+	if err != nil || depth != 10 {
+		t.Errorf("Expected a chain of 10 diffs, got %d, %v", depth, err)
+	}
+
+	if err := db.CompactDiffs("doc"); err != nil {
+		t.Fatal(err)
+	}
+	_, _, depth, err = db.blocks[0].readAt(indexOffset(db.blocks[0], "doc"))
+	if err != nil || depth != 0 {
+		t.Errorf("Expected compacted record, got chain of %d, %v", depth, err)
+	}
+	if value, err := db.Get("doc"); err != nil || value != string(doc) {
+		t.Errorf("Bad value after compaction: %v", err)
+	}
+	if err := db.CompactDiffs("missing"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	// повністю інше значення пишеться без диффу
+	before, _ := db.blocks[0].size()
+	other := string(bytes.Repeat([]byte("x"), 1024))
+	if err := db.PutDiff("doc", other); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := db.blocks[0].size()
+	if expected := int64(len((&Entry{key: "doc", value: other}).Encode())); after-before != expected {
+		t.Errorf("Expected a full record of %d bytes, got %d", expected, after-before)
+	}
+
+	db.Close()
+	db, err = NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if value, err := db.Get("doc"); err != nil || value != other {
+		t.Errorf("Bad value after reopen: %v", err)
+	}
+}
+
+func TestDb_PutDiffWritePath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	doc := bytes.Repeat([]byte("0123456789"), 100)
+	if err := db.PutDiff("doc", string(doc)); err != nil {
+		t.Fatal(err)
+	}
+	var seen []*Entry
+	unwatch := db.Watch(func(e *Entry) { seen = append(seen, e) })
+	copy(doc[500:], "edit")
+	if err := db.PutDiff("doc", string(doc)); err != nil {
+		t.Fatal(err)
+	}
+	unwatch()
+	if len(seen) != 1 || seen[0].valueType != STRING_TYPE || seen[0].value != string(doc) {
+		t.Errorf("Expected the watcher to see the full value, got %d events", len(seen))
+	}
+	_, _, depth, _ := db.blocks[0].readAt(indexOffset(db.blocks[0], "doc"))
+	if depth != 1 {
+		t.Errorf("Expected a diff record, got chain of %d", depth)
+	}
+
+	db.standby = true
+	if err := db.PutDiff("doc", string(doc)+"x"); err != ErrReadOnly {
+		t.Errorf("Expected ErrReadOnly on a standby database, got %v", err)
+	}
+	db.standby = false
+	if value, _ := db.Get("doc"); value != string(doc) {
+		t.Errorf("Expected a rejected PutDiff to leave the value unchanged")
+	}
+}
+
+func indexOffset(b *block, key string) int64 {
+	offset, _ := b.index.Find(key)
+	return offset
+}
@@ -0,0 +1,90 @@
+package datastore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DistributedFileLock guards a database on storage where flock does not
+// work, such as a directory synced to object storage.
+type DistributedFileLock interface {
+	// Acquire blocks until the lock is held or ctx is done.
+	Acquire(ctx context.Context) error
+	Release() error
+}
+
+// releaseScript видаляє ключ лише тоді, коли в ньому досі наш токен:
+// інакше блокування вже прострочилось і належить комусь іншому
+const releaseScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) else return 0 end`
+
+// distributedLockRetry - пауза між спробами взяти зайняте блокування
+const distributedLockRetry = 10 * time.Millisecond
+
+// RedisDistributedLock is a DistributedFileLock kept in a Redis key with
+// SET NX PX. The key expires after ttl, so a crashed holder can't block the
+// others forever; the holder must finish its work or release before that.
+type RedisDistributedLock struct {
+	client *RedisClient
+	key    string
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	token string
+}
+
+func NewRedisDistributedLock(client *RedisClient, lockKey string, ttl time.Duration) *RedisDistributedLock {
+	return &RedisDistributedLock{client: client, key: lockKey, ttl: ttl}
+}
+
+func (l *RedisDistributedLock) Acquire(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.token != "" {
+		return fmt.Errorf("lock %q is already held", l.key)
+	}
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return err
+	}
+	token := hex.EncodeToString(b[:])
+	ttl := strconv.FormatInt(l.ttl.Milliseconds(), 10)
+	for {
+		reply, err := l.client.do("SET", l.key, token, "NX", "PX", ttl)
+		if err != nil {
+			return err
+		}
+		//на зайнятий ключ SET NX відповідає nil
+		if reply != nil {
+			l.token = token
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(distributedLockRetry):
+		}
+	}
+}
+
+func (l *RedisDistributedLock) Release() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.token == "" {
+		return fmt.Errorf("lock %q is not held", l.key)
+	}
+	token := l.token
+	l.token = ""
+	reply, err := l.client.do("EVAL", releaseScript, "1", l.key, token)
+	if err != nil {
+		return err
+	}
+	if reply != int64(1) {
+		return fmt.Errorf("lock %q expired before release", l.key)
+	}
+	return nil
+}
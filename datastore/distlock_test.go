@@ -0,0 +1,80 @@
+package datastore
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRedisDistributedLock(t *testing.T) {
+	fake := &fakeRedis{strings: map[string]string{}}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go fake.serve(l)
+
+	var holders int32
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		client, err := NewRedisClient(l.Addr().String(), "pass")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+		lock := NewRedisDistributedLock(client, "db:lock", time.Minute)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if err := lock.Acquire(context.Background()); err != nil {
+					t.Error(err)
+					return
+				}
+				if n := atomic.AddInt32(&holders, 1); n != 1 {
+					t.Errorf("Expected a single holder, got %d", n)
+				}
+				time.Sleep(time.Millisecond)
+				atomic.AddInt32(&holders, -1)
+				if err := lock.Release(); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	client, err := NewRedisClient(l.Addr().String(), "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	first := NewRedisDistributedLock(client, "db:lock", 20*time.Millisecond)
+	second := NewRedisDistributedLock(client, "db:lock", time.Minute)
+	if err := first.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	if err := second.Acquire(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected the held lock to time out, got %v", err)
+	}
+	cancel()
+	//після ttl блокування переходить до другого, і перший не може його зняти
+	if err := second.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Release(); err == nil {
+		t.Errorf("Expected an error releasing an expired lock")
+	}
+	if err := second.Release(); err != nil {
+		t.Error(err)
+	}
+	if err := second.Release(); err == nil {
+		t.Errorf("Expected an error releasing a lock that is not held")
+	}
+}
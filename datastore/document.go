@@ -0,0 +1,308 @@
+package datastore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Документ — це JSON-сумісне значення, збережене як CBOR у розмітці рядка.
+
+// обмежує рекурсію на зловмисно вкладених даних
+const maxDocumentDepth = 64
+
+// NewDocumentEntry encodes doc as CBOR. doc may be any value accepted by
+// encoding/json; it is stored with JSON types (objects, arrays, strings,
+// numbers, booleans and null).
+func NewDocumentEntry(key string, doc interface{}) (*Entry, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	res, err := cborAppendValue(nil, v)
+	if err != nil {
+		return nil, err
+	}
+	return &Entry{key: key, valueType: DOCUMENT_TYPE, value: string(res)}, nil
+}
+
+func (e *Entry) GetDocument(dst interface{}) error {
+	if e.valueType != DOCUMENT_TYPE {
+		return fmt.Errorf("wrong type of value")
+	}
+	v, err := decodeDocument(e.value)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+func (db *Db) PutDocument(key string, doc interface{}) error {
+	e, err := NewDocumentEntry(key, doc)
+	if err != nil {
+		return err
+	}
+	return db.putEntry(e)
+}
+
+// ProjectDocument returns the part of the document stored under key that path
+// points to, decoding only that part. Path is a dot-separated list of object
+// fields with optional [n] array indexes, e.g. "address.lines[0]".
+func ProjectDocument(db *Db, key string, path string) (interface{}, error) {
+	val, vType, err := db.getType(key)
+	if err != nil {
+		return nil, err
+	}
+	if vType != "document" {
+		return nil, fmt.Errorf("wrong type of value")
+	}
+	steps, err := parseDocumentPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &cborReader{data: []byte(val)}
+	for _, step := range steps {
+		major, n, err := r.head()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case step.field != "" && major == cborMap:
+			found := false
+			for i := uint64(0); i < n && !found; i++ {
+				keyMajor, keyLen, err := r.head()
+				if err != nil {
+					return nil, err
+				}
+				name, err := r.text(keyMajor, keyLen)
+				if err != nil {
+					return nil, err
+				}
+				if name == step.field {
+					found = true
+				} else if err := r.skip(0); err != nil {
+					return nil, err
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("field %q not found in document", step.field)
+			}
+		case step.field == "" && major == cborArray:
+			if uint64(step.index) >= n {
+				return nil, fmt.Errorf("index %d out of range of %d items", step.index, n)
+			}
+			for i := 0; i < step.index; i++ {
+				if err := r.skip(0); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			return nil, fmt.Errorf("path %q does not match document structure", path)
+		}
+	}
+	return r.value(0)
+}
+
+type documentStep struct {
+	field string
+	index int
+}
+
+func parseDocumentPath(path string) ([]documentStep, error) {
+	var steps []documentStep
+	if path == "" {
+		return steps, nil
+	}
+	for _, part := range strings.Split(path, ".") {
+		name, indexes, hasIndex := strings.Cut(part, "[")
+		if name == "" && !hasIndex {
+			return nil, fmt.Errorf("empty field in path %q", path)
+		}
+		if name != "" {
+			steps = append(steps, documentStep{field: name})
+		}
+		if !hasIndex {
+			continue
+		}
+		indexes, ok := strings.CutSuffix(indexes, "]")
+		if !ok {
+			return nil, fmt.Errorf("bad index in path %q", path)
+		}
+		for _, idx := range strings.Split(indexes, "][") {
+			n, err := strconv.Atoi(idx)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("bad index in path %q", path)
+			}
+			steps = append(steps, documentStep{index: n})
+		}
+	}
+	return steps, nil
+}
+
+func cborAppendValue(dst []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(dst, cborSimple<<5|22), nil
+	case bool:
+		if v {
+			return append(dst, cborSimple<<5|21), nil
+		}
+		return append(dst, cborSimple<<5|20), nil
+	case string:
+		return cborString(dst, v), nil
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return cborInt(dst, n), nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, err
+		}
+		dst = append(dst, cborSimple<<5|27)
+		return binary.BigEndian.AppendUint64(dst, math.Float64bits(f)), nil
+	case []interface{}:
+		dst = cborHead(dst, cborArray, uint64(len(v)))
+		for _, item := range v {
+			var err error
+			if dst, err = cborAppendValue(dst, item); err != nil {
+				return nil, err
+			}
+		}
+		return dst, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		dst = cborHead(dst, cborMap, uint64(len(v)))
+		for _, k := range keys {
+			dst = cborString(dst, k)
+			var err error
+			if dst, err = cborAppendValue(dst, v[k]); err != nil {
+				return nil, err
+			}
+		}
+		return dst, nil
+	}
+	return nil, fmt.Errorf("unsupported document value %T", v)
+}
+
+func decodeDocument(data string) (interface{}, error) {
+	r := &cborReader{data: []byte(data)}
+	v, err := r.value(0)
+	if err != nil {
+		return nil, err
+	}
+	if r.pos != len(r.data) {
+		return nil, fmt.Errorf("unexpected %d trailing bytes", len(r.data)-r.pos)
+	}
+	return v, nil
+}
+
+func (r *cborReader) value(depth int) (interface{}, error) {
+	if depth > maxDocumentDepth {
+		return nil, fmt.Errorf("document is nested too deeply")
+	}
+	if r.pos >= len(r.data) {
+		return nil, fmt.Errorf("unexpected end of cbor data")
+	}
+	info := r.data[r.pos] & 0x1f
+	major, n, err := r.head()
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case cborUint, cborNegInt:
+		return r.int(major, n)
+	case cborText:
+		return r.text(major, n)
+	case cborArray:
+		if n > uint64(len(r.data)-r.pos) {
+			return nil, fmt.Errorf("unexpected end of cbor data")
+		}
+		res := make([]interface{}, n)
+		for i := range res {
+			if res[i], err = r.value(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	case cborMap:
+		if n > uint64(len(r.data)-r.pos) {
+			return nil, fmt.Errorf("unexpected end of cbor data")
+		}
+		res := make(map[string]interface{}, n)
+		for i := uint64(0); i < n; i++ {
+			keyMajor, keyLen, err := r.head()
+			if err != nil {
+				return nil, err
+			}
+			key, err := r.text(keyMajor, keyLen)
+			if err != nil {
+				return nil, err
+			}
+			if res[key], err = r.value(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	case cborSimple:
+		switch {
+		case info == 20:
+			return false, nil
+		case info == 21:
+			return true, nil
+		case info == 22:
+			return nil, nil
+		case info == 26:
+			return float64(math.Float32frombits(uint32(n))), nil
+		case info == 27:
+			return math.Float64frombits(n), nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported cbor item: major type %d, info %d", major, info)
+}
+
+func (r *cborReader) skip(depth int) error {
+	if depth > maxDocumentDepth {
+		return fmt.Errorf("document is nested too deeply")
+	}
+	major, n, err := r.head()
+	if err != nil {
+		return err
+	}
+	switch major {
+	case cborBytes, cborText:
+		if n > uint64(len(r.data)-r.pos) {
+			return fmt.Errorf("unexpected end of cbor data")
+		}
+		r.pos += int(n)
+	case cborArray, cborMap:
+		if major == cborMap {
+			n *= 2
+		}
+		for i := uint64(0); i < n; i++ {
+			if err := r.skip(depth + 1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+type testAddress struct {
+	City  string   `json:"city"`
+	Lines []string `json:"lines"`
+}
+
+type testUser struct {
+	Name    string      `json:"name"`
+	Age     int         `json:"age"`
+	Score   float64     `json:"score"`
+	Active  bool        `json:"active"`
+	Manager *testUser   `json:"manager"`
+	Address testAddress `json:"address"`
+}
+
+func TestDocumentEntry(t *testing.T) {
+	doc := testUser{
+		Name:    "alice",
+		Age:     30,
+		Score:   -1.5,
+		Active:  true,
+		Address: testAddress{City: "Kyiv", Lines: []string{"Khreshchatyk 1", "apt 2"}},
+	}
+	e, err := NewDocumentEntry("user:1", doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Type() != "document" {
+		t.Errorf("Expected document type, got %s", e.Type())
+	}
+
+	var decoded Entry
+	decoded.Decode(e.Encode())
+	var res testUser
+	if err := decoded.GetDocument(&res); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, doc) {
+		t.Errorf("Bad document: expected %+v, got %+v", doc, res)
+	}
+
+	if err := (&Entry{key: "key", value: "value"}).GetDocument(&res); err == nil {
+		t.Error("Expected error for a string entry")
+	}
+	if _, err := NewDocumentEntry("key", func() {}); err == nil {
+		t.Error("Expected error for a value JSON can't encode")
+	}
+}
+
+func TestProjectDocument(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	doc := map[string]interface{}{
+		"name": "alice",
+		"address": map[string]interface{}{
+			"city":  "Kyiv",
+			"lines": []interface{}{"Khreshchatyk 1", "apt 2"},
+		},
+		"matrix": []interface{}{[]interface{}{1, 2}, []interface{}{3, 4}},
+		"tags":   nil,
+	}
+	if err := db.PutDocument("user:1", doc); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put("plain", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		path     string
+		expected interface{}
+	}{
+		{"name", "alice"},
+		{"address.city", "Kyiv"},
+		{"address.lines[1]", "apt 2"},
+		{"matrix[1][0]", int64(3)},
+		{"tags", nil},
+		{"address", map[string]interface{}{"city": "Kyiv", "lines": []interface{}{"Khreshchatyk 1", "apt 2"}}},
+	}
+	for _, c := range cases {
+		res, err := ProjectDocument(db, "user:1", c.path)
+		if err != nil {
+			t.Errorf("Projection %s failed: %v", c.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(res, c.expected) {
+			t.Errorf("Bad projection %s: expected %v, got %v", c.path, c.expected, res)
+		}
+	}
+
+	for _, path := range []string{"missing", "address.lines[2]", "name.first", "address..city", "matrix[x]"} {
+		if _, err := ProjectDocument(db, "user:1", path); err == nil {
+			t.Errorf("Expected error for path %s", path)
+		}
+	}
+	if _, err := ProjectDocument(db, "plain", "name"); err == nil {
+		t.Error("Expected error for a string value")
+	}
+	if _, err := db.Get("user:1"); err == nil {
+		t.Error("Expected Get to reject a document value")
+	}
+}
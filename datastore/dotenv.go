@@ -0,0 +1,186 @@
+package datastore
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// Розбір .env повторює поширені правила godotenv: необов'язковий префікс
+// export, значення без лапок до коментаря " #", зворотна риска в кінці
+// рядка продовжує значення на наступному, у подвійних лапках працюють
+// екранування і перенесення рядків, в одинарних усе береться як є.
+// Змінні ${VAR} не підставляються.
+
+type configVar struct {
+	name, value string
+}
+
+func validDotenvName(name string) bool {
+	for i, c := range []byte(name) {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && (c >= '0' && c <= '9' || c == '.')) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// dotenvRest перевіряє, що після значення в лапках лишився тільки коментар
+func dotenvRest(rest string) error {
+	rest = strings.TrimLeft(rest, " \t")
+	if rest != "" && rest[0] != '#' {
+		return fmt.Errorf("unexpected %q after the closing quote", rest)
+	}
+	return nil
+}
+
+func parseDotenv(src string) ([]configVar, error) {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	var vars []configVar
+	for n := 0; n < len(lines); n++ {
+		start := n
+		line := strings.TrimLeft(lines[n], " \t")
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		eq := strings.IndexByte(line, '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", n+1)
+		}
+		name := strings.TrimSpace(line[:eq])
+		if !validDotenvName(name) {
+			return nil, fmt.Errorf("line %d: bad variable name %q", n+1, name)
+		}
+		raw := strings.TrimLeft(line[eq+1:], " \t")
+
+		var sb strings.Builder
+		switch {
+		case strings.HasPrefix(raw, "'"):
+			raw = raw[1:]
+			for {
+				if end := strings.IndexByte(raw, '\''); end >= 0 {
+					sb.WriteString(raw[:end])
+					if err := dotenvRest(raw[end+1:]); err != nil {
+						return nil, fmt.Errorf("line %d: %v", n+1, err)
+					}
+					break
+				}
+				if n++; n == len(lines) {
+					return nil, fmt.Errorf("line %d: unterminated quote", start+1)
+				}
+				sb.WriteString(raw + "\n")
+				raw = lines[n]
+			}
+		case strings.HasPrefix(raw, `"`):
+			raw = raw[1:]
+		quoted:
+			for {
+				for i := 0; i < len(raw); i++ {
+					c := raw[i]
+					if c == '"' {
+						if err := dotenvRest(raw[i+1:]); err != nil {
+							return nil, fmt.Errorf("line %d: %v", n+1, err)
+						}
+						break quoted
+					}
+					if c != '\\' || i+1 == len(raw) {
+						sb.WriteByte(c)
+						continue
+					}
+					i++
+					switch raw[i] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 'r':
+						sb.WriteByte('\r')
+					case 't':
+						sb.WriteByte('\t')
+					case '"', '\\', '$', '`':
+						sb.WriteByte(raw[i])
+					default:
+						sb.WriteByte('\\')
+						sb.WriteByte(raw[i])
+					}
+				}
+				if n++; n == len(lines) {
+					return nil, fmt.Errorf("line %d: unterminated quote", start+1)
+				}
+				sb.WriteByte('\n')
+				raw = lines[n]
+			}
+		default:
+			for {
+				if i := strings.Index(raw, " #"); i >= 0 {
+					raw = raw[:i]
+				} else if i := strings.Index(raw, "\t#"); i >= 0 {
+					raw = raw[:i]
+				} else if strings.HasPrefix(raw, "#") {
+					raw = ""
+				}
+				raw = strings.TrimRight(raw, " \t")
+				if !strings.HasSuffix(raw, `\`) || n+1 == len(lines) {
+					sb.WriteString(raw)
+					break
+				}
+				sb.WriteString(raw[:len(raw)-1] + "\n")
+				n++
+				raw = lines[n]
+			}
+		}
+		vars = append(vars, configVar{name, sb.String()})
+	}
+	return vars, nil
+}
+
+// ImportDotenv writes each variable of the .env file at path into db as a
+// string entry "namespace:NAME". The whole file is parsed before the first
+// write.
+func ImportDotenv(path string, db *Db, namespace string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	vars, err := parseDotenv(string(data))
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	for _, v := range vars {
+		if err := db.Put(namespacedKey(namespace, v.name), v.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dotenvQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "$", `\$`, "`", "\\`")
+	return `"` + r.Replace(s) + `"`
+}
+
+// ExportDotenv writes the keys of namespace to the file dst as NAME="value"
+// lines, in key order. Values of other types are written as their text and
+// read back as strings.
+func ExportDotenv(db *Db, namespace string, dst string) error {
+	entries, err := db.entries()
+	if err != nil {
+		return err
+	}
+	prefix := namespacedKey(namespace, "")
+	var sb strings.Builder
+	for _, e := range entries {
+		if !strings.HasPrefix(e.key, prefix) {
+			continue
+		}
+		name := e.key[len(prefix):]
+		if !validDotenvName(name) {
+			return fmt.Errorf("key %q is not a valid variable name", e.key)
+		}
+		if !utf8.ValidString(e.value) {
+			return fmt.Errorf("value of %q is not text", e.key)
+		}
+		fmt.Fprintf(&sb, "%s=%s\n", name, dotenvQuote(e.value))
+	}
+	return os.WriteFile(dst, []byte(sb.String()), 0o644)
+}
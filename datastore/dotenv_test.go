@@ -0,0 +1,120 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testDotenv = `# сервіс замовлень
+APP_NAME=orders
+APP_ENV = production
+export PORT=8080
+DEBUG=false # вимкнено в проді
+EMPTY=
+HASH_IN_VALUE=abc#def
+DATABASE_URL="postgres://user:p@ss@db:5432/orders?sslmode=disable"
+GREETING="Hello, \"world\"!"
+ESCAPES="tab\there\nnew line \\ slash"
+DOLLAR="price: \$5"
+SINGLE='no $expansion or \n escapes'
+QUOTED_COMMENT="value" # коментар після лапок
+SPACES="  padded  "
+MULTI_QUOTED="first line
+second line"
+CONTINUED=part one \
+part two
+PRIVATE_KEY='-----BEGIN KEY-----
+abc
+-----END KEY-----'
+UNICODE=привіт
+EQUALS=a=b=c
+LOG.LEVEL=info
+TRAILING_TAB=value	# коментар після табуляції
+`
+
+func TestImportExportDotenv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(filepath.Join(dir, "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	file := filepath.Join(dir, ".env")
+	if err := os.WriteFile(file, []byte(testDotenv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportDotenv(file, db, "orders"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"APP_NAME":       "orders",
+		"APP_ENV":        "production",
+		"PORT":           "8080",
+		"DEBUG":          "false",
+		"EMPTY":          "",
+		"HASH_IN_VALUE":  "abc#def",
+		"DATABASE_URL":   "postgres://user:p@ss@db:5432/orders?sslmode=disable",
+		"GREETING":       `Hello, "world"!`,
+		"ESCAPES":        "tab\there\nnew line \\ slash",
+		"DOLLAR":         "price: $5",
+		"SINGLE":         `no $expansion or \n escapes`,
+		"QUOTED_COMMENT": "value",
+		"SPACES":         "  padded  ",
+		"MULTI_QUOTED":   "first line\nsecond line",
+		"CONTINUED":      "part one \npart two",
+		"PRIVATE_KEY":    "-----BEGIN KEY-----\nabc\n-----END KEY-----",
+		"UNICODE":        "привіт",
+		"EQUALS":         "a=b=c",
+		"LOG.LEVEL":      "info",
+		"TRAILING_TAB":   "value",
+	}
+	for name, value := range want {
+		if e, err := db.getEntry("orders:" + name); err != nil || e.valueType != STRING_TYPE || e.value != value {
+			t.Errorf("Bad %s: expected %q, got %v, %v", name, value, e, err)
+		}
+	}
+
+	exported := filepath.Join(dir, "exported.env")
+	if err := ExportDotenv(db, "orders", exported); err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportDotenv(exported, db, "copy"); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := db.entries()
+	if len(entries) != 2*len(want) {
+		t.Errorf("Expected %d entries, got %d", 2*len(want), len(entries))
+	}
+	for name, value := range want {
+		if e, err := db.getEntry("copy:" + name); err != nil || e.valueType != STRING_TYPE || e.value != value {
+			t.Errorf("Bad round trip of %s: expected %q, got %v, %v", name, value, e, err)
+		}
+	}
+
+	for _, bad := range []string{
+		"NO_EQUALS\n",
+		"1ST=x\n",
+		"OPEN=\"never closed\n",
+		"AFTER=\"x\" y\n",
+	} {
+		if err := os.WriteFile(file, []byte(bad), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := ImportDotenv(file, db, "bad"); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+	if err := db.Put("orders:bad-name", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportDotenv(db, "orders", exported); err == nil {
+		t.Errorf("Expected an error for a key that is not a variable name")
+	}
+}
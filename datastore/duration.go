@@ -0,0 +1,57 @@
+package datastore
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// durationOperator зберігає 8 байт time.Duration (наносекунди);
+// у Entry.value тривалість лежить у форматі time.Duration.String()
+type durationOperator struct{}
+
+func (s durationOperator) Encode(e *Entry, dst []byte) []byte {
+	d, err := time.ParseDuration(e.value)
+	if err != nil {
+		panic(err)
+	}
+	res, offset := encodeKeyInto(e, 4, dst)
+	res[offset] = DURATION_TYPE
+	binary.LittleEndian.PutUint64(res[offset+TYPE_SIZE:], uint64(d))
+	return res
+}
+
+func (s durationOperator) EncodeInto(e *Entry, dst []byte) ([]byte, error) {
+	d, err := time.ParseDuration(e.value)
+	if err != nil {
+		return dst, err
+	}
+	dst = appendKey(dst, e, 4)
+	dst = append(dst, DURATION_TYPE)
+	return binary.LittleEndian.AppendUint64(dst, uint64(d)), nil
+}
+
+func (s durationOperator) Decode(input []byte, e *Entry) {
+	kl := len(e.key)
+	e.value = time.Duration(binary.LittleEndian.Uint64(input[kl+TYPE_SIZE+8:])).String()
+}
+
+func (s durationOperator) Read(in *bufio.Reader) (string, error) {
+	data, err := in.Peek(8)
+	if err != nil {
+		return "", err
+	}
+	return time.Duration(binary.LittleEndian.Uint64(data)).String(), nil
+}
+
+func NewDurationEntry(key string, d time.Duration) *Entry {
+	return &Entry{key: key, valueType: DURATION_TYPE, value: d.String()}
+}
+
+func (e *Entry) GetDuration() (time.Duration, error) {
+	if e.valueType != DURATION_TYPE {
+		return 0, fmt.Errorf("wrong type of value")
+	}
+	return time.ParseDuration(e.value)
+}
@@ -0,0 +1,28 @@
+package datastore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDuration(t *testing.T) {
+	e := NewDurationEntry("ttl", time.Hour+time.Minute)
+	if e.value != "1h1m0s" {
+		t.Errorf("Bad duration text: %s", e.value)
+	}
+	var decoded Entry
+	decoded.Decode(e.Encode())
+	if decoded.value != "1h1m0s" {
+		t.Errorf("Bad decoded duration text: %s", decoded.value)
+	}
+	d, err := decoded.GetDuration()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d != time.Hour+time.Minute {
+		t.Errorf("Expected %v, got %v", time.Hour+time.Minute, d)
+	}
+	if _, err := EncodeInto(&Entry{key: "ttl", valueType: DURATION_TYPE, value: "soon"}, nil); err == nil {
+		t.Errorf("Expected an error for a bad duration")
+	}
+}
@@ -0,0 +1,161 @@
+package datastore
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LeaderElection elects one of several candidates as the leader by storing
+// its ID under key with a hard TTL. The key is written with compareAndSwap,
+// so only one candidate can take it while it is held and unexpired.
+type LeaderElection struct {
+	db  *Db
+	key string
+
+	mu        sync.Mutex
+	candidate string
+	ttl       time.Duration
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func NewLeaderElection(db *Db, key string) *LeaderElection {
+	return &LeaderElection{db: db, key: key}
+}
+
+func (le *LeaderElection) leaderEntry(candidateID string, ttl time.Duration) *Entry {
+	e := &Entry{key: le.key, valueType: STRING_TYPE, value: candidateID}
+	e.SetTTL(0, ttl)
+	return e
+}
+
+// Campaign takes the leadership for candidateID for ttl if nobody holds it
+// or the holder's TTL ran out, and reports whether candidateID is the
+// leader. Campaigning again as the leader renews the TTL.
+func (le *LeaderElection) Campaign(candidateID string, ttl time.Duration) (bool, error) {
+	if candidateID == "" {
+		return false, fmt.Errorf("candidate id must not be empty")
+	}
+	cur, err := le.db.getEntry(le.key)
+	if err == ErrNotFound {
+		//прострочений запис читається як відсутній
+		cur = nil
+	} else if err != nil {
+		return false, err
+	}
+	if cur != nil && cur.value != candidateID {
+		return false, nil
+	}
+	won, err := le.db.compareAndSwap(cur, le.leaderEntry(candidateID, ttl))
+	if err != nil || !won {
+		return false, err
+	}
+	le.mu.Lock()
+	le.candidate, le.ttl = candidateID, ttl
+	le.mu.Unlock()
+	return true, nil
+}
+
+// Leader returns the ID of the current leader, or "" if there is none.
+func (le *LeaderElection) Leader() (string, error) {
+	e, err := le.db.getEntry(le.key)
+	if err == ErrNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return e.value, nil
+}
+
+// Heartbeat renews the TTL of the won leadership every interval until
+// Resign or until another candidate takes over. interval must be well below
+// the TTL.
+func (le *LeaderElection) Heartbeat(interval time.Duration) {
+	le.mu.Lock()
+	defer le.mu.Unlock()
+	if le.stop != nil || le.candidate == "" {
+		return
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	le.stop, le.done = stop, done
+	candidate, ttl := le.candidate, le.ttl
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			if won, err := le.Campaign(candidate, ttl); err != nil || !won {
+				if err != nil && le.db.logger != nil {
+					le.db.logger.Error("leader heartbeat failed", "key", le.key, "error", err)
+				}
+				le.mu.Lock()
+				if le.stop == stop {
+					le.stop, le.candidate = nil, ""
+				}
+				le.mu.Unlock()
+				return
+			}
+		}
+	}()
+}
+
+// Resign stops the heartbeat and gives up the leadership if this election
+// still holds it.
+func (le *LeaderElection) Resign() error {
+	le.mu.Lock()
+	candidate, done := le.candidate, le.done
+	if le.stop != nil {
+		close(le.stop)
+		le.stop = nil
+	}
+	le.candidate = ""
+	le.mu.Unlock()
+	//серцебиття може саме зараз оновлювати запис
+	if done != nil {
+		<-done
+	}
+	if candidate == "" {
+		return nil
+	}
+	cur, err := le.db.getEntry(le.key)
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil || cur.value != candidate {
+		return err
+	}
+	_, err = le.db.compareAndSwap(cur, &Entry{key: le.key, valueType: TOMBSTONE_TYPE})
+	return err
+}
+
+// WatchLeader calls fn with the new leader ID whenever the leadership is
+// taken, and with "" on Resign. An expired TTL is not a write, so it is
+// seen only when the next candidate wins. fn runs on the writing goroutine.
+func (le *LeaderElection) WatchLeader(fn func(newLeader string)) (cancel func()) {
+	last := ""
+	var mu sync.Mutex
+	return le.db.Watch(func(e *Entry) {
+		if e.key != le.key {
+			return
+		}
+		leader := e.value
+		if e.valueType == TOMBSTONE_TYPE {
+			leader = ""
+		}
+		//оновлення TTL тим самим лідером не є зміною
+		mu.Lock()
+		changed := leader != last
+		last = leader
+		mu.Unlock()
+		if changed {
+			fn(leader)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLeaderElection(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var changesMu sync.Mutex
+	var changes []string
+	watcher := NewLeaderElection(db, "leader")
+	cancel := watcher.WatchLeader(func(leader string) {
+		changesMu.Lock()
+		changes = append(changes, leader)
+		changesMu.Unlock()
+	})
+	defer cancel()
+
+	var holders, wins int32
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			le := NewLeaderElection(db, "leader")
+			for j := 0; j < 20; j++ {
+				won, err := le.Campaign(id, time.Minute)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if !won {
+					continue
+				}
+				atomic.AddInt32(&wins, 1)
+				if n := atomic.AddInt32(&holders, 1); n != 1 {
+					t.Errorf("Expected a single leader, got %d", n)
+				}
+				time.Sleep(time.Millisecond)
+				atomic.AddInt32(&holders, -1)
+				if err := le.Resign(); err != nil {
+					t.Error(err)
+				}
+			}
+		}(fmt.Sprintf("worker-%d", i))
+	}
+	wg.Wait()
+	if wins == 0 {
+		t.Fatalf("Expected some candidate to win")
+	}
+	changesMu.Lock()
+	if len(changes) != int(wins)*2 {
+		t.Errorf("Expected a leader change per win and resignation, got %d for %d wins", len(changes), wins)
+	}
+	changesMu.Unlock()
+
+	a, b := NewLeaderElection(db, "leader"), NewLeaderElection(db, "leader")
+	if won, _ := a.Campaign("a", 50*time.Millisecond); !won {
+		t.Fatalf("Expected a to win a free election")
+	}
+	a.Heartbeat(5 * time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	if won, _ := b.Campaign("b", time.Minute); won {
+		t.Errorf("Expected the heartbeat to keep a as the leader")
+	}
+	if leader, _ := b.Leader(); leader != "a" {
+		t.Errorf("Expected a to lead, got %q", leader)
+	}
+
+	if err := a.Resign(); err != nil {
+		t.Fatal(err)
+	}
+	if won, _ := b.Campaign("b", time.Minute); !won {
+		t.Errorf("Expected b to win after a resigned")
+	}
+
+	//лідер без серцебиття втрачає лідерство після TTL
+	c := NewLeaderElection(db, "leader")
+	now := time.Now().Add(2 * time.Minute)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+	if won, _ := c.Campaign("c", time.Minute); !won {
+		t.Errorf("Expected c to take over an expired leadership")
+	}
+	if err := b.Resign(); err != nil {
+		t.Fatal(err)
+	}
+	if leader, _ := c.Leader(); leader != "c" {
+		t.Errorf("Expected a stale Resign to leave c leading, got %q", leader)
+	}
+}
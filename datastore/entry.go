@@ -4,35 +4,64 @@ import (
 	"bufio"
 	"encoding/binary"
 	"fmt"
+	"io"
+	"math"
 	"strconv"
 )
 
 type Entry struct {
-	key       string
-	valueType byte
-	value     string
+	key         string
+	valueType   byte
+	value       string
+	annotations string
+}
+
+func (e *Entry) Key() string {
+	return e.key
+}
+
+func (e *Entry) Type() string {
+	return ToType(e.valueType)
+}
+
+func (e *Entry) Value() string {
+	return e.value
 }
 
 type typeOperator interface {
-	Encode(*Entry) []byte
+	Encode(*Entry, []byte) []byte
+	EncodeInto(*Entry, []byte) ([]byte, error)
 	Decode([]byte, *Entry)
 	Read(*bufio.Reader) (string, error)
 }
 
 type stringOperator struct{}
 
-func encodeKey(e *Entry, vl int) ([]byte, int) {
+func encodeKeyInto(e *Entry, vl int, dst []byte) ([]byte, int) {
 	kl := len(e.key)
 	size := kl + TYPE_SIZE + vl + 12
-	res := make([]byte, size)
+	var res []byte
+	if len(dst) >= size {
+		res = dst[:size]
+	} else {
+		res = make([]byte, size)
+	}
 	binary.LittleEndian.PutUint32(res, uint32(size))
 	binary.LittleEndian.PutUint32(res[4:], uint32(kl))
 	copy(res[8:], e.key)
 	return res, kl + 8
 }
 
-func (s stringOperator) Encode(e *Entry) []byte {
-	res, offset := encodeKey(e, len(e.value))
+func appendKey(dst []byte, e *Entry, vl int) []byte {
+	kl := len(e.key)
+	size := kl + TYPE_SIZE + vl + 12
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(size))
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(kl))
+	return append(dst, e.key...)
+}
+
+func (s stringOperator) Encode(e *Entry, dst []byte) []byte {
+	res, offset := encodeKeyInto(e, len(e.value), dst)
 	vl := len(e.value)
 	res[offset] = STRING_TYPE
 	binary.LittleEndian.PutUint32(res[offset+TYPE_SIZE:], uint32(vl))
@@ -40,6 +69,14 @@ func (s stringOperator) Encode(e *Entry) []byte {
 	return res
 }
 
+func (s stringOperator) EncodeInto(e *Entry, dst []byte) ([]byte, error) {
+	vl := len(e.value)
+	dst = appendKey(dst, e, vl)
+	dst = append(dst, STRING_TYPE)
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(vl))
+	return append(dst, e.value...), nil
+}
+
 func (s stringOperator) Decode(input []byte, e *Entry) {
 	kl := len(e.key)
 	vl := binary.LittleEndian.Uint32(input[kl+TYPE_SIZE+8:])
@@ -60,7 +97,7 @@ func (s stringOperator) Read(in *bufio.Reader) (string, error) {
 	}
 
 	data := make([]byte, valSize)
-	n, err := in.Read(data)
+	n, err := io.ReadFull(in, data)
 	if err != nil {
 		return "", err
 	}
@@ -71,19 +108,93 @@ func (s stringOperator) Read(in *bufio.Reader) (string, error) {
 	return string(data), nil
 }
 
+// taggedStringOperator зберігає значення в розмітці рядка під іншим байтом типу
+type taggedStringOperator struct {
+	valueType byte
+}
+
+func (s taggedStringOperator) Encode(e *Entry, dst []byte) []byte {
+	res := stringOperator{}.Encode(e, dst)
+	res[8+len(e.key)] = s.valueType
+	return res
+}
+
+func (s taggedStringOperator) EncodeInto(e *Entry, dst []byte) ([]byte, error) {
+	start := len(dst)
+	res, err := stringOperator{}.EncodeInto(e, dst)
+	if err != nil {
+		return res, err
+	}
+	res[start+8+len(e.key)] = s.valueType
+	return res, nil
+}
+
+func (s taggedStringOperator) Decode(input []byte, e *Entry) {
+	stringOperator{}.Decode(input, e)
+}
+
+func (s taggedStringOperator) Read(in *bufio.Reader) (string, error) {
+	return stringOperator{}.Read(in)
+}
+
+// validatedStringOperator теж пише розмітку рядка, але перевіряє структуру
+// значення при записі; decodeRecord перевіряє її при читанні
+type validatedStringOperator struct {
+	valueType byte
+	validate  func(value string) error
+}
+
+func (s validatedStringOperator) Encode(e *Entry, dst []byte) []byte {
+	return taggedStringOperator{s.valueType}.Encode(e, dst)
+}
+
+func (s validatedStringOperator) EncodeInto(e *Entry, dst []byte) ([]byte, error) {
+	if err := s.validate(e.value); err != nil {
+		return dst, err
+	}
+	return taggedStringOperator{s.valueType}.EncodeInto(e, dst)
+}
+
+func (s validatedStringOperator) Decode(input []byte, e *Entry) {
+	stringOperator{}.Decode(input, e)
+}
+
+func (s validatedStringOperator) Read(in *bufio.Reader) (string, error) {
+	return stringOperator{}.Read(in)
+}
+
 type int64Operator struct{}
 
-func (s int64Operator) Encode(e *Entry) []byte {
-	res, offset := encodeKey(e, 8)
+func (s int64Operator) Encode(e *Entry, dst []byte) []byte {
 	i, err := strconv.ParseInt(e.value, 10, 64)
 	if err != nil {
 		panic(err)
 	}
+	if fitsVarint(i) {
+		return varintOperator{}.encode(e, i, dst)
+	}
+	res, offset := encodeKeyInto(e, 8, dst)
 	res[offset] = INT64_TYPE
 	binary.LittleEndian.PutUint64(res[offset+TYPE_SIZE:], uint64(i))
+	binary.LittleEndian.PutUint32(res[offset+TYPE_SIZE+8:], 0)
 	return res
 }
 
+func (s int64Operator) EncodeInto(e *Entry, dst []byte) ([]byte, error) {
+	i, err := strconv.ParseInt(e.value, 10, 64)
+	if err != nil {
+		return dst, err
+	}
+	if fitsVarint(i) {
+		return varintOperator{}.appendTo(dst, e, i), nil
+	}
+	dst = appendKey(dst, e, 8)
+	dst = append(dst, INT64_TYPE)
+	dst = binary.LittleEndian.AppendUint64(dst, uint64(i))
+	// розмір запису рахує ще 4 байти довжини значення, яких int64 не пише
+	return append(dst, 0, 0, 0, 0), nil
+}
+
 func (s int64Operator) Decode(input []byte, e *Entry) {
 	kl := len(e.key)
 	value := binary.LittleEndian.Uint64(input[kl+TYPE_SIZE+8 : kl+TYPE_SIZE+16])
@@ -99,9 +210,95 @@ func (s int64Operator) Read(in *bufio.Reader) (string, error) {
 	return fmt.Sprintf("%d", int64(value)), nil
 }
 
+// невеликі int64 пишуться як zigzag varint; для користувача тип лишається int64
+type varintOperator struct{}
+
+func fitsVarint(i int64) bool {
+	return i >= -math.MaxUint32 && i <= math.MaxUint32
+}
+
+func (s varintOperator) encode(e *Entry, i int64, dst []byte) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(buf[:], i)
+	// у varint-запису немає 4 байтів довжини значення
+	res, offset := encodeKeyInto(e, n-4, dst)
+	res[offset] = VARINT_INT64_TYPE
+	copy(res[offset+TYPE_SIZE:], buf[:n])
+	return res
+}
+
+func (s varintOperator) appendTo(dst []byte, e *Entry, i int64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(buf[:], i)
+	dst = appendKey(dst, e, n-4)
+	dst = append(dst, VARINT_INT64_TYPE)
+	return append(dst, buf[:n]...)
+}
+
+func (s varintOperator) Encode(e *Entry, dst []byte) []byte {
+	return int64Operator{}.Encode(e, dst)
+}
+
+func (s varintOperator) EncodeInto(e *Entry, dst []byte) ([]byte, error) {
+	return int64Operator{}.EncodeInto(e, dst)
+}
+
+func (s varintOperator) Decode(input []byte, e *Entry) {
+	kl := len(e.key)
+	value, _ := binary.Varint(input[kl+TYPE_SIZE+8:])
+	e.value = strconv.FormatInt(value, 10)
+	e.valueType = INT64_TYPE
+}
+
+func (s varintOperator) Read(in *bufio.Reader) (string, error) {
+	value, err := binary.ReadVarint(in)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(value, 10), nil
+}
+
 var typeToByte map[string]byte = map[string]byte{
-	"string": STRING_TYPE,
-	"int64":  INT64_TYPE,
+	"string":     STRING_TYPE,
+	"int64":      INT64_TYPE,
+	"document":   DOCUMENT_TYPE,
+	"vclock":     VECTOR_CLOCK_TYPE,
+	"lamport":    LAMPORT_TYPE,
+	"gset":       GSET_TYPE,
+	"orset":      ORSET_TYPE,
+	"tpset":      TPSET_TYPE,
+	"lwwmap":     LWWMAP_TYPE,
+	"pncounter":  PNCOUNTER_TYPE,
+	"deltaint64": DELTA_INT64_TYPE,
+	"rlestring":  RLE_STRING_TYPE,
+	"dictstring": DICT_STRING_TYPE,
+	"decimal":    DECIMAL_TYPE,
+	"uuid":       UUID_TYPE,
+	"timestamp":  TIMESTAMP_TYPE,
+	"duration":   DURATION_TYPE,
+	"geo":        GEO_TYPE,
+	"ipaddr":     IPADDR_TYPE,
+	"bitset":     BITSET_TYPE,
+	"rational":   RATIONAL_TYPE,
+	"complex":    COMPLEX_TYPE,
+	"matrix":     MATRIX_TYPE,
+	"histogram":  HISTOGRAM_TYPE,
+	"hll":        HLL_TYPE,
+	"roaring":    ROARING_TYPE,
+	"linkednode": LINKED_NODE_TYPE,
+	"graphnode":  GRAPH_NODE_TYPE,
+	"trie":       TRIE_TYPE,
+	"timeseries": TIME_SERIES_TYPE,
+	"eventlog":   EVENT_LOG_TYPE,
+	"dagnode":    DAG_NODE_TYPE,
+	"websession": SESSION_TYPE,
+	"sortedset":  SORTEDSET_TYPE,
+	"suboffset":  SUBSCRIPTION_TYPE,
+	"ratebucket": RATE_BUCKET_TYPE,
+	"flag":       FEATURE_FLAG_TYPE,
+	"circuit":    CIRCUIT_STATE_TYPE,
+	"float64":    FLOAT64_TYPE,
+	"service":    SERVICE_TYPE,
 }
 
 func ToByte(valueType string) byte {
@@ -118,19 +315,124 @@ func ToType(value byte) string {
 }
 
 var operators map[byte]typeOperator = map[byte]typeOperator{
-	STRING_TYPE: stringOperator{},
-	INT64_TYPE:  int64Operator{},
+	STRING_TYPE:        stringOperator{},
+	INT64_TYPE:         int64Operator{},
+	VARINT_INT64_TYPE:  varintOperator{},
+	DIFF_STRING_TYPE:   taggedStringOperator{DIFF_STRING_TYPE},
+	DOCUMENT_TYPE:      taggedStringOperator{DOCUMENT_TYPE},
+	SOFT_DELETED_TYPE:  taggedStringOperator{SOFT_DELETED_TYPE},
+	TOMBSTONE_TYPE:     taggedStringOperator{TOMBSTONE_TYPE},
+	VECTOR_CLOCK_TYPE:  validatedStringOperator{VECTOR_CLOCK_TYPE, validateVectorClock},
+	LAMPORT_TYPE:       lamportOperator{},
+	GSET_TYPE:          validatedStringOperator{GSET_TYPE, validateStringSet},
+	ORSET_TYPE:         validatedStringOperator{ORSET_TYPE, validateORSet},
+	TPSET_TYPE:         validatedStringOperator{TPSET_TYPE, validateTwoPSet},
+	LWWMAP_TYPE:        validatedStringOperator{LWWMAP_TYPE, validateLWWMap},
+	PNCOUNTER_TYPE:     validatedStringOperator{PNCOUNTER_TYPE, validatePNCounter},
+	DELTA_INT64_TYPE:   validatedStringOperator{DELTA_INT64_TYPE, validateInt64Series},
+	RLE_STRING_TYPE:    validatedStringOperator{RLE_STRING_TYPE, validateRLERun},
+	DICT_STRING_TYPE:   dictStringOperator{},
+	DECIMAL_TYPE:       decimalOperator{},
+	UUID_TYPE:          uuidOperator{},
+	TIMESTAMP_TYPE:     timestampOperator{},
+	DURATION_TYPE:      durationOperator{},
+	GEO_TYPE:           geoOperator{},
+	IPADDR_TYPE:        ipOperator{},
+	BITSET_TYPE:        validatedStringOperator{BITSET_TYPE, validateBitset},
+	RATIONAL_TYPE:      rationalOperator{},
+	COMPLEX_TYPE:       complexOperator{},
+	MATRIX_TYPE:        matrixOperator{},
+	HISTOGRAM_TYPE:     validatedStringOperator{HISTOGRAM_TYPE, validateHistogram},
+	HLL_TYPE:           validatedStringOperator{HLL_TYPE, validateHLL},
+	ROARING_TYPE:       validatedStringOperator{ROARING_TYPE, validateRoaring},
+	LINKED_NODE_TYPE:   validatedStringOperator{LINKED_NODE_TYPE, validateLinkedNode},
+	GRAPH_NODE_TYPE:    validatedStringOperator{GRAPH_NODE_TYPE, validateGraphNode},
+	TRIE_TYPE:          validatedStringOperator{TRIE_TYPE, validateTrie},
+	TIME_SERIES_TYPE:   validatedStringOperator{TIME_SERIES_TYPE, validateTimeSeries},
+	EVENT_LOG_TYPE:     validatedStringOperator{EVENT_LOG_TYPE, validateEventLog},
+	DAG_NODE_TYPE:      validatedStringOperator{DAG_NODE_TYPE, validateDagNode},
+	SESSION_TYPE:       validatedStringOperator{SESSION_TYPE, validateWebSession},
+	SORTEDSET_TYPE:     validatedStringOperator{SORTEDSET_TYPE, validateSortedSet},
+	SUBSCRIPTION_TYPE:  subscriptionOperator{},
+	RATE_BUCKET_TYPE:   validatedStringOperator{RATE_BUCKET_TYPE, validateRateBucket},
+	FEATURE_FLAG_TYPE:  validatedStringOperator{FEATURE_FLAG_TYPE, validateFeatureFlag},
+	CIRCUIT_STATE_TYPE: validatedStringOperator{CIRCUIT_STATE_TYPE, validateStoredCircuit},
+	FLOAT64_TYPE:       validatedStringOperator{FLOAT64_TYPE, validateFloat64},
+	SERVICE_TYPE:       validatedStringOperator{SERVICE_TYPE, validateServiceInstance},
 }
 
 const (
-	TYPE_SIZE        = 1
-	STRING_TYPE byte = 0
-	INT64_TYPE  byte = 1
+	TYPE_SIZE               = 1
+	STRING_TYPE        byte = 0
+	INT64_TYPE         byte = 1
+	VARINT_INT64_TYPE  byte = 2
+	DIFF_STRING_TYPE   byte = 3
+	DOCUMENT_TYPE      byte = 4
+	SOFT_DELETED_TYPE  byte = 5
+	TOMBSTONE_TYPE     byte = 6
+	VECTOR_CLOCK_TYPE  byte = 7
+	LAMPORT_TYPE       byte = 8
+	GSET_TYPE          byte = 9
+	ORSET_TYPE         byte = 10
+	TPSET_TYPE         byte = 11
+	LWWMAP_TYPE        byte = 12
+	PNCOUNTER_TYPE     byte = 13
+	DELTA_INT64_TYPE   byte = 14
+	RLE_STRING_TYPE    byte = 15
+	DICT_STRING_TYPE   byte = 16
+	DECIMAL_TYPE       byte = 17
+	UUID_TYPE          byte = 18
+	TIMESTAMP_TYPE     byte = 19
+	DURATION_TYPE      byte = 20
+	GEO_TYPE           byte = 21
+	IPADDR_TYPE        byte = 22
+	BITSET_TYPE        byte = 23
+	RATIONAL_TYPE      byte = 24
+	COMPLEX_TYPE       byte = 25
+	MATRIX_TYPE        byte = 26
+	HISTOGRAM_TYPE     byte = 27
+	HLL_TYPE           byte = 28
+	ROARING_TYPE       byte = 29
+	LINKED_NODE_TYPE   byte = 30
+	GRAPH_NODE_TYPE    byte = 31
+	TRIE_TYPE          byte = 32
+	TIME_SERIES_TYPE   byte = 33
+	EVENT_LOG_TYPE     byte = 34
+	DAG_NODE_TYPE      byte = 35
+	SESSION_TYPE       byte = 36
+	SORTEDSET_TYPE     byte = 37
+	SUBSCRIPTION_TYPE  byte = 38
+	RATE_BUCKET_TYPE   byte = 39
+	FEATURE_FLAG_TYPE  byte = 40
+	CIRCUIT_STATE_TYPE byte = 41
+	FLOAT64_TYPE       byte = 42
+	SERVICE_TYPE       byte = 43
+
+	// старший біт байта типу позначає запис з анотаціями
+	ANNOTATED_FLAG byte = 0x80
 )
 
 func (e *Entry) Encode() []byte {
 	operator := operators[e.valueType]
-	return operator.Encode(e)
+	return appendAnnotations(operator.Encode(e, nil), 0, e)
+}
+
+func (e *Entry) EncodeBuffer(dst []byte) []byte {
+	operator := operators[e.valueType]
+	return appendAnnotations(operator.Encode(e, dst), 0, e)
+}
+
+func EncodeInto(e *Entry, dst []byte) ([]byte, error) {
+	operator, ok := operators[e.valueType]
+	if !ok {
+		return dst, fmt.Errorf("unknown value type %d", e.valueType)
+	}
+	start := len(dst)
+	res, err := operator.EncodeInto(e, dst)
+	if err != nil {
+		return res, err
+	}
+	return appendAnnotations(res, start, e), nil
 }
 
 func (e *Entry) Decode(input []byte) {
@@ -140,8 +442,14 @@ func (e *Entry) Decode(input []byte) {
 	e.key = string(keyBuf)
 
 	typeValue := input[kl+8]
+	e.annotations = ""
+	if typeValue&ANNOTATED_FLAG != 0 {
+		typeValue &^= ANNOTATED_FLAG
+		e.annotations = string(annotationSection(input))
+	}
 	operator := operators[typeValue]
 
+	e.valueType = typeValue
 	operator.Decode(input, e)
 }
 
@@ -170,10 +478,20 @@ func readValue(in *bufio.Reader) (output, error) {
 		return output{}, err
 	}
 
-	operator := operators[valueType[0]]
+	rawType := valueType[0] &^ ANNOTATED_FLAG
+	operator := operators[rawType]
 	data, err := operator.Read(in)
 	if err != nil {
 		return output{}, err
 	}
-	return output{ToType(valueType[0]), data}, nil
+	switch rawType {
+	case VARINT_INT64_TYPE:
+		return output{"int64", data}, nil
+	case DIFF_STRING_TYPE:
+		//дифф розгортає блок, бо база лежить в іншому записі
+		return output{diffTypeName, data}, nil
+	case TOMBSTONE_TYPE:
+		return output{tombstoneTypeName, data}, nil
+	}
+	return output{ToType(rawType), data}, nil
 }
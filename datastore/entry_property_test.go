@@ -0,0 +1,367 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+const propertyCases = 10000
+
+// propertyEntry генерує довільний запис одного з вбудованих типів
+// зі значенням, коректним для цього типу
+type propertyEntry struct {
+	e Entry
+}
+
+func randomString(r *rand.Rand, maxLen int) string {
+	b := make([]byte, r.Intn(maxLen+1))
+	r.Read(b)
+	return string(b)
+}
+
+func randomInt64(r *rand.Rand) int64 {
+	// і малі значення (varint), і на всю ширину
+	switch r.Intn(3) {
+	case 0:
+		return int64(r.Intn(1000)) - 500
+	case 1:
+		return r.Int63n(1<<33) - 1<<32
+	}
+	return int64(r.Uint64())
+}
+
+// randomFloat64 бере довільні біти, щоб траплялися й нескінченності,
+// і субнормальні числа; NaN не зберігає біти при форматуванні, тож його немає
+func randomFloat64(r *rand.Rand) float64 {
+	if f := math.Float64frombits(r.Uint64()); !math.IsNaN(f) {
+		return f
+	}
+	return 0
+}
+
+func randomStrings(r *rand.Rand, n, maxLen int) []string {
+	res := make([]string, r.Intn(n+1))
+	for i := range res {
+		res[i] = randomString(r, maxLen)
+	}
+	return res
+}
+
+func randomStringMap(r *rand.Rand) map[string]string {
+	m := make(map[string]string)
+	for i := r.Intn(4); i > 0; i-- {
+		m[randomString(r, 8)] = randomString(r, 16)
+	}
+	return m
+}
+
+// propertyValues дає для кожного вбудованого типу коректне значення в тому
+// вигляді, в якому його повертає декодування
+var propertyValues = map[byte]func(r *rand.Rand) string{
+	STRING_TYPE: func(r *rand.Rand) string {
+		return randomString(r, 4096)
+	},
+	INT64_TYPE: func(r *rand.Rand) string {
+		return strconv.FormatInt(randomInt64(r), 10)
+	},
+	VARINT_INT64_TYPE: func(r *rand.Rand) string {
+		return strconv.FormatInt(randomInt64(r), 10)
+	},
+	DIFF_STRING_TYPE: func(r *rand.Rand) string {
+		return randomString(r, 64)
+	},
+	DOCUMENT_TYPE: func(r *rand.Rand) string {
+		doc, _ := NewDocumentEntry("", map[string]interface{}{"n": r.Int63(), "s": randomString(r, 16)})
+		return doc.value
+	},
+	SOFT_DELETED_TYPE: func(r *rand.Rand) string {
+		return randomString(r, 64)
+	},
+	TOMBSTONE_TYPE: func(r *rand.Rand) string {
+		return ""
+	},
+	VECTOR_CLOCK_TYPE: func(r *rand.Rand) string {
+		clock := make(map[string]uint64)
+		for i := r.Intn(5); i > 0; i-- {
+			clock["node"+strconv.Itoa(r.Intn(100))] = r.Uint64()
+		}
+		value, _ := encodeVectorClock(clock)
+		return value
+	},
+	LAMPORT_TYPE: func(r *rand.Rand) string {
+		return strconv.FormatUint(r.Uint64(), 10)
+	},
+	GSET_TYPE: func(r *rand.Rand) string {
+		return encodeStringSet(randomStrings(r, 5, 8))
+	},
+	ORSET_TYPE: func(r *rand.Rand) string {
+		s := newORSet()
+		for i, elem := range randomStrings(r, 5, 8) {
+			s.add(elem, "tag-"+strconv.Itoa(i))
+			if r.Intn(3) == 0 {
+				s.remove(elem)
+			}
+		}
+		return s.encode()
+	},
+	TPSET_TYPE: func(r *rand.Rand) string {
+		return (&TwoPSet{adds: randomStrings(r, 5, 8), removes: randomStrings(r, 3, 8)}).encode()
+	},
+	LWWMAP_TYPE: func(r *rand.Rand) string {
+		m := &LWWMap{fields: make(map[string]lwwRegister)}
+		for i := r.Intn(4); i > 0; i-- {
+			m.fields[randomString(r, 8)] = lwwRegister{randomString(r, 16), r.Int63()}
+		}
+		return m.encode()
+	},
+	PNCOUNTER_TYPE: func(r *rand.Rand) string {
+		c := &PNCounter{positive: make(map[string]uint64), negative: make(map[string]uint64)}
+		for i := r.Intn(4); i > 0; i-- {
+			c.positive["node"+strconv.Itoa(r.Intn(10))] = r.Uint64() >> 2
+			c.negative["node"+strconv.Itoa(r.Intn(10))] = r.Uint64() >> 2
+		}
+		value, _ := c.encode()
+		return value
+	},
+	DELTA_INT64_TYPE: func(r *rand.Rand) string {
+		n := r.Intn(5) + 1
+		keys, values := make([]string, n), make([]int64, n)
+		values[0] = randomInt64(r)
+		for i := range keys {
+			keys[i] = "ts:" + strconv.Itoa(r.Intn(1000))
+			if i > 0 {
+				//дельта мусить вміщатися в int32
+				values[i] = values[i-1] + int64(int32(r.Uint32()))
+			}
+		}
+		return encodeInt64Series(keys, values)
+	},
+	RLE_STRING_TYPE: func(r *rand.Rand) string {
+		return encodeRLERun(append(randomStrings(r, 4, 8), randomString(r, 8)), randomString(r, 32))
+	},
+	DICT_STRING_TYPE: func(r *rand.Rand) string {
+		return strconv.Itoa(r.Intn(math.MaxUint16 + 1))
+	},
+	DECIMAL_TYPE: func(r *rand.Rand) string {
+		return formatDecimal(randomInt64(r), int8(r.Intn(256)-128))
+	},
+	UUID_TYPE: func(r *rand.Rand) string {
+		var id [16]byte
+		r.Read(id[:])
+		return formatUUID(id)
+	},
+	TIMESTAMP_TYPE: func(r *rand.Rand) string {
+		return formatTimestamp(int64(r.Uint64()))
+	},
+	DURATION_TYPE: func(r *rand.Rand) string {
+		return time.Duration(randomInt64(r)).String()
+	},
+	GEO_TYPE: func(r *rand.Rand) string {
+		return formatGeo(r.Float64()*180-90, r.Float64()*360-180)
+	},
+	IPADDR_TYPE: func(r *rand.Rand) string {
+		ip := make(net.IP, []int{net.IPv4len, net.IPv6len}[r.Intn(2)])
+		r.Read(ip)
+		return ip.String()
+	},
+	BITSET_TYPE: func(r *rand.Rand) string {
+		size := r.Intn(200) + 1
+		e := NewBitsetEntry("", size)
+		for i := r.Intn(size); i > 0; i-- {
+			e.SetBit(r.Intn(size), true)
+		}
+		return e.value
+	},
+	RATIONAL_TYPE: func(r *rand.Rand) string {
+		denom := randomInt64(r)
+		if denom == 0 {
+			denom = 1
+		}
+		return formatRational(randomInt64(r), denom)
+	},
+	COMPLEX_TYPE: func(r *rand.Rand) string {
+		return formatComplex(complex(randomFloat64(r), randomFloat64(r)))
+	},
+	MATRIX_TYPE: func(r *rand.Rand) string {
+		rows, cols := r.Intn(4)+1, r.Intn(4)+1
+		data := make([]float64, rows*cols)
+		for i := range data {
+			data[i] = randomFloat64(r)
+		}
+		return formatMatrix(rows, cols, data)
+	},
+	HISTOGRAM_TYPE: func(r *rand.Rand) string {
+		bounds := make([]float64, r.Intn(5)+1)
+		for i := range bounds {
+			bounds[i] = float64(i) + r.Float64()
+		}
+		e := NewHistogramEntry("", bounds)
+		for i := r.Intn(10); i > 0; i-- {
+			e.Observe(r.NormFloat64() * 5)
+		}
+		return e.value
+	},
+	HLL_TYPE: func(r *rand.Rand) string {
+		e := NewHLLEntry("")
+		for i := r.Intn(20); i > 0; i-- {
+			e.HLLAdd([]byte(randomString(r, 16)))
+		}
+		return e.value
+	},
+	ROARING_TYPE: func(r *rand.Rand) string {
+		e := NewRoaringEntry("")
+		for i := r.Intn(20); i > 0; i-- {
+			e.RoaringAdd(uint64(r.Intn(1 << 20)))
+		}
+		return e.value
+	},
+	LINKED_NODE_TYPE: func(r *rand.Rand) string {
+		return encodeLinkedNode(randomString(r, 32), randomString(r, 8), randomString(r, 8))
+	},
+	GRAPH_NODE_TYPE: func(r *rand.Rand) string {
+		return (&GraphNode{Value: randomString(r, 32), Neighbors: randomStrings(r, 4, 8)}).encode()
+	},
+	TRIE_TYPE: func(r *rand.Rand) string {
+		e := NewTrieEntry("")
+		for i := r.Intn(6); i > 0; i-- {
+			e.TrieInsert("w"+randomString(r, 6), randomString(r, 8))
+		}
+		return e.value
+	},
+	TIME_SERIES_TYPE: func(r *rand.Rand) string {
+		ts, values := make([]int64, r.Intn(20)), make([]float64, 0)
+		next := r.Int63n(1 << 40)
+		for i := range ts {
+			ts[i] = next
+			next += r.Int63n(1000) + 1
+			values = append(values, randomFloat64(r))
+		}
+		return encodeTimeSeries(ts, values)
+	},
+	EVENT_LOG_TYPE: func(r *rand.Rand) string {
+		var events []*Event
+		for i := r.Intn(4); i > 0; i-- {
+			events = append(events, &Event{r.Int63(), randomString(r, 8), randomString(r, 32)})
+		}
+		return encodeEventLog(events)
+	},
+	DAG_NODE_TYPE: func(r *rand.Rand) string {
+		return (&DagNode{ContentHash: randomString(r, 8), Parents: randomStrings(r, 3, 8), Author: randomString(r, 8), Message: randomString(r, 32), Timestamp: r.Int63()}).encode()
+	},
+	SESSION_TYPE: func(r *rand.Rand) string {
+		return (&WebSession{ID: randomString(r, 8), UserID: randomString(r, 8), Data: randomStringMap(r), CreatedAt: r.Int63(), LastSeenAt: r.Int63(), ExpiresAt: r.Int63()}).encode()
+	},
+	SORTEDSET_TYPE: func(r *rand.Rand) string {
+		z := newSortedSet()
+		for _, member := range randomStrings(r, 5, 8) {
+			z.add(member, randomFloat64(r))
+		}
+		return z.encode()
+	},
+	SUBSCRIPTION_TYPE: func(r *rand.Rand) string {
+		return strconv.FormatUint(r.Uint64(), 10)
+	},
+	RATE_BUCKET_TYPE: func(r *rand.Rand) string {
+		burst := r.Float64()*100 + 1
+		return (&rateBucket{tokens: r.Float64() * burst, lastRefillNs: r.Int63(), ratePerSec: r.Float64() * 1000, burst: burst}).encode()
+	},
+	FEATURE_FLAG_TYPE: func(r *rand.Rand) string {
+		e, _ := NewFeatureFlagEntry("", &FeatureFlag{Enabled: r.Intn(2) == 0, RolloutPercent: r.Float64() * 100, TargetUserIDs: randomStrings(r, 4, 8), Metadata: randomStringMap(r)})
+		return e.value
+	},
+	CIRCUIT_STATE_TYPE: func(r *rand.Rand) string {
+		return (&storedCircuit{state: CircuitState(r.Intn(3)), failureCount: r.Int63n(100), lastFailureNs: r.Int63(), halfOpenAttempts: r.Int63n(10)}).encode()
+	},
+	FLOAT64_TYPE: func(r *rand.Rand) string {
+		return formatFloat64(randomFloat64(r))
+	},
+	SERVICE_TYPE: func(r *rand.Rand) string {
+		return encodeServiceInstance(randomString(r, 21), randomStringMap(r))
+	},
+}
+
+// propertyTypes впорядковані, щоб той самий seed давав ті самі записи
+var propertyTypes = func() []byte {
+	var types []byte
+	for t := range propertyValues {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}()
+
+func (propertyEntry) Generate(r *rand.Rand, size int) reflect.Value {
+	e := Entry{key: randomString(r, 64)}
+	e.valueType = propertyTypes[r.Intn(len(propertyTypes))]
+	e.value = propertyValues[e.valueType](r)
+	for i := r.Intn(3); i > 0; i-- {
+		e.Annotate(randomString(r, 8), randomString(r, 16))
+	}
+	return reflect.ValueOf(propertyEntry{e})
+}
+
+func TestEntry_PropertyCoversOperators(t *testing.T) {
+	for valueType, operator := range operators {
+		//типи з плагінів тести реєструють самі
+		if _, ok := operator.(codecOperator); ok {
+			continue
+		}
+		if _, ok := propertyValues[valueType]; !ok {
+			t.Errorf("No property generator for type %d %q", valueType, ToType(valueType))
+		}
+	}
+}
+
+func TestEntry_PropertyRoundTrip(t *testing.T) {
+	roundTrip := func(p propertyEntry) bool {
+		data, err := EncodeInto(&p.e, nil)
+		if err != nil {
+			t.Logf("EncodeInto(%q): %v", p.e.key, err)
+			return false
+		}
+		//varint читається назад як звичайний int64
+		want := p.e
+		if want.valueType == VARINT_INT64_TYPE {
+			want.valueType = INT64_TYPE
+		}
+		var decoded Entry
+		decoded.Decode(data)
+		if decoded != want {
+			t.Logf("Decode: expected %+v, got %+v", want, decoded)
+			return false
+		}
+		var unsafeDecoded Entry
+		UnsafeDecode(data, &unsafeDecoded)
+		if unsafeDecoded != want {
+			t.Logf("UnsafeDecode: expected %+v, got %+v", want, unsafeDecoded)
+			return false
+		}
+		if entries, err := DecodeMany(data); err != nil || *entries[0] != want {
+			t.Logf("DecodeMany(%+v): %v", p.e, err)
+			return false
+		}
+		return string(p.e.Encode()) == string(data)
+	}
+	if err := quick.Check(roundTrip, &quick.Config{MaxCount: propertyCases}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEntry_PropertySizeField(t *testing.T) {
+	sizeField := func(p propertyEntry) bool {
+		data := p.e.Encode()
+		return len(data) >= 8 && int(binary.LittleEndian.Uint32(data)) == len(data) &&
+			int(binary.LittleEndian.Uint32(data[4:])) == len(p.e.key)
+	}
+	if err := quick.Check(sizeField, &quick.Config{MaxCount: propertyCases}); err != nil {
+		t.Error(err)
+	}
+}
@@ -3,11 +3,18 @@ package datastore
 import (
 	"bufio"
 	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 )
 
 func TestEntry_Encode(t *testing.T) {
-	e := Entry{"key", ToByte("string"), "value"}
+	e := Entry{key: "key", valueType: ToByte("string"), value: "value"}
 	e.Decode(e.Encode())
 	if e.key != "key" {
 		t.Error("incorrect key")
@@ -18,7 +25,7 @@ func TestEntry_Encode(t *testing.T) {
 }
 
 func TestReadValue(t *testing.T) {
-	e := Entry{"key", ToByte("string"), "test-value"}
+	e := Entry{key: "key", valueType: ToByte("string"), value: "test-value"}
 	data := e.Encode()
 	v, err := readValue(bufio.NewReader(bytes.NewReader(data)))
 	if err != nil {
@@ -33,7 +40,7 @@ func TestReadValue(t *testing.T) {
 }
 
 func TestReadValueInt64(t *testing.T) {
-	e := Entry{"key", ToByte("int64"), "-12"}
+	e := Entry{key: "key", valueType: ToByte("int64"), value: "-12"}
 	data := e.Encode()
 	v, err := readValue(bufio.NewReader(bytes.NewReader(data)))
 	if err != nil {
@@ -46,3 +53,264 @@ func TestReadValueInt64(t *testing.T) {
 		t.Errorf("Got bad value type [%s]", v)
 	}
 }
+
+func TestEncodeInto(t *testing.T) {
+	entries := []Entry{
+		{key: "key", valueType: ToByte("string"), value: "value"},
+		{key: "", valueType: ToByte("string"), value: ""},
+		{key: "key", valueType: ToByte("int64"), value: "-12"},
+	}
+	prefix := []byte("prefix")
+	for _, e := range entries {
+		dst := append([]byte(nil), prefix...)
+		dst, err := EncodeInto(&e, dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(dst[:len(prefix)], prefix) {
+			t.Errorf("Prefix was overwritten for %v", e)
+		}
+		if !bytes.Equal(dst[len(prefix):], e.Encode()) {
+			t.Errorf("EncodeInto differs from Encode for %v", e)
+		}
+	}
+
+	if _, err := EncodeInto(&Entry{key: "key", valueType: ToByte("int64"), value: "nan"}, nil); err == nil {
+		t.Error("Expected error for malformed int64 value")
+	}
+	if _, err := EncodeInto(&Entry{key: "key", valueType: 42, value: "value"}, nil); err == nil {
+		t.Error("Expected error for unknown type")
+	}
+}
+
+func BenchmarkEncode_Int64(b *testing.B) {
+	e := Entry{key: "benchmark-key", valueType: ToByte("int64"), value: "1234567890"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = e.Encode()
+	}
+}
+
+func BenchmarkEncodeInto_Int64Pool(b *testing.B) {
+	e := Entry{key: "benchmark-key", valueType: ToByte("int64"), value: "1234567890"}
+	pool := sync.Pool{New: func() interface{} {
+		buf := make([]byte, 0, 64)
+		return &buf
+	}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := pool.Get().(*[]byte)
+		data, err := EncodeInto(&e, (*buf)[:0])
+		if err != nil {
+			b.Fatal(err)
+		}
+		*buf = data
+		pool.Put(buf)
+	}
+}
+
+func TestUnsafeDecode(t *testing.T) {
+	e := Entry{key: "key", valueType: ToByte("string"), value: "value"}
+	data := e.Encode()
+
+	var decoded Entry
+	UnsafeDecode(data, &decoded)
+	if decoded != e {
+		t.Errorf("Bad entry decoded: expected %v, got %v", e, decoded)
+	}
+
+	var detached Entry
+	UnsafeDecode(data, &detached)
+	detached.Detach()
+
+	for i := range data {
+		data[i] = 0
+	}
+	if decoded.key == "key" {
+		t.Error("Expected key to share memory with the zeroed input")
+	}
+	if detached.key != "key" || detached.value != "value" {
+		t.Errorf("Detached entry was corrupted: %v", detached)
+	}
+}
+
+func TestUnsafeDecodeInt64(t *testing.T) {
+	e := Entry{key: "key", valueType: ToByte("int64"), value: "-12"}
+	var decoded Entry
+	UnsafeDecode(e.Encode(), &decoded)
+	if decoded != e {
+		t.Errorf("Bad entry decoded: expected %v, got %v", e, decoded)
+	}
+}
+
+func TestEncodeBuffer(t *testing.T) {
+	entries := []Entry{
+		{key: "key", valueType: ToByte("string"), value: "value"},
+		{key: "key", valueType: ToByte("int64"), value: "-12"},
+	}
+	for _, e := range entries {
+		dirty := bytes.Repeat([]byte{0xff}, 64)
+		data := e.EncodeBuffer(dirty)
+		if !bytes.Equal(data, e.Encode()) {
+			t.Errorf("EncodeBuffer differs from Encode for %v", e)
+		}
+		if &data[0] != &dirty[0] {
+			t.Errorf("Expected buffer to be reused for %v", e)
+		}
+
+		small := make([]byte, 4)
+		data = e.EncodeBuffer(small)
+		if !bytes.Equal(data, e.Encode()) {
+			t.Errorf("EncodeBuffer differs from Encode for %v", e)
+		}
+	}
+}
+
+func BenchmarkEncode_Int64Million(b *testing.B) {
+	e := Entry{key: "benchmark-key", valueType: ToByte("int64"), value: "1234567890"}
+	b.Run("new buffer", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < 1000000; j++ {
+				_ = e.Encode()
+			}
+		}
+	})
+	b.Run("reused buffer", func(b *testing.B) {
+		buf := make([]byte, 64)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < 1000000; j++ {
+				_ = e.EncodeBuffer(buf)
+			}
+		}
+	})
+}
+
+// межові довжини ключа і значення, на яких зсув арифметики розміру на 1 став би помітним
+func TestEntry_EncodingBoundaries(t *testing.T) {
+	for _, kl := range []int{0, 1, 2} {
+		for _, vl := range []int{0, 1, math.MaxUint16, math.MaxUint16 + 1} {
+			e := Entry{key: strings.Repeat("k", kl), valueType: STRING_TYPE, value: strings.Repeat("v", vl)}
+			data := e.Encode()
+			if len(data) != kl+TYPE_SIZE+vl+12 {
+				t.Errorf("kl=%d vl=%d: expected %d bytes, got %d", kl, vl, kl+TYPE_SIZE+vl+12, len(data))
+				continue
+			}
+			if binary.LittleEndian.Uint32(data) != uint32(len(data)) ||
+				binary.LittleEndian.Uint32(data[4:]) != uint32(kl) ||
+				data[8+kl] != STRING_TYPE ||
+				binary.LittleEndian.Uint32(data[9+kl:]) != uint32(vl) {
+				t.Errorf("kl=%d vl=%d: bad header % x", kl, vl, data[:min(len(data), 16+kl)])
+			}
+			into, err := EncodeInto(&e, []byte("prefix"))
+			if err != nil || string(into[6:]) != string(data) {
+				t.Errorf("kl=%d vl=%d: EncodeInto differs from Encode", kl, vl)
+			}
+
+			// два записи поспіль: декодер не має зачепити сусідній
+			next := Entry{key: "next", valueType: INT64_TYPE, value: "-1"}
+			entries, err := DecodeMany(append(append([]byte{}, data...), next.Encode()...))
+			if err != nil || len(entries) != 2 || *entries[0] != e || *entries[1] != next {
+				t.Errorf("kl=%d vl=%d: bad records decoded: %v", kl, vl, err)
+			}
+			var unsafeDecoded Entry
+			UnsafeDecode(data, &unsafeDecoded)
+			if unsafeDecoded != e {
+				t.Errorf("kl=%d vl=%d: bad UnsafeDecode", kl, vl)
+			}
+
+			// запис, коротший або довший на байт, відкидається
+			short := append([]byte{}, data[:len(data)-1]...)
+			binary.LittleEndian.PutUint32(short, uint32(len(short)))
+			long := append(append([]byte{}, data...), 0)
+			binary.LittleEndian.PutUint32(long, uint32(len(long)))
+			for _, bad := range [][]byte{short, long} {
+				if _, err := DecodeMany(bad); err == nil {
+					t.Errorf("kl=%d vl=%d: expected error for %d-byte record", kl, vl, len(bad))
+				}
+			}
+		}
+
+		for _, value := range []string{"0", "1", "-1", strconv.FormatInt(math.MaxUint32, 10), strconv.FormatInt(math.MaxUint32+1, 10), strconv.FormatInt(math.MinInt64, 10)} {
+			e := Entry{key: strings.Repeat("k", kl), valueType: INT64_TYPE, value: value}
+			data := e.Encode()
+			if binary.LittleEndian.Uint32(data) != uint32(len(data)) {
+				t.Errorf("kl=%d value=%s: size field %d for %d bytes", kl, value, binary.LittleEndian.Uint32(data), len(data))
+			}
+			var decoded Entry
+			decoded.Decode(data)
+			if decoded != e {
+				t.Errorf("kl=%d value=%s: decoded %v", kl, value, decoded)
+			}
+			out, err := readValue(bufio.NewReader(bytes.NewReader(data)))
+			if err != nil || out.value != value {
+				t.Errorf("kl=%d value=%s: readValue got %q, %v", kl, value, out.value, err)
+			}
+			long := append(append([]byte{}, data...), 0)
+			binary.LittleEndian.PutUint32(long, uint32(len(long)))
+			if _, err := DecodeMany(long); err == nil {
+				t.Errorf("kl=%d value=%s: expected error for %d-byte record", kl, value, len(long))
+			}
+		}
+	}
+}
+
+func TestEntry_VarintInt64(t *testing.T) {
+	cases := []struct {
+		value    string
+		diskType byte
+	}{
+		{"0", VARINT_INT64_TYPE},
+		{"-1000", VARINT_INT64_TYPE},
+		{"4294967295", VARINT_INT64_TYPE},
+		{"-4294967295", VARINT_INT64_TYPE},
+		{"4294967296", INT64_TYPE},
+		{"-9223372036854775808", INT64_TYPE},
+	}
+	for _, c := range cases {
+		e := Entry{key: "key", valueType: ToByte("int64"), value: c.value}
+		data := e.Encode()
+		if data[8+len(e.key)] != c.diskType {
+			t.Errorf("Bad type byte for %s: %d", c.value, data[8+len(e.key)])
+		}
+		into, err := EncodeInto(&e, nil)
+		if err != nil || !bytes.Equal(into, data) {
+			t.Errorf("EncodeInto differs from Encode for %s", c.value)
+		}
+
+		var decoded Entry
+		decoded.Decode(data)
+		if decoded != e {
+			t.Errorf("Bad entry decoded: expected %v, got %v", e, decoded)
+		}
+		if _, err := DecodeMany(data); err != nil {
+			t.Errorf("DecodeMany failed for %s: %v", c.value, err)
+		}
+		v, err := readValue(bufio.NewReader(bytes.NewReader(data)))
+		if err != nil || v.value != c.value || v.valueType != "int64" {
+			t.Errorf("Bad value read for %s: %v, %v", c.value, v, err)
+		}
+	}
+}
+
+func BenchmarkEncode_VarintSize(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	entries := make([]Entry, 10000)
+	for i := range entries {
+		entries[i] = Entry{key: fmt.Sprintf("key%d", i), valueType: INT64_TYPE, value: strconv.Itoa(rnd.Intn(2001) - 1000)}
+	}
+
+	b.ReportAllocs()
+	var fixed, adaptive int
+	for i := 0; i < b.N; i++ {
+		fixed, adaptive = 0, 0
+		for _, e := range entries {
+			// фіксований INT64_TYPE: 8 байтів значення і 4 байти доповнення
+			fixed += 8 + len(e.key) + TYPE_SIZE + 12
+			adaptive += len(e.Encode())
+		}
+	}
+	b.ReportMetric(float64(adaptive)/float64(len(entries)), "bytes/entry")
+	b.ReportMetric(100*(1-float64(adaptive)/float64(fixed)), "%saved")
+}
@@ -0,0 +1,42 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"strings"
+	"unsafe"
+)
+
+func unsafeString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return unsafe.String(&b[0], len(b))
+}
+
+// UnsafeDecode decodes input like Decode, but the key and string values of e
+// point into input instead of being copied. input must not be modified or
+// unmapped while e is in use; call Detach before the memory goes away.
+func UnsafeDecode(input []byte, e *Entry) {
+	kl := binary.LittleEndian.Uint32(input[4:])
+	e.key = unsafeString(input[8 : kl+8])
+	e.valueType = input[kl+8]
+	e.annotations = ""
+	if e.valueType&ANNOTATED_FLAG != 0 {
+		e.valueType &^= ANNOTATED_FLAG
+		e.annotations = unsafeString(annotationSection(input))
+	}
+
+	if e.valueType != STRING_TYPE {
+		operators[e.valueType].Decode(input, e)
+		return
+	}
+	vl := binary.LittleEndian.Uint32(input[kl+TYPE_SIZE+8:])
+	start := kl + TYPE_SIZE + 12
+	e.value = unsafeString(input[start : start+vl])
+}
+
+func (e *Entry) Detach() {
+	e.key = strings.Clone(e.key)
+	e.value = strings.Clone(e.value)
+	e.annotations = strings.Clone(e.annotations)
+}
@@ -0,0 +1,234 @@
+package datastore
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+var ErrSessionExpired = fmt.Errorf("session expired")
+
+const sessionKeyPrefix = "session:"
+
+type SessionEvent int
+
+const (
+	SESSION_CREATED SessionEvent = iota
+	SESSION_DELETED
+)
+
+func (ev SessionEvent) String() string {
+	switch ev {
+	case SESSION_CREATED:
+		return "CREATED"
+	case SESSION_DELETED:
+		return "DELETED"
+	}
+	return fmt.Sprintf("SessionEvent(%d)", int(ev))
+}
+
+// Session owns ephemeral entries, like a ZooKeeper session owns ephemeral
+// znodes. The session node and every ephemeral entry carry a hard TTL that
+// Heartbeat keeps extending; once it lapses, the sweep goroutine tombstones
+// them all.
+type Session struct {
+	db  *Db
+	id  string
+	ttl time.Duration
+
+	mu        sync.Mutex
+	expired   bool
+	ephemeral map[string]bool
+	listeners []func(key string, event SessionEvent)
+	heartbeat chan struct{}
+
+	closed chan struct{}
+	wg     sync.WaitGroup
+}
+
+func NewSession(db *Db, id string, ttl time.Duration) (*Session, error) {
+	s := &Session{
+		db:        db,
+		id:        id,
+		ttl:       ttl,
+		ephemeral: make(map[string]bool),
+		closed:    make(chan struct{}),
+	}
+	if err := s.touch(s.nodeKey()); err != nil {
+		return nil, err
+	}
+	s.wg.Add(1)
+	go s.sweep()
+	return s, nil
+}
+
+func (s *Session) ID() string {
+	return s.id
+}
+
+func (s *Session) nodeKey() string {
+	return sessionKeyPrefix + s.id
+}
+
+// touch переписує запис із новим терміном життя; значенням є власник-сесія
+func (s *Session) touch(key string) error {
+	e := &Entry{key: key, valueType: STRING_TYPE, value: s.id}
+	e.SetTTL(0, s.ttl)
+	return s.db.putEntry(e)
+}
+
+// Heartbeat extends the session every interval until Stop or Close. The
+// interval has to be shorter than the session TTL.
+func (s *Session) Heartbeat(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.heartbeat != nil || s.expired {
+		return
+	}
+	stop := make(chan struct{})
+	s.heartbeat = stop
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-s.closed:
+				return
+			case <-ticker.C:
+				s.refresh()
+			}
+		}
+	}()
+}
+
+// Stop stops the heartbeat; the session then expires after its TTL.
+func (s *Session) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.heartbeat != nil {
+		close(s.heartbeat)
+		s.heartbeat = nil
+	}
+}
+
+func (s *Session) refresh() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.expired {
+		return
+	}
+	//помилку запису не повертаємо: сесія просто спливе, і sweep прибере записи
+	if err := s.touch(s.nodeKey()); err != nil {
+		return
+	}
+	for key := range s.ephemeral {
+		s.touch(key)
+	}
+}
+
+func (s *Session) SessionListener(fn func(key string, event SessionEvent)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+func (s *Session) notify(keys []string, event SessionEvent) {
+	s.mu.Lock()
+	listeners := append([]func(string, SessionEvent){}, s.listeners...)
+	s.mu.Unlock()
+	for _, key := range keys {
+		for _, fn := range listeners {
+			fn(key, event)
+		}
+	}
+}
+
+func (s *Session) sweep() {
+	defer s.wg.Done()
+	interval := s.ttl / 4
+	if interval <= 0 {
+		interval = time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.closed:
+			return
+		case <-ticker.C:
+			if deleted := s.expire(); deleted != nil {
+				s.notify(deleted, SESSION_DELETED)
+				return
+			}
+		}
+	}
+}
+
+// expire перевіряє вузол сесії і, якщо його TTL сплив, видаляє всі ефемерні записи
+func (s *Session) expire() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.db.getEntry(s.nodeKey()); err != ErrNotFound {
+		return nil
+	}
+	s.expired = true
+	if s.heartbeat != nil {
+		close(s.heartbeat)
+		s.heartbeat = nil
+	}
+	deleted := make([]string, 0, len(s.ephemeral))
+	for key := range s.ephemeral {
+		s.db.putTombstone(key)
+		deleted = append(deleted, key)
+	}
+	s.db.putTombstone(s.nodeKey())
+	s.ephemeral = nil
+	return deleted
+}
+
+func (s *Session) Expired() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.expired
+}
+
+// Close stops the session goroutines without deleting its entries; they
+// still disappear once their TTL lapses.
+func (s *Session) Close() {
+	s.mu.Lock()
+	select {
+	case <-s.closed:
+	default:
+		close(s.closed)
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// RegisterEphemeral writes key as an ephemeral entry owned by session. The
+// entry's value is the session ID.
+func (db *Db) RegisterEphemeral(key string, session *Session) error {
+	if session.db != db {
+		return fmt.Errorf("session %s belongs to another Db", session.id)
+	}
+	session.mu.Lock()
+	if session.expired {
+		session.mu.Unlock()
+		return ErrSessionExpired
+	}
+	created := !session.ephemeral[key]
+	err := session.touch(key)
+	if err == nil {
+		session.ephemeral[key] = true
+	}
+	session.mu.Unlock()
+	if err != nil || !created {
+		return err
+	}
+	session.notify([]string{key}, SESSION_CREATED)
+	return nil
+}
@@ -0,0 +1,72 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+type sessionEventRecord struct {
+	key   string
+	event SessionEvent
+}
+
+func TestSession_Ephemeral(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	s, err := NewSession(db, "worker-1", 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	events := make(chan sessionEventRecord, 10)
+	s.SessionListener(func(key string, event SessionEvent) {
+		events <- sessionEventRecord{key, event}
+	})
+
+	if err := db.RegisterEphemeral("locks/job", s); err != nil {
+		t.Fatal(err)
+	}
+	if ev := <-events; ev != (sessionEventRecord{"locks/job", SESSION_CREATED}) {
+		t.Errorf("Bad event: %v", ev)
+	}
+	s.Heartbeat(20 * time.Millisecond)
+
+	//кілька TTL поспіль запис має жити завдяки heartbeat
+	time.Sleep(300 * time.Millisecond)
+	if owner, err := db.Get("locks/job"); err != nil || owner != "worker-1" {
+		t.Fatalf("Expected ephemeral entry while heartbeat runs, got %q, %v", owner, err)
+	}
+
+	s.Stop()
+	select {
+	case ev := <-events:
+		if ev != (sessionEventRecord{"locks/job", SESSION_DELETED}) {
+			t.Errorf("Bad event: %v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected ephemeral entry to be deleted after heartbeat stopped")
+	}
+	if !s.Expired() {
+		t.Error("Expected session to be expired")
+	}
+	if _, err := db.Get("locks/job"); err != ErrNotFound {
+		t.Errorf("Expected ephemeral entry to be gone, got %v", err)
+	}
+	if _, err := db.Get("session:worker-1"); err != ErrNotFound {
+		t.Errorf("Expected session node to be gone, got %v", err)
+	}
+	if err := db.RegisterEphemeral("locks/other", s); err != ErrSessionExpired {
+		t.Errorf("Expected ErrSessionExpired, got %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package datastore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// EtcdClient talks to the JSON gateway of etcd v3 (/v3/kv/..., /v3/watch),
+// which every etcd server since 3.4 serves next to gRPC.
+type EtcdClient struct {
+	endpoint string
+	client   *http.Client
+	//скільки ключів просити в одному Range
+	pageSize int64
+}
+
+// NewEtcdClient returns a client for the etcd member at endpoint, for
+// example http://127.0.0.1:2379.
+func NewEtcdClient(endpoint string) (*EtcdClient, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported etcd url scheme %q", u.Scheme)
+	}
+	return &EtcdClient{endpoint: strings.TrimRight(endpoint, "/"), client: &http.Client{}, pageSize: 1000}, nil
+}
+
+// у JSON шлюзу ключі й значення - байти, тобто base64
+type etcdKV struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value,omitempty"`
+}
+
+type etcdRangeRequest struct {
+	Key      []byte `json:"key"`
+	RangeEnd []byte `json:"range_end,omitempty"`
+	Limit    int64  `json:"limit,omitempty"`
+}
+
+type etcdRangeResponse struct {
+	Kvs  []etcdKV `json:"kvs"`
+	More bool     `json:"more"`
+}
+
+// etcdPrefixRange - діапазон ключів з префіксом, як clientv3.WithPrefix
+func etcdPrefixRange(prefix string) etcdRangeRequest {
+	if prefix == "" {
+		//"\x00".."\x00" в etcd означає всі ключі
+		return etcdRangeRequest{Key: []byte{0}, RangeEnd: []byte{0}}
+	}
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return etcdRangeRequest{Key: []byte(prefix), RangeEnd: end[:i+1]}
+		}
+	}
+	//префікс з одних 0xff: до кінця простору ключів
+	return etcdRangeRequest{Key: []byte(prefix), RangeEnd: []byte{0}}
+}
+
+func (c *EtcdClient) call(ctx context.Context, method string, req, res interface{}) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+"/v3/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("etcd %s responded %s", method, resp.Status)
+	}
+	if res == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+		return fmt.Errorf("bad etcd %s response: %v", method, err)
+	}
+	return nil
+}
+
+// ImportEtcd copies every etcd key under prefix into db as a string entry
+// with the same key, reading the range in pages.
+func ImportEtcd(client *EtcdClient, prefix string, db *Db) error {
+	req := etcdPrefixRange(prefix)
+	req.Limit = client.pageSize
+	for {
+		var res etcdRangeResponse
+		if err := client.call(context.Background(), "kv/range", req, &res); err != nil {
+			return err
+		}
+		for _, kv := range res.Kvs {
+			if err := db.Put(string(kv.Key), string(kv.Value)); err != nil {
+				return err
+			}
+		}
+		if !res.More || len(res.Kvs) == 0 {
+			return nil
+		}
+		//наступна сторінка починається одразу після останнього ключа
+		req.Key = append(res.Kvs[len(res.Kvs)-1].Key, 0)
+	}
+}
+
+type etcdWatchMessage struct {
+	Result struct {
+		Created bool `json:"created"`
+		Events  []struct {
+			Type string `json:"type"`
+			Kv   etcdKV `json:"kv"`
+		} `json:"events"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// ImportEtcdWatch applies changes under prefix to db as etcd reports them:
+// puts become string entries and deletes become tombstones. It blocks until
+// ctx is done, then returns nil, or until the watch fails.
+func ImportEtcdWatch(client *EtcdClient, prefix string, db *Db, ctx context.Context) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"create_request": etcdPrefixRange(prefix),
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, client.endpoint+"/v3/watch", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp, err := client.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("etcd watch responded %s", resp.Status)
+	}
+
+	//шлюз стрімить по одному JSON-об'єкту на подію
+	dec := json.NewDecoder(bufio.NewReader(resp.Body))
+	for {
+		var msg etcdWatchMessage
+		if err := dec.Decode(&msg); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("etcd watch stream ended: %v", err)
+		}
+		if msg.Error != nil {
+			return fmt.Errorf("etcd watch failed: %s", msg.Error.Message)
+		}
+		for _, ev := range msg.Result.Events {
+			key := string(ev.Kv.Key)
+			if ev.Type == "DELETE" {
+				err = db.putTombstone(key)
+			} else {
+				err = db.Put(key, string(ev.Kv.Value))
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ExportEtcd writes every key of db under prefix to etcd, one Put per key.
+// Values of other types are sent as their text.
+func ExportEtcd(db *Db, prefix string, client *EtcdClient) error {
+	entries, err := db.entries()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !strings.HasPrefix(e.key, prefix) {
+			continue
+		}
+		if err := client.call(context.Background(), "kv/put", etcdKV{Key: []byte(e.key), Value: []byte(e.value)}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
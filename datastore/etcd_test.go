@@ -0,0 +1,146 @@
+package datastore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockEtcd відтворює kv/range, kv/put і watch JSON-шлюзу etcd
+type mockEtcd struct {
+	mu     sync.Mutex
+	data   map[string]string
+	ranges int
+	events chan string
+}
+
+func (m *mockEtcd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	switch r.URL.Path {
+	case "/v3/kv/range":
+		defer m.mu.Unlock()
+		m.ranges++
+		var req etcdRangeRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		var keys []string
+		for k := range m.data {
+			if k >= string(req.Key) && (bytes.Equal(req.RangeEnd, []byte{0}) || k < string(req.RangeEnd)) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		var res etcdRangeResponse
+		if req.Limit > 0 && int64(len(keys)) > req.Limit {
+			keys, res.More = keys[:req.Limit], true
+		}
+		for _, k := range keys {
+			res.Kvs = append(res.Kvs, etcdKV{Key: []byte(k), Value: []byte(m.data[k])})
+		}
+		json.NewEncoder(w).Encode(res)
+	case "/v3/kv/put":
+		defer m.mu.Unlock()
+		var kv etcdKV
+		json.NewDecoder(r.Body).Decode(&kv)
+		m.data[string(kv.Key)] = string(kv.Value)
+		w.Write([]byte("{}"))
+	case "/v3/watch":
+		m.mu.Unlock()
+		w.Write([]byte(`{"result":{"created":true}}` + "\n"))
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case ev := <-m.events:
+				w.Write([]byte(ev + "\n"))
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	default:
+		m.mu.Unlock()
+		http.NotFound(w, r)
+	}
+}
+
+func TestEtcdKV(t *testing.T) {
+	etcd := &mockEtcd{data: map[string]string{"other": "x"}, events: make(chan string)}
+	for i := 0; i < 50; i++ {
+		etcd.data[fmt.Sprintf("/app/key-%02d", i)] = fmt.Sprintf("value %d", i)
+	}
+	server := httptest.NewServer(etcd)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	client, err := NewEtcdClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.pageSize = 20
+	if err := ImportEtcd(client, "/app/", db); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := db.entries()
+	if len(entries) != 50 {
+		t.Errorf("Expected 50 imported keys, got %d", len(entries))
+	}
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("/app/key-%02d", i)
+		if v, err := db.Get(key); err != nil || v != fmt.Sprintf("value %d", i) {
+			t.Errorf("Bad %s: %q, %v", key, v, err)
+		}
+	}
+	if etcd.ranges != 3 {
+		t.Errorf("Expected 3 pages of 20 keys, got %d range calls", etcd.ranges)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- ImportEtcdWatch(client, "/app/", db, ctx) }()
+	etcd.events <- `{"result":{"events":[{"kv":{"key":"L2FwcC9uZXc=","value":"ZnJlc2g="}}]}}`
+	etcd.events <- `{"result":{"events":[{"type":"DELETE","kv":{"key":"L2FwcC9rZXktMDA="}}]}}`
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		_, missing := db.Get("/app/key-00")
+		if v, _ := db.Get("/app/new"); v == "fresh" && missing == ErrNotFound {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Watch events were not applied")
+		}
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected a cancelled watch to return nil, got %v", err)
+	}
+
+	if err := db.Put("/app/key-01", "changed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportEtcd(db, "/app/", client); err != nil {
+		t.Fatal(err)
+	}
+	if etcd.data["/app/key-01"] != "changed" || etcd.data["/app/new"] != "fresh" {
+		t.Errorf("Bad export: %q, %q", etcd.data["/app/key-01"], etcd.data["/app/new"])
+	}
+	if got := etcdPrefixRange("a\xff"); string(got.RangeEnd) != "b" {
+		t.Errorf("Bad range end for a\\xff: %q", got.RangeEnd)
+	}
+}
@@ -0,0 +1,107 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Журнал подій зберігається як [подій u32], а далі для кожної події
+// [час UnixNano i64][тип з префіксом довжини][дані з префіксом довжини].
+
+// Event is one entry of an aggregate's event log.
+type Event struct {
+	Timestamp int64
+	Type      string
+	Payload   string
+}
+
+func encodeEventLog(events []*Event) string {
+	res := binary.LittleEndian.AppendUint32(nil, uint32(len(events)))
+	for _, ev := range events {
+		res = binary.LittleEndian.AppendUint64(res, uint64(ev.Timestamp))
+		res = appendLengthPrefixed(res, ev.Type)
+		res = appendLengthPrefixed(res, ev.Payload)
+	}
+	return string(res)
+}
+
+func parseEventLog(data string) ([]*Event, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("corrupted event log")
+	}
+	n := int(binary.LittleEndian.Uint32([]byte(data[:4])))
+	//кожна подія займає щонайменше 16 байт
+	if n > (len(data)-4)/16 {
+		return nil, fmt.Errorf("corrupted event log")
+	}
+	data = data[4:]
+	events := make([]*Event, 0, n)
+	for i := 0; i < n; i++ {
+		if len(data) < 8 {
+			return nil, fmt.Errorf("corrupted event log")
+		}
+		ev := &Event{Timestamp: int64(binary.LittleEndian.Uint64([]byte(data[:8])))}
+		var err error
+		if ev.Type, data, err = readLengthPrefixed(data[8:]); err != nil {
+			return nil, err
+		}
+		if ev.Payload, data, err = readLengthPrefixed(data); err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	if data != "" {
+		return nil, fmt.Errorf("corrupted event log")
+	}
+	return events, nil
+}
+
+func validateEventLog(data string) error {
+	_, err := parseEventLog(data)
+	return err
+}
+
+func (e *Entry) Events() ([]*Event, error) {
+	if e.valueType != EVENT_LOG_TYPE {
+		return nil, fmt.Errorf("wrong type of value")
+	}
+	return parseEventLog(e.value)
+}
+
+// AppendEvent adds an event stamped with the current time to the log at key,
+// creating the log if needed.
+func AppendEvent(db *Db, key string, eventType, payload string) error {
+	_, err := db.updateEntry(key, func(cur *Entry) (*Entry, error) {
+		var events []*Event
+		if cur != nil {
+			var err error
+			if events, err = cur.Events(); err != nil {
+				return nil, err
+			}
+		}
+		events = append(events, &Event{Timestamp: timeNow().UnixNano(), Type: eventType, Payload: payload})
+		return &Entry{valueType: EVENT_LOG_TYPE, value: encodeEventLog(events)}, nil
+	})
+	return err
+}
+
+// ReplayEvents folds the events at key in append order, starting from a nil
+// state; a missing key replays no events.
+func ReplayEvents(db *Db, key string, reducer func(state interface{}, event *Event) interface{}) (interface{}, error) {
+	e, err := db.getEntry(key)
+	if err == ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	events, err := e.Events()
+	if err != nil {
+		return nil, err
+	}
+	var state interface{}
+	for _, ev := range events {
+		state = reducer(state, ev)
+	}
+	return state, nil
+}
@@ -0,0 +1,74 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestEventLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	expected := 0
+	for i, amount := range []int{100, 30, 250, 75, 5} {
+		eventType := "Deposit"
+		if i%2 == 1 {
+			eventType = "Withdraw"
+			expected -= amount
+		} else {
+			expected += amount
+		}
+		if err := AppendEvent(db, "account:1", eventType, strconv.Itoa(amount)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	balance := func(state interface{}, event *Event) interface{} {
+		sum, _ := state.(int)
+		amount, _ := strconv.Atoi(event.Payload)
+		if event.Type == "Withdraw" {
+			amount = -amount
+		}
+		return sum + amount
+	}
+	state, err := ReplayEvents(db, "account:1", balance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != expected {
+		t.Errorf("Expected balance %d, got %v", expected, state)
+	}
+	if state, err := ReplayEvents(db, "account:2", balance); err != nil || state != nil {
+		t.Errorf("Expected no state for a missing aggregate, got %v, %v", state, err)
+	}
+
+	e, err := db.getEntry("account:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := e.Events()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].Timestamp < events[i-1].Timestamp {
+			t.Errorf("Events out of order at %d", i)
+		}
+	}
+	if err := db.Put("plain", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := AppendEvent(db, "plain", "Deposit", "1"); err == nil {
+		t.Errorf("Expected an error for a non-log key")
+	}
+}
@@ -0,0 +1,83 @@
+package datastore
+
+import "time"
+
+type expiryNotifier struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// ExpiryNotifier starts a goroutine that sends each entry with a hard TTL
+// on the returned channel leadTime before it expires, once per version of
+// the key. Starting a new notifier stops the previous one; the channel is
+// closed by StopExpiryNotifier.
+func (db *Db) ExpiryNotifier(leadTime time.Duration) <-chan *Entry {
+	db.StopExpiryNotifier()
+
+	n := &expiryNotifier{stop: make(chan struct{}), done: make(chan struct{})}
+	ch := make(chan *Entry)
+	db.notifierMu.Lock()
+	db.notifier = n
+	db.notifierMu.Unlock()
+
+	//сканування частіше за випередження, щоб сповіщення не запізнювалось більше ніж на чверть
+	interval := min(max(leadTime/4, time.Millisecond), time.Second)
+	go func() {
+		defer close(n.done)
+		defer close(ch)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		// ключ -> термін версії, про яку вже сповістили
+		notified := make(map[string]time.Time)
+		for {
+			select {
+			case <-n.stop:
+				return
+			case <-ticker.C:
+			}
+			entries, err := db.scanEntries()
+			if err != nil {
+				if db.logger != nil {
+					db.logger.Warn("expiry scan failed", "error", err)
+				}
+				continue
+			}
+			now := timeNow()
+			present := make(map[string]bool, len(notified))
+			for _, e := range entries {
+				expiry, ok := e.HardExpiry()
+				if !ok || e.valueType == SOFT_DELETED_TYPE {
+					continue
+				}
+				present[e.key] = true
+				if notified[e.key].Equal(expiry) || now.Before(expiry.Add(-leadTime)) || !now.Before(expiry) {
+					continue
+				}
+				select {
+				case ch <- e:
+					notified[e.key] = expiry
+				case <-n.stop:
+					return
+				}
+			}
+			for key := range notified {
+				if !present[key] {
+					delete(notified, key)
+				}
+			}
+		}
+	}()
+	return ch
+}
+
+// StopExpiryNotifier stops the notifier goroutine and waits for it to exit.
+func (db *Db) StopExpiryNotifier() {
+	db.notifierMu.Lock()
+	n := db.notifier
+	db.notifier = nil
+	db.notifierMu.Unlock()
+	if n != nil {
+		close(n.stop)
+		<-n.done
+	}
+}
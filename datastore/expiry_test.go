@@ -0,0 +1,57 @@
+package datastore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpiryNotifier(t *testing.T) {
+	db := newTestDb(t)
+	if err := db.Put("plain", "value"); err != nil {
+		t.Fatal(err)
+	}
+	created := time.Now()
+	if err := db.PutWithTTL("key", "value", 0, 200*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	ch := db.ExpiryNotifier(50 * time.Millisecond)
+
+	select {
+	case e := <-ch:
+		elapsed := time.Since(created)
+		if e.Key() != "key" || e.Value() != "value" {
+			t.Errorf("Bad entry notified: %v", e)
+		}
+		if elapsed < 100*time.Millisecond || elapsed > 200*time.Millisecond {
+			t.Errorf("Expected notification 100-200ms after write, got %v", elapsed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected expiry notification")
+	}
+
+	// та сама версія ключа вдруге не надсилається
+	select {
+	case e := <-ch:
+		t.Errorf("Expected no duplicate notification, got %v", e)
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	// нова версія з новим TTL сповіщається знову
+	if err := db.PutWithTTL("key", "renewed", 0, 60*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-ch:
+		if e.Value() != "renewed" {
+			t.Errorf("Bad entry notified: %v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected notification for the renewed key")
+	}
+
+	db.StopExpiryNotifier()
+	if _, ok := <-ch; ok {
+		t.Error("Expected channel to be closed after stop")
+	}
+	db.StopExpiryNotifier()
+}
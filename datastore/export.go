@@ -0,0 +1,239 @@
+package datastore
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Експорт пише останню версію кожного ключа; анотації не переносяться.
+
+type exportRecord struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Value string `json:"value,omitempty"`
+	//бінарні значення (документи, годинники, множини) JSON не зберігає як є
+	ValueBase64 string `json:"value_base64,omitempty"`
+}
+
+func newExportRecord(e *Entry) exportRecord {
+	record := exportRecord{Key: e.key, Type: e.Type()}
+	if utf8.ValidString(e.value) {
+		record.Value = e.value
+	} else {
+		record.ValueBase64 = base64.StdEncoding.EncodeToString([]byte(e.value))
+	}
+	return record
+}
+
+func exportEntries(src string) ([]*Entry, error) {
+	db, err := NewDb(src, WithReadOnly())
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return db.entries()
+}
+
+// ExportJSON writes every key of the database in src to w as one JSON object
+// per line, in key order. Type names come from the schema written by
+// WriteSchemaHeader when there is one.
+func ExportJSON(src string, w io.Writer) error {
+	entries, err := exportEntries(src)
+	if err != nil {
+		return err
+	}
+	schema, err := readSchema(src)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		record := newExportRecord(e)
+		if name, ok := schema[e.valueType]; ok {
+			record.Type = name
+		}
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportCSV writes every key of the database in src to w as key, type,
+// value, value_base64 rows, in key order. Values that CSV can't carry as is
+// go to the last column in base64, as in ExportJSON.
+func ExportCSV(src string, w io.Writer, delimiter rune) error {
+	entries, err := exportEntries(src)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = delimiter
+	for _, e := range entries {
+		record := newExportRecord(e)
+		//csv.Reader перетворює \r\n у полі на \n
+		if strings.ContainsRune(record.Value, '\r') {
+			record.Value, record.ValueBase64 = "", base64.StdEncoding.EncodeToString([]byte(e.value))
+		}
+		if err := cw.Write([]string{record.Key, record.Type, record.Value, record.ValueBase64}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func ExportJSONGzipped(src string, w io.Writer) error {
+	zw := gzip.NewWriter(w)
+	if err := ExportJSON(src, zw); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func ExportCSVGzipped(src string, w io.Writer, delimiter rune) error {
+	zw := gzip.NewWriter(w)
+	if err := ExportCSV(src, zw, delimiter); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func newImportEntry(key, valueType, value string) (*Entry, error) {
+	if _, ok := typeToByte[valueType]; !ok {
+		return nil, fmt.Errorf("unknown value type %q", valueType)
+	}
+	return &Entry{key: key, valueType: ToByte(valueType), value: value}, nil
+}
+
+func importEntry(db *Db, key, valueType, value string) error {
+	e, err := newImportEntry(key, valueType, value)
+	if err != nil {
+		return err
+	}
+	return db.putEntry(e)
+}
+
+// recordEntry будує запис з record під ключем key
+func recordEntry(key string, record exportRecord) (*Entry, error) {
+	value := record.Value
+	if record.ValueBase64 != "" {
+		data, err := base64.StdEncoding.DecodeString(record.ValueBase64)
+		if err != nil {
+			return nil, fmt.Errorf("bad value of %q: %v", record.Key, err)
+		}
+		value = string(data)
+	}
+	return newImportEntry(key, record.Type, value)
+}
+
+// importRecord пише запис експорту під ключем key
+func importRecord(db *Db, key string, record exportRecord) error {
+	e, err := recordEntry(key, record)
+	if err != nil {
+		return err
+	}
+	return db.putEntry(e)
+}
+
+// storedEntry повертає e таким, яким його прочитає getEntry, не читаючи
+// базу знову: паралельний запис міг уже замінити ключ
+func storedEntry(e *Entry) *Entry {
+	var res Entry
+	res.Decode(e.Encode())
+	return &res
+}
+
+// ImportJSON writes the records produced by ExportJSON into the database in dst.
+func ImportJSON(dst string, r io.Reader) error {
+	db, err := NewDb(dst)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	dec := json.NewDecoder(r)
+	for {
+		var record exportRecord
+		if err := dec.Decode(&record); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := importRecord(db, record.Key, record); err != nil {
+			return err
+		}
+	}
+}
+
+// ImportCSV writes the rows produced by ExportCSV into the database in dst.
+// Rows without the value_base64 column are read as well.
+func ImportCSV(dst string, r io.Reader, delimiter rune) error {
+	db, err := NewDb(dst)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	cr := csv.NewReader(r)
+	cr.Comma = delimiter
+	cr.FieldsPerRecord = -1
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(row) != 3 && len(row) != 4 {
+			line, _ := cr.FieldPos(0)
+			return fmt.Errorf("line %d: expected 3 or 4 fields, got %d", line, len(row))
+		}
+		record := exportRecord{Key: row[0], Type: row[1], Value: row[2]}
+		if len(row) == 4 {
+			record.ValueBase64 = row[3]
+		}
+		if err := importRecord(db, record.Key, record); err != nil {
+			return err
+		}
+	}
+}
+
+// maybeGunzip розпаковує потік, лише якщо він починається з магічних байтів gzip
+func maybeGunzip(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b {
+		return br, nil
+	}
+	return gzip.NewReader(br)
+}
+
+// ImportJSONGzipped is ImportJSON for a gzip-compressed stream; uncompressed
+// input is detected and read as is.
+func ImportJSONGzipped(dst string, r io.Reader) error {
+	zr, err := maybeGunzip(r)
+	if err != nil {
+		return err
+	}
+	return ImportJSON(dst, zr)
+}
+
+// ImportCSVGzipped is ImportCSV for a gzip-compressed stream; uncompressed
+// input is detected and read as is.
+func ImportCSVGzipped(dst string, r io.Reader, delimiter rune) error {
+	zr, err := maybeGunzip(r)
+	if err != nil {
+		return err
+	}
+	return ImportCSV(dst, zr, delimiter)
+}
@@ -0,0 +1,119 @@
+package datastore
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newExportTestDb(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	src := filepath.Join(dir, "src")
+	db, err := NewDb(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Put("plain", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put("quoted", "a,\"b\";\nc"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put("binary", "ab\r\ncd\xff"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put("crlf", "line\r\nnext"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.PutInt64("counter", -42); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.PutDocument("doc", map[string]interface{}{"name": "x", "n": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.putTombstone("plain"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put("plain", "again"); err != nil {
+		t.Fatal(err)
+	}
+	return src
+}
+
+func assertSameEntries(t *testing.T, src, dst string) {
+	t.Helper()
+	want, err := exportEntries(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := exportEntries(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d entries, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if *got[i] != *want[i] {
+			t.Errorf("Bad entry imported: expected %v, got %v", want[i], got[i])
+		}
+	}
+}
+
+func TestExportImport_JSONGzipped(t *testing.T) {
+	src := newExportTestDb(t)
+	var plain, zipped bytes.Buffer
+	if err := ExportJSON(src, &plain); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportJSONGzipped(src, &zipped); err != nil {
+		t.Fatal(err)
+	}
+	if b := zipped.Bytes(); len(b) < 2 || b[0] != 0x1f || b[1] != 0x8b {
+		t.Fatalf("Expected gzip output")
+	}
+
+	for name, data := range map[string][]byte{"gzipped": zipped.Bytes(), "plain": plain.Bytes()} {
+		dst := filepath.Join(filepath.Dir(src), "json-"+name)
+		if err := ImportJSONGzipped(dst, bytes.NewReader(data)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		assertSameEntries(t, src, dst)
+	}
+}
+
+func TestExportImport_CSVGzipped(t *testing.T) {
+	src := newExportTestDb(t)
+	var plain, zipped bytes.Buffer
+	if err := ExportCSV(src, &plain, ';'); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportCSVGzipped(src, &zipped, ';'); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range map[string][]byte{"gzipped": zipped.Bytes(), "plain": plain.Bytes()} {
+		dst := filepath.Join(filepath.Dir(src), "csv-"+name)
+		if err := ImportCSVGzipped(dst, bytes.NewReader(data), ';'); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		assertSameEntries(t, src, dst)
+	}
+
+	dst := filepath.Join(filepath.Dir(src), "csv-old")
+	if err := ImportCSV(dst, bytes.NewReader([]byte("key;string;value\n")), ';'); err != nil {
+		t.Errorf("Expected rows without value_base64 to import, got %v", err)
+	}
+
+	dst = filepath.Join(filepath.Dir(src), "csv-bad")
+	if err := ImportCSV(dst, bytes.NewReader([]byte("key;nosuchtype;value\n")), ';'); err == nil {
+		t.Errorf("Expected an error for an unknown type")
+	}
+}
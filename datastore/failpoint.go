@@ -0,0 +1,71 @@
+//go:build failpoint
+
+package datastore
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+)
+
+// Точки відмов компілюються лише з тегом failpoint: go test -tags failpoint.
+
+type failpointState struct {
+	rate float64
+	rnd  *rand.Rand
+	hits int
+}
+
+var (
+	failpointsMu sync.Mutex
+	failpoints   = make(map[string]*failpointState)
+)
+
+// enableFailpoint makes the named failpoint fail with probability rate.
+func enableFailpoint(name string, rate float64, seed int64) {
+	failpointsMu.Lock()
+	defer failpointsMu.Unlock()
+	failpoints[name] = &failpointState{rate: rate, rnd: rand.New(rand.NewSource(seed))}
+}
+
+// disableFailpoint turns the failpoint off and returns how many times it fired.
+func disableFailpoint(name string) int {
+	failpointsMu.Lock()
+	defer failpointsMu.Unlock()
+	fp, ok := failpoints[name]
+	if !ok {
+		return 0
+	}
+	delete(failpoints, name)
+	return fp.hits
+}
+
+func failpointFires(name string) (*failpointState, bool) {
+	failpointsMu.Lock()
+	defer failpointsMu.Unlock()
+	fp, ok := failpoints[name]
+	if !ok || fp.rnd.Float64() >= fp.rate {
+		return nil, false
+	}
+	fp.hits++
+	return fp, true
+}
+
+func failpoint(name string) error {
+	if _, ok := failpointFires(name); ok {
+		return fmt.Errorf("failpoint %s: injected error", name)
+	}
+	return nil
+}
+
+// tornWrite повертає, скільки байтів із size встигне записатися до відмови
+func tornWrite(name string, size int) (int, error) {
+	fp, ok := failpointFires(name)
+	if !ok || size == 0 {
+		return size, nil
+	}
+	failpointsMu.Lock()
+	n := fp.rnd.Intn(size)
+	failpointsMu.Unlock()
+	return n, fmt.Errorf("failpoint %s: injected error after %d of %d bytes", name, n, size)
+}
@@ -0,0 +1,11 @@
+//go:build !failpoint
+
+package datastore
+
+func failpoint(name string) error {
+	return nil
+}
+
+func tornWrite(name string, size int) (int, error) {
+	return size, nil
+}
@@ -0,0 +1,175 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+)
+
+// FeatureFlag turns a feature on for the listed target users and for
+// RolloutPercent of everyone else. A disabled flag is off for all users.
+type FeatureFlag struct {
+	Enabled        bool
+	RolloutPercent float64
+	TargetUserIDs  []string
+	Metadata       map[string]string
+}
+
+// розмітка: [увімкнено 1 байт][відсоток f64][n u32][користувачі]...
+// [пар u32][ключ][значення]... з префіксами довжини
+func (f *FeatureFlag) encode() string {
+	res := []byte{0}
+	if f.Enabled {
+		res[0] = 1
+	}
+	res = binary.LittleEndian.AppendUint64(res, math.Float64bits(f.RolloutPercent))
+	users := append([]string(nil), f.TargetUserIDs...)
+	sort.Strings(users)
+	res = binary.LittleEndian.AppendUint32(res, uint32(len(users)))
+	for _, u := range users {
+		res = appendLengthPrefixed(res, u)
+	}
+	keys := make([]string, 0, len(f.Metadata))
+	for k := range f.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	res = binary.LittleEndian.AppendUint32(res, uint32(len(keys)))
+	for _, k := range keys {
+		res = appendLengthPrefixed(res, k)
+		res = appendLengthPrefixed(res, f.Metadata[k])
+	}
+	return string(res)
+}
+
+func readFlagCount(data string, minItemSize int) (int, string, error) {
+	if len(data) < 4 {
+		return 0, "", fmt.Errorf("corrupted feature flag")
+	}
+	n := int(binary.LittleEndian.Uint32([]byte(data[:4])))
+	if n > (len(data)-4)/minItemSize {
+		return 0, "", fmt.Errorf("corrupted feature flag")
+	}
+	return n, data[4:], nil
+}
+
+func parseFeatureFlag(data string) (*FeatureFlag, error) {
+	if len(data) < 9 || data[0] > 1 {
+		return nil, fmt.Errorf("corrupted feature flag")
+	}
+	f := &FeatureFlag{Enabled: data[0] == 1, Metadata: make(map[string]string)}
+	f.RolloutPercent = math.Float64frombits(binary.LittleEndian.Uint64([]byte(data[1:9])))
+	if checkRolloutPercent(f.RolloutPercent) != nil {
+		return nil, fmt.Errorf("corrupted feature flag")
+	}
+	n, data, err := readFlagCount(data[9:], 4)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		var u string
+		if u, data, err = readLengthPrefixed(data); err != nil {
+			return nil, err
+		}
+		f.TargetUserIDs = append(f.TargetUserIDs, u)
+	}
+	if n, data, err = readFlagCount(data, 8); err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		var k, v string
+		if k, data, err = readLengthPrefixed(data); err != nil {
+			return nil, err
+		}
+		if v, data, err = readLengthPrefixed(data); err != nil {
+			return nil, err
+		}
+		f.Metadata[k] = v
+	}
+	if len(data) != 0 {
+		return nil, fmt.Errorf("corrupted feature flag")
+	}
+	return f, nil
+}
+
+func validateFeatureFlag(data string) error {
+	_, err := parseFeatureFlag(data)
+	return err
+}
+
+func checkRolloutPercent(percent float64) error {
+	if !(percent >= 0 && percent <= 100) {
+		return fmt.Errorf("rollout percent %v is outside 0..100", percent)
+	}
+	return nil
+}
+
+func NewFeatureFlagEntry(key string, f *FeatureFlag) (*Entry, error) {
+	if err := checkRolloutPercent(f.RolloutPercent); err != nil {
+		return nil, err
+	}
+	return &Entry{key: key, valueType: FEATURE_FLAG_TYPE, value: f.encode()}, nil
+}
+
+func (e *Entry) FeatureFlag() (*FeatureFlag, error) {
+	if e.valueType != FEATURE_FLAG_TYPE {
+		return nil, fmt.Errorf("wrong type of value")
+	}
+	return parseFeatureFlag(e.value)
+}
+
+// rolloutBucket детерміновано кладе користувача в один з 10000 кошиків;
+// ключ прапорця входить у хеш, щоб різні прапорці вмикались різним людям
+func rolloutBucket(flagKey, userID string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(flagKey))
+	h.Write([]byte{0})
+	h.Write([]byte(userID))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	return x % 10000
+}
+
+// IsEnabled reports whether the flag at flagKey is on for userID: target
+// users always get it, the rest by a stable hash of the user ID.
+func IsEnabled(db *Db, flagKey, userID string) (bool, error) {
+	e, err := db.getEntry(flagKey)
+	if err != nil {
+		return false, err
+	}
+	f, err := e.FeatureFlag()
+	if err != nil {
+		return false, err
+	}
+	if !f.Enabled {
+		return false, nil
+	}
+	for _, u := range f.TargetUserIDs {
+		if u == userID {
+			return true, nil
+		}
+	}
+	return float64(rolloutBucket(flagKey, userID)) < f.RolloutPercent*100, nil
+}
+
+func UpdateRollout(db *Db, flagKey string, percent float64) error {
+	if err := checkRolloutPercent(percent); err != nil {
+		return err
+	}
+	_, err := db.updateEntry(flagKey, func(cur *Entry) (*Entry, error) {
+		if cur == nil {
+			return nil, ErrNotFound
+		}
+		f, err := cur.FeatureFlag()
+		if err != nil {
+			return nil, err
+		}
+		f.RolloutPercent = percent
+		return &Entry{valueType: FEATURE_FLAG_TYPE, value: f.encode()}, nil
+	})
+	return err
+}
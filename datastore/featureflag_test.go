@@ -0,0 +1,76 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestFeatureFlag(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := NewFeatureFlagEntry("bad", &FeatureFlag{RolloutPercent: 101}); err == nil {
+		t.Errorf("Expected an error for a rollout above 100%%")
+	}
+	e, err := NewFeatureFlagEntry("checkout", &FeatureFlag{
+		Enabled:        true,
+		RolloutPercent: 50,
+		TargetUserIDs:  []string{"vip"},
+		Metadata:       map[string]string{"owner": "payments"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.putEntry(e); err != nil {
+		t.Fatal(err)
+	}
+
+	countEnabled := func() int {
+		n := 0
+		for i := 0; i < 1000; i++ {
+			on, err := IsEnabled(db, "checkout", fmt.Sprintf("user-%d", i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if on {
+				n++
+			}
+		}
+		return n
+	}
+	if n := countEnabled(); n < 450 || n > 550 {
+		t.Errorf("Expected about 500 of 1000 users enabled at 50%%, got %d", n)
+	}
+	if on, _ := IsEnabled(db, "checkout", "user-7"); on != (rolloutBucket("checkout", "user-7") < 5000) {
+		t.Errorf("Bad rollout decision for user-7")
+	}
+
+	if err := UpdateRollout(db, "checkout", 0); err != nil {
+		t.Fatal(err)
+	}
+	if n := countEnabled(); n != 0 {
+		t.Errorf("Expected no users enabled at 0%%, got %d", n)
+	}
+	if on, err := IsEnabled(db, "checkout", "vip"); err != nil || !on {
+		t.Errorf("Expected the target user to stay enabled, got %v, %v", on, err)
+	}
+
+	stored, _ := db.getEntry("checkout")
+	f, err := stored.FeatureFlag()
+	if err != nil || f.Metadata["owner"] != "payments" || f.RolloutPercent != 0 {
+		t.Errorf("Bad feature flag after UpdateRollout: %+v, %v", f, err)
+	}
+	if err := UpdateRollout(db, "missing", 10); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
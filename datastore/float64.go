@@ -0,0 +1,44 @@
+package datastore
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// float64 зберігаємо текстом strconv.FormatFloat(v, 'g', -1, 64): найкоротший
+// запис, що читається назад у ті самі біти
+func formatFloat64(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func validateFloat64(value string) error {
+	if _, err := strconv.ParseFloat(value, 64); err != nil {
+		return fmt.Errorf("bad float64 %q", value)
+	}
+	return nil
+}
+
+// NewFloat64Entry keeps the exact bits of v, except NaN payloads, which do
+// not survive the text form.
+func NewFloat64Entry(key string, v float64) *Entry {
+	return &Entry{key: key, valueType: FLOAT64_TYPE, value: formatFloat64(v)}
+}
+
+func (e *Entry) GetFloat64() (float64, error) {
+	if e.valueType != FLOAT64_TYPE {
+		return 0, fmt.Errorf("wrong type of value")
+	}
+	return strconv.ParseFloat(e.value, 64)
+}
+
+func (db *Db) PutFloat64(key string, v float64) error {
+	return db.putEntry(NewFloat64Entry(key, v))
+}
+
+func (db *Db) GetFloat64(key string) (float64, error) {
+	e, err := db.getEntry(key)
+	if err != nil {
+		return 0, err
+	}
+	return e.GetFloat64()
+}
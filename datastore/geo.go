@@ -0,0 +1,142 @@
+package datastore
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// geoOperator зберігає широту й довготу як два float64 (16 байт);
+// у Entry.value координати лежать як "широта,довгота"
+type geoOperator struct{}
+
+// середній радіус Землі
+const earthRadiusMeters = 6371000
+
+func formatGeo(lat, lon float64) string {
+	return strconv.FormatFloat(lat, 'g', -1, 64) + "," + strconv.FormatFloat(lon, 'g', -1, 64)
+}
+
+func parseGeo(s string) (float64, float64, error) {
+	latText, lonText, ok := strings.Cut(s, ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("bad geo point %q", s)
+	}
+	lat, err := strconv.ParseFloat(latText, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("bad geo point %q: %v", s, err)
+	}
+	lon, err := strconv.ParseFloat(lonText, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("bad geo point %q: %v", s, err)
+	}
+	//NaN не проходить жодну з перевірок
+	if !(lat >= -90 && lat <= 90) || !(lon >= -180 && lon <= 180) {
+		return 0, 0, fmt.Errorf("geo point %q out of range", s)
+	}
+	return lat, lon, nil
+}
+
+func (s geoOperator) Encode(e *Entry, dst []byte) []byte {
+	lat, lon, err := parseGeo(e.value)
+	if err != nil {
+		panic(err)
+	}
+	res, offset := encodeKeyInto(e, 12, dst)
+	res[offset] = GEO_TYPE
+	binary.LittleEndian.PutUint64(res[offset+TYPE_SIZE:], math.Float64bits(lat))
+	binary.LittleEndian.PutUint64(res[offset+TYPE_SIZE+8:], math.Float64bits(lon))
+	return res
+}
+
+func (s geoOperator) EncodeInto(e *Entry, dst []byte) ([]byte, error) {
+	lat, lon, err := parseGeo(e.value)
+	if err != nil {
+		return dst, err
+	}
+	dst = appendKey(dst, e, 12)
+	dst = append(dst, GEO_TYPE)
+	dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(lat))
+	return binary.LittleEndian.AppendUint64(dst, math.Float64bits(lon)), nil
+}
+
+func decodeGeo(data []byte) string {
+	lat := math.Float64frombits(binary.LittleEndian.Uint64(data))
+	lon := math.Float64frombits(binary.LittleEndian.Uint64(data[8:]))
+	return formatGeo(lat, lon)
+}
+
+func (s geoOperator) Decode(input []byte, e *Entry) {
+	kl := len(e.key)
+	e.value = decodeGeo(input[kl+TYPE_SIZE+8:])
+}
+
+func (s geoOperator) Read(in *bufio.Reader) (string, error) {
+	data, err := in.Peek(16)
+	if err != nil {
+		return "", err
+	}
+	return decodeGeo(data), nil
+}
+
+func NewGeoEntry(key string, lat, lon float64) *Entry {
+	return &Entry{key: key, valueType: GEO_TYPE, value: formatGeo(lat, lon)}
+}
+
+func (e *Entry) GetGeo() (lat, lon float64, err error) {
+	if e.valueType != GEO_TYPE {
+		return 0, 0, fmt.Errorf("wrong type of value")
+	}
+	return parseGeo(e.value)
+}
+
+// GeoDistanceMeters returns the great-circle distance between two geo
+// entries using the haversine formula on a spherical Earth.
+func GeoDistanceMeters(a, b *Entry) (float64, error) {
+	lat1, lon1, err := a.GetGeo()
+	if err != nil {
+		return 0, err
+	}
+	lat2, lon2, err := b.GetGeo()
+	if err != nil {
+		return 0, err
+	}
+	phi1, phi2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+	h := math.Sin(dPhi/2)*math.Sin(dPhi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h))), nil
+}
+
+// GeoRangeQuery returns every geo entry within radiusMeters of the point
+// stored at centerKey, the center itself included.
+func GeoRangeQuery(db *Db, centerKey string, radiusMeters float64) ([]*Entry, error) {
+	center, err := db.getEntry(centerKey)
+	if err != nil {
+		return nil, err
+	}
+	if center.valueType != GEO_TYPE {
+		return nil, fmt.Errorf("wrong type of value")
+	}
+	entries, err := db.entries()
+	if err != nil {
+		return nil, err
+	}
+	var res []*Entry
+	for _, e := range entries {
+		if e.valueType != GEO_TYPE {
+			continue
+		}
+		d, err := GeoDistanceMeters(center, e)
+		if err != nil {
+			return nil, err
+		}
+		if d <= radiusMeters {
+			res = append(res, e)
+		}
+	}
+	return res, nil
+}
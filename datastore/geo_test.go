@@ -0,0 +1,75 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"testing"
+)
+
+func TestGeo(t *testing.T) {
+	kyiv := NewGeoEntry("kyiv", 50.4501, 30.5234)
+	var decoded Entry
+	decoded.Decode(kyiv.Encode())
+	if lat, lon, err := decoded.GetGeo(); err != nil || lat != 50.4501 || lon != 30.5234 {
+		t.Errorf("Bad geo round trip: %v, %v, %v", lat, lon, err)
+	}
+	for _, bad := range []string{"", "50.45", "x,1", "91,0", "0,181", "NaN,0"} {
+		if _, err := EncodeInto(&Entry{key: "k", valueType: GEO_TYPE, value: bad}, nil); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+
+	//відстані між містами за великим колом, ±1 км
+	paris := NewGeoEntry("paris", 48.8566, 2.3522)
+	london := NewGeoEntry("london", 51.5074, -0.1278)
+	lviv := NewGeoEntry("lviv", 49.8397, 24.0297)
+	for _, c := range []struct {
+		a, b   *Entry
+		meters float64
+	}{
+		{paris, london, 343500},
+		{kyiv, lviv, 467900},
+		{kyiv, kyiv, 0},
+	} {
+		d, err := GeoDistanceMeters(c.a, c.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(d-c.meters) > 1000 {
+			t.Errorf("Bad distance %s-%s: expected about %.0f, got %.0f", c.a.key, c.b.key, c.meters, d)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, e := range []*Entry{kyiv, lviv, paris, london, {key: "note", valueType: STRING_TYPE, value: "50,30"}} {
+		if err := db.putEntry(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	res, err := GeoRangeQuery(db, "kyiv", 500000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, e := range res {
+		keys = append(keys, e.key)
+	}
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "kyiv" || keys[1] != "lviv" {
+		t.Errorf("Expected kyiv and lviv within 500 km, got %v", keys)
+	}
+	if _, err := GeoRangeQuery(db, "note", 1); err == nil {
+		t.Errorf("Expected an error for a non-geo center")
+	}
+}
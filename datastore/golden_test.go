@@ -0,0 +1,154 @@
+package datastore
+
+import (
+	"bytes"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata/golden")
+
+const goldenDir = "testdata/golden"
+
+// goldenEntries фіксує формат кожного оператора; значення з довжиною
+// MaxUint32 у файл не покласти, тож межа u32 перевіряється на int64
+func goldenEntries() map[string]*Entry {
+	annotated := &Entry{key: "key", valueType: STRING_TYPE, value: "value"}
+	annotated.Annotate("ttl.hard", "1700000000000000000")
+	document, _ := NewDocumentEntry("doc", map[string]interface{}{"a": []interface{}{1, "b", true, nil}})
+	clock, _ := NewVectorClockEntry("clock", map[string]uint64{"a": 1, "b": math.MaxUint64})
+	orset := newORSet()
+	orset.add("x", "tag-1")
+	orset.add("y", "tag-2")
+	orset.remove("y")
+	lwwmap := &LWWMap{fields: map[string]lwwRegister{"title": {"hello", 1700000000}}}
+	pn := &PNCounter{positive: map[string]uint64{"a": 5}, negative: map[string]uint64{"b": 2}}
+	pnValue, _ := pn.encode()
+	bitset := NewBitsetEntry("flags", 12)
+	bitset.SetBit(0, true)
+	bitset.SetBit(11, true)
+	hist := NewHistogramEntry("latency", []float64{0.1, 1})
+	hist.Observe(0.5)
+	hll := NewHLLEntry("visitors")
+	hll.HLLAdd([]byte("a"))
+	roaring := NewRoaringEntry("ids")
+	for _, i := range []uint64{1, 2, 70000} {
+		roaring.RoaringAdd(i)
+	}
+	trie := NewTrieEntry("dict")
+	for _, word := range []string{"to", "tea", "ten", "i"} {
+		trie.TrieInsert(word, strings.ToUpper(word))
+	}
+	flag, _ := NewFeatureFlagEntry("checkout", &FeatureFlag{Enabled: true, RolloutPercent: 12.5, TargetUserIDs: []string{"u2", "u1"}, Metadata: map[string]string{"owner": "payments"}})
+	board := newSortedSet()
+	for member, score := range map[string]float64{"alice": 10, "bob": -2.5, "carol": 10} {
+		board.add(member, score)
+	}
+
+	return map[string]*Entry{
+		"string":           {key: "key", valueType: STRING_TYPE, value: "value"},
+		"string_empty_key": {key: "", valueType: STRING_TYPE, value: "value"},
+		"string_empty":     {key: "key", valueType: STRING_TYPE, value: ""},
+		"string_all_empty": {key: "", valueType: STRING_TYPE, value: ""},
+		"string_annotated": annotated,
+		"int64_varint":     {key: "n", valueType: INT64_TYPE, value: "-42"},
+		"int64_max_uint32": {key: "n", valueType: INT64_TYPE, value: strconv.FormatInt(math.MaxUint32, 10)},
+		"int64_above_u32":  {key: "n", valueType: INT64_TYPE, value: strconv.FormatInt(math.MaxUint32+1, 10)},
+		"int64_min":        {key: "n", valueType: INT64_TYPE, value: strconv.FormatInt(math.MinInt64, 10)},
+		"int64_empty_key":  {key: "", valueType: INT64_TYPE, value: "0"},
+		"diff":             {key: "key", valueType: DIFF_STRING_TYPE, value: "\x00\x03"},
+		"document":         document,
+		"soft_deleted":     {key: "key", valueType: SOFT_DELETED_TYPE, value: "value"},
+		"tombstone":        {key: "key", valueType: TOMBSTONE_TYPE},
+		"vclock":           clock,
+		"lamport_max":      {key: "clock", valueType: LAMPORT_TYPE, value: strconv.FormatUint(math.MaxUint64, 10)},
+		"gset":             {key: "set", valueType: GSET_TYPE, value: encodeStringSet([]string{"b", "", "a"})},
+		"orset":            {key: "set", valueType: ORSET_TYPE, value: orset.encode()},
+		"tpset":            {key: "set", valueType: TPSET_TYPE, value: (&TwoPSet{adds: []string{"a", "b"}, removes: []string{"a"}}).encode()},
+		"lwwmap":           {key: "map", valueType: LWWMAP_TYPE, value: lwwmap.encode()},
+		"pncounter":        {key: "counter", valueType: PNCOUNTER_TYPE, value: pnValue},
+		"deltaint64":       {key: "ts:1", valueType: DELTA_INT64_TYPE, value: encodeInt64Series([]string{"ts:1", "ts:2", "ts:3"}, []int64{1000, 1001, 990})},
+		"rlestring":        {key: "s:1", valueType: RLE_STRING_TYPE, value: encodeRLERun([]string{"s:1", "s:2", "s:3"}, "OK")},
+		"dictstring":       {key: "country", valueType: DICT_STRING_TYPE, value: "65535"},
+		"decimal":          {key: "price", valueType: DECIMAL_TYPE, value: "-123.45"},
+		"uuid":             {key: "id", valueType: UUID_TYPE, value: "123e4567-e89b-12d3-a456-426614174000"},
+		"timestamp":        {key: "created", valueType: TIMESTAMP_TYPE, value: "2024-05-01T12:30:00.123456789Z"},
+		"duration":         {key: "ttl", valueType: DURATION_TYPE, value: "1h30m0s"},
+		"geo":              {key: "office", valueType: GEO_TYPE, value: "50.4501,30.5234"},
+		"ipaddr":           {key: "gateway", valueType: IPADDR_TYPE, value: "2001:db8::1"},
+		"bitset":           bitset,
+		"rational":         {key: "ratio", valueType: RATIONAL_TYPE, value: "-1/3"},
+		"complex":          {key: "z", valueType: COMPLEX_TYPE, value: "(1.5-2.25i)"},
+		"matrix":           {key: "m", valueType: MATRIX_TYPE, value: "1 0.5; -2 3"},
+		"histogram":        hist,
+		"hll":              hll,
+		"roaring":          roaring,
+		"linkednode":       {key: "task:2", valueType: LINKED_NODE_TYPE, value: encodeLinkedNode("send report", "task:3", "task:1")},
+		"graphnode":        {key: "user:1", valueType: GRAPH_NODE_TYPE, value: (&GraphNode{Value: "alice", Neighbors: []string{"user:2", "user:3"}}).encode()},
+		"trie":             trie,
+		"timeseries":       {key: "cpu", valueType: TIME_SERIES_TYPE, value: encodeTimeSeries([]int64{1700000000, 1700000010, 1700000020, 1700000031}, []float64{0.5, 0.5, 0.75, -12})},
+		"eventlog":         {key: "account:1", valueType: EVENT_LOG_TYPE, value: encodeEventLog([]*Event{{1700000000000000000, "Deposit", "100"}, {1700000001000000000, "Withdraw", "30"}})},
+		"dagnode":          {key: "commit", valueType: DAG_NODE_TYPE, value: (&DagNode{ContentHash: "ab12", Parents: []string{"cd34", "ef56"}, Author: "alice", Message: "merge", Timestamp: 1700000000000000000}).encode()},
+		"websession":       {key: "websession:0123", valueType: SESSION_TYPE, value: (&WebSession{ID: "0123", UserID: "user:1", Data: map[string]string{"cart": "3", "lang": "uk"}, CreatedAt: 1, LastSeenAt: 2, ExpiresAt: 3}).encode()},
+		"sortedset":        {key: "board", valueType: SORTEDSET_TYPE, value: board.encode()},
+		"subscription":     {key: "sub:orders:billing", valueType: SUBSCRIPTION_TYPE, value: "42"},
+		"ratebucket":       {key: "limit:api", valueType: RATE_BUCKET_TYPE, value: (&rateBucket{tokens: 7.5, lastRefillNs: 1700000000000000000, ratePerSec: 100, burst: 10}).encode()},
+		"featureflag":      flag,
+		"circuit":          {key: "breaker:payments", valueType: CIRCUIT_STATE_TYPE, value: (&storedCircuit{state: CIRCUIT_OPEN, failureCount: 5, lastFailureNs: 1700000000000000000, halfOpenAttempts: 1}).encode()},
+		"float64":          {key: "sensor:temp", valueType: FLOAT64_TYPE, value: formatFloat64(21.5)},
+		"service":          {key: "service:api/i-1", valueType: SERVICE_TYPE, value: encodeServiceInstance("10.0.0.1:8080", map[string]string{"zone": "a", "version": "2"})},
+	}
+}
+
+func TestGenerateGolden(t *testing.T) {
+	if !*updateGolden {
+		t.Skip("run with -update to rewrite golden files")
+	}
+	if err := os.MkdirAll(goldenDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, e := range goldenEntries() {
+		data, err := EncodeInto(e, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(goldenDir, name+".bin"), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestVerifyGolden(t *testing.T) {
+	entries := goldenEntries()
+	files, err := filepath.Glob(filepath.Join(goldenDir, "*.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(entries) {
+		t.Errorf("Expected %d golden files, found %d; run with -update after adding a case", len(entries), len(files))
+	}
+	for name, e := range entries {
+		golden, err := os.ReadFile(filepath.Join(goldenDir, name+".bin"))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		data, err := EncodeInto(e, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(data, golden) {
+			t.Errorf("%s: encoding changed\n got: % x\nwant: % x", name, data, golden)
+		}
+		// старі файли мають і далі читатися
+		decoded, err := DecodeMany(golden)
+		if err != nil || len(decoded) != 1 || *decoded[0] != *e {
+			t.Errorf("%s: golden file no longer decodes to the entry: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package datastore
+
+import "fmt"
+
+// Вузол графа зберігається як рядки з префіксом довжини: значення вузла,
+// а за ним ключі сусідів у порядку додавання ребер.
+
+// GraphNode is a graph vertex with its outgoing edges.
+type GraphNode struct {
+	Value     string
+	Neighbors []string
+}
+
+func (n *GraphNode) encode() string {
+	res := appendLengthPrefixed(nil, n.Value)
+	for _, key := range n.Neighbors {
+		res = appendLengthPrefixed(res, key)
+	}
+	return string(res)
+}
+
+func parseGraphNode(data string) (*GraphNode, error) {
+	value, rest, err := readLengthPrefixed(data)
+	if err != nil {
+		return nil, err
+	}
+	n := &GraphNode{Value: value}
+	for rest != "" {
+		var key string
+		if key, rest, err = readLengthPrefixed(rest); err != nil {
+			return nil, err
+		}
+		n.Neighbors = append(n.Neighbors, key)
+	}
+	return n, nil
+}
+
+func validateGraphNode(data string) error {
+	_, err := parseGraphNode(data)
+	return err
+}
+
+func (e *Entry) GraphNode() (*GraphNode, error) {
+	if e.valueType != GRAPH_NODE_TYPE {
+		return nil, fmt.Errorf("wrong type of value")
+	}
+	return parseGraphNode(e.value)
+}
+
+// GetGraphNode returns the node at key; a key that only appears as an edge
+// target is a node with no value and no edges.
+func GetGraphNode(db *Db, key string) (*GraphNode, error) {
+	e, err := db.getEntry(key)
+	if err == ErrNotFound {
+		return &GraphNode{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e.GraphNode()
+}
+
+func updateGraphNode(db *Db, key string, fn func(n *GraphNode) bool) error {
+	_, err := db.updateEntry(key, func(cur *Entry) (*Entry, error) {
+		n := &GraphNode{}
+		if cur != nil {
+			var err error
+			if n, err = cur.GraphNode(); err != nil {
+				return nil, err
+			}
+		}
+		//нічого не змінилося - не пишемо
+		if !fn(n) && cur != nil {
+			return nil, nil
+		}
+		return &Entry{valueType: GRAPH_NODE_TYPE, value: n.encode()}, nil
+	})
+	return err
+}
+
+// SetGraphNodeValue sets the value of a node, keeping its edges.
+func SetGraphNodeValue(db *Db, key, value string) error {
+	return updateGraphNode(db, key, func(n *GraphNode) bool {
+		changed := n.Value != value
+		n.Value = value
+		return changed
+	})
+}
+
+// AddEdge adds a directed edge, creating the from node if needed; adding an
+// existing edge is a no-op.
+func AddEdge(db *Db, from, to string) error {
+	return updateGraphNode(db, from, func(n *GraphNode) bool {
+		for _, key := range n.Neighbors {
+			if key == to {
+				return false
+			}
+		}
+		n.Neighbors = append(n.Neighbors, to)
+		return true
+	})
+}
+
+func RemoveEdge(db *Db, from, to string) error {
+	if _, err := db.getEntry(from); err != nil {
+		return err
+	}
+	return updateGraphNode(db, from, func(n *GraphNode) bool {
+		for i, key := range n.Neighbors {
+			if key == to {
+				n.Neighbors = append(n.Neighbors[:i], n.Neighbors[i+1:]...)
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// BFS returns the keys reachable from start in breadth-first order, start
+// included, going at most maxDepth edges away; a negative maxDepth means no
+// limit.
+func BFS(db *Db, start string, maxDepth int) ([]string, error) {
+	res := []string{start}
+	seen := map[string]bool{start: true}
+	for depth, level := 0, []string{start}; len(level) > 0 && (maxDepth < 0 || depth < maxDepth); depth++ {
+		var next []string
+		for _, key := range level {
+			n, err := GetGraphNode(db, key)
+			if err != nil {
+				return nil, err
+			}
+			for _, neighbor := range n.Neighbors {
+				if !seen[neighbor] {
+					seen[neighbor] = true
+					next = append(next, neighbor)
+				}
+			}
+		}
+		res = append(res, next...)
+		level = next
+	}
+	return res, nil
+}
+
+// DFS returns the keys reachable from start in depth-first preorder,
+// visiting neighbours in edge order.
+func DFS(db *Db, start string) ([]string, error) {
+	var res []string
+	seen := make(map[string]bool)
+	stack := []string{start}
+	for len(stack) > 0 {
+		key := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		res = append(res, key)
+		n, err := GetGraphNode(db, key)
+		if err != nil {
+			return nil, err
+		}
+		//у зворотному порядку, щоб перший сусід вийшов зі стеку першим
+		for i := len(n.Neighbors) - 1; i >= 0; i-- {
+			if !seen[n.Neighbors[i]] {
+				stack = append(stack, n.Neighbors[i])
+			}
+		}
+	}
+	return res, nil
+}
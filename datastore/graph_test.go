@@ -0,0 +1,71 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestGraph(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	//двійкове дерево 1..15 з кількома зворотними ребрами і окремою компонентою 16..20
+	for i := 1; i <= 7; i++ {
+		for _, child := range []int{2 * i, 2*i + 1} {
+			if err := AddEdge(db, fmt.Sprint(i), fmt.Sprint(child)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for _, edge := range [][2]int{{15, 1}, {9, 3}, {16, 17}, {17, 18}, {18, 19}, {19, 20}, {20, 16}, {2, 2}} {
+		if err := AddEdge(db, fmt.Sprint(edge[0]), fmt.Sprint(edge[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := AddEdge(db, "1", "2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetGraphNodeValue(db, "1", "root"); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := GetGraphNode(db, "1"); err != nil || n.Value != "root" || fmt.Sprint(n.Neighbors) != "[2 3]" {
+		t.Errorf("Bad node 1: %+v, %v", n, err)
+	}
+
+	order, err := BFS(db, "1", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(order) != "[1 2 3 4 5 6 7 8 9 10 11 12 13 14 15]" {
+		t.Errorf("Bad BFS order: %v", order)
+	}
+	if order, _ := BFS(db, "1", 2); fmt.Sprint(order) != "[1 2 3 4 5 6 7]" {
+		t.Errorf("Bad BFS order with depth 2: %v", order)
+	}
+	if order, _ := DFS(db, "1"); fmt.Sprint(order) != "[1 2 4 8 9 3 6 12 13 7 14 15 5 10 11]" {
+		t.Errorf("Bad DFS order: %v", order)
+	}
+	if order, _ := DFS(db, "16"); len(order) != 5 {
+		t.Errorf("Expected 5 nodes in the cycle, got %v", order)
+	}
+
+	if err := RemoveEdge(db, "1", "3"); err != nil {
+		t.Fatal(err)
+	}
+	if order, _ := BFS(db, "1", -1); fmt.Sprint(order) != "[1 2 4 5 8 9 10 11 3 6 7 12 13 14 15]" {
+		t.Errorf("Bad BFS order after removing 1->3: %v", order)
+	}
+	if err := RemoveEdge(db, "missing", "1"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
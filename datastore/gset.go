@@ -0,0 +1,118 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// Множина зберігається як відсортовані унікальні елементи [len u32][елемент].
+
+func appendLengthPrefixed(dst []byte, s string) []byte {
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(len(s)))
+	return append(dst, s...)
+}
+
+func readLengthPrefixed(data string) (string, string, error) {
+	if len(data) < 4 {
+		return "", "", fmt.Errorf("corrupted length prefix")
+	}
+	n := int(binary.LittleEndian.Uint32([]byte(data[:4])))
+	if n > len(data)-4 {
+		return "", "", fmt.Errorf("corrupted length prefix")
+	}
+	return data[4 : 4+n], data[4+n:], nil
+}
+
+func encodeStringSet(elems []string) string {
+	sorted := append([]string(nil), elems...)
+	sort.Strings(sorted)
+	var res []byte
+	for i, elem := range sorted {
+		if i > 0 && elem == sorted[i-1] {
+			continue
+		}
+		res = appendLengthPrefixed(res, elem)
+	}
+	return string(res)
+}
+
+func parseStringSet(data string) ([]string, error) {
+	var elems []string
+	for len(data) > 0 {
+		elem, rest, err := readLengthPrefixed(data)
+		if err != nil {
+			return nil, err
+		}
+		if len(elems) > 0 && elem <= elems[len(elems)-1] {
+			return nil, fmt.Errorf("set elements out of order")
+		}
+		elems = append(elems, elem)
+		data = rest
+	}
+	return elems, nil
+}
+
+func validateStringSet(data string) error {
+	_, err := parseStringSet(data)
+	return err
+}
+
+func setContains(elems []string, elem string) bool {
+	i := sort.SearchStrings(elems, elem)
+	return i < len(elems) && elems[i] == elem
+}
+
+func (db *Db) typedSet(key string, valueType byte) ([]string, error) {
+	e, err := db.getEntry(key)
+	if err == ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if e.valueType != valueType {
+		return nil, fmt.Errorf("wrong type of value")
+	}
+	return parseStringSet(e.value)
+}
+
+// GSetMerge adds every element of other to the grow-only set at key.
+func GSetMerge(db *Db, key string, other []string) error {
+	_, err := db.updateEntry(key, func(cur *Entry) (*Entry, error) {
+		var elems []string
+		if cur != nil {
+			if cur.valueType != GSET_TYPE {
+				return nil, fmt.Errorf("wrong type of value")
+			}
+			var err error
+			if elems, err = parseStringSet(cur.value); err != nil {
+				return nil, err
+			}
+		}
+		value := encodeStringSet(append(elems, other...))
+		//нічого нового - не пишемо
+		if cur != nil && value == cur.value {
+			return nil, nil
+		}
+		return &Entry{valueType: GSET_TYPE, value: value}, nil
+	})
+	return err
+}
+
+func GSetAdd(db *Db, key string, elem string) error {
+	return GSetMerge(db, key, []string{elem})
+}
+
+func GSetContains(db *Db, key string, elem string) (bool, error) {
+	elems, err := db.typedSet(key, GSET_TYPE)
+	if err != nil {
+		return false, err
+	}
+	return setContains(elems, elem), nil
+}
+
+// GSetElements returns the set in sorted order; a missing key is an empty set.
+func GSetElements(db *Db, key string) ([]string, error) {
+	return db.typedSet(key, GSET_TYPE)
+}
@@ -0,0 +1,88 @@
+package datastore
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestGSet_MergeReplicas(t *testing.T) {
+	a := newTestDb(t)
+	b := newTestDb(t)
+
+	// репліки приймають записи незалежно одна від одної
+	for _, elem := range []string{"x", "y", "shared"} {
+		if err := GSetAdd(a, "set", elem); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, elem := range []string{"z", "shared", ""} {
+		if err := GSetAdd(b, "set", elem); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fromA, err := GSetElements(a, "set")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromB, err := GSetElements(b, "set")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := GSetMerge(a, "set", fromB); err != nil {
+		t.Fatal(err)
+	}
+	if err := GSetMerge(b, "set", fromA); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"", "shared", "x", "y", "z"}
+	for _, db := range []*Db{a, b} {
+		elems, err := GSetElements(db, "set")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(elems, expected) {
+			t.Errorf("Expected union %q, got %q", expected, elems)
+		}
+	}
+	if ok, err := GSetContains(a, "set", "z"); err != nil || !ok {
+		t.Errorf("Expected merged element to be present, got %v, %v", ok, err)
+	}
+	if ok, err := GSetContains(a, "missing", "z"); err != nil || ok {
+		t.Errorf("Expected missing set to be empty, got %v, %v", ok, err)
+	}
+	if err := a.Put("text", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := GSetAdd(a, "text", "x"); err == nil {
+		t.Error("Expected error for non-set value")
+	}
+}
+
+func TestGSet_ConcurrentAdds(t *testing.T) {
+	db := newTestDb(t)
+	var wg sync.WaitGroup
+	for _, elem := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		wg.Add(1)
+		go func(elem string) {
+			defer wg.Done()
+			if err := GSetAdd(db, "set", elem); err != nil {
+				t.Error(err)
+			}
+		}(elem)
+	}
+	wg.Wait()
+	elems, err := GSetElements(db, "set")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(elems) != 8 {
+		t.Errorf("Expected all 8 concurrent adds to survive, got %q", elems)
+	}
+
+	if _, err := EncodeInto(&Entry{key: "set", valueType: GSET_TYPE, value: encodeStringSet([]string{"b"}) + encodeStringSet([]string{"a"})}, nil); err == nil {
+		t.Error("Expected error for unsorted set")
+	}
+}
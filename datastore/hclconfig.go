@@ -0,0 +1,268 @@
+package datastore
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Бібліотеки HCL у дереві теж немає. Читаємо блоки
+//
+//	entry "ключ" {
+//	  type  = "string"
+//	  value = "значення"
+//	}
+//
+// з коментарями #, // і /* */. Значення - рядки в лапках або числа;
+// інтерполяції ${...}, heredoc і вирази не підтримуються.
+
+type hclToken struct {
+	kind byte //'i' ідентифікатор, 's' рядок, 'n' число, '\n', '{', '}', '='
+	text string
+	line int
+}
+
+func hclIdentByte(c byte, first bool) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || !first && (c >= '0' && c <= '9' || c == '-')
+}
+
+func tokenizeHCL(src string) ([]hclToken, error) {
+	var tokens []hclToken
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '\n':
+			tokens = append(tokens, hclToken{kind: '\n', line: line})
+			line++
+			i++
+		case c == '#' || strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+		case c == '{' || c == '}' || c == '=':
+			tokens = append(tokens, hclToken{kind: c, line: line})
+			i++
+		case c == '"':
+			s, n, err := readHCLString(src[i:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			tokens = append(tokens, hclToken{kind: 's', text: s, line: line})
+			i += n
+		case c == '-' || c >= '0' && c <= '9':
+			start := i
+			for i++; i < len(src) && strings.IndexByte("0123456789.eE+-", src[i]) >= 0; i++ {
+			}
+			text := src[start:i]
+			if _, err := strconv.ParseFloat(text, 64); err != nil {
+				return nil, fmt.Errorf("line %d: bad number %q", line, text)
+			}
+			tokens = append(tokens, hclToken{kind: 'n', text: text, line: line})
+		case hclIdentByte(c, true):
+			start := i
+			for i++; i < len(src) && hclIdentByte(src[i], false); i++ {
+			}
+			tokens = append(tokens, hclToken{kind: 'i', text: src[start:i], line: line})
+		default:
+			return nil, fmt.Errorf("line %d: unexpected character %q", line, c)
+		}
+	}
+	return tokens, nil
+}
+
+// readHCLString читає рядок у лапках на початку s і повертає його разом
+// з кількістю прочитаних байтів; $${ і %%{ - екрановані ${ і %{
+func readHCLString(s string) (string, int, error) {
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			return sb.String(), i + 1, nil
+		case c == '\n':
+			return "", 0, fmt.Errorf("unterminated string")
+		case strings.HasPrefix(s[i:], "$${") || strings.HasPrefix(s[i:], "%%{"):
+			sb.WriteString(s[i+1 : i+3])
+			i += 2
+		case strings.HasPrefix(s[i:], "${") || strings.HasPrefix(s[i:], "%{"):
+			return "", 0, fmt.Errorf("template expressions are not supported")
+		case c != '\\':
+			sb.WriteByte(c)
+		case i+1 == len(s):
+			return "", 0, fmt.Errorf("unterminated string")
+		default:
+			i++
+			switch s[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case '"', '\\':
+				sb.WriteByte(s[i])
+			case 'u', 'U':
+				size := 4
+				if s[i] == 'U' {
+					size = 8
+				}
+				if i+size >= len(s) {
+					return "", 0, fmt.Errorf("bad unicode escape")
+				}
+				code, err := strconv.ParseUint(s[i+1:i+1+size], 16, 32)
+				if err != nil || !utf8.ValidRune(rune(code)) {
+					return "", 0, fmt.Errorf("bad unicode escape")
+				}
+				sb.WriteRune(rune(code))
+				i += size
+			default:
+				return "", 0, fmt.Errorf("bad escape \\%c", s[i])
+			}
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+func hclQuote(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&sb, `\u%04X`, r)
+		case (r == '$' || r == '%') && strings.HasPrefix(s[i+1:], "{"):
+			sb.WriteRune(r)
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+func parseHCLEntries(src string) ([]exportRecord, error) {
+	tokens, err := tokenizeHCL(src)
+	if err != nil {
+		return nil, err
+	}
+	pos := 0
+	next := func() hclToken {
+		if pos == len(tokens) {
+			line := 1
+			if len(tokens) != 0 {
+				line = tokens[len(tokens)-1].line
+			}
+			return hclToken{kind: 0, line: line}
+		}
+		pos++
+		return tokens[pos-1]
+	}
+	skipNewlines := func() {
+		for pos < len(tokens) && tokens[pos].kind == '\n' {
+			pos++
+		}
+	}
+
+	var records []exportRecord
+	for skipNewlines(); pos < len(tokens); skipNewlines() {
+		t := next()
+		if t.kind != 'i' || t.text != "entry" {
+			return nil, fmt.Errorf("line %d: expected an entry block", t.line)
+		}
+		label, open := next(), next()
+		if label.kind != 's' || open.kind != '{' {
+			return nil, fmt.Errorf("line %d: expected entry \"key\" {", t.line)
+		}
+		record := exportRecord{Key: label.text}
+		seen := make(map[string]bool)
+		for skipNewlines(); ; skipNewlines() {
+			name := next()
+			if name.kind == '}' {
+				break
+			}
+			eq, value := next(), next()
+			if name.kind != 'i' || eq.kind != '=' || (value.kind != 's' && value.kind != 'n') {
+				return nil, fmt.Errorf("line %d: expected name = \"value\"", name.line)
+			}
+			if pos < len(tokens) && tokens[pos].kind != '\n' && tokens[pos].kind != '}' {
+				return nil, fmt.Errorf("line %d: expected a newline after %s", name.line, name.text)
+			}
+			if name.text == "key" || seen[name.text] {
+				return nil, fmt.Errorf("line %d: duplicate %s of %q", name.line, name.text, record.Key)
+			}
+			seen[name.text] = true
+			if err := setRecordField(&record, name.text, value.text); err != nil {
+				return nil, fmt.Errorf("line %d: %v", name.line, err)
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// ImportHCL writes the entry blocks of the HCL file at hclPath into db. Each
+// block is labelled with the key and sets type and value. The whole file is
+// parsed before the first write.
+func ImportHCL(hclPath string, db *Db) error {
+	data, err := os.ReadFile(hclPath)
+	if err != nil {
+		return err
+	}
+	records, err := parseHCLEntries(string(data))
+	if err != nil {
+		return fmt.Errorf("%s: %v", hclPath, err)
+	}
+	for _, record := range records {
+		if err := importRecord(db, record.Key, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportHCL writes every key of db to w as entry blocks, in key order.
+func ExportHCL(db *Db, w io.Writer) error {
+	entries, err := db.entries()
+	if err != nil {
+		return err
+	}
+	for i, e := range entries {
+		if i != 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		field, value := "value", hclQuote(e.value)
+		if !utf8.ValidString(e.value) {
+			field, value = "value_base64", strconv.Quote(base64.StdEncoding.EncodeToString([]byte(e.value)))
+		}
+		if _, err := fmt.Fprintf(w, "entry %s {\n  type = %s\n  %s = %s\n}\n", hclQuote(e.key), hclQuote(e.Type()), field, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package datastore
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testConfigHCL = `# секрети сервісу
+entry "db/host" {
+  type  = "string"
+  value = "10.0.0.5"
+}
+
+entry "db/port" {
+  type  = "int64" // порт як число
+  value = 5432
+}
+
+/* шаблони екрановано,
+   тож вони лишаються текстом */
+entry "db/dsn" {
+  type  = "string"
+  value = "postgres://$${USER}@host/%%{db}\tx"
+}
+entry "db/timeout" {
+  type = "duration"
+  value = "1m0s" }
+`
+
+func TestImportExportHCL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(filepath.Join(dir, "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	file := filepath.Join(dir, "config.hcl")
+	if err := os.WriteFile(file, []byte(testConfigHCL), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportHCL(file, db); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{
+		"db/host":    "10.0.0.5",
+		"db/port":    "5432",
+		"db/dsn":     "postgres://${USER}@host/%{db}\tx",
+		"db/timeout": "1m0s",
+	} {
+		e, err := db.getEntry(key)
+		if err != nil || e.value != want {
+			t.Errorf("Bad value of %s: expected %q, got %v, %v", key, want, e, err)
+		}
+	}
+	if port, err := db.GetInt64("db/port"); err != nil || port != 5432 {
+		t.Errorf("Bad db/port: %d, %v", port, err)
+	}
+
+	var out bytes.Buffer
+	if err := ExportHCL(db, &out); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, out.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	copyDb, err := NewDb(filepath.Join(dir, "copy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer copyDb.Close()
+	if err := ImportHCL(file, copyDb); err != nil {
+		t.Fatalf("Exported HCL does not import: %v\n%s", err, out.String())
+	}
+	want, _ := db.entries()
+	got, _ := copyDb.entries()
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d entries, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if *got[i] != *want[i] {
+			t.Errorf("Bad round trip: expected %v, got %v", want[i], got[i])
+		}
+	}
+
+	for _, bad := range []string{
+		`entry "a" { type = "string" value = "b" }`,
+		`entry "a" { value = "${var.x}" }`,
+		`entry "a" { type = string }`,
+		`resource "a" {}`,
+		`entry "a" { key = "b" }`,
+		"entry \"a\" {\n  type = \"string\"\n",
+	} {
+		if err := os.WriteFile(file, []byte(bad), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := ImportHCL(file, db); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Гістограма зберігається як [кошиків u16][межі f64 × (кошиків-1)][лічильники u64 × кошиків].
+// Кошик i рахує значення <= межі i; останній кошик - усе, що більше за останню межу.
+
+type histogram struct {
+	bounds []float64
+	counts []uint64
+}
+
+func (h *histogram) encode() string {
+	res := binary.LittleEndian.AppendUint16(nil, uint16(len(h.counts)))
+	for _, b := range h.bounds {
+		res = binary.LittleEndian.AppendUint64(res, math.Float64bits(b))
+	}
+	for _, c := range h.counts {
+		res = binary.LittleEndian.AppendUint64(res, c)
+	}
+	return string(res)
+}
+
+func parseHistogram(data string) (*histogram, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("corrupted histogram")
+	}
+	n := int(binary.LittleEndian.Uint16([]byte(data[:2])))
+	if n < 1 || len(data) != 2+8*(2*n-1) {
+		return nil, fmt.Errorf("corrupted histogram")
+	}
+	raw := []byte(data[2:])
+	h := &histogram{bounds: make([]float64, n-1), counts: make([]uint64, n)}
+	for i := range h.bounds {
+		h.bounds[i] = math.Float64frombits(binary.LittleEndian.Uint64(raw[8*i:]))
+		if math.IsNaN(h.bounds[i]) || (i > 0 && h.bounds[i] <= h.bounds[i-1]) {
+			return nil, fmt.Errorf("histogram bounds must be increasing")
+		}
+	}
+	raw = raw[8*(n-1):]
+	for i := range h.counts {
+		h.counts[i] = binary.LittleEndian.Uint64(raw[8*i:])
+	}
+	return h, nil
+}
+
+func validateHistogram(data string) error {
+	_, err := parseHistogram(data)
+	return err
+}
+
+func (e *Entry) histogram() (*histogram, error) {
+	if e.valueType != HISTOGRAM_TYPE {
+		return nil, fmt.Errorf("wrong type of value")
+	}
+	return parseHistogram(e.value)
+}
+
+// NewHistogramEntry returns an empty histogram with one bucket per upper
+// bound plus an overflow bucket. Bounds are sorted and deduplicated; a NaN
+// bound makes the entry fail to encode.
+func NewHistogramEntry(key string, bounds []float64) *Entry {
+	sorted := append([]float64(nil), bounds...)
+	sort.Float64s(sorted)
+	h := &histogram{}
+	for i, b := range sorted {
+		if i == 0 || b != sorted[i-1] {
+			h.bounds = append(h.bounds, b)
+		}
+	}
+	h.counts = make([]uint64, len(h.bounds)+1)
+	return &Entry{key: key, valueType: HISTOGRAM_TYPE, value: h.encode()}
+}
+
+func (e *Entry) Observe(value float64) error {
+	if math.IsNaN(value) {
+		return fmt.Errorf("cannot observe NaN")
+	}
+	h, err := e.histogram()
+	if err != nil {
+		return err
+	}
+	h.counts[sort.SearchFloat64s(h.bounds, value)]++
+	e.value = h.encode()
+	return nil
+}
+
+// GetHistogram returns the upper bounds and the counts; counts has one
+// more element than bounds for values above the last bound.
+func (e *Entry) GetHistogram() (bounds []float64, counts []uint64, err error) {
+	h, err := e.histogram()
+	if err != nil {
+		return nil, nil, err
+	}
+	return h.bounds, h.counts, nil
+}
+
+// MergeHistograms adds up the counts of two histograms with the same
+// bounds; the result keeps the key of a.
+func MergeHistograms(a, b *Entry) (*Entry, error) {
+	x, err := a.histogram()
+	if err != nil {
+		return nil, err
+	}
+	y, err := b.histogram()
+	if err != nil {
+		return nil, err
+	}
+	if len(x.bounds) != len(y.bounds) {
+		return nil, fmt.Errorf("histogram bounds differ")
+	}
+	for i := range x.bounds {
+		if x.bounds[i] != y.bounds[i] {
+			return nil, fmt.Errorf("histogram bounds differ")
+		}
+	}
+	for i := range x.counts {
+		x.counts[i] += y.counts[i]
+	}
+	return &Entry{key: a.key, valueType: HISTOGRAM_TYPE, value: x.encode()}, nil
+}
@@ -0,0 +1,69 @@
+package datastore
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestHistogram(t *testing.T) {
+	e := NewHistogramEntry("latency", []float64{100, 10, 50, 10})
+	rnd := rand.New(rand.NewSource(1))
+	var expected [4]uint64
+	for i := 0; i < 1000; i++ {
+		v := rnd.Float64() * 200
+		switch {
+		case v <= 10:
+			expected[0]++
+		case v <= 50:
+			expected[1]++
+		case v <= 100:
+			expected[2]++
+		default:
+			expected[3]++
+		}
+		if err := e.Observe(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Observe(math.NaN()); err == nil {
+		t.Errorf("Expected an error for NaN")
+	}
+
+	var decoded Entry
+	decoded.Decode(e.Encode())
+	bounds, counts, err := decoded.GetHistogram()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bounds) != 3 || bounds[0] != 10 || bounds[1] != 50 || bounds[2] != 100 {
+		t.Errorf("Bad bounds: %v", bounds)
+	}
+	var total uint64
+	for i, c := range counts {
+		if c != expected[i] {
+			t.Errorf("Bad count in bucket %d: expected %d, got %d", i, expected[i], c)
+		}
+		total += c
+	}
+	if total != 1000 {
+		t.Errorf("Expected 1000 observations, got %d", total)
+	}
+
+	//значення на межі потрапляє в кошик цієї межі
+	other := NewHistogramEntry("latency", []float64{10, 50, 100})
+	other.Observe(10)
+	merged, err := MergeHistograms(e, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, counts, _ := merged.GetHistogram(); counts[0] != expected[0]+1 {
+		t.Errorf("Bad merged count: %d", counts[0])
+	}
+	if _, err := MergeHistograms(e, NewHistogramEntry("x", []float64{1})); err == nil {
+		t.Errorf("Expected an error for different bounds")
+	}
+	if _, err := EncodeInto(NewHistogramEntry("x", []float64{1, math.NaN()}), nil); err == nil {
+		t.Errorf("Expected an error for a NaN bound")
+	}
+}
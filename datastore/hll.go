@@ -0,0 +1,180 @@
+package datastore
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+const (
+	hllPrecision = 14
+	hllRegisters = 1 << hllPrecision
+)
+
+// fnv погано перемішує біти, тому доганяємо фіналізатором з murmur3
+func hllHash(item []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(item)
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+func hllAdd(registers []byte, item []byte) {
+	x := hllHash(item)
+	index := x >> (64 - hllPrecision)
+	rank := byte(bits.LeadingZeros64(x<<hllPrecision|1<<(hllPrecision-1)) + 1)
+	if rank > registers[index] {
+		registers[index] = rank
+	}
+}
+
+func hllEstimate(registers []byte) uint64 {
+	m := float64(len(registers))
+	var sum float64
+	zeros := 0
+	for _, r := range registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && zeros != 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+// HLLSketch returns the HyperLogLog registers of the keys that can be read:
+// deleted, soft-deleted and expired keys are left out.
+func HLLSketch(db *Db) ([]byte, error) {
+	registers := make([]byte, hllRegisters)
+	err := db.scanVersions(func(key string, e *Entry) error {
+		if e != nil && e.valueType != SOFT_DELETED_TYPE {
+			hllAdd(registers, []byte(key))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return registers, nil
+}
+
+func HyperLogLog(db *Db) (uint64, error) {
+	registers, err := HLLSketch(db)
+	if err != nil {
+		return 0, err
+	}
+	return hllEstimate(registers), nil
+}
+
+func HLLMerge(a, b []byte) []byte {
+	if len(a) != len(b) {
+		return nil
+	}
+	res := make([]byte, len(a))
+	for i := range a {
+		res[i] = a[i]
+		if b[i] > res[i] {
+			res[i] = b[i]
+		}
+	}
+	return res
+}
+
+func HLLEstimate(sketch []byte) uint64 {
+	return hllEstimate(sketch)
+}
+
+// HLL_TYPE зберігає регістри скетча як є: hllRegisters байт рангів
+
+func validateHLL(data string) error {
+	if len(data) != hllRegisters {
+		return fmt.Errorf("corrupted hll sketch length %d", len(data))
+	}
+	for i := 0; i < len(data); i++ {
+		if data[i] > 64-hllPrecision+1 {
+			return fmt.Errorf("corrupted hll register %d", i)
+		}
+	}
+	return nil
+}
+
+func (e *Entry) hllRegisters() ([]byte, error) {
+	if e.valueType != HLL_TYPE {
+		return nil, fmt.Errorf("wrong type of value")
+	}
+	if err := validateHLL(e.value); err != nil {
+		return nil, err
+	}
+	return []byte(e.value), nil
+}
+
+func NewHLLEntry(key string) *Entry {
+	return &Entry{key: key, valueType: HLL_TYPE, value: string(make([]byte, hllRegisters))}
+}
+
+// HLLAdd copies the 16 KB sketch on every call; use IncrementalHLL to add
+// many items at once.
+func (e *Entry) HLLAdd(item []byte) error {
+	registers, err := e.hllRegisters()
+	if err != nil {
+		return err
+	}
+	hllAdd(registers, item)
+	e.value = string(registers)
+	return nil
+}
+
+func (e *Entry) HLLEstimate() (uint64, error) {
+	registers, err := e.hllRegisters()
+	if err != nil {
+		return 0, err
+	}
+	return hllEstimate(registers), nil
+}
+
+// MergeHLL returns the union of two sketches under the key of a.
+func MergeHLL(a, b *Entry) (*Entry, error) {
+	x, err := a.hllRegisters()
+	if err != nil {
+		return nil, err
+	}
+	y, err := b.hllRegisters()
+	if err != nil {
+		return nil, err
+	}
+	return &Entry{key: a.key, valueType: HLL_TYPE, value: string(HLLMerge(x, y))}, nil
+}
+
+// IncrementalHLL adds a batch of items to the sketch stored at key, creating
+// it if needed. The sketch is read and written once per batch, and nothing
+// is written if no register changed.
+func IncrementalHLL(db *Db, key string, items [][]byte) error {
+	_, err := db.updateEntry(key, func(cur *Entry) (*Entry, error) {
+		existing := cur != nil
+		if !existing {
+			cur = NewHLLEntry(key)
+		}
+		registers, err := cur.hllRegisters()
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			hllAdd(registers, item)
+		}
+		if existing && string(registers) == cur.value {
+			return nil, nil
+		}
+		return &Entry{valueType: HLL_TYPE, value: string(registers)}, nil
+	})
+	return err
+}
@@ -0,0 +1,160 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHLLEstimate(t *testing.T) {
+	const n = 1000000
+	registers := make([]byte, hllRegisters)
+	for i := 0; i < n; i++ {
+		hllAdd(registers, []byte("key"+strconv.Itoa(i)))
+	}
+	if len(registers) != 16*1024 {
+		t.Errorf("Expected 16 KB of registers, got %d bytes", len(registers))
+	}
+	estimate := HLLEstimate(registers)
+	if diff := math.Abs(float64(estimate)-n) / n; diff > 0.02 {
+		t.Errorf("Estimate %d is %.2f%% away from %d", estimate, diff*100, n)
+	}
+}
+
+func TestHLLMerge(t *testing.T) {
+	a := make([]byte, hllRegisters)
+	b := make([]byte, hllRegisters)
+	for i := 0; i < 20000; i++ {
+		hllAdd(a, []byte("key"+strconv.Itoa(i)))
+		hllAdd(b, []byte("key"+strconv.Itoa(i+10000)))
+	}
+	estimate := HLLEstimate(HLLMerge(a, b))
+	if diff := math.Abs(float64(estimate)-30000) / 30000; diff > 0.02 {
+		t.Errorf("Merged estimate %d is %.2f%% away from 30000", estimate, diff*100)
+	}
+	if HLLMerge(a, b[:10]) != nil {
+		t.Error("Expected nil when merging sketches of different sizes")
+	}
+}
+
+func TestHyperLogLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for i := 0; i < 5000; i++ {
+		if err := db.Put(fmt.Sprintf("key%d", i%1000), "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	estimate, err := HyperLogLog(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := math.Abs(float64(estimate)-1000) / 1000; diff > 0.02 {
+		t.Errorf("Estimate %d is %.2f%% away from 1000", estimate, diff*100)
+	}
+
+	//видалені, м'яко видалені й прострочені ключі не рахуються
+	for i := 0; i < 300; i++ {
+		key := fmt.Sprintf("key%d", i)
+		var err error
+		switch i % 3 {
+		case 0:
+			err = db.putTombstone(key)
+		case 1:
+			err = db.SoftDelete(key, time.Hour)
+		case 2:
+			err = db.PutWithTTL(key, "value", 0, time.Nanosecond)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(time.Millisecond)
+	if estimate, err = HyperLogLog(db); err != nil {
+		t.Fatal(err)
+	}
+	if diff := math.Abs(float64(estimate)-700) / 700; diff > 0.02 {
+		t.Errorf("Estimate %d is %.2f%% away from 700", estimate, diff*100)
+	}
+}
+
+func TestHLLEntry(t *testing.T) {
+	e := NewHLLEntry("visitors")
+	for i := 0; i < 100; i++ {
+		if err := e.HLLAdd([]byte("user" + strconv.Itoa(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var decoded Entry
+	decoded.Decode(e.Encode())
+	if estimate, err := decoded.HLLEstimate(); err != nil || estimate < 98 || estimate > 102 {
+		t.Errorf("Bad estimate for 100 items: %d, %v", estimate, err)
+	}
+	other := NewHLLEntry("other")
+	other.HLLAdd([]byte("user0"))
+	other.HLLAdd([]byte("user1000"))
+	merged, err := MergeHLL(e, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate, _ := merged.HLLEstimate(); estimate < 99 || estimate > 103 {
+		t.Errorf("Bad merged estimate: %d", estimate)
+	}
+	if _, err := EncodeInto(&Entry{key: "k", valueType: HLL_TYPE, value: "short"}, nil); err == nil {
+		t.Errorf("Expected an error for a short sketch")
+	}
+
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	const n = 1000000
+	batch := make([][]byte, 0, 100000)
+	for i := 0; i < n; i++ {
+		batch = append(batch, []byte("item"+strconv.Itoa(i)))
+		if len(batch) == cap(batch) {
+			if err := IncrementalHLL(db, "visitors", batch); err != nil {
+				t.Fatal(err)
+			}
+			batch = batch[:0]
+		}
+	}
+	stored, err := db.getEntry("visitors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	estimate, err := stored.HLLEstimate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := math.Abs(float64(estimate)-n) / n; diff > 0.02 {
+		t.Errorf("Estimate %d is %.2f%% away from %d", estimate, diff*100, n)
+	}
+	if err := db.Put("plain", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := IncrementalHLL(db, "plain", [][]byte{[]byte("x")}); err == nil {
+		t.Errorf("Expected an error for a non-hll key")
+	}
+}
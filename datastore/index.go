@@ -0,0 +1,58 @@
+package datastore
+
+// Index maps the keys of a segment to the offsets of their latest records.
+// Every segment gets its own index, built when the segment is opened. The
+// default is a Go map; WithIndex picks another backing, such as RadixTree or
+// a dictionary index, that trades lookup speed for memory. Blocks guard the
+// index with their own lock, so implementations need not be safe for
+// concurrent use.
+type Index interface {
+	Insert(key string, offset int64)
+	Find(key string) (int64, bool)
+	Len() int
+	// Range calls fn for every key until fn returns false.
+	Range(fn func(key string, offset int64) bool)
+}
+
+type hashIndex map[string]int64
+
+func (idx hashIndex) Insert(key string, offset int64) {
+	idx[key] = offset
+}
+
+func (idx hashIndex) Find(key string) (int64, bool) {
+	offset, ok := idx[key]
+	return offset, ok
+}
+
+func (idx hashIndex) Len() int {
+	return len(idx)
+}
+
+func (idx hashIndex) Range(fn func(key string, offset int64) bool) {
+	for key, offset := range idx {
+		if !fn(key, offset) {
+			return
+		}
+	}
+}
+
+// newIndex повертає порожній індекс для нового сегмента
+func (db *Db) newIndex() Index {
+	if db.indexFactory != nil {
+		return db.indexFactory()
+	}
+	return make(hashIndex)
+}
+
+// keys повертає ключі індексу блоку
+func (b *block) keys() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	keys := make([]string, 0, b.index.Len())
+	b.index.Range(func(key string, _ int64) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
@@ -0,0 +1,180 @@
+package datastore
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// INI читаємо за звичаями configparser: коментарі з ; або # на початку
+// рядка чи після пробілу, роздільник = або :, рядки з відступом під
+// ключем продовжують багаторядкове значення. Значення в подвійних лапках
+// береться без лапок, щоб зберегти пробіли і ; всередині.
+
+func stripINIComment(s string) string {
+	for i := 0; i < len(s); i++ {
+		if (s[i] == ';' || s[i] == '#') && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t') {
+			return strings.TrimRight(s[:i], " \t")
+		}
+	}
+	return strings.TrimRight(s, " \t")
+}
+
+func parseINIValue(s string) (string, error) {
+	if strings.HasPrefix(s, `"`) {
+		end := strings.IndexByte(s[1:], '"')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quote")
+		}
+		if rest := strings.TrimLeft(s[end+2:], " \t"); rest != "" && rest[0] != ';' && rest[0] != '#' {
+			return "", fmt.Errorf("unexpected %q after the closing quote", rest)
+		}
+		return s[1 : end+1], nil
+	}
+	return stripINIComment(s), nil
+}
+
+func parseINI(src string) ([]configVar, error) {
+	var vars []configVar
+	section := ""
+	//останнє значення ще може продовжитися рядками з відступом
+	continued := false
+	for n, line := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		indented := trimmed != "" && (line[0] == ' ' || line[0] == '\t')
+		switch {
+		case trimmed == "":
+			continued = false
+		case indented && continued:
+			if part := stripINIComment(trimmed); part != "" {
+				vars[len(vars)-1].value += "\n" + part
+			}
+		case trimmed[0] == ';' || trimmed[0] == '#':
+		case trimmed[0] == '[':
+			end := strings.IndexByte(trimmed, ']')
+			if end < 0 || stripINIComment(trimmed[end+1:]) != "" {
+				return nil, fmt.Errorf("line %d: bad section header %q", n+1, trimmed)
+			}
+			section = strings.TrimSpace(trimmed[1:end])
+			if section == "" {
+				return nil, fmt.Errorf("line %d: bad section name %q", n+1, section)
+			}
+			continued = false
+		default:
+			sep := strings.IndexAny(trimmed, "=:")
+			if sep <= 0 {
+				return nil, fmt.Errorf("line %d: expected key = value", n+1)
+			}
+			value, err := parseINIValue(strings.TrimSpace(trimmed[sep+1:]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", n+1, err)
+			}
+			key := strings.TrimSpace(trimmed[:sep])
+			if section != "" {
+				key = section + "." + key
+			}
+			vars = append(vars, configVar{key, value})
+			//значення в лапках завершене
+			continued = !strings.HasPrefix(strings.TrimSpace(trimmed[sep+1:]), `"`)
+		}
+	}
+	return vars, nil
+}
+
+// ImportINI writes each key of the INI file at path into db as a string
+// entry "section.key"; keys above the first section keep their name. The
+// whole file is parsed before the first write.
+func ImportINI(path string, db *Db) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	vars, err := parseINI(string(data))
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	for _, v := range vars {
+		if err := db.Put(v.name, v.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatINIValue(key, value string) (string, error) {
+	if !utf8.ValidString(value) || strings.Contains(value, "\n\n") || strings.ContainsAny(value, "\r") {
+		return "", fmt.Errorf("value of %q cannot be written to INI", key)
+	}
+	lines := strings.Split(value, "\n")
+	for i, line := range lines {
+		plain := strings.TrimSpace(line) == line && stripINIComment(line) == line && !strings.HasPrefix(line, `"`)
+		switch {
+		case plain && (line != "" || len(lines) == 1):
+		case len(lines) == 1 && !strings.Contains(line, `"`):
+			lines[i] = `"` + line + `"`
+		default:
+			return "", fmt.Errorf("value of %q cannot be written to INI", key)
+		}
+	}
+	return strings.Join(lines, "\n\t"), nil
+}
+
+// ExportINI writes every key of db to w, grouping keys by the part before
+// the first dot into sections. Keys without a dot go above the sections.
+func ExportINI(db *Db, w io.Writer) error {
+	entries, err := db.entries()
+	if err != nil {
+		return err
+	}
+	var global []*Entry
+	sections := make(map[string][]*Entry)
+	var order []string
+	for _, e := range entries {
+		dot := strings.IndexByte(e.key, '.')
+		if dot < 0 {
+			global = append(global, e)
+			continue
+		}
+		section := e.key[:dot]
+		if _, ok := sections[section]; !ok {
+			order = append(order, section)
+		}
+		sections[section] = append(sections[section], e)
+	}
+
+	var sb strings.Builder
+	write := func(name string, e *Entry) error {
+		if name == "" || strings.TrimSpace(name) != name || strings.ContainsAny(name, "=:\n") || strings.IndexAny(name[:1], "[;#") == 0 {
+			return fmt.Errorf("key %q cannot be written to INI", e.key)
+		}
+		value, err := formatINIValue(e.key, e.value)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&sb, "%s = %s\n", name, value)
+		return nil
+	}
+	for _, e := range global {
+		if err := write(e.key, e); err != nil {
+			return err
+		}
+	}
+	for _, section := range order {
+		if strings.TrimSpace(section) != section || section == "" || strings.ContainsAny(section, "]\n") {
+			return fmt.Errorf("section %q cannot be written to INI", section)
+		}
+		if sb.Len() != 0 {
+			sb.WriteByte('\n')
+		}
+		fmt.Fprintf(&sb, "[%s]\n", section)
+		for _, e := range sections[section] {
+			if err := write(e.key[len(section)+1:], e); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
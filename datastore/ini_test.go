@@ -0,0 +1,123 @@
+package datastore
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testINI = `; конфіг старого сервісу
+[server]
+host = 0.0.0.0
+port = 8080 ; порт за замовчуванням
+timeout: 30s
+banner = "  Welcome; stay a while  "
+motd = first line
+	second line
+  third line # останній
+
+[database]
+driver=postgres
+dsn = postgres://db:5432/app?x=1#frag
+user = app
+password = "s3cr#t ;x"
+pool.size = 10
+
+# журналювання
+[logging]
+level = info
+format = json
+output = /var/log/app.log
+rotate = daily
+fields = request_id
+	user_id
+`
+
+func TestImportExportINI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(filepath.Join(dir, "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	file := filepath.Join(dir, "app.ini")
+	if err := os.WriteFile(file, []byte(testINI), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportINI(file, db); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"server.host":        "0.0.0.0",
+		"server.port":        "8080",
+		"server.timeout":     "30s",
+		"server.banner":      "  Welcome; stay a while  ",
+		"server.motd":        "first line\nsecond line\nthird line",
+		"database.driver":    "postgres",
+		"database.dsn":       "postgres://db:5432/app?x=1#frag",
+		"database.user":      "app",
+		"database.password":  "s3cr#t ;x",
+		"database.pool.size": "10",
+		"logging.level":      "info",
+		"logging.format":     "json",
+		"logging.output":     "/var/log/app.log",
+		"logging.rotate":     "daily",
+		"logging.fields":     "request_id\nuser_id",
+	}
+	entries, _ := db.entries()
+	if len(entries) != 15 {
+		t.Errorf("Expected 15 entries, got %d", len(entries))
+	}
+	for key, value := range want {
+		if got, err := db.Get(key); err != nil || got != value {
+			t.Errorf("Bad %s: expected %q, got %q, %v", key, value, got, err)
+		}
+	}
+
+	if err := db.Put("version", "2"); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := ExportINI(db, &out); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, out.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	copyDb, err := NewDb(filepath.Join(dir, "copy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer copyDb.Close()
+	if err := ImportINI(file, copyDb); err != nil {
+		t.Fatalf("Exported INI does not import: %v\n%s", err, out.String())
+	}
+	want["version"] = "2"
+	for key, value := range want {
+		if got, err := copyDb.Get(key); err != nil || got != value {
+			t.Errorf("Bad round trip of %s: expected %q, got %q, %v", key, value, got, err)
+		}
+	}
+
+	if err := db.Put("notes.text", "a\n\nb"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportINI(db, &out); err == nil {
+		t.Errorf("Expected an error for a value with an empty line")
+	}
+	for _, bad := range []string{"[server\nhost = x\n", "just text\n", "key = \"open\n"} {
+		if err := os.WriteFile(file, []byte(bad), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := ImportINI(file, db); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
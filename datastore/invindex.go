@@ -0,0 +1,181 @@
+package datastore
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+type InvertedIndex struct {
+	words map[string][]string
+}
+
+// індекс лежить поруч із директорією, бо в ній recover чекає лише сегменти
+func invertedIndexPath(db *Db) string {
+	return strings.TrimRight(db.dir, string(os.PathSeparator)) + ".idx"
+}
+
+func BuildInvertedIndex(db *Db) (*InvertedIndex, error) {
+	entries, err := db.entries()
+	if err != nil {
+		return nil, err
+	}
+
+	sets := make(map[string]map[string]struct{})
+	for _, e := range entries {
+		if e.valueType != STRING_TYPE {
+			continue
+		}
+		for _, word := range strings.Fields(e.value) {
+			word = strings.ToLower(word)
+			if sets[word] == nil {
+				sets[word] = make(map[string]struct{})
+			}
+			sets[word][e.key] = struct{}{}
+		}
+	}
+
+	idx := &InvertedIndex{words: make(map[string][]string, len(sets))}
+	for word, set := range sets {
+		keys := make([]string, 0, len(set))
+		for key := range set {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		idx.words[word] = keys
+	}
+
+	if err := idx.save(invertedIndexPath(db)); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+func (idx *InvertedIndex) Search(word string) []string {
+	return idx.words[strings.ToLower(word)]
+}
+
+func (idx *InvertedIndex) SearchAll(words []string) []string {
+	if len(words) == 0 {
+		return nil
+	}
+	res := idx.Search(words[0])
+	for _, word := range words[1:] {
+		other := idx.Search(word)
+		var both []string
+		for i, j := 0, 0; i < len(res) && j < len(other); {
+			switch {
+			case res[i] < other[j]:
+				i++
+			case res[i] > other[j]:
+				j++
+			default:
+				both = append(both, res[i])
+				i++
+				j++
+			}
+		}
+		res = both
+	}
+	return res
+}
+
+func writeString(w *bufio.Writer, s string) error {
+	var l [4]byte
+	binary.LittleEndian.PutUint32(l[:], uint32(len(s)))
+	if _, err := w.Write(l[:]); err != nil {
+		return err
+	}
+	_, err := w.WriteString(s)
+	return err
+}
+
+func readString(r *bufio.Reader) (string, error) {
+	var l [4]byte
+	if _, err := io.ReadFull(r, l[:]); err != nil {
+		return "", err
+	}
+	data := make([]byte, binary.LittleEndian.Uint32(l[:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (idx *InvertedIndex) save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	words := make([]string, 0, len(idx.words))
+	for word := range idx.words {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	w := bufio.NewWriter(f)
+	var n [4]byte
+	binary.LittleEndian.PutUint32(n[:], uint32(len(words)))
+	if _, err := w.Write(n[:]); err != nil {
+		return err
+	}
+	for _, word := range words {
+		keys := idx.words[word]
+		if err := writeString(w, word); err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint32(n[:], uint32(len(keys)))
+		if _, err := w.Write(n[:]); err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err := writeString(w, key); err != nil {
+				return err
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+func LoadInvertedIndex(db *Db) (*InvertedIndex, error) {
+	f, err := os.Open(invertedIndexPath(db))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var n [4]byte
+	if _, err := io.ReadFull(r, n[:]); err != nil {
+		return nil, err
+	}
+	count := binary.LittleEndian.Uint32(n[:])
+	idx := &InvertedIndex{words: make(map[string][]string)}
+	for i := uint32(0); i < count; i++ {
+		word, err := readString(r)
+		if err != nil {
+			return nil, fmt.Errorf("corrupted inverted index: %v", err)
+		}
+		if _, err := io.ReadFull(r, n[:]); err != nil {
+			return nil, fmt.Errorf("corrupted inverted index: %v", err)
+		}
+		keys := make([]string, binary.LittleEndian.Uint32(n[:]))
+		for j := range keys {
+			keys[j], err = readString(r)
+			if err != nil {
+				return nil, fmt.Errorf("corrupted inverted index: %v", err)
+			}
+		}
+		idx.words[word] = keys
+	}
+	return idx, nil
+}
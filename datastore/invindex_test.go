@@ -0,0 +1,73 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestInvertedIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Remove(dir + ".idx")
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	sentences := []string{
+		"The quick brown fox jumps over the lazy dog",
+		"A journey of a thousand miles begins with a single step",
+		"To be or not to be",
+		"the early bird catches the worm",
+	}
+	var withThe, withFox []string
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("doc%03d", i)
+		s := sentences[i%len(sentences)]
+		if err := db.Put(key, s); err != nil {
+			t.Fatal(err)
+		}
+		if i%len(sentences) == 0 || i%len(sentences) == 3 {
+			withThe = append(withThe, key)
+		}
+		if i%len(sentences) == 0 {
+			withFox = append(withFox, key)
+		}
+	}
+	if err := db.PutInt64("number", 42); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := BuildInvertedIndex(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := idx.Search("the"); !reflect.DeepEqual(got, withThe) {
+		t.Errorf("Search(the): expected %v, got %v", withThe, got)
+	}
+	if got := idx.SearchAll([]string{"the", "fox"}); !reflect.DeepEqual(got, withFox) {
+		t.Errorf("SearchAll(the, fox): expected %v, got %v", withFox, got)
+	}
+	if got := idx.SearchAll([]string{"fox", "worm"}); len(got) != 0 {
+		t.Errorf("SearchAll(fox, worm): expected no keys, got %v", got)
+	}
+	if got := idx.Search("42"); len(got) != 0 {
+		t.Errorf("Expected int64 entries to be skipped, got %v", got)
+	}
+
+	loaded, err := LoadInvertedIndex(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.words, idx.words) {
+		t.Error("Loaded index differs from the built one")
+	}
+}
@@ -0,0 +1,132 @@
+package datastore
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+)
+
+// ipOperator зберігає байт версії (4 або 6) і 4 чи 16 байт адреси;
+// у Entry.value адреса лежить у вигляді net.IP.String(). IPv4,
+// відображені в IPv6 (::ffff:a.b.c.d), зберігаються як IPv4.
+type ipOperator struct{}
+
+func parseIP(s string) (net.IP, error) {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("bad ip address %q", s)
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4, nil
+	}
+	return ip, nil
+}
+
+func ipVersion(ip net.IP) byte {
+	if len(ip) == net.IPv4len {
+		return 4
+	}
+	return 6
+}
+
+func decodeIP(data []byte) net.IP {
+	n := net.IPv6len
+	if data[0] == 4 {
+		n = net.IPv4len
+	}
+	return append(net.IP(nil), data[1:1+n]...)
+}
+
+func (s ipOperator) Encode(e *Entry, dst []byte) []byte {
+	ip, err := parseIP(e.value)
+	if err != nil {
+		panic(err)
+	}
+	res, offset := encodeKeyInto(e, len(ip)-3, dst)
+	res[offset] = IPADDR_TYPE
+	res[offset+TYPE_SIZE] = ipVersion(ip)
+	copy(res[offset+TYPE_SIZE+1:], ip)
+	return res
+}
+
+func (s ipOperator) EncodeInto(e *Entry, dst []byte) ([]byte, error) {
+	ip, err := parseIP(e.value)
+	if err != nil {
+		return dst, err
+	}
+	dst = appendKey(dst, e, len(ip)-3)
+	dst = append(dst, IPADDR_TYPE, ipVersion(ip))
+	return append(dst, ip...), nil
+}
+
+func (s ipOperator) Decode(input []byte, e *Entry) {
+	kl := len(e.key)
+	e.value = decodeIP(input[kl+TYPE_SIZE+8:]).String()
+}
+
+func (s ipOperator) Read(in *bufio.Reader) (string, error) {
+	version, err := in.Peek(1)
+	if err != nil {
+		return "", err
+	}
+	n := net.IPv6len
+	if version[0] == 4 {
+		n = net.IPv4len
+	}
+	data, err := in.Peek(1 + n)
+	if err != nil {
+		return "", err
+	}
+	return decodeIP(data).String(), nil
+}
+
+func NewIPEntry(key string, ip net.IP) *Entry {
+	return &Entry{key: key, valueType: IPADDR_TYPE, value: ip.String()}
+}
+
+func (e *Entry) GetIP() (net.IP, error) {
+	if e.valueType != IPADDR_TYPE {
+		return nil, fmt.Errorf("wrong type of value")
+	}
+	return parseIP(e.value)
+}
+
+// IPRangeQuery returns every ip address entry inside the cidr block. An
+// IPv4 block matches only IPv4 addresses and vice versa.
+func IPRangeQuery(db *Db, cidr string) ([]*Entry, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := db.entries()
+	if err != nil {
+		return nil, err
+	}
+	var res []*Entry
+	for _, e := range entries {
+		if e.valueType != IPADDR_TYPE {
+			continue
+		}
+		ip, err := parseIP(e.value)
+		if err != nil {
+			return nil, err
+		}
+		if ipInNetwork(ip, network) {
+			res = append(res, e)
+		}
+	}
+	return res, nil
+}
+
+func ipInNetwork(ip net.IP, network *net.IPNet) bool {
+	//net.ParseCIDR повертає 4-байтову мережу для IPv4
+	if len(ip) != len(network.IP) {
+		return false
+	}
+	for i := range ip {
+		if ip[i]&network.Mask[i] != network.IP[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"os"
+	"sort"
+	"testing"
+)
+
+func TestIPAddr(t *testing.T) {
+	for _, c := range []struct {
+		text string
+		size int
+	}{
+		{"192.168.1.10", 5},
+		{"::ffff:192.168.1.10", 5},
+		{"2001:db8::1", 17},
+	} {
+		e := &Entry{key: "ip", valueType: IPADDR_TYPE, value: c.text}
+		data := e.Encode()
+		if len(data) != len(e.key)+8+TYPE_SIZE+c.size {
+			t.Errorf("Bad encoded size for %s: %d", c.text, len(data))
+		}
+		var decoded Entry
+		decoded.Decode(data)
+		ip, err := decoded.GetIP()
+		if err != nil || !ip.Equal(net.ParseIP(c.text)) {
+			t.Errorf("Bad ip round trip for %s: %v, %v", c.text, ip, err)
+		}
+	}
+	if _, err := EncodeInto(&Entry{key: "ip", valueType: IPADDR_TYPE, value: "300.1.1.1"}, nil); err == nil {
+		t.Errorf("Expected an error for a bad address")
+	}
+
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	//адреси з 10.0.0.0/22, щоб приблизно чверть потрапила в /24
+	rnd := rand.New(rand.NewSource(1))
+	var expected []string
+	for i := 0; i < 100; i++ {
+		ip := net.IPv4(10, 0, byte(rnd.Intn(4)), byte(rnd.Intn(256)))
+		key := fmt.Sprintf("host%03d", i)
+		if ip.To4()[2] == 2 {
+			expected = append(expected, key)
+		}
+		if err := db.putEntry(NewIPEntry(key, ip)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.putEntry(NewIPEntry("v6", net.ParseIP("::a00:200"))); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := IPRangeQuery(db, "10.0.2.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, e := range res {
+		keys = append(keys, e.key)
+	}
+	sort.Strings(keys)
+	if len(expected) == 0 || fmt.Sprint(keys) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, keys)
+	}
+	if _, err := IPRangeQuery(db, "10.0.2.0"); err == nil {
+		t.Errorf("Expected an error for a bad cidr")
+	}
+}
@@ -0,0 +1,195 @@
+package datastore
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	keyDictMaxEntries = 127
+	keyDictCodeBase   = 0x80
+	keyDictEscape     = 0xff
+
+	keyDictSample    = 10000
+	keyDictMaxSubstr = 16
+)
+
+// Байти 0x80..0xfe стиснутого ключа — номери записів словника, 0xff
+// екранує наступний байт, решта байтів переноситься як є.
+type KeyDictionary struct {
+	entries []string
+	// номери записів за першим байтом, від найдовших до найкоротших
+	byFirst [256][]int
+}
+
+func BuildDictionary(keys []string, maxSize int) *KeyDictionary {
+	if maxSize > keyDictMaxEntries {
+		maxSize = keyDictMaxEntries
+	}
+	sample := keys
+	if len(sample) > keyDictSample {
+		sample = sample[:keyDictSample]
+	}
+
+	counts := make(map[string]int)
+	for _, key := range sample {
+		seen := make(map[string]bool)
+		for i := range key {
+			for l := 3; l <= keyDictMaxSubstr && i+l <= len(key); l++ {
+				s := key[i : i+l]
+				if !seen[s] {
+					seen[s] = true
+					counts[s]++
+				}
+			}
+		}
+	}
+
+	candidates := make([]string, 0, len(counts))
+	for s, c := range counts {
+		if c > 1 {
+			candidates = append(candidates, s)
+		}
+	}
+	score := func(s string) int { return counts[s] * (len(s) - 1) }
+	sort.Slice(candidates, func(i, j int) bool {
+		if score(candidates[i]) != score(candidates[j]) {
+			return score(candidates[i]) > score(candidates[j])
+		}
+		return candidates[i] < candidates[j]
+	})
+
+	d := &KeyDictionary{}
+	for _, s := range candidates {
+		if len(d.entries) >= maxSize {
+			break
+		}
+		covered := false
+		for _, e := range d.entries {
+			if strings.Contains(e, s) || strings.Contains(s, e) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			d.entries = append(d.entries, s)
+		}
+	}
+
+	for code, e := range d.entries {
+		d.byFirst[e[0]] = append(d.byFirst[e[0]], code)
+	}
+	for _, codes := range d.byFirst {
+		sort.SliceStable(codes, func(i, j int) bool {
+			return len(d.entries[codes[i]]) > len(d.entries[codes[j]])
+		})
+	}
+	return d
+}
+
+func (d *KeyDictionary) Len() int {
+	return len(d.entries)
+}
+
+func (d *KeyDictionary) Compress(key string) []byte {
+	res := make([]byte, 0, len(key))
+	for i := 0; i < len(key); {
+		matched := false
+		for _, code := range d.byFirst[key[i]] {
+			if strings.HasPrefix(key[i:], d.entries[code]) {
+				res = append(res, byte(keyDictCodeBase+code))
+				i += len(d.entries[code])
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		if key[i] >= keyDictCodeBase {
+			res = append(res, keyDictEscape)
+		}
+		res = append(res, key[i])
+		i++
+	}
+	return res
+}
+
+func (d *KeyDictionary) Decompress(compressedKey []byte) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(compressedKey); i++ {
+		b := compressedKey[i]
+		switch {
+		case b == keyDictEscape:
+			i++
+			if i >= len(compressedKey) {
+				return "", fmt.Errorf("truncated escape in compressed key")
+			}
+			sb.WriteByte(compressedKey[i])
+		case b >= keyDictCodeBase:
+			code := int(b - keyDictCodeBase)
+			if code >= len(d.entries) {
+				return "", fmt.Errorf("unknown dictionary code %d", code)
+			}
+			sb.WriteString(d.entries[code])
+		default:
+			sb.WriteByte(b)
+		}
+	}
+	return sb.String(), nil
+}
+
+var _ Index = (*CompressedIndex)(nil)
+
+// CompressedIndex is an Index that compresses keys with a KeyDictionary and
+// keeps them in a RadixTree. Compressed template keys are short and still
+// share their prefixes, so the tree stores little more than the varying
+// part of each key.
+type CompressedIndex struct {
+	dict *KeyDictionary
+	tree *RadixTree
+}
+
+// DictionaryIndex returns an empty index compressing keys with d. Build d
+// from a sample of the keys the database will hold, and pass the index to a
+// Db with WithIndex(func() Index { return DictionaryIndex(d) }).
+func DictionaryIndex(d *KeyDictionary) *CompressedIndex {
+	return &CompressedIndex{dict: d, tree: NewRadixTree()}
+}
+
+func (idx *CompressedIndex) Insert(key string, offset int64) {
+	idx.tree.Insert(string(idx.dict.Compress(key)), offset)
+}
+
+func (idx *CompressedIndex) Find(key string) (int64, bool) {
+	return idx.tree.Find(string(idx.dict.Compress(key)))
+}
+
+func (idx *CompressedIndex) Delete(key string) {
+	idx.tree.Delete(string(idx.dict.Compress(key)))
+}
+
+func (idx *CompressedIndex) Len() int {
+	return idx.tree.Len()
+}
+
+// Range calls fn for every key until fn returns false. Keys come in the
+// order of their compressed form, not in key order.
+func (idx *CompressedIndex) Range(fn func(key string, offset int64) bool) {
+	idx.tree.Range(func(compressed string, offset int64) bool {
+		//стиснуті ключі виробляє лише Compress, тож розпаковуються завжди
+		key, _ := idx.dict.Decompress([]byte(compressed))
+		return fn(key, offset)
+	})
+}
+
+func (idx *CompressedIndex) Keys() []string {
+	keys := make([]string, 0, idx.Len())
+	idx.Range(func(key string, _ int64) bool {
+		keys = append(keys, key)
+		return true
+	})
+	sort.Strings(keys)
+	return keys
+}
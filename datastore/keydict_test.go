@@ -0,0 +1,154 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func templateKeys(n int) []string {
+	kinds := []string{"profile", "settings", "sessions", "avatar"}
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("user:%d:%s", 10000+i, kinds[i%len(kinds)])
+	}
+	return keys
+}
+
+func TestKeyDictionary(t *testing.T) {
+	keys := templateKeys(1000)
+	d := BuildDictionary(keys, 64)
+	if d.Len() == 0 || d.Len() > 64 {
+		t.Fatalf("Unexpected dictionary size %d", d.Len())
+	}
+
+	inputs := append(keys, "", "plain", "\x80\xff\xfe binary", "профіль")
+	var raw, compressed int
+	for _, key := range inputs {
+		c := d.Compress(key)
+		raw += len(key)
+		compressed += len(c)
+		got, err := d.Decompress(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != key {
+			t.Errorf("Round trip of %q gave %q", key, got)
+		}
+	}
+	if compressed*2 > raw {
+		t.Errorf("Expected template keys to compress at least 2x, got %d -> %d bytes", raw, compressed)
+	}
+
+	if _, err := d.Decompress([]byte{0xff}); err == nil {
+		t.Error("Expected error for truncated escape")
+	}
+	if _, err := d.Decompress([]byte{0xfe}); err == nil {
+		t.Error("Expected error for unknown code")
+	}
+}
+
+func TestDictionaryIndex(t *testing.T) {
+	keys := templateKeys(100)
+	idx := DictionaryIndex(BuildDictionary(keys, 32))
+	for i, key := range keys {
+		idx.Insert(key, int64(i))
+	}
+	for i, key := range keys {
+		if offset, ok := idx.Find(key); !ok || offset != int64(i) {
+			t.Errorf("Find(%s) = %d, %v", key, offset, ok)
+		}
+	}
+	idx.Delete(keys[0])
+	if _, ok := idx.Find(keys[0]); ok || idx.Len() != 99 {
+		t.Error("Expected key to be deleted")
+	}
+	stored := idx.Keys()
+	if len(stored) != 99 || !strings.HasPrefix(stored[0], "user:") {
+		t.Errorf("Unexpected keys %v", stored[:1])
+	}
+}
+
+func TestDb_DictionaryIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	keys := templateKeys(300)
+	d := BuildDictionary(keys, 32)
+	opts := []Option{WithMaxFileSize(4096), WithIndex(func() Index { return DictionaryIndex(d) })}
+	db, err := NewDb(dir, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, key := range keys {
+		if err := db.Put(key, fmt.Sprint(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	//ключ поза шаблоном теж має працювати, лише стискається гірше
+	if err := db.Put("other\x80key", "value"); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	db, err = NewDb(dir, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for i, key := range keys {
+		if v, err := db.Get(key); err != nil || v != fmt.Sprint(i) {
+			t.Errorf("Bad value of %s: %q, %v", key, v, err)
+		}
+	}
+	if v, err := db.Get("other\x80key"); err != nil || v != "value" {
+		t.Errorf("Bad value of a key outside the template: %q, %v", v, err)
+	}
+	if entries, err := db.entries(); err != nil || len(entries) != 301 {
+		t.Errorf("Expected 301 entries, got %d, %v", len(entries), err)
+	}
+}
+
+func BenchmarkDictionaryIndexMemory(b *testing.B) {
+	keys := templateKeys(500000)
+	d := BuildDictionary(keys, keyDictMaxEntries)
+	b.Run("map", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			before := heapInUse()
+			m := make(map[string]int64)
+			for j, key := range keys {
+				m[strings.Clone(key)] = int64(j)
+			}
+			b.ReportMetric(float64(heapInUse()-before)/float64(len(keys)), "bytes/key")
+			runtime.KeepAlive(m)
+		}
+	})
+	b.Run("RadixTree", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			before := heapInUse()
+			tree := NewRadixTree()
+			for j, key := range keys {
+				tree.Insert(key, int64(j))
+			}
+			b.ReportMetric(float64(heapInUse()-before)/float64(len(keys)), "bytes/key")
+			runtime.KeepAlive(tree)
+		}
+	})
+	b.Run("DictionaryIndex", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			before := heapInUse()
+			idx := DictionaryIndex(d)
+			for j, key := range keys {
+				idx.Insert(key, int64(j))
+			}
+			b.ReportMetric(float64(heapInUse()-before)/float64(len(keys)), "bytes/key")
+			runtime.KeepAlive(idx)
+		}
+	})
+}
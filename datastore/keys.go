@@ -0,0 +1,50 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"unsafe"
+)
+
+func stringBytes(s string) []byte {
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
+
+//go:nosplit
+func CompareKeys(a, b string) int {
+	x, y := stringBytes(a), stringBytes(b)
+	n := len(x)
+	if len(y) < n {
+		n = len(y)
+	}
+
+	i := 0
+	for ; i+8 <= n; i += 8 {
+		u := binary.LittleEndian.Uint64(x[i:])
+		v := binary.LittleEndian.Uint64(y[i:])
+		if u != v {
+			//у little-endian перший різний байт — наймолодший різний біт
+			j := i + bits.TrailingZeros64(u^v)/8
+			if x[j] < y[j] {
+				return -1
+			}
+			return 1
+		}
+	}
+	for ; i < n; i++ {
+		if x[i] != y[i] {
+			if x[i] < y[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case len(x) < len(y):
+		return -1
+	case len(x) > len(y):
+		return 1
+	}
+	return 0
+}
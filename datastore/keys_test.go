@@ -0,0 +1,82 @@
+package datastore
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestCompareKeys(t *testing.T) {
+	sign := func(n int) int {
+		switch {
+		case n < 0:
+			return -1
+		case n > 0:
+			return 1
+		}
+		return 0
+	}
+
+	cases := [][2]string{
+		{"", ""},
+		{"", "a"},
+		{"a", "b"},
+		{"abcdefgh", "abcdefgh"},
+		{"abcdefgh", "abcdefgi"},
+		{"abcdefgha", "abcdefgh"},
+		{"abcdefghijklmnop", "abcdefghijklmnoq"},
+		{"\x01\x00\x00\x00\x00\x00\x00\x00", "\x00\x01\x00\x00\x00\x00\x00\x00"},
+		{"\xff", "\x00"},
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		a := make([]byte, r.Intn(40))
+		r.Read(a)
+		b := append([]byte(nil), a...)
+		if len(b) > 0 {
+			b[r.Intn(len(b))] = byte(r.Intn(256))
+		}
+		cases = append(cases, [2]string{string(a), string(b)})
+	}
+
+	for _, c := range cases {
+		expected := strings.Compare(c[0], c[1])
+		if got := sign(CompareKeys(c[0], c[1])); got != expected {
+			t.Errorf("CompareKeys(%q, %q) = %d, expected %d", c[0], c[1], got, expected)
+		}
+		if got := sign(CompareKeys(c[1], c[0])); got != -expected {
+			t.Errorf("CompareKeys(%q, %q) = %d, expected %d", c[1], c[0], got, -expected)
+		}
+	}
+}
+
+func benchmarkKeys() []string {
+	r := rand.New(rand.NewSource(1))
+	prefix := strings.Repeat("k", 56)
+	keys := make([]string, 10000)
+	for i := range keys {
+		suffix := make([]byte, 16)
+		r.Read(suffix)
+		keys[i] = prefix + string(suffix)
+	}
+	return keys
+}
+
+func BenchmarkSortKeys(b *testing.B) {
+	keys := benchmarkKeys()
+	work := make([]string, len(keys))
+	b.Run("CompareKeys", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			copy(work, keys)
+			sort.Slice(work, func(i, j int) bool { return CompareKeys(work[i], work[j]) < 0 })
+		}
+	})
+	b.Run("bytes.Compare", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			copy(work, keys)
+			sort.Slice(work, func(i, j int) bool { return bytes.Compare(stringBytes(work[i]), stringBytes(work[j])) < 0 })
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package datastore
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+)
+
+// lamportOperator зберігає рівно 8 байт лічильника без довжини значення
+type lamportOperator struct{}
+
+func (s lamportOperator) Encode(e *Entry, dst []byte) []byte {
+	n, err := strconv.ParseUint(e.value, 10, 64)
+	if err != nil {
+		panic(err)
+	}
+	res, offset := encodeKeyInto(e, 4, dst)
+	res[offset] = LAMPORT_TYPE
+	binary.LittleEndian.PutUint64(res[offset+TYPE_SIZE:], n)
+	return res
+}
+
+func (s lamportOperator) EncodeInto(e *Entry, dst []byte) ([]byte, error) {
+	n, err := strconv.ParseUint(e.value, 10, 64)
+	if err != nil {
+		return dst, err
+	}
+	dst = appendKey(dst, e, 4)
+	dst = append(dst, LAMPORT_TYPE)
+	return binary.LittleEndian.AppendUint64(dst, n), nil
+}
+
+func (s lamportOperator) Decode(input []byte, e *Entry) {
+	kl := len(e.key)
+	e.value = strconv.FormatUint(binary.LittleEndian.Uint64(input[kl+TYPE_SIZE+8:]), 10)
+}
+
+func (s lamportOperator) Read(in *bufio.Reader) (string, error) {
+	data, err := in.Peek(8)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(binary.LittleEndian.Uint64(data), 10), nil
+}
+
+func (db *Db) GetLamport(key string) (uint64, error) {
+	e, err := db.getEntry(key)
+	if err != nil {
+		return 0, err
+	}
+	if e.valueType != LAMPORT_TYPE {
+		return 0, fmt.Errorf("wrong type of value")
+	}
+	return strconv.ParseUint(e.value, 10, 64)
+}
+
+func advanceLamport(db *Db, key string, next func(uint64) uint64) (uint64, error) {
+	var n uint64
+	_, err := db.updateEntry(key, func(cur *Entry) (*Entry, error) {
+		var local uint64
+		if cur != nil {
+			if cur.valueType != LAMPORT_TYPE {
+				return nil, fmt.Errorf("wrong type of value")
+			}
+			var err error
+			if local, err = strconv.ParseUint(cur.value, 10, 64); err != nil {
+				return nil, err
+			}
+		}
+		n = next(local)
+		if n <= local {
+			return nil, fmt.Errorf("lamport clock %s overflowed", key)
+		}
+		return &Entry{valueType: LAMPORT_TYPE, value: strconv.FormatUint(n, 10)}, nil
+	})
+	return n, err
+}
+
+// IncrementLamport ticks the clock for a local event and returns the new time.
+func IncrementLamport(db *Db, key string) (uint64, error) {
+	return advanceLamport(db, key, func(local uint64) uint64 {
+		return local + 1
+	})
+}
+
+// UpdateLamport merges the timestamp of a received message: the clock moves
+// to max(local, received) + 1.
+func UpdateLamport(db *Db, key string, received uint64) (uint64, error) {
+	return advanceLamport(db, key, func(local uint64) uint64 {
+		return max(local, received) + 1
+	})
+}
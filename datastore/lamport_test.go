@@ -0,0 +1,105 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"sync"
+	"testing"
+)
+
+func newTestDb(t *testing.T) *Db {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestLamport_MessageExchange(t *testing.T) {
+	a := newTestDb(t)
+	b := newTestDb(t)
+
+	last := map[string]uint64{}
+	step := func(node string, ts uint64, err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		//локальний час вузла строго зростає
+		if ts <= last[node] {
+			t.Errorf("Node %s: clock went from %d to %d", node, last[node], ts)
+		}
+		last[node] = ts
+	}
+
+	for round := 0; round < 5; round++ {
+		// a має кілька локальних подій, потім надсилає повідомлення b
+		for i := 0; i < 3; i++ {
+			ts, err := IncrementLamport(a, "clock")
+			step("a", ts, err)
+		}
+		ts, err := IncrementLamport(a, "clock")
+		step("a", ts, err)
+
+		received, err := UpdateLamport(b, "clock", ts)
+		step("b", received, err)
+		//подія отримання завжди пізніша за подію надсилання
+		if received <= ts {
+			t.Errorf("Receive at %d is not after send at %d", received, ts)
+		}
+
+		ts, err = IncrementLamport(b, "clock")
+		step("b", ts, err)
+		received, err = UpdateLamport(a, "clock", ts)
+		step("a", received, err)
+		if received <= ts {
+			t.Errorf("Receive at %d is not after send at %d", received, ts)
+		}
+	}
+
+	if n, err := a.GetLamport("clock"); err != nil || n != last["a"] {
+		t.Errorf("Expected stored clock %d, got %d, %v", last["a"], n, err)
+	}
+	if _, err := UpdateLamport(a, "clock", math.MaxUint64); err == nil {
+		t.Error("Expected error on clock overflow")
+	}
+	if err := a.Put("text", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := IncrementLamport(a, "text"); err == nil {
+		t.Error("Expected error for non-lamport value")
+	}
+}
+
+func TestLamport_ConcurrentIncrements(t *testing.T) {
+	db := newTestDb(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if _, err := IncrementLamport(db, "clock"); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n, err := db.GetLamport("clock"); err != nil || n != 200 {
+		t.Errorf("Expected 200 increments, got %d, %v", n, err)
+	}
+
+	var e Entry
+	e.Decode((&Entry{key: "clock", valueType: LAMPORT_TYPE, value: "12345678901234"}).Encode())
+	if e.Value() != "12345678901234" || e.Type() != "lamport" {
+		t.Errorf("Bad entry decoded: %v", e)
+	}
+}
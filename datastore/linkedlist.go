@@ -0,0 +1,137 @@
+package datastore
+
+import "fmt"
+
+// Вузол списку зберігається як три рядки з префіксом довжини: значення,
+// ключ наступного і ключ попереднього вузла. Порожній ключ - кінець списку.
+
+func encodeLinkedNode(value, next, prev string) string {
+	res := appendLengthPrefixed(nil, value)
+	res = appendLengthPrefixed(res, next)
+	return string(appendLengthPrefixed(res, prev))
+}
+
+func parseLinkedNode(data string) (value, next, prev string, err error) {
+	if value, data, err = readLengthPrefixed(data); err != nil {
+		return
+	}
+	if next, data, err = readLengthPrefixed(data); err != nil {
+		return
+	}
+	if prev, data, err = readLengthPrefixed(data); err != nil {
+		return
+	}
+	if data != "" {
+		err = fmt.Errorf("corrupted linked node")
+	}
+	return
+}
+
+func validateLinkedNode(data string) error {
+	_, _, _, err := parseLinkedNode(data)
+	return err
+}
+
+// LinkedNode returns the value of a list node and the keys of its
+// neighbours; an empty key means there is none.
+func (e *Entry) LinkedNode() (value, next, prev string, err error) {
+	if e.valueType != LINKED_NODE_TYPE {
+		return "", "", "", fmt.Errorf("wrong type of value")
+	}
+	return parseLinkedNode(e.value)
+}
+
+// latestNode читає вузол без блокування; викликається під db.mu
+func (db *Db) latestNode(key string) (value, next, prev string, err error) {
+	e, err := db.latestEntry(key)
+	if err != nil {
+		return "", "", "", err
+	}
+	return e.LinkedNode()
+}
+
+// NewLinkedListHead starts a list with a single node; key must not exist yet.
+func NewLinkedListHead(db *Db, key, value string) (*Entry, error) {
+	if key == "" {
+		return nil, fmt.Errorf("linked node key must not be empty")
+	}
+	e := &Entry{key: key, valueType: LINKED_NODE_TYPE, value: encodeLinkedNode(value, "", "")}
+	swapped, err := db.compareAndSwap(nil, e)
+	if err != nil {
+		return nil, err
+	}
+	if !swapped {
+		return nil, fmt.Errorf("key %s already exists", key)
+	}
+	return e, nil
+}
+
+// InsertAfter links a new node between pivotKey and its successor. All
+// three writes happen under one lock; the new node is written first and the
+// pivot last, so a crash in between leaves forward traversal intact.
+func InsertAfter(db *Db, pivotKey, newKey, value string) error {
+	if newKey == "" {
+		return fmt.Errorf("linked node key must not be empty")
+	}
+	db.mu.Lock()
+	written, err := db.insertAfter(pivotKey, newKey, value)
+	db.unlockWrite()
+	if err != nil {
+		return err
+	}
+	for _, e := range written {
+		db.emit(e)
+	}
+	return nil
+}
+
+func (db *Db) insertAfter(pivotKey, newKey, value string) ([]*Entry, error) {
+	if _, err := db.latestEntry(newKey); err != ErrNotFound {
+		if err == nil {
+			err = fmt.Errorf("key %s already exists", newKey)
+		}
+		return nil, err
+	}
+	pivotValue, next, pivotPrev, err := db.latestNode(pivotKey)
+	if err != nil {
+		return nil, err
+	}
+	written := []*Entry{{key: newKey, valueType: LINKED_NODE_TYPE, value: encodeLinkedNode(value, next, pivotKey)}}
+	if next != "" {
+		nextValue, nextNext, _, err := db.latestNode(next)
+		if err != nil {
+			return nil, err
+		}
+		written = append(written, &Entry{key: next, valueType: LINKED_NODE_TYPE, value: encodeLinkedNode(nextValue, nextNext, newKey)})
+	}
+	written = append(written, &Entry{key: pivotKey, valueType: LINKED_NODE_TYPE, value: encodeLinkedNode(pivotValue, newKey, pivotPrev)})
+	for i, e := range written {
+		if err := db.appendEntry(e); err != nil {
+			return written[:i], err
+		}
+	}
+	return written, nil
+}
+
+// Traverse follows next links from headKey and returns the nodes in order.
+func Traverse(db *Db, headKey string) ([]*Entry, error) {
+	var res []*Entry
+	seen := make(map[string]bool)
+	for key := headKey; key != ""; {
+		if seen[key] {
+			return nil, fmt.Errorf("linked list has a cycle at %s", key)
+		}
+		seen[key] = true
+		e, err := db.getEntry(key)
+		if err != nil {
+			return nil, err
+		}
+		_, next, _, err := e.LinkedNode()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+		key = next
+	}
+	return res, nil
+}
@@ -0,0 +1,82 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestLinkedList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewLinkedListHead(db, "n0", "v0"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewLinkedListHead(db, "n0", "again"); err == nil {
+		t.Errorf("Expected an error for an existing head")
+	}
+	for i := 1; i < 10; i++ {
+		if err := InsertAfter(db, fmt.Sprintf("n%d", i-1), fmt.Sprintf("n%d", i), fmt.Sprintf("v%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := InsertAfter(db, "n4", "mid", "inserted"); err != nil {
+		t.Fatal(err)
+	}
+	if err := InsertAfter(db, "n4", "n7", "dup"); err == nil {
+		t.Errorf("Expected an error for an existing key")
+	}
+	if err := InsertAfter(db, "missing", "x", "v"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound for a missing pivot, got %v", err)
+	}
+
+	nodes, err := Traverse(db, "n0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"n0", "n1", "n2", "n3", "n4", "mid", "n5", "n6", "n7", "n8", "n9"}
+	if len(nodes) != len(expected) {
+		t.Fatalf("Expected %d nodes, got %d", len(expected), len(nodes))
+	}
+	for i, e := range nodes {
+		value, _, prev, err := e.LinkedNode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.key != expected[i] {
+			t.Errorf("Bad node %d: expected %s, got %s", i, expected[i], e.key)
+		}
+		if i > 0 && prev != expected[i-1] {
+			t.Errorf("Bad prev link of %s: %s", e.key, prev)
+		}
+		if e.key == "mid" && value != "inserted" {
+			t.Errorf("Bad inserted value: %s", value)
+		}
+	}
+
+	//після перевідкриття список той самий
+	db.Close()
+	db, err = NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if nodes, err := Traverse(db, "n0"); err != nil || len(nodes) != 11 {
+		t.Errorf("Bad traverse after reopen: %d nodes, %v", len(nodes), err)
+	}
+	if err := db.Put("plain", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Traverse(db, "plain"); err == nil {
+		t.Errorf("Expected an error for a non-node key")
+	}
+}
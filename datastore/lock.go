@@ -0,0 +1,70 @@
+package datastore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrAlreadyLocked means another process has the database open for writing.
+var ErrAlreadyLocked = fmt.Errorf("database is locked by another process")
+
+// lockFileName лежить серед сегментів; NewDb пропускає його при відновленні
+const lockFileName = "LOCK"
+
+// Lock takes the advisory lock that keeps other processes from opening the
+// database for writing, or returns ErrAlreadyLocked. NewDb takes it for
+// every database not opened read-only and Close releases it.
+func (db *Db) Lock() error {
+	ok, err := db.TryLock()
+	if err == nil && !ok {
+		err = ErrAlreadyLocked
+	}
+	return err
+}
+
+// TryLock is like Lock but reports a lock held elsewhere as false.
+func (db *Db) TryLock() (bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.tryLockFile()
+}
+
+// Unlock releases the lock taken by Lock or TryLock.
+func (db *Db) Unlock() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.unlockFile()
+}
+
+// tryLockFile викликається під db.mu
+func (db *Db) tryLockFile() (bool, error) {
+	if db.lockFile != nil {
+		return true, nil
+	}
+	f, err := os.OpenFile(filepath.Join(db.dir, lockFileName), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return false, err
+	}
+	ok, err := flockExclusive(f)
+	if err != nil || !ok {
+		f.Close()
+		return false, err
+	}
+	db.lockFile = f
+	return true, nil
+}
+
+// unlockFile викликається під db.mu; файл не видаляємо, бо інший процес
+// міг уже відкрити його і чекати на блокування
+func (db *Db) unlockFile() error {
+	if db.lockFile == nil {
+		return nil
+	}
+	err := funlock(db.lockFile)
+	if closeErr := db.lockFile.Close(); err == nil {
+		err = closeErr
+	}
+	db.lockFile = nil
+	return err
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package datastore
+
+import "os"
+
+// без flock блокування лише позначається: захисту від інших процесів немає
+func flockExclusive(f *os.File) (bool, error) {
+	return true, nil
+}
+
+func funlock(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,65 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	//flock діє на відкритий файл, тож дві Db в одному процесі поводяться як два процеси
+	var wg sync.WaitGroup
+	dbs := make([]*Db, 2)
+	errs := make([]error, 2)
+	for i := range dbs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			dbs[i], errs[i] = NewDb(dir)
+		}(i)
+	}
+	wg.Wait()
+	var db *Db
+	switch {
+	case errs[0] == nil && errs[1] == ErrAlreadyLocked:
+		db = dbs[0]
+	case errs[1] == nil && errs[0] == ErrAlreadyLocked:
+		db = dbs[1]
+	default:
+		t.Fatalf("Expected exactly one ErrAlreadyLocked, got %v and %v", errs[0], errs[1])
+	}
+
+	if ok, err := db.TryLock(); !ok || err != nil {
+		t.Errorf("Expected the holder to keep the lock, got %v, %v", ok, err)
+	}
+	if err := db.Put("key", "value"); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := NewDb(dir, WithReadOnly())
+	if err != nil {
+		t.Fatalf("Expected a read-only open to ignore the lock: %v", err)
+	}
+	if v, err := reader.Get("key"); err != nil || v != "value" {
+		t.Errorf("Bad value: %q, %v", v, err)
+	}
+	reader.Close()
+
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	db, err = NewDb(dir)
+	if err != nil {
+		t.Fatalf("Expected the lock to be released by Close: %v", err)
+	}
+	defer db.Close()
+	if v, err := db.Get("key"); err != nil || v != "value" {
+		t.Errorf("Bad value after reopening: %q, %v", v, err)
+	}
+}
@@ -0,0 +1,154 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+type lwwRegister struct {
+	value string
+	ts    int64
+}
+
+// wins вирішує нічию за значенням, щоб усі репліки зійшлися однаково
+func (r lwwRegister) wins(other lwwRegister) bool {
+	if r.ts != other.ts {
+		return r.ts > other.ts
+	}
+	return r.value > other.value
+}
+
+// LWWMap is a last-write-wins map: every field keeps the value written with
+// the highest timestamp.
+type LWWMap struct {
+	fields map[string]lwwRegister
+}
+
+func (m *LWWMap) Get(field string) (string, int64, bool) {
+	r, ok := m.fields[field]
+	return r.value, r.ts, ok
+}
+
+func (m *LWWMap) Fields() []string {
+	fields := make([]string, 0, len(m.fields))
+	for field := range m.fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// розмітка поля: [len u32][поле][len u32][значення][мітка часу i64]
+func (m *LWWMap) encode() string {
+	var res []byte
+	for _, field := range m.Fields() {
+		r := m.fields[field]
+		res = appendLengthPrefixed(res, field)
+		res = appendLengthPrefixed(res, r.value)
+		res = binary.LittleEndian.AppendUint64(res, uint64(r.ts))
+	}
+	return string(res)
+}
+
+func parseLWWMap(data string) (*LWWMap, error) {
+	m := &LWWMap{fields: make(map[string]lwwRegister)}
+	for prev := ""; len(data) > 0; {
+		field, rest, err := readLengthPrefixed(data)
+		if err != nil {
+			return nil, err
+		}
+		if len(m.fields) > 0 && field <= prev {
+			return nil, fmt.Errorf("lww-map fields out of order")
+		}
+		value, rest, err := readLengthPrefixed(rest)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) < 8 {
+			return nil, fmt.Errorf("corrupted lww-map timestamp")
+		}
+		m.fields[field] = lwwRegister{value, int64(binary.LittleEndian.Uint64([]byte(rest[:8])))}
+		prev = field
+		data = rest[8:]
+	}
+	return m, nil
+}
+
+func validateLWWMap(data string) error {
+	_, err := parseLWWMap(data)
+	return err
+}
+
+func updateLWWMap(db *Db, key string, fn func(m *LWWMap) bool) error {
+	_, err := db.updateEntry(key, func(cur *Entry) (*Entry, error) {
+		m := &LWWMap{fields: make(map[string]lwwRegister)}
+		if cur != nil {
+			if cur.valueType != LWWMAP_TYPE {
+				return nil, fmt.Errorf("wrong type of value")
+			}
+			var err error
+			if m, err = parseLWWMap(cur.value); err != nil {
+				return nil, err
+			}
+		}
+		if !fn(m) {
+			return nil, nil
+		}
+		return &Entry{valueType: LWWMAP_TYPE, value: m.encode()}, nil
+	})
+	return err
+}
+
+// LWWMapSet writes field unless the stored value has a newer timestamp; on
+// an equal timestamp the local write wins.
+func LWWMapSet(db *Db, key, field, value string, ts int64) error {
+	return updateLWWMap(db, key, func(m *LWWMap) bool {
+		if r, ok := m.fields[field]; ok && r.ts > ts {
+			return false
+		}
+		m.fields[field] = lwwRegister{value, ts}
+		return true
+	})
+}
+
+func LWWMapGet(db *Db, key, field string) (string, int64, error) {
+	m, err := LWWMapState(db, key)
+	if err != nil {
+		return "", 0, err
+	}
+	value, ts, ok := m.Get(field)
+	if !ok {
+		return "", 0, ErrNotFound
+	}
+	return value, ts, nil
+}
+
+// LWWMapMerge keeps, per field, the value with the higher timestamp; equal
+// timestamps are resolved by comparing values so replicas converge.
+func LWWMapMerge(db *Db, key string, other *LWWMap) error {
+	return updateLWWMap(db, key, func(m *LWWMap) bool {
+		changed := false
+		for field, r := range other.fields {
+			if cur, ok := m.fields[field]; !ok || r.wins(cur) {
+				m.fields[field] = r
+				changed = true
+			}
+		}
+		return changed
+	})
+}
+
+func LWWMapState(db *Db, key string) (*LWWMap, error) {
+	e, err := db.getEntry(key)
+	if err == ErrNotFound {
+		return &LWWMap{fields: make(map[string]lwwRegister)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if e.valueType != LWWMAP_TYPE {
+		return nil, fmt.Errorf("wrong type of value")
+	}
+	return parseLWWMap(e.value)
+}
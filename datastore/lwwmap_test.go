@@ -0,0 +1,77 @@
+package datastore
+
+import "testing"
+
+func TestLWWMap_ConcurrentWrites(t *testing.T) {
+	a := newTestDb(t)
+	b := newTestDb(t)
+
+	// обидва вузли пишуть те саме поле; у b мітка часу новіша
+	if err := LWWMapSet(a, "doc", "title", "from a", 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := LWWMapSet(b, "doc", "title", "from b", 105); err != nil {
+		t.Fatal(err)
+	}
+	if err := LWWMapSet(a, "doc", "body", "text", 101); err != nil {
+		t.Fatal(err)
+	}
+	if err := LWWMapSet(b, "doc", "tie", "bbb", 50); err != nil {
+		t.Fatal(err)
+	}
+	if err := LWWMapSet(a, "doc", "tie", "aaa", 50); err != nil {
+		t.Fatal(err)
+	}
+
+	stateA, err := LWWMapState(a, "doc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateB, err := LWWMapState(b, "doc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LWWMapMerge(a, "doc", stateB); err != nil {
+		t.Fatal(err)
+	}
+	if err := LWWMapMerge(b, "doc", stateA); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]struct {
+		value string
+		ts    int64
+	}{
+		"title": {"from b", 105},
+		"body":  {"text", 101},
+		"tie":   {"bbb", 50},
+	}
+	for _, db := range []*Db{a, b} {
+		for field, want := range expected {
+			value, ts, err := LWWMapGet(db, "doc", field)
+			if err != nil || value != want.value || ts != want.ts {
+				t.Errorf("Field %s: expected %q@%d, got %q@%d, %v", field, want.value, want.ts, value, ts, err)
+			}
+		}
+	}
+
+	// застарілий запис ігнорується, запис з тією ж міткою перезаписує
+	if err := LWWMapSet(a, "doc", "title", "stale", 104); err != nil {
+		t.Fatal(err)
+	}
+	if value, _, _ := LWWMapGet(a, "doc", "title"); value != "from b" {
+		t.Errorf("Expected stale write to be ignored, got %q", value)
+	}
+	if err := LWWMapSet(a, "doc", "title", "same ts", 105); err != nil {
+		t.Fatal(err)
+	}
+	if value, _, _ := LWWMapGet(a, "doc", "title"); value != "same ts" {
+		t.Errorf("Expected write with equal timestamp to win, got %q", value)
+	}
+	if _, _, err := LWWMapGet(a, "doc", "missing"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if _, err := parseLWWMap("\x01\x00\x00\x00a\x00\x00\x00\x00"); err == nil {
+		t.Error("Expected error for missing timestamp")
+	}
+}
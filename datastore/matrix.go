@@ -0,0 +1,156 @@
+package datastore
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// matrixOperator зберігає [рядки u16][стовпці u16] і елементи float64 по
+// рядках. У Entry.value матриця лежить текстом по рядках: "1 2; 3 4", тож
+// значення починається з першого рядка.
+type matrixOperator struct{}
+
+// обмеження, щоб розмір запису вміщався в u32
+const maxMatrixElements = 1 << 24
+
+func checkMatrixSize(rows, cols int) error {
+	if rows < 1 || cols < 1 || rows > math.MaxUint16 || cols > math.MaxUint16 || rows*cols > maxMatrixElements {
+		return fmt.Errorf("bad matrix size %dx%d", rows, cols)
+	}
+	return nil
+}
+
+func formatMatrix(rows, cols int, data []float64) string {
+	var sb strings.Builder
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			sb.WriteString("; ")
+		}
+		for j := 0; j < cols; j++ {
+			if j > 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(strconv.FormatFloat(data[i*cols+j], 'g', -1, 64))
+		}
+	}
+	return sb.String()
+}
+
+func parseMatrix(s string) (int, int, []float64, error) {
+	lines := strings.Split(s, ";")
+	rows, cols := len(lines), len(strings.Fields(lines[0]))
+	if err := checkMatrixSize(rows, cols); err != nil {
+		return 0, 0, nil, err
+	}
+	data := make([]float64, 0, rows*cols)
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != cols {
+			return 0, 0, nil, fmt.Errorf("matrix rows have different lengths")
+		}
+		for _, field := range fields {
+			x, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return 0, 0, nil, fmt.Errorf("bad matrix element %q", field)
+			}
+			data = append(data, x)
+		}
+	}
+	return rows, cols, data, nil
+}
+
+func decodeMatrix(data []byte) string {
+	rows := int(binary.LittleEndian.Uint16(data))
+	cols := int(binary.LittleEndian.Uint16(data[2:]))
+	elems := make([]float64, rows*cols)
+	for i := range elems {
+		elems[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[4+8*i:]))
+	}
+	return formatMatrix(rows, cols, elems)
+}
+
+func (s matrixOperator) Encode(e *Entry, dst []byte) []byte {
+	res, err := s.EncodeInto(e, dst[:0])
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+func (s matrixOperator) EncodeInto(e *Entry, dst []byte) ([]byte, error) {
+	rows, cols, data, err := parseMatrix(e.value)
+	if err != nil {
+		return dst, err
+	}
+	dst = appendKey(dst, e, 8*len(data))
+	dst = append(dst, MATRIX_TYPE)
+	dst = binary.LittleEndian.AppendUint16(dst, uint16(rows))
+	dst = binary.LittleEndian.AppendUint16(dst, uint16(cols))
+	for _, x := range data {
+		dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(x))
+	}
+	return dst, nil
+}
+
+func (s matrixOperator) Decode(input []byte, e *Entry) {
+	kl := len(e.key)
+	e.value = decodeMatrix(input[kl+TYPE_SIZE+8:])
+}
+
+func (s matrixOperator) Read(in *bufio.Reader) (string, error) {
+	header, err := in.Peek(4)
+	if err != nil {
+		return "", err
+	}
+	n := int(binary.LittleEndian.Uint16(header)) * int(binary.LittleEndian.Uint16(header[2:]))
+	data, err := in.Peek(4 + 8*n)
+	if err != nil {
+		return "", err
+	}
+	return decodeMatrix(data), nil
+}
+
+func NewMatrixEntry(key string, rows, cols int, data []float64) (*Entry, error) {
+	if err := checkMatrixSize(rows, cols); err != nil {
+		return nil, err
+	}
+	if len(data) != rows*cols {
+		return nil, fmt.Errorf("matrix %dx%d needs %d elements, got %d", rows, cols, rows*cols, len(data))
+	}
+	return &Entry{key: key, valueType: MATRIX_TYPE, value: formatMatrix(rows, cols, data)}, nil
+}
+
+func (e *Entry) GetMatrix() (rows, cols int, data []float64, err error) {
+	if e.valueType != MATRIX_TYPE {
+		return 0, 0, nil, fmt.Errorf("wrong type of value")
+	}
+	return parseMatrix(e.value)
+}
+
+// MatrixMultiply returns the product a×b under the key of a.
+func MatrixMultiply(a, b *Entry) (*Entry, error) {
+	n, m, x, err := a.GetMatrix()
+	if err != nil {
+		return nil, err
+	}
+	m2, p, y, err := b.GetMatrix()
+	if err != nil {
+		return nil, err
+	}
+	if m != m2 {
+		return nil, fmt.Errorf("cannot multiply %dx%d by %dx%d matrix", n, m, m2, p)
+	}
+	res := make([]float64, n*p)
+	for i := 0; i < n; i++ {
+		for k := 0; k < m; k++ {
+			for j := 0; j < p; j++ {
+				res[i*p+j] += x[i*m+k] * y[k*p+j]
+			}
+		}
+	}
+	return NewMatrixEntry(a.key, n, p, res)
+}
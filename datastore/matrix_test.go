@@ -0,0 +1,58 @@
+package datastore
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMatrix(t *testing.T) {
+	a, err := NewMatrixEntry("a", 2, 2, []float64{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.value != "1 2; 3 4" {
+		t.Errorf("Bad matrix text: %s", a.value)
+	}
+	data := a.Encode()
+	if len(data) != len(a.key)+8+TYPE_SIZE+4+4*8 {
+		t.Errorf("Bad encoded size: %d", len(data))
+	}
+	decoded, err := DecodeMany(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, cols, elems, err := decoded[0].GetMatrix()
+	if err != nil || rows != 2 || cols != 2 || fmt.Sprint(elems) != "[1 2 3 4]" {
+		t.Errorf("Bad matrix round trip: %dx%d %v, %v", rows, cols, elems, err)
+	}
+
+	b, _ := NewMatrixEntry("b", 2, 3, []float64{5, 6, 7, 8, 9, 10})
+	product, err := MatrixMultiply(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, cols, elems, _ = product.GetMatrix()
+	if rows != 2 || cols != 3 || fmt.Sprint(elems) != "[21 24 27 47 54 61]" {
+		t.Errorf("Bad product: %dx%d %v", rows, cols, elems)
+	}
+	if _, err := MatrixMultiply(b, a); err == nil {
+		t.Errorf("Expected an error for mismatched sizes")
+	}
+
+	if _, err := NewMatrixEntry("m", 2, 2, []float64{1, 2, 3}); err == nil {
+		t.Errorf("Expected an error for a short data slice")
+	}
+	if _, err := NewMatrixEntry("m", 0, 2, nil); err == nil {
+		t.Errorf("Expected an error for an empty matrix")
+	}
+	for _, bad := range []string{"", "1 2; 3", "1 x"} {
+		if _, err := EncodeInto(&Entry{key: "m", valueType: MATRIX_TYPE, value: bad}, nil); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+	//заголовок обіцяє більше елементів, ніж є в записі
+	data[len(a.key)+8+TYPE_SIZE] = 3
+	if _, err := DecodeMany(data); err == nil {
+		t.Errorf("Expected an error for a corrupted matrix header")
+	}
+}
@@ -0,0 +1,214 @@
+package datastore
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	memcachedVersion      = "1.6.0-datastore"
+	maxMemcachedKeyLen    = 250
+	maxMemcachedValueSize = 1 << 20
+	// exptime більший за 30 днів memcached трактує як unix-час
+	memcachedRelativeExpiry = 60 * 60 * 24 * 30
+)
+
+// MemcachedServer serves the memcached ASCII protocol (get, set, delete,
+// version) on top of a Db. Values are string entries; the flags of a set are
+// kept as an int64 entry under "<key>:flags".
+type MemcachedServer struct {
+	tcpServer
+	db *Db
+	//значення і прапорці - два записи, тож set і get мають бути атомарними разом
+	dbMu sync.Mutex
+}
+
+func NewMemcachedServer(db *Db, addr string) (*MemcachedServer, error) {
+	s := &MemcachedServer{db: db}
+	if err := s.listen(addr, s.handle); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func memcachedFlagsKey(key string) string {
+	return key + ":flags"
+}
+
+func validMemcachedKey(key string) bool {
+	if len(key) == 0 || len(key) > maxMemcachedKeyLen {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *MemcachedServer) handle(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		line, err := readRESPLine(r)
+		if err != nil {
+			return
+		}
+		if err := s.execute(r, w, strings.Fields(line)); err != nil {
+			w.Flush()
+			return
+		}
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// execute повертає помилку лише тоді, коли з'єднання далі не можна читати
+func (s *MemcachedServer) execute(r *bufio.Reader, w *bufio.Writer, args []string) error {
+	if len(args) == 0 {
+		w.WriteString("ERROR\r\n")
+		return nil
+	}
+	switch args[0] {
+	case "get":
+		if len(args) < 2 {
+			w.WriteString("ERROR\r\n")
+			return nil
+		}
+		s.get(w, args[1:])
+	case "set":
+		return s.set(r, w, args[1:])
+	case "delete":
+		if len(args) < 2 || len(args) > 3 {
+			w.WriteString("ERROR\r\n")
+			return nil
+		}
+		reply := s.delete(args[1])
+		if len(args) == 3 && args[2] == "noreply" {
+			return nil
+		}
+		w.WriteString(reply)
+	case "version":
+		fmt.Fprintf(w, "VERSION %s\r\n", memcachedVersion)
+	default:
+		w.WriteString("ERROR\r\n")
+	}
+	return nil
+}
+
+func (s *MemcachedServer) get(w *bufio.Writer, keys []string) {
+	s.dbMu.Lock()
+	defer s.dbMu.Unlock()
+	for _, key := range keys {
+		if !validMemcachedKey(key) {
+			continue
+		}
+		val, err := s.db.Get(key)
+		if err != nil {
+			continue
+		}
+		flags, err := s.db.GetInt64(memcachedFlagsKey(key))
+		if err != nil {
+			flags = 0
+		}
+		fmt.Fprintf(w, "VALUE %s %d %d\r\n%s\r\n", key, flags, len(val), val)
+	}
+	w.WriteString("END\r\n")
+}
+
+func (s *MemcachedServer) set(r *bufio.Reader, w *bufio.Writer, args []string) error {
+	if len(args) != 4 && len(args) != 5 {
+		w.WriteString("ERROR\r\n")
+		return nil
+	}
+	key := args[0]
+	flags, flagsErr := strconv.ParseUint(args[1], 10, 32)
+	exptime, expErr := strconv.ParseInt(args[2], 10, 64)
+	size, sizeErr := strconv.Atoi(args[3])
+	noreply := len(args) == 5 && args[4] == "noreply"
+	if sizeErr != nil || size < 0 || size > maxMemcachedValueSize {
+		w.WriteString("CLIENT_ERROR bad data chunk\r\n")
+		return fmt.Errorf("bad data chunk")
+	}
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return err
+	}
+	if string(data[size:]) != "\r\n" {
+		w.WriteString("CLIENT_ERROR bad data chunk\r\n")
+		return nil
+	}
+	if !validMemcachedKey(key) || flagsErr != nil || expErr != nil {
+		w.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return nil
+	}
+
+	var ttl time.Duration
+	switch {
+	case exptime > memcachedRelativeExpiry:
+		ttl = time.Unix(exptime, 0).Sub(timeNow())
+	case exptime != 0:
+		ttl = time.Duration(exptime) * time.Second
+	}
+	//запис, що вже застарів, memcached приймає, але одразу видаляє
+	if exptime != 0 && ttl <= 0 {
+		s.delete(key)
+		if !noreply {
+			w.WriteString("STORED\r\n")
+		}
+		return nil
+	}
+
+	s.dbMu.Lock()
+	err := s.putWithFlags(key, string(data[:size]), int64(flags), ttl)
+	s.dbMu.Unlock()
+	if noreply {
+		return nil
+	}
+	if err != nil {
+		fmt.Fprintf(w, "SERVER_ERROR %s\r\n", strings.NewReplacer("\r", " ", "\n", " ").Replace(err.Error()))
+		return nil
+	}
+	w.WriteString("STORED\r\n")
+	return nil
+}
+
+func (s *MemcachedServer) putWithFlags(key, value string, flags int64, ttl time.Duration) error {
+	//прапорці пишемо першими, щоб значення ніколи не було видно зі старими прапорцями
+	flagsEntry := &Entry{key: memcachedFlagsKey(key), valueType: INT64_TYPE, value: strconv.FormatInt(flags, 10)}
+	e := &Entry{key: key, valueType: STRING_TYPE, value: value}
+	flagsEntry.SetTTL(0, ttl)
+	e.SetTTL(0, ttl)
+	if err := s.db.putEntry(flagsEntry); err != nil {
+		return err
+	}
+	return s.db.putEntry(e)
+}
+
+func (s *MemcachedServer) delete(key string) string {
+	if !validMemcachedKey(key) {
+		return "CLIENT_ERROR bad command line format\r\n"
+	}
+	s.dbMu.Lock()
+	defer s.dbMu.Unlock()
+	if _, _, err := s.db.getType(key); err == ErrNotFound {
+		return "NOT_FOUND\r\n"
+	} else if err != nil {
+		return fmt.Sprintf("SERVER_ERROR %s\r\n", err)
+	}
+	if err := s.db.putTombstone(key); err != nil {
+		return fmt.Sprintf("SERVER_ERROR %s\r\n", err)
+	}
+	s.db.putTombstone(memcachedFlagsKey(key))
+	return "DELETED\r\n"
+}
@@ -0,0 +1,166 @@
+package datastore
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newMemcachedTestServer(t *testing.T) (*Db, *MemcachedServer) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	s, err := NewMemcachedServer(db, "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return db, s
+}
+
+type memcachedTestConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialMemcached(t *testing.T, s *MemcachedServer) *memcachedTestConn {
+	conn, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &memcachedTestConn{conn, bufio.NewReader(conn)}
+}
+
+// do надсилає запит і читає відповідь до рядка, яким вона закінчується
+func (c *memcachedTestConn) do(req string, last func(string) bool) ([]string, error) {
+	if _, err := c.conn.Write([]byte(req)); err != nil {
+		return nil, err
+	}
+	var lines []string
+	for {
+		line, err := readRESPLine(c.r)
+		if err != nil {
+			return lines, err
+		}
+		lines = append(lines, line)
+		if last(line) {
+			return lines, nil
+		}
+	}
+}
+
+func anyLine(string) bool { return true }
+
+func endLine(line string) bool { return line == "END" }
+
+func TestMemcachedServer(t *testing.T) {
+	db, s := newMemcachedTestServer(t)
+	c := dialMemcached(t, s)
+
+	cases := []struct {
+		req      string
+		last     func(string) bool
+		expected string
+	}{
+		{"version\r\n", anyLine, "VERSION " + memcachedVersion},
+		{"set key 42 0 11\r\nhello world\r\n", anyLine, "STORED"},
+		{"get key missing\r\n", endLine, "VALUE key 42 11|hello world|END"},
+		{"set other 0 0 1 noreply\r\nx\r\nget other\r\n", endLine, "VALUE other 0 1|x|END"},
+		{"delete key\r\n", anyLine, "DELETED"},
+		{"delete key\r\n", anyLine, "NOT_FOUND"},
+		{"get key\r\n", endLine, "END"},
+		{"set key x 0 1\r\nx\r\n", anyLine, "CLIENT_ERROR bad command line format"},
+		{"set key 0 -1 1\r\nx\r\nget key\r\n", endLine, "STORED|END"},
+		{"flush_all\r\n", anyLine, "ERROR"},
+	}
+	for _, tc := range cases {
+		lines, err := c.do(tc.req, tc.last)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(lines, "|"); got != tc.expected {
+			t.Errorf("Request %q: expected %q, got %q", tc.req, tc.expected, got)
+		}
+	}
+
+	if _, err := c.do("set key 7 0 5\r\nvalue\r\n", anyLine); err != nil {
+		t.Fatal(err)
+	}
+	if flags, err := db.GetInt64("key:flags"); err != nil || flags != 7 {
+		t.Errorf("Expected flags stored as int64 entry, got %d, %v", flags, err)
+	}
+}
+
+func TestMemcachedServer_Expiry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	_, s := newMemcachedTestServer(t)
+	c := dialMemcached(t, s)
+	if _, err := c.do("set key 1 10 5\r\nvalue\r\n", anyLine); err != nil {
+		t.Fatal(err)
+	}
+	if lines, _ := c.do("get key\r\n", endLine); len(lines) != 3 {
+		t.Errorf("Expected value before expiry, got %v", lines)
+	}
+	now = now.Add(11 * time.Second)
+	if lines, _ := c.do("get key\r\n", endLine); len(lines) != 1 {
+		t.Errorf("Expected miss after expiry, got %v", lines)
+	}
+}
+
+func TestMemcachedServer_GetThenSetRace(t *testing.T) {
+	_, s := newMemcachedTestServer(t)
+
+	const clients = 8
+	const rounds = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, clients)
+	for i := 0; i < clients; i++ {
+		c := dialMemcached(t, s)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < rounds; j++ {
+				value := fmt.Sprintf("value-%d", i)
+				req := fmt.Sprintf("set race %d 0 %d\r\n%s\r\n", i, len(value), value)
+				if lines, err := c.do(req, anyLine); err != nil || lines[0] != "STORED" {
+					errs <- fmt.Errorf("client %d: bad set reply %v, %v", i, lines, err)
+					return
+				}
+				lines, err := c.do("get race\r\n", endLine)
+				if err != nil || len(lines) != 3 {
+					errs <- fmt.Errorf("client %d: bad get reply %v, %v", i, lines, err)
+					return
+				}
+				//значення іншого клієнта допустиме, але прапорці мають бути від того самого set
+				var flags, size int
+				fmt.Sscanf(lines[0], "VALUE race %d %d", &flags, &size)
+				if lines[1] != fmt.Sprintf("value-%d", flags) || size != len(lines[1]) {
+					errs <- fmt.Errorf("client %d: value %q read with flags %d", i, lines[1], flags)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,200 @@
+package datastore
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Migration struct {
+	Version int
+	Up      func(entries []*Entry) ([]*Entry, error)
+	Down    func(entries []*Entry) ([]*Entry, error)
+}
+
+type Migrator struct {
+	migrations []Migration
+}
+
+func NewMigrator() *Migrator {
+	return &Migrator{}
+}
+
+func (m *Migrator) Register(migration Migration) {
+	m.migrations = append(m.migrations, migration)
+}
+
+func schemaPath(db *Db) string {
+	return strings.TrimRight(db.dir, string(os.PathSeparator)) + ".schema"
+}
+
+// SchemaVersion returns the version of the last migration applied to db, or 0.
+func SchemaVersion(db *Db) (int, error) {
+	data, err := os.ReadFile(schemaPath(db))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	version, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("corrupted schema version: %v", err)
+	}
+	return version, nil
+}
+
+func setSchemaVersion(db *Db, version int) error {
+	return writeFileAtomic(schemaPath(db), func(w *bufio.Writer) error {
+		_, err := fmt.Fprintln(w, version)
+		return err
+	})
+}
+
+func (m *Migrator) sorted() ([]Migration, error) {
+	res := append([]Migration(nil), m.migrations...)
+	sort.Slice(res, func(i, j int) bool { return res[i].Version < res[j].Version })
+	for i, migration := range res {
+		if migration.Version <= 0 {
+			return nil, fmt.Errorf("migration version must be positive, got %d", migration.Version)
+		}
+		if i > 0 && res[i-1].Version == migration.Version {
+			return nil, fmt.Errorf("duplicate migration version %d", migration.Version)
+		}
+	}
+	return res, nil
+}
+
+// RunMigrations brings db to targetVersion, running Up functions in order when
+// upgrading and Down functions in reverse order when downgrading. targetVersion
+// must be 0 or the version of a registered migration.
+func (m *Migrator) RunMigrations(db *Db, targetVersion int) error {
+	migrations, err := m.sorted()
+	if err != nil {
+		return err
+	}
+	known := targetVersion == 0
+	for _, migration := range migrations {
+		known = known || migration.Version == targetVersion
+	}
+	if !known {
+		return fmt.Errorf("unknown migration version %d", targetVersion)
+	}
+	current, err := SchemaVersion(db)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range migrations {
+		if migration.Version <= current || migration.Version > targetVersion {
+			continue
+		}
+		if err := applyMigration(db, migration.Up); err != nil {
+			return fmt.Errorf("migration %d up: %v", migration.Version, err)
+		}
+		if err := setSchemaVersion(db, migration.Version); err != nil {
+			return err
+		}
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+		if migration.Version > current || migration.Version <= targetVersion {
+			continue
+		}
+		if migration.Down == nil {
+			return fmt.Errorf("migration %d has no down function", migration.Version)
+		}
+		if err := applyMigration(db, migration.Down); err != nil {
+			return fmt.Errorf("migration %d down: %v", migration.Version, err)
+		}
+		previous := 0
+		if i > 0 {
+			previous = migrations[i-1].Version
+		}
+		if err := setSchemaVersion(db, previous); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rollback undoes the last steps applied migrations.
+func (m *Migrator) Rollback(db *Db, steps int) error {
+	migrations, err := m.sorted()
+	if err != nil {
+		return err
+	}
+	current, err := SchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	var applied []int
+	for _, migration := range migrations {
+		if migration.Version <= current {
+			applied = append(applied, migration.Version)
+		}
+	}
+	if steps > len(applied) {
+		return fmt.Errorf("can't roll back %d migrations, only %d applied", steps, len(applied))
+	}
+	target := 0
+	if steps < len(applied) {
+		target = applied[len(applied)-steps-1]
+	}
+	return m.RunMigrations(db, target)
+}
+
+// applyMigration записує лише різницю між станом до і після міграції
+func applyMigration(db *Db, fn func(entries []*Entry) ([]*Entry, error)) error {
+	//entries() віддає записи без анотацій, а міграція має їх бачити і зберігати
+	entries, err := db.scanEntries()
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	before := make(map[string]Entry, len(entries))
+	for _, e := range entries {
+		before[e.key] = *e
+	}
+
+	//міграція може змінювати записи на місці, тож порівнюємо з копіями
+	res, err := fn(entries)
+	if err != nil {
+		return err
+	}
+
+	after := make(map[string]bool, len(res))
+	for _, e := range res {
+		if _, ok := operators[e.valueType]; !ok || e.valueType == TOMBSTONE_TYPE {
+			return fmt.Errorf("unknown value type %d for key %s", e.valueType, e.key)
+		}
+		if after[e.key] {
+			return fmt.Errorf("duplicate key %s", e.key)
+		}
+		after[e.key] = true
+	}
+	for _, e := range res {
+		old, ok := before[e.key]
+		//новий запис під тим самим ключем зберігає TTL і мітку видалення
+		if ok && e.annotations == "" {
+			e.annotations = old.annotations
+		}
+		if ok && old == *e {
+			continue
+		}
+		if err := db.putEntry(e); err != nil {
+			return err
+		}
+	}
+	for key := range before {
+		if !after[key] {
+			if err := db.putTombstone(key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func renamePrefix(from, to string) func(entries []*Entry) ([]*Entry, error) {
+	return func(entries []*Entry) ([]*Entry, error) {
+		for _, e := range entries {
+			if rest, ok := strings.CutPrefix(e.key, from); ok {
+				e.key = to + rest
+			}
+		}
+		return entries, nil
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Remove(dir + ".schema")
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for i := 0; i < 10; i++ {
+		if err := db.Put(fmt.Sprintf("user:%d", i), fmt.Sprintf("name%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.PutInt64("counter", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMigrator()
+	m.Register(Migration{Version: 2, Up: func(entries []*Entry) ([]*Entry, error) {
+		return append(entries, &Entry{key: "schema", value: "v2"}), nil
+	}, Down: func(entries []*Entry) ([]*Entry, error) {
+		var res []*Entry
+		for _, e := range entries {
+			if e.key != "schema" {
+				res = append(res, e)
+			}
+		}
+		return res, nil
+	}})
+	m.Register(Migration{Version: 1, Up: renamePrefix("user:", "account:"), Down: renamePrefix("account:", "user:")})
+
+	if err := m.RunMigrations(db, 1); err != nil {
+		t.Fatal(err)
+	}
+	if version, err := SchemaVersion(db); err != nil || version != 1 {
+		t.Errorf("Expected schema version 1, got %d, %v", version, err)
+	}
+	if value, err := db.Get("account:3"); err != nil || value != "name3" {
+		t.Errorf("Expected renamed key, got %q, %v", value, err)
+	}
+	if _, err := db.Get("user:3"); err != ErrNotFound {
+		t.Errorf("Expected old key to be removed, got %v", err)
+	}
+	if n, err := db.GetInt64("counter"); err != nil || n != 1 {
+		t.Errorf("Expected untouched key to stay, got %d, %v", n, err)
+	}
+
+	if err := m.RunMigrations(db, 2); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := db.Get("schema"); err != nil || value != "v2" {
+		t.Errorf("Expected migration 2 to run, got %q, %v", value, err)
+	}
+
+	if err := m.Rollback(db, 2); err != nil {
+		t.Fatal(err)
+	}
+	if version, err := SchemaVersion(db); err != nil || version != 0 {
+		t.Errorf("Expected schema version 0, got %d, %v", version, err)
+	}
+	if value, err := db.Get("user:3"); err != nil || value != "name3" {
+		t.Errorf("Expected rollback to restore key, got %q, %v", value, err)
+	}
+	if _, err := db.Get("schema"); err != ErrNotFound {
+		t.Errorf("Expected rollback to remove key, got %v", err)
+	}
+	if entries, err := db.entries(); err != nil || len(entries) != 11 {
+		t.Errorf("Expected 11 entries after rollback, got %d, %v", len(entries), err)
+	}
+
+	if err := m.RunMigrations(db, 3); err == nil {
+		t.Error("Expected error for unknown target version")
+	}
+	if err := m.Rollback(db, 1); err == nil {
+		t.Error("Expected error when nothing is applied")
+	}
+	m.Register(Migration{Version: 1})
+	if err := m.RunMigrations(db, 1); err == nil {
+		t.Error("Expected error for duplicate migration version")
+	}
+}
+
+func TestMigratorKeepsAnnotations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Remove(dir + ".schema")
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.PutWithTTL("session", "token", time.Hour, 2*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	before, err := db.getEntry("session")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//міграція будує нові записи замість того, щоб змінювати старі
+	m := NewMigrator()
+	m.Register(Migration{Version: 1, Up: func(entries []*Entry) ([]*Entry, error) {
+		var res []*Entry
+		for _, e := range entries {
+			res = append(res, &Entry{key: e.key, valueType: e.valueType, value: strings.ToUpper(e.value)})
+		}
+		return res, nil
+	}})
+	if err := m.RunMigrations(db, 1); err != nil {
+		t.Fatal(err)
+	}
+	e, err := db.getEntry("session")
+	if err != nil || e.value != "TOKEN" {
+		t.Fatalf("Expected the migrated value, got %v, %v", e, err)
+	}
+	wantSoft, _ := before.SoftExpiry()
+	wantHard, _ := before.HardExpiry()
+	if soft, ok := e.SoftExpiry(); !ok || !soft.Equal(wantSoft) {
+		t.Errorf("Expected soft expiry %v, got %v, %v", wantSoft, soft, ok)
+	}
+	if hard, ok := e.HardExpiry(); !ok || !hard.Equal(wantHard) {
+		t.Errorf("Expected hard expiry %v, got %v, %v", wantHard, hard, ok)
+	}
+}
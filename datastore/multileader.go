@@ -0,0 +1,245 @@
+package datastore
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// clockAnnotation тримає векторний годинник версії ключа в кодуванні vclock.go
+const clockAnnotation = "multileader.clock"
+
+// ConflictResolver picks the value of key when local and remote versions
+// were written concurrently on different leaders. It must be deterministic
+// so that every leader settles on the same value.
+type ConflictResolver func(key string, local, remote *Entry) (*Entry, error)
+
+// MultiLeader accepts writes on every leader and exchanges them with its
+// peers. Each write carries a vector clock of (leader ID, sequence number)
+// pairs; a received version that neither precedes nor follows the local
+// one is a conflict and goes to the ConflictResolver.
+type MultiLeader struct {
+	db      *Db
+	localID string
+	peers   []string
+	client  *http.Client
+
+	mu       sync.Mutex
+	seq      uint64
+	pending  []*Entry
+	resolver ConflictResolver
+}
+
+// NewMultiLeader makes db a leader named localID. peers are the base URLs of
+// the other leaders, each serving its MultiLeader as an http.Handler.
+func NewMultiLeader(localID string, peers []string, db *Db) (*MultiLeader, error) {
+	if localID == "" {
+		return nil, fmt.Errorf("leader id must not be empty")
+	}
+	ml := &MultiLeader{
+		db:       db,
+		localID:  localID,
+		peers:    peers,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		resolver: resolveByValue,
+	}
+	//лічильник продовжуємо з найбільшого, що вже є в базі
+	err := db.scanVersions(func(key string, e *Entry) error {
+		if e == nil {
+			return nil
+		}
+		clock, err := entryClock(e)
+		if err != nil {
+			return err
+		}
+		if clock[localID] > ml.seq {
+			ml.seq = clock[localID]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ml, nil
+}
+
+// resolveByValue - типовий вибір: більше значення перемагає, як у LWW-мапі
+// при однакових мітках
+func resolveByValue(key string, local, remote *Entry) (*Entry, error) {
+	if remote.value > local.value {
+		return remote, nil
+	}
+	return local, nil
+}
+
+// SetConflictResolver replaces the default resolver, which keeps the greater
+// value.
+func (ml *MultiLeader) SetConflictResolver(resolver ConflictResolver) {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	ml.resolver = resolver
+}
+
+func entryClock(e *Entry) (map[string]uint64, error) {
+	data, ok := e.Annotation(clockAnnotation)
+	if !ok {
+		return map[string]uint64{}, nil
+	}
+	return parseVectorClock(data)
+}
+
+// localClock повертає годинник поточної версії key; викликається під ml.mu
+func (ml *MultiLeader) localClock(key string) (*Entry, map[string]uint64, error) {
+	cur, err := ml.db.getEntry(key)
+	if err == ErrNotFound {
+		return nil, map[string]uint64{}, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	clock, err := entryClock(cur)
+	return cur, clock, err
+}
+
+func (ml *MultiLeader) writeVersion(e *Entry, clock map[string]uint64) error {
+	data, err := encodeVectorClock(clock)
+	if err != nil {
+		return err
+	}
+	e.Annotate(clockAnnotation, data)
+	return ml.db.putEntry(e)
+}
+
+// Put writes value under key on this leader; the next Broadcast sends it to
+// the peers.
+func (ml *MultiLeader) Put(key, value string) error {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	_, clock, err := ml.localClock(key)
+	if err != nil {
+		return err
+	}
+	ml.seq++
+	clock[ml.localID] = ml.seq
+	e := &Entry{key: key, valueType: STRING_TYPE, value: value}
+	if err := ml.writeVersion(e, clock); err != nil {
+		return err
+	}
+	ml.pending = append(ml.pending, e)
+	return nil
+}
+
+// Broadcast pushes the local writes made since the last successful
+// Broadcast to every peer. Writes stay queued until all peers accepted
+// them; peers ignore versions they already have.
+func (ml *MultiLeader) Broadcast() error {
+	ml.mu.Lock()
+	pending := ml.pending
+	ml.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+	var body []byte
+	for _, e := range pending {
+		var err error
+		if body, err = EncodeInto(e, body); err != nil {
+			return err
+		}
+	}
+	var errs []string
+	for _, peer := range ml.peers {
+		if err := ml.send(peer, body); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", peer, err))
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("broadcast failed: %s", strings.Join(errs, "; "))
+	}
+	ml.mu.Lock()
+	ml.pending = ml.pending[len(pending):]
+	ml.mu.Unlock()
+	return nil
+}
+
+func (ml *MultiLeader) send(peer string, body []byte) error {
+	resp, err := ml.client.Post(strings.TrimRight(peer, "/")+"/replicate", "application/octet-stream", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("peer responded %s", resp.Status)
+	}
+	return nil
+}
+
+// ServeHTTP receives the writes a peer broadcasts.
+func (ml *MultiLeader) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 64<<20))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	entries, err := DecodeMany(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, e := range entries {
+		if err := ml.apply(e); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// apply порівнює годинники: старішу версію пропускає, новішу пише, а
+// паралельну віддає resolver; результат отримує об'єднаний годинник, тож
+// лідери сходяться без повторного обміну
+func (ml *MultiLeader) apply(remote *Entry) error {
+	remoteClock, err := entryClock(remote)
+	if err != nil {
+		return err
+	}
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	local, localClock, err := ml.localClock(remote.key)
+	if err != nil {
+		return err
+	}
+	switch {
+	case local == nil || HappensBefore(localClock, remoteClock):
+		return ml.db.putEntry(remote)
+	case !HappensBefore(remoteClock, localClock) && !clocksEqual(localClock, remoteClock):
+		winner, err := ml.resolver(remote.key, local, remote)
+		if err != nil {
+			return err
+		}
+		resolved := &Entry{key: remote.key, valueType: winner.valueType, value: winner.value}
+		return ml.writeVersion(resolved, MergeClocks(localClock, remoteClock))
+	}
+	return nil
+}
+
+func clocksEqual(a, b map[string]uint64) bool {
+	//відсутній вузол рівнозначний нулю
+	for node, n := range a {
+		if b[node] != n {
+			return false
+		}
+	}
+	for node, n := range b {
+		if a[node] != n {
+			return false
+		}
+	}
+	return true
+}
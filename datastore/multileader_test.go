@@ -0,0 +1,121 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestMultiLeader(t *testing.T) {
+	newLeader := func(id string) (*MultiLeader, *Db) {
+		dir, err := ioutil.TempDir("", "test-db")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.RemoveAll(dir) })
+		db, err := NewDb(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		ml, err := NewMultiLeader(id, nil, db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ml, db
+	}
+	a, dbA := newLeader("eu")
+	b, dbB := newLeader("us")
+	serverA, serverB := httptest.NewServer(a), httptest.NewServer(b)
+	defer serverA.Close()
+	defer serverB.Close()
+	a.peers, b.peers = []string{serverB.URL}, []string{serverA.URL}
+
+	var mu sync.Mutex
+	conflicts := map[string]int{}
+	resolver := func(key string, local, remote *Entry) (*Entry, error) {
+		mu.Lock()
+		conflicts[key]++
+		mu.Unlock()
+		if len(remote.value) > len(local.value) {
+			return remote, nil
+		}
+		return local, nil
+	}
+	a.SetConflictResolver(resolver)
+	b.SetConflictResolver(resolver)
+
+	//обидва лідери пишуть той самий ключ, не бачачи один одного
+	var wg sync.WaitGroup
+	for _, w := range []struct {
+		ml    *MultiLeader
+		value string
+	}{{a, "from eu"}, {b, "from the us"}} {
+		wg.Add(1)
+		go func(ml *MultiLeader, value string) {
+			defer wg.Done()
+			if err := ml.Put("shared", value); err != nil {
+				t.Error(err)
+			}
+		}(w.ml, w.value)
+	}
+	wg.Wait()
+	if err := a.Put("eu-only", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Broadcast(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Broadcast(); err != nil {
+		t.Fatal(err)
+	}
+
+	if conflicts["shared"] != 2 || len(conflicts) != 1 {
+		t.Errorf("Expected the resolver to be called once on each leader, got %v", conflicts)
+	}
+	for _, db := range []*Db{dbA, dbB} {
+		if v, err := db.Get("shared"); err != nil || v != "from the us" {
+			t.Errorf("Expected the leaders to converge, got %q, %v", v, err)
+		}
+	}
+
+	//запис після отримання причинно новіший і конфлікту не дає
+	if err := b.Put("eu-only", "2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Broadcast(); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := dbA.Get("eu-only"); v != "2" || conflicts["eu-only"] != 0 {
+		t.Errorf("Expected the causal update to win without a conflict, got %q", v)
+	}
+	//повторна доставка нічого не змінює
+	if err := b.send(serverA.URL, mustEncode(t, dbB, "shared")); err != nil {
+		t.Fatal(err)
+	}
+	if conflicts["shared"] != 2 {
+		t.Errorf("Expected a redelivered version to be ignored, got %v", conflicts)
+	}
+
+	restarted, err := NewMultiLeader("eu", nil, dbA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restarted.seq != a.seq {
+		t.Errorf("Expected the sequence to resume at %d, got %d", a.seq, restarted.seq)
+	}
+}
+
+func mustEncode(t *testing.T, db *Db, key string) []byte {
+	e, err := db.getEntry(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := EncodeInto(e, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
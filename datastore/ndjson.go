@@ -0,0 +1,48 @@
+package datastore
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ExportNDJSON writes every key of the database in src to w as one JSON
+// object per line; it is the same format ExportJSON produces.
+func ExportNDJSON(src string, w io.Writer) error {
+	return ExportJSON(src, w)
+}
+
+// ImportNDJSON writes the records of r, one JSON object per line, into db.
+// A last line that is cut short (no newline and not valid JSON, as left by
+// truncating a file) is skipped with a warning to the Db logger; any other
+// bad line is an error.
+func ImportNDJSON(r io.Reader, db *Db) error {
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		complete := strings.HasSuffix(line, "\n")
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			var record exportRecord
+			if decodeErr := json.Unmarshal([]byte(trimmed), &record); decodeErr != nil {
+				if complete {
+					return fmt.Errorf("line %d: %v", n, decodeErr)
+				}
+				if db.logger != nil {
+					db.logger.Warn("skipped truncated ndjson line", "line", n, "error", decodeErr)
+				}
+				return nil
+			}
+			if err := importRecord(db, record.Key, record); err != nil {
+				return fmt.Errorf("line %d: %v", n, err)
+			}
+		}
+		if !complete {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package datastore
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNDJSONTruncatedImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src, err := NewDb(filepath.Join(dir, "src"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if err := src.Put(fmt.Sprintf("key-%03d", i), fmt.Sprintf("value %d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src.Close()
+
+	var out bytes.Buffer
+	if err := ExportNDJSON(filepath.Join(dir, "src"), &out); err != nil {
+		t.Fatal(err)
+	}
+	//обрізаємо останній рядок посередині, як при недописаному файлі
+	data := out.String()
+	last := strings.LastIndex(strings.TrimSuffix(data, "\n"), "\n")
+	truncated := data[:last+10]
+
+	var logs bytes.Buffer
+	dst, err := NewDb(filepath.Join(dir, "dst"), WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+	if err := ImportNDJSON(strings.NewReader(truncated), dst); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := dst.entries()
+	if len(entries) != 99 {
+		t.Errorf("Expected 99 entries, got %d", len(entries))
+	}
+	if v, err := dst.Get("key-098"); err != nil || v != "value 98" {
+		t.Errorf("Bad imported value: %q, %v", v, err)
+	}
+	if !strings.Contains(logs.String(), "truncated ndjson line") {
+		t.Errorf("Expected a warning for the truncated line, got %q", logs.String())
+	}
+
+	if err := ImportNDJSON(strings.NewReader("{\"key\": \"a\"\n{}\n"), dst); err == nil {
+		t.Errorf("Expected an error for a bad line in the middle")
+	}
+}
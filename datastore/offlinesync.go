@@ -0,0 +1,396 @@
+package datastore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Ключ на сервері несе номер версії syncSeqAnnotation. Клієнт пам'ятає, від
+// якої версії сервера походить його ключ (syncBaseAnnotation), а незапушені
+// зміни позначає локальним номером syncLocalAnnotation.
+const (
+	syncSeqAnnotation   = "sync.seq"
+	syncBaseAnnotation  = "sync.base"
+	syncLocalAnnotation = "sync.local"
+	syncWatermarkHeader = "X-Sync-Watermark"
+)
+
+// ErrSyncConflict means the server has versions the client has not pulled
+// yet; Pull resolves them and the Push can be repeated.
+var ErrSyncConflict = fmt.Errorf("server has newer versions, pull before pushing")
+
+func annotationUint(e *Entry, name string) uint64 {
+	v, ok := e.Annotation(name)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.ParseUint(v, 10, 64)
+	return n
+}
+
+// syncCopy повертає копію e без службових анотацій синхронізації
+func syncCopy(e *Entry) *Entry {
+	res := &Entry{key: e.key, valueType: e.valueType, value: e.value}
+	for name, value := range e.Annotations() {
+		if name != syncSeqAnnotation && name != syncBaseAnnotation && name != syncLocalAnnotation {
+			res.Annotate(name, value)
+		}
+	}
+	return res
+}
+
+func encodeEntries(entries []*Entry) ([]byte, error) {
+	var body []byte
+	for _, e := range entries {
+		var err error
+		if body, err = EncodeInto(e, body); err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
+
+// SyncServer is the central copy offline clients sync with. Serve it over
+// HTTP: GET returns the versions after the since watermark, POST accepts a
+// client's changes.
+type SyncServer struct {
+	db  *Db
+	mu  sync.Mutex
+	seq uint64
+}
+
+func NewSyncServer(db *Db) (*SyncServer, error) {
+	s := &SyncServer{db: db}
+	err := db.scanVersions(func(key string, e *Entry) error {
+		if e != nil {
+			if seq := annotationUint(e, syncSeqAnnotation); seq > s.seq {
+				s.seq = seq
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *SyncServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		since, err := strconv.ParseUint(r.URL.Query().Get("since"), 10, 64)
+		if err != nil {
+			http.Error(w, "bad since watermark", http.StatusBadRequest)
+			return
+		}
+		s.serveChanges(w, since)
+	case http.MethodPost:
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 64<<20))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		entries, err := DecodeMany(data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.accept(w, entries)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *SyncServer) serveChanges(w http.ResponseWriter, since uint64) {
+	s.mu.Lock()
+	watermark := s.seq
+	var changes []*Entry
+	err := s.db.scanVersions(func(key string, e *Entry) error {
+		if e != nil && annotationUint(e, syncSeqAnnotation) > since {
+			changes = append(changes, e)
+		}
+		return nil
+	})
+	s.mu.Unlock()
+	if err == nil {
+		var body []byte
+		if body, err = encodeEntries(changes); err == nil {
+			w.Header().Set(syncWatermarkHeader, strconv.FormatUint(watermark, 10))
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(body)
+			return
+		}
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// accept пише зміни клієнта лише якщо жодна не перекриває новішу версію;
+// у відповідь іде збережена версія кожного ключа в порядку запиту
+func (s *SyncServer) accept(w http.ResponseWriter, entries []*Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range entries {
+		cur, err := s.db.getEntry(e.key)
+		if err == ErrNotFound {
+			continue
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		//повтор уже прийнятого пушу не є конфліктом
+		if annotationUint(cur, syncSeqAnnotation) > annotationUint(e, syncBaseAnnotation) &&
+			(cur.valueType != e.valueType || cur.value != e.value) {
+			http.Error(w, ErrSyncConflict.Error(), http.StatusConflict)
+			return
+		}
+	}
+	stored := make([]*Entry, len(entries))
+	for i, e := range entries {
+		s.seq++
+		stored[i] = syncCopy(e)
+		stored[i].Annotate(syncSeqAnnotation, strconv.FormatUint(s.seq, 10))
+		if err := s.db.putEntry(stored[i]); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	body, err := encodeEntries(stored)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(syncWatermarkHeader, strconv.FormatUint(s.seq, 10))
+	w.Write(body)
+}
+
+type SyncOptions struct {
+	// Resolver picks the value of a key changed both locally and on the
+	// server since the last sync. Defaults to keeping the greater value.
+	Resolver ConflictResolver
+	Client   *http.Client
+}
+
+// SyncClient keeps a local Db usable offline and merges it with a
+// SyncServer. Only writes made through its Put are pushed.
+type SyncClient struct {
+	local     *Db
+	serverURL string
+	opts      SyncOptions
+
+	mu        sync.Mutex
+	seq       uint64
+	watermark uint64
+}
+
+func syncStatePath(db *Db) string {
+	return strings.TrimRight(db.dir, string(os.PathSeparator)) + ".sync"
+}
+
+// NewSyncClient syncs local with the SyncServer served at serverURL. The
+// pull watermark is kept in a .sync file next to the database directory.
+func NewSyncClient(local *Db, serverURL string, opts SyncOptions) (*SyncClient, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported sync server url scheme %q", u.Scheme)
+	}
+	if opts.Resolver == nil {
+		opts.Resolver = resolveByValue
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	c := &SyncClient{local: local, serverURL: serverURL, opts: opts}
+	data, err := os.ReadFile(syncStatePath(local))
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	case len(data) != 8:
+		return nil, fmt.Errorf("corrupted sync state %s", syncStatePath(local))
+	default:
+		c.watermark = binary.LittleEndian.Uint64(data)
+	}
+	err = local.scanVersions(func(key string, e *Entry) error {
+		if e != nil {
+			if seq := annotationUint(e, syncLocalAnnotation); seq > c.seq {
+				c.seq = seq
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Put writes value under key locally; the next Push sends it to the server.
+func (c *SyncClient) Put(key, value string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := &Entry{key: key, valueType: STRING_TYPE, value: value}
+	base := uint64(0)
+	if cur, err := c.local.getEntry(key); err == nil {
+		base = annotationUint(cur, syncBaseAnnotation)
+	} else if err != ErrNotFound {
+		return err
+	}
+	c.seq++
+	e.Annotate(syncBaseAnnotation, strconv.FormatUint(base, 10))
+	e.Annotate(syncLocalAnnotation, strconv.FormatUint(c.seq, 10))
+	return c.local.putEntry(e)
+}
+
+func (c *SyncClient) saveWatermark() error {
+	return writeFileAtomic(syncStatePath(c.local), func(w *bufio.Writer) error {
+		var n [8]byte
+		binary.LittleEndian.PutUint64(n[:], c.watermark)
+		_, err := w.Write(n[:])
+		return err
+	})
+}
+
+// Pull fetches the server versions written after the last Pull and merges
+// them. A key also changed locally since the last Push goes to the
+// resolver; if the local value wins it stays pending for the next Push.
+func (c *SyncClient) Pull() (received int, err error) {
+	c.mu.Lock()
+	since := c.watermark
+	c.mu.Unlock()
+	resp, err := c.opts.Client.Get(c.serverURL + "?since=" + strconv.FormatUint(since, 10))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("sync server responded %s", resp.Status)
+	}
+	watermark, err := strconv.ParseUint(resp.Header.Get(syncWatermarkHeader), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("sync server sent a bad watermark")
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	remote, err := DecodeMany(data)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range remote {
+		if err := c.merge(e); err != nil {
+			return received, err
+		}
+		received++
+	}
+	c.watermark = watermark
+	return received, c.saveWatermark()
+}
+
+// merge пише серверну версію e; викликається під c.mu
+func (c *SyncClient) merge(e *Entry) error {
+	seq := strconv.FormatUint(annotationUint(e, syncSeqAnnotation), 10)
+	merged := syncCopy(e)
+	merged.Annotate(syncBaseAnnotation, seq)
+	cur, err := c.local.getEntry(e.key)
+	if err != nil && err != ErrNotFound {
+		return err
+	}
+	if err == nil {
+		if _, pending := cur.Annotation(syncLocalAnnotation); pending && (cur.valueType != e.valueType || cur.value != e.value) {
+			winner, err := c.opts.Resolver(e.key, syncCopy(cur), syncCopy(e))
+			if err != nil {
+				return err
+			}
+			if winner.valueType != e.valueType || winner.value != e.value {
+				//локальне значення перемогло: лишається на пуш, але вже поверх цієї версії
+				c.seq++
+				merged = &Entry{key: e.key, valueType: winner.valueType, value: winner.value}
+				merged.Annotate(syncBaseAnnotation, seq)
+				merged.Annotate(syncLocalAnnotation, strconv.FormatUint(c.seq, 10))
+			}
+		}
+	}
+	return c.local.putEntry(merged)
+}
+
+// Push sends the local writes not pushed yet. It fails with ErrSyncConflict
+// when the server changed one of the keys since the last Pull.
+func (c *SyncClient) Push() (sent int, err error) {
+	c.mu.Lock()
+	var pending []*Entry
+	err = c.local.scanVersions(func(key string, e *Entry) error {
+		if e != nil {
+			if _, ok := e.Annotation(syncLocalAnnotation); ok {
+				pending = append(pending, e)
+			}
+		}
+		return nil
+	})
+	c.mu.Unlock()
+	if err != nil || len(pending) == 0 {
+		return 0, err
+	}
+	body, err := encodeEntries(pending)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.opts.Client.Post(c.serverURL, "application/octet-stream", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusConflict {
+		return 0, ErrSyncConflict
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("sync server responded %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	stored, err := DecodeMany(data)
+	if err != nil {
+		return 0, err
+	}
+	if len(stored) != len(pending) {
+		return 0, fmt.Errorf("sync server stored %d of %d entries", len(stored), len(pending))
+	}
+
+	//ключ, змінений під час пушу, лишається на наступний раз
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, e := range pending {
+		cur, err := c.local.getEntry(e.key)
+		if err != nil && err != ErrNotFound {
+			return sent, err
+		}
+		if err == nil && annotationUint(cur, syncLocalAnnotation) == annotationUint(e, syncLocalAnnotation) {
+			synced := syncCopy(e)
+			synced.Annotate(syncBaseAnnotation, strconv.FormatUint(annotationUint(stored[i], syncSeqAnnotation), 10))
+			if err := c.local.putEntry(synced); err != nil {
+				return sent, err
+			}
+		}
+		sent++
+	}
+	return sent, nil
+}
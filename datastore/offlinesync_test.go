@@ -0,0 +1,108 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestSyncClient(t *testing.T) {
+	newTestDb := func() *Db {
+		dir, err := ioutil.TempDir("", "test-db")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.RemoveAll(dir) })
+		db, err := NewDb(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			db.Close()
+			os.Remove(syncStatePath(db))
+		})
+		return db
+	}
+	serverDb := newTestDb()
+	server, err := NewSyncServer(serverDb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	conflicts := 0
+	opts := SyncOptions{Resolver: func(key string, local, remote *Entry) (*Entry, error) {
+		conflicts++
+		return resolveByValue(key, local, remote)
+	}}
+	dbA, dbB := newTestDb(), newTestDb()
+	a, err := NewSyncClient(dbA, httpServer.URL, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewSyncClient(dbB, httpServer.URL, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Put("x", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := a.Push(); err != nil || n != 1 {
+		t.Fatalf("Expected 1 entry pushed, got %d, %v", n, err)
+	}
+	if n, err := b.Pull(); err != nil || n != 1 {
+		t.Fatalf("Expected 1 entry pulled, got %d, %v", n, err)
+	}
+
+	//обидва пристрої офлайн змінюють той самий ключ
+	a.Put("x", "2")
+	b.Put("x", "3")
+	b.Put("y", "b")
+	if _, err := a.Push(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Push(); err != ErrSyncConflict {
+		t.Fatalf("Expected ErrSyncConflict, got %v", err)
+	}
+	if _, err := b.Pull(); err != nil {
+		t.Fatal(err)
+	}
+	if conflicts != 1 {
+		t.Errorf("Expected one conflict, got %d", conflicts)
+	}
+	if n, err := b.Push(); err != nil || n != 2 {
+		t.Fatalf("Expected 2 entries pushed, got %d, %v", n, err)
+	}
+	if _, err := a.Pull(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Pull(); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := b.Push(); err != nil || n != 0 {
+		t.Errorf("Expected nothing left to push, got %d, %v", n, err)
+	}
+
+	for name, db := range map[string]*Db{"server": serverDb, "a": dbA, "b": dbB} {
+		if v, _ := db.Get("x"); v != "3" {
+			t.Errorf("Expected x=3 on %s, got %q", name, v)
+		}
+		if v, _ := db.Get("y"); v != "b" {
+			t.Errorf("Expected y=b on %s, got %q", name, v)
+		}
+	}
+	if conflicts != 1 {
+		t.Errorf("Expected no more conflicts after convergence, got %d", conflicts)
+	}
+
+	restarted, err := NewSyncClient(dbA, httpServer.URL, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := restarted.Pull(); err != nil || n != 0 {
+		t.Errorf("Expected the watermark to survive a restart, got %d, %v", n, err)
+	}
+}
@@ -0,0 +1,95 @@
+package datastore
+
+import (
+	"log/slog"
+	"time"
+)
+
+type DbOptions struct {
+	BufferSize  int
+	ReadOnly    bool
+	MaxFileSize int64
+	PageCache   *PageCache
+	Logger      *slog.Logger
+	Changelog   ChangelogEmitter
+	//0 вимикає квоту
+	MaxDiskBytes int64
+	//частка MaxDiskBytes, на якій викликаються колбеки RegisterQuotaWarning
+	QuotaWarningThreshold float64
+	//налаштування збережених запобіжників; 0 означає типові значення
+	CircuitErrorThreshold int
+	CircuitCooldown       time.Duration
+	//створює індекс кожного сегмента; nil - звичайна мапа
+	NewIndex func() Index
+}
+
+type Option func(*DbOptions)
+
+func WithBufferSize(n int) Option {
+	return func(o *DbOptions) {
+		o.BufferSize = n
+	}
+}
+
+func WithReadOnly() Option {
+	return func(o *DbOptions) {
+		o.ReadOnly = true
+	}
+}
+
+func WithMaxFileSize(n int64) Option {
+	return func(o *DbOptions) {
+		o.MaxFileSize = n
+	}
+}
+
+func WithPageCache(c *PageCache) Option {
+	return func(o *DbOptions) {
+		o.PageCache = c
+	}
+}
+
+func WithLogger(l *slog.Logger) Option {
+	return func(o *DbOptions) {
+		o.Logger = l
+	}
+}
+
+func WithChangelog(emitter ChangelogEmitter) Option {
+	return func(o *DbOptions) {
+		o.Changelog = emitter
+	}
+}
+
+func WithMaxDiskBytes(n int64) Option {
+	return func(o *DbOptions) {
+		o.MaxDiskBytes = n
+	}
+}
+
+func WithQuotaWarningThreshold(fraction float64) Option {
+	return func(o *DbOptions) {
+		o.QuotaWarningThreshold = fraction
+	}
+}
+
+func WithCircuitStateDefaults(errorThreshold int, cooldown time.Duration) Option {
+	return func(o *DbOptions) {
+		o.CircuitErrorThreshold = errorThreshold
+		o.CircuitCooldown = cooldown
+	}
+}
+
+// WithIndex makes every segment index its keys with an Index from newIndex
+// instead of a map, e.g. WithIndex(func() Index { return NewRadixTree() }).
+func WithIndex(newIndex func() Index) Option {
+	return func(o *DbOptions) {
+		o.NewIndex = newIndex
+	}
+}
+
+func WithOptions(options DbOptions) Option {
+	return func(o *DbOptions) {
+		*o = options
+	}
+}
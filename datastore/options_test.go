@@ -0,0 +1,86 @@
+package datastore
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDb_Options(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	db, err := NewDb(dir, WithMaxFileSize(100), WithBufferSize(64), WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.segmentSize != 100 {
+		t.Errorf("Expected segment size 100, got %d", db.segmentSize)
+	}
+	value := strings.Repeat("v", 200)
+	for _, key := range []string{"key1", "key2"} {
+		if err := db.Put(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(db.blocks) != 2 {
+		t.Errorf("Expected MaxFileSize to trigger a new segment, got %d blocks", len(db.blocks))
+	}
+	if got, err := db.Get("key1"); err != nil || got != value {
+		t.Errorf("Cannot read with a small buffer: %v", err)
+	}
+	if !strings.Contains(logs.String(), "created segment") {
+		t.Errorf("Expected segment creation to be logged, got %q", logs.String())
+	}
+	db.Close()
+
+	t.Run("read-only", func(t *testing.T) {
+		ro, err := NewDb(dir, WithReadOnly())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ro.Close()
+
+		if got, err := ro.Get("key2"); err != nil || got != value {
+			t.Errorf("Cannot read from read-only db: %v", err)
+		}
+		if err := ro.Put("key3", "value"); err != ErrReadOnly {
+			t.Errorf("Expected ErrReadOnly, got %v", err)
+		}
+		if err := ro.PutInt64("key3", 1); err != ErrReadOnly {
+			t.Errorf("Expected ErrReadOnly, got %v", err)
+		}
+	})
+
+	t.Run("read-only empty dir", func(t *testing.T) {
+		empty, err := ioutil.TempDir("", "test-db")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(empty)
+
+		ro, err := NewDb(empty, WithOptions(DbOptions{ReadOnly: true}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ro.Close()
+		if _, err := ro.Get("key"); err != ErrNotFound {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		names, err := ioutil.ReadDir(empty)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(names) != 0 {
+			t.Errorf("Read-only db created %d files", len(names))
+		}
+	})
+}
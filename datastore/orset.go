@@ -0,0 +1,224 @@
+package datastore
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sort"
+)
+
+// ORSet is an observed-remove set: every add gets a unique tag, and a remove
+// only cancels the tags it has seen, so an add concurrent with a remove
+// survives the merge. Removed tags are kept to stop merges from bringing them
+// back, so the state grows with every remove.
+type ORSet struct {
+	tags    map[string]map[string]struct{}
+	removed map[string]struct{}
+}
+
+func newORSet() *ORSet {
+	return &ORSet{tags: make(map[string]map[string]struct{}), removed: make(map[string]struct{})}
+}
+
+func (s *ORSet) Contains(elem string) bool {
+	return len(s.tags[elem]) > 0
+}
+
+func (s *ORSet) Elements() []string {
+	elems := make([]string, 0, len(s.tags))
+	for elem := range s.tags {
+		elems = append(elems, elem)
+	}
+	sort.Strings(elems)
+	return elems
+}
+
+func (s *ORSet) add(elem, tag string) {
+	if _, ok := s.removed[tag]; ok {
+		return
+	}
+	if s.tags[elem] == nil {
+		s.tags[elem] = make(map[string]struct{})
+	}
+	s.tags[elem][tag] = struct{}{}
+}
+
+func (s *ORSet) remove(elem string) {
+	for tag := range s.tags[elem] {
+		s.removed[tag] = struct{}{}
+	}
+	delete(s.tags, elem)
+}
+
+func (s *ORSet) merge(other *ORSet) {
+	for tag := range other.removed {
+		s.removed[tag] = struct{}{}
+	}
+	for elem, tags := range s.tags {
+		for tag := range tags {
+			if _, ok := s.removed[tag]; ok {
+				delete(tags, tag)
+			}
+		}
+		if len(tags) == 0 {
+			delete(s.tags, elem)
+		}
+	}
+	for elem, tags := range other.tags {
+		for tag := range tags {
+			s.add(elem, tag)
+		}
+	}
+}
+
+func setKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// розмітка: [len u32][живі елементи][множина видалених тегів];
+// живий елемент - це [len u32][елемент][len u32][множина тегів]
+func (s *ORSet) encode() string {
+	var live []byte
+	for _, elem := range s.Elements() {
+		live = appendLengthPrefixed(live, elem)
+		live = appendLengthPrefixed(live, encodeStringSet(setKeys(s.tags[elem])))
+	}
+	res := appendLengthPrefixed(nil, string(live))
+	return string(res) + encodeStringSet(setKeys(s.removed))
+}
+
+func parseORSet(data string) (*ORSet, error) {
+	live, rest, err := readLengthPrefixed(data)
+	if err != nil {
+		return nil, err
+	}
+	removed, err := parseStringSet(rest)
+	if err != nil {
+		return nil, err
+	}
+	s := newORSet()
+	for _, tag := range removed {
+		s.removed[tag] = struct{}{}
+	}
+	for i, prev := 0, ""; len(live) > 0; i++ {
+		elem, rest, err := readLengthPrefixed(live)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 && elem <= prev {
+			return nil, fmt.Errorf("or-set elements out of order")
+		}
+		encodedTags, rest, err := readLengthPrefixed(rest)
+		if err != nil {
+			return nil, err
+		}
+		tags, err := parseStringSet(encodedTags)
+		if err != nil {
+			return nil, err
+		}
+		if len(tags) == 0 {
+			return nil, fmt.Errorf("or-set element %q has no tags", elem)
+		}
+		for _, tag := range tags {
+			s.add(elem, tag)
+		}
+		prev = elem
+		live = rest
+	}
+	return s, nil
+}
+
+func validateORSet(data string) error {
+	_, err := parseORSet(data)
+	return err
+}
+
+func newORSetTag() (string, error) {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[:]); err != nil {
+		return "", err
+	}
+	//UUID версії 4, варіант RFC 4122
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+	return formatUUID(uuid), nil
+}
+
+func orSetOf(e *Entry) (*ORSet, error) {
+	if e == nil {
+		return newORSet(), nil
+	}
+	if e.valueType != ORSET_TYPE {
+		return nil, fmt.Errorf("wrong type of value")
+	}
+	return parseORSet(e.value)
+}
+
+func updateORSet(db *Db, key string, fn func(s *ORSet) (bool, error)) error {
+	_, err := db.updateEntry(key, func(cur *Entry) (*Entry, error) {
+		s, err := orSetOf(cur)
+		if err != nil {
+			return nil, err
+		}
+		changed, err := fn(s)
+		if err != nil || !changed {
+			return nil, err
+		}
+		return &Entry{valueType: ORSET_TYPE, value: s.encode()}, nil
+	})
+	return err
+}
+
+func ORSetAdd(db *Db, key, elem string) error {
+	tag, err := newORSetTag()
+	if err != nil {
+		return err
+	}
+	return updateORSet(db, key, func(s *ORSet) (bool, error) {
+		s.add(elem, tag)
+		return true, nil
+	})
+}
+
+// ORSetRemove cancels the tags of elem that this replica has observed.
+func ORSetRemove(db *Db, key, elem string) error {
+	return updateORSet(db, key, func(s *ORSet) (bool, error) {
+		if !s.Contains(elem) {
+			return false, nil
+		}
+		s.remove(elem)
+		return true, nil
+	})
+}
+
+// ORSetMerge merges the state of another replica into the set at key.
+func ORSetMerge(db *Db, key string, other *ORSet) error {
+	return updateORSet(db, key, func(s *ORSet) (bool, error) {
+		before := s.encode()
+		s.merge(other)
+		return s.encode() != before, nil
+	})
+}
+
+func ORSetContains(db *Db, key, elem string) (bool, error) {
+	s, err := ORSetState(db, key)
+	if err != nil {
+		return false, err
+	}
+	return s.Contains(elem), nil
+}
+
+// ORSetState returns the replica state at key, to be merged elsewhere.
+func ORSetState(db *Db, key string) (*ORSet, error) {
+	e, err := db.getEntry(key)
+	if err == ErrNotFound {
+		return newORSet(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return orSetOf(e)
+}
@@ -0,0 +1,90 @@
+package datastore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func mergeORSetInto(t *testing.T, dst, src *Db, key string) {
+	state, err := ORSetState(src, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ORSetMerge(dst, key, state); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestORSet_ConcurrentAddSurvivesRemove(t *testing.T) {
+	a := newTestDb(t)
+	b := newTestDb(t)
+
+	// обидві репліки додають той самий елемент незалежно
+	if err := ORSetAdd(a, "set", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ORSetAdd(b, "set", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ORSetAdd(a, "set", "y"); err != nil {
+		t.Fatal(err)
+	}
+	mergeORSetInto(t, b, a, "set")
+
+	// a не бачила тегу від b, тож її видалення його не скасовує
+	if err := ORSetRemove(a, "set", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := ORSetContains(a, "set", "x"); err != nil || ok {
+		t.Errorf("Expected x to be removed locally, got %v, %v", ok, err)
+	}
+	mergeORSetInto(t, a, b, "set")
+	mergeORSetInto(t, b, a, "set")
+	for _, db := range []*Db{a, b} {
+		if ok, err := ORSetContains(db, "set", "x"); err != nil || !ok {
+			t.Errorf("Expected concurrent add of x to survive, got %v, %v", ok, err)
+		}
+	}
+
+	// після повного обміну видалення бачить усі теги
+	if err := ORSetRemove(b, "set", "x"); err != nil {
+		t.Fatal(err)
+	}
+	mergeORSetInto(t, a, b, "set")
+	for _, db := range []*Db{a, b} {
+		s, err := ORSetState(db, "set")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(s.Elements(), []string{"y"}) {
+			t.Errorf("Expected only y left, got %q", s.Elements())
+		}
+	}
+
+	// повторне додавання отримує новий тег і знову видиме
+	if err := ORSetAdd(a, "set", "x"); err != nil {
+		t.Fatal(err)
+	}
+	mergeORSetInto(t, b, a, "set")
+	if ok, err := ORSetContains(b, "set", "x"); err != nil || !ok {
+		t.Errorf("Expected re-added x to be present, got %v, %v", ok, err)
+	}
+}
+
+func TestORSet_Encoding(t *testing.T) {
+	s := newORSet()
+	s.add("x", "t1")
+	s.add("x", "t2")
+	s.add("y", "t3")
+	s.remove("y")
+	decoded, err := parseORSet(s.encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, s) {
+		t.Errorf("Bad or-set decoded: %v, expected %v", decoded, s)
+	}
+	if _, err := parseORSet("\x05\x00\x00\x00ab"); err == nil {
+		t.Error("Expected error for corrupted or-set")
+	}
+}
@@ -0,0 +1,173 @@
+package datastore
+
+import (
+	"container/list"
+	"io"
+	"os"
+	"sync"
+)
+
+const pageSize = 4096
+
+type pageKey struct {
+	path  string
+	index int64
+}
+
+type cachedPage struct {
+	key  pageKey
+	data []byte
+}
+
+type PageCache struct {
+	mu       sync.Mutex
+	capacity int64
+	size     int64
+	pages    map[pageKey]*list.Element
+	lru      *list.List
+
+	hits, misses, evicted int64
+
+	//якщо задано, сторінки зберігаються стиснутими
+	codec CompressionCodec
+}
+
+type PageCacheStats struct {
+	HitRatio     float64
+	EvictedPages int64
+	//для стиснутого кешу — розмір після стиснення
+	CachedBytes int64
+	CachedPages int
+}
+
+func NewPageCache(capacity int64) *PageCache {
+	return &PageCache{
+		capacity: capacity,
+		pages:    make(map[pageKey]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+func (c *PageCache) Stats() PageCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := PageCacheStats{EvictedPages: c.evicted, CachedBytes: c.size, CachedPages: len(c.pages)}
+	if total := c.hits + c.misses; total != 0 {
+		stats.HitRatio = float64(c.hits) / float64(total)
+	}
+	return stats
+}
+
+func (c *PageCache) get(key pageKey) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.pages[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.lru.MoveToFront(el)
+	return el.Value.(*cachedPage).data, true
+}
+
+func (c *PageCache) add(key pageKey, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if int64(len(data)) > c.capacity {
+		return
+	}
+	if el, ok := c.pages[key]; ok {
+		c.removeElement(el)
+	}
+	c.pages[key] = c.lru.PushFront(&cachedPage{key, data})
+	c.size += int64(len(data))
+	for c.size > c.capacity {
+		c.removeElement(c.lru.Back())
+		c.evicted++
+	}
+}
+
+func (c *PageCache) removeElement(el *list.Element) {
+	page := c.lru.Remove(el).(*cachedPage)
+	delete(c.pages, page.key)
+	c.size -= int64(len(page.data))
+}
+
+func (c *PageCache) invalidateFile(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, el := range c.pages {
+		if key.path == path {
+			c.removeElement(el)
+		}
+	}
+}
+
+// cachedFile відкриває сегмент лише тоді, коли сторінки немає в кеші
+type cachedFile struct {
+	cache *PageCache
+	path  string
+	file  *os.File
+}
+
+func (f *cachedFile) page(index int64) ([]byte, error) {
+	key := pageKey{f.path, index}
+	if data, ok := f.cache.get(key); ok {
+		if f.cache.codec != nil {
+			return f.cache.codec.Decompress(data)
+		}
+		return data, nil
+	}
+	if f.file == nil {
+		file, err := os.Open(f.path)
+		if err != nil {
+			return nil, err
+		}
+		f.file = file
+	}
+	data := make([]byte, pageSize)
+	n, err := f.file.ReadAt(data, index*pageSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	data = data[:n]
+	//сегменти лише дописуються, тож незмінні тільки повні сторінки
+	if n == pageSize {
+		cached := data
+		if f.cache.codec != nil {
+			if cached, err = f.cache.codec.Compress(data); err != nil {
+				return nil, err
+			}
+		}
+		f.cache.add(key, cached)
+	}
+	return data, nil
+}
+
+func (f *cachedFile) ReadAt(p []byte, off int64) (int, error) {
+	read := 0
+	for read < len(p) {
+		pos := off + int64(read)
+		data, err := f.page(pos / pageSize)
+		if err != nil {
+			return read, err
+		}
+		start := int(pos % pageSize)
+		if start >= len(data) {
+			return read, io.EOF
+		}
+		read += copy(p[read:], data[start:])
+		if len(data) < pageSize && read < len(p) {
+			return read, io.EOF
+		}
+	}
+	return read, nil
+}
+
+func (f *cachedFile) Close() error {
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Close()
+}
@@ -0,0 +1,190 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPageCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetPageCache(NewPageCache(4 * pageSize))
+
+	value := strings.Repeat("v", 100)
+	for i := 0; i < 200; i++ {
+		if err := db.Put(fmt.Sprintf("key%d", i), value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("get through cache", func(t *testing.T) {
+		for round := 0; round < 2; round++ {
+			for i := 0; i < 200; i++ {
+				got, err := db.Get(fmt.Sprintf("key%d", i))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != value {
+					t.Errorf("Bad value for key%d", i)
+				}
+			}
+		}
+		stats := db.PageCacheStats()
+		if stats.HitRatio <= 0 {
+			t.Errorf("Expected cache hits, got %+v", stats)
+		}
+		if stats.EvictedPages == 0 {
+			t.Errorf("Expected evictions with a 4 page cache, got %+v", stats)
+		}
+		if stats.CachedBytes > 4*pageSize {
+			t.Errorf("Cache exceeds capacity: %+v", stats)
+		}
+	})
+
+	t.Run("appends after caching", func(t *testing.T) {
+		for i := 0; i < 200; i++ {
+			key := fmt.Sprintf("key%d", i)
+			if err := db.Put(key, key); err != nil {
+				t.Fatal(err)
+			}
+			got, err := db.Get(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != key {
+				t.Errorf("Stale value for %s: %s", key, got)
+			}
+		}
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		db.segmentSize = 2000
+		for round := 0; round < 3; round++ {
+			for i := 0; i < 200; i++ {
+				key := fmt.Sprintf("key%d", i)
+				if err := db.Put(key, fmt.Sprintf("%s-%d", key, round)); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		for i := 0; i < 200; i++ {
+			key := fmt.Sprintf("key%d", i)
+			got, err := db.Get(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != key+"-2" {
+				t.Errorf("Bad value after merge for %s: %s", key, got)
+			}
+		}
+	})
+}
+
+func TestCompressedPageCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for i := 0; i < 2000; i++ {
+		if err := db.Put(fmt.Sprintf("key%d", i), fmt.Sprintf("user=%d;status=active;region=eu-west", i%50)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	size, err := db.CurrentSizeBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages := int(size / pageSize)
+	//60% від розміру даних: без стиснення все не вміститься
+	capacity := size * 6 / 10
+
+	readAll := func(c *PageCache) PageCacheStats {
+		db.SetPageCache(c)
+		for round := 0; round < 2; round++ {
+			for i := 0; i < 2000; i++ {
+				got, err := db.Get(fmt.Sprintf("key%d", i))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := fmt.Sprintf("user=%d;status=active;region=eu-west", i%50); got != want {
+					t.Fatalf("Bad value for key%d: %s", i, got)
+				}
+			}
+		}
+		return c.Stats()
+	}
+
+	plain := readAll(NewPageCache(capacity))
+	if plain.EvictedPages == 0 {
+		t.Errorf("Expected evictions without compression, got %+v", plain)
+	}
+	compressed := readAll(NewCompressedPageCache(capacity, &FlateCodec{}))
+	if compressed.EvictedPages != 0 || compressed.CachedPages != pages {
+		t.Errorf("Expected all %d pages to fit compressed, got %+v", pages, compressed)
+	}
+	if compressed.CachedBytes > capacity {
+		t.Errorf("Cache exceeds capacity: %+v", compressed)
+	}
+	t.Logf("%d pages, %d bytes compressed to %d", pages, int64(pages)*pageSize, compressed.CachedBytes)
+}
+
+func BenchmarkPageCache(b *testing.B) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewDb(dir)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+
+	const n = 20000
+	value := strings.Repeat("v", 100)
+	for i := 0; i < n; i++ {
+		if err := db.Put(fmt.Sprintf("key%d", i), value); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	run := func(b *testing.B) {
+		zipf := rand.NewZipf(rand.New(rand.NewSource(1)), 1.1, 1, n-1)
+		for i := 0; i < b.N; i++ {
+			if _, err := db.Get(fmt.Sprintf("key%d", zipf.Uint64())); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.Run("no cache", run)
+	db.SetPageCache(NewPageCache(256 * pageSize))
+	b.Run("cache", run)
+	c := NewCompressedPageCache(256*pageSize, &FlateCodec{})
+	db.SetPageCache(c)
+	b.Run("compressed cache", func(b *testing.B) {
+		run(b)
+		stats := c.Stats()
+		b.ReportMetric(float64(stats.CachedBytes)/float64(stats.CachedPages), "bytes/page")
+	})
+}
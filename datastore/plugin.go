@@ -0,0 +1,109 @@
+package datastore
+
+import (
+	"bufio"
+	"fmt"
+	"plugin"
+)
+
+// TypeCodec converts values of a custom type between their string form and
+// the bytes stored on disk. Its methods use only builtin types, so a plugin
+// can implement it without importing this package.
+type TypeCodec interface {
+	EncodeValue(value string) ([]byte, error)
+	DecodeValue(data []byte) (string, error)
+}
+
+// codecOperator пише закодоване значення в розмітці рядка
+type codecOperator struct {
+	valueType byte
+	codec     TypeCodec
+}
+
+func (s codecOperator) Encode(e *Entry, dst []byte) []byte {
+	data, err := s.codec.EncodeValue(e.value)
+	if err != nil {
+		panic(err)
+	}
+	encoded := Entry{key: e.key, value: string(data)}
+	return taggedStringOperator{s.valueType}.Encode(&encoded, dst)
+}
+
+func (s codecOperator) EncodeInto(e *Entry, dst []byte) ([]byte, error) {
+	data, err := s.codec.EncodeValue(e.value)
+	if err != nil {
+		return dst, err
+	}
+	encoded := Entry{key: e.key, value: string(data)}
+	return taggedStringOperator{s.valueType}.EncodeInto(&encoded, dst)
+}
+
+func (s codecOperator) Decode(input []byte, e *Entry) {
+	stringOperator{}.Decode(input, e)
+	//Decode не повертає помилок; DecodeMany перевіряє значення заздалегідь
+	value, err := s.codec.DecodeValue([]byte(e.value))
+	if err != nil {
+		value = ""
+	}
+	e.value = value
+}
+
+func (s codecOperator) Read(in *bufio.Reader) (string, error) {
+	data, err := stringOperator{}.Read(in)
+	if err != nil {
+		return "", err
+	}
+	return s.codec.DecodeValue([]byte(data))
+}
+
+var customTypes = make(map[string]byte)
+
+// RegisterType adds a value type stored through codec. Like the rest of the
+// type registry it is not synchronized: register types before opening
+// databases that use them.
+func RegisterType(valueType byte, name string, codec TypeCodec) error {
+	if valueType&ANNOTATED_FLAG != 0 {
+		return fmt.Errorf("type byte %d is reserved", valueType)
+	}
+	if _, ok := operators[valueType]; ok {
+		return fmt.Errorf("type byte %d is already registered", valueType)
+	}
+	if _, ok := typeToByte[name]; ok || name == "" || name == diffTypeName || name == tombstoneTypeName {
+		return fmt.Errorf("type name %q is already registered", name)
+	}
+	operators[valueType] = codecOperator{valueType, codec}
+	typeToByte[name] = valueType
+	customTypes[name] = valueType
+	return nil
+}
+
+// LoadTypePlugin opens the Go plugin at path and registers its
+// TypeOperatorPlugin symbol, which must implement TypeCodec, as a new type.
+func LoadTypePlugin(path string, valueType byte, name string) error {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return err
+	}
+	sym, err := p.Lookup("TypeOperatorPlugin")
+	if err != nil {
+		return err
+	}
+	codec, ok := sym.(TypeCodec)
+	if !ok {
+		return fmt.Errorf("plugin %s: TypeOperatorPlugin of type %T does not implement TypeCodec", path, sym)
+	}
+	return RegisterType(valueType, name, codec)
+}
+
+// UnloadTypePlugin removes a type added by LoadTypePlugin or RegisterType.
+// Go can't unload the plugin itself, so its code stays mapped.
+func UnloadTypePlugin(typeName string) error {
+	valueType, ok := customTypes[typeName]
+	if !ok {
+		return fmt.Errorf("type %q was not registered by a plugin", typeName)
+	}
+	delete(operators, valueType)
+	delete(typeToByte, typeName)
+	delete(customTypes, typeName)
+	return nil
+}
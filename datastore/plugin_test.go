@@ -0,0 +1,166 @@
+package datastore
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type hexCodec struct{}
+
+func (hexCodec) EncodeValue(value string) ([]byte, error) {
+	return hex.DecodeString(value)
+}
+
+func (hexCodec) DecodeValue(data []byte) (string, error) {
+	return hex.EncodeToString(data), nil
+}
+
+func TestRegisterType(t *testing.T) {
+	if err := RegisterType(0x70, "hex", hexCodec{}); err != nil {
+		t.Fatal(err)
+	}
+	defer UnloadTypePlugin("hex")
+
+	if err := RegisterType(0x71, "hex", hexCodec{}); err == nil {
+		t.Error("Expected error for duplicate type name")
+	}
+	if err := RegisterType(INT64_TYPE, "other", hexCodec{}); err == nil {
+		t.Error("Expected error for duplicate type byte")
+	}
+	if err := RegisterType(ANNOTATED_FLAG|1, "other", hexCodec{}); err == nil {
+		t.Error("Expected error for reserved type byte")
+	}
+
+	e := Entry{key: "key", valueType: ToByte("hex"), value: "deadbeef"}
+	data := e.Encode()
+	if len(data) != 8+len(e.key)+TYPE_SIZE+4+4 {
+		t.Errorf("Expected value to be stored as 4 bytes, got record of %d", len(data))
+	}
+	var decoded Entry
+	decoded.Decode(data)
+	if decoded != e || decoded.Type() != "hex" {
+		t.Errorf("Bad entry decoded: expected %v, got %v", e, decoded)
+	}
+	if _, err := EncodeInto(&Entry{key: "key", valueType: ToByte("hex"), value: "xyz"}, nil); err == nil {
+		t.Error("Expected error for a value the codec rejects")
+	}
+
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.PutValue("key", "hex", "cafe"); err != nil {
+		t.Fatal(err)
+	}
+	if value, vType, err := db.GetValue("key"); err != nil || value != "cafe" || vType != "hex" {
+		t.Errorf("Bad value read: %q, %q, %v", value, vType, err)
+	}
+	if err := db.PutValue("key", "hex", "not hex"); err == nil {
+		t.Error("Expected error for a value the codec rejects")
+	}
+	if err := db.PutValue("key", "missing", "value"); err == nil {
+		t.Error("Expected error for unknown type")
+	}
+
+	if err := UnloadTypePlugin("hex"); err != nil {
+		t.Fatal(err)
+	}
+	if err := UnloadTypePlugin("string"); err == nil {
+		t.Error("Expected error for a built-in type")
+	}
+	if _, ok := operators[0x70]; ok {
+		t.Error("Expected type byte to be free after unload")
+	}
+}
+
+// плагін не імпортує datastore: тестова збірка пакета відрізняється від тієї,
+// з якою зібрано б плагін, і plugin.Open би його не прийняв
+const timestampPluginSource = `package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+type timestampCodec struct{}
+
+func (timestampCodec) EncodeValue(value string) ([]byte, error) {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, err
+	}
+	return binary.BigEndian.AppendUint64(nil, uint64(t.UnixNano())), nil
+}
+
+func (timestampCodec) DecodeValue(data []byte) (string, error) {
+	if len(data) != 8 {
+		return "", fmt.Errorf("bad timestamp length %d", len(data))
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(data))).UTC().Format(time.RFC3339Nano), nil
+}
+
+var TypeOperatorPlugin timestampCodec
+`
+
+func TestLoadTypePlugin(t *testing.T) {
+	if testing.Short() {
+		t.Skip("building a plugin is slow")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool is not available")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module timestampplugin\n\ngo 1.21\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(timestampPluginSource), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "timestamp.so")
+	cmd := exec.Command(goTool, "build", "-buildmode=plugin", "-o", path, ".")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("can't build plugin: %v\n%s", err, out)
+	}
+
+	if err := LoadTypePlugin(path, 0x72, "plugintime"); err != nil {
+		//наприклад, тест зібрано з -race, а плагін без нього
+		if strings.Contains(err.Error(), "different version of package") {
+			t.Skipf("plugin does not match the test binary: %v", err)
+		}
+		//плагін не вивантажується, тож з -count>1 повторне завантаження неможливе
+		if strings.Contains(err.Error(), "plugin already loaded") {
+			t.Skipf("plugin was loaded by an earlier run: %v", err)
+		}
+		t.Fatal(err)
+	}
+	defer UnloadTypePlugin("plugintime")
+
+	e := Entry{key: "created", valueType: ToByte("plugintime"), value: "2024-05-01T12:30:00.5Z"}
+	var decoded Entry
+	decoded.Decode(e.Encode())
+	if decoded != e {
+		t.Errorf("Bad entry decoded: expected %v, got %v", e, decoded)
+	}
+	if _, err := EncodeInto(&Entry{key: "key", valueType: ToByte("plugintime"), value: "yesterday"}, nil); err == nil {
+		t.Error("Expected error for a malformed timestamp")
+	}
+
+	if err := LoadTypePlugin(filepath.Join(dir, "missing.so"), 0x73, "missing"); err == nil {
+		t.Error("Expected error for a missing plugin")
+	}
+}
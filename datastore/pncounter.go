@@ -0,0 +1,137 @@
+package datastore
+
+import (
+	"fmt"
+	"math"
+)
+
+// PNCounter is a positive-negative counter: each node only grows its own
+// entries in the increment and decrement vectors, so merging takes the
+// per-node maximum of both.
+type PNCounter struct {
+	positive map[string]uint64
+	negative map[string]uint64
+}
+
+func (c *PNCounter) Value() int64 {
+	var pos, neg uint64
+	for _, n := range c.positive {
+		pos += n
+	}
+	for _, n := range c.negative {
+		neg += n
+	}
+	return int64(pos - neg)
+}
+
+// розмітка: [len u32][вектор збільшень][вектор зменшень] у форматі векторного годинника
+func (c *PNCounter) encode() (string, error) {
+	pos, err := encodeVectorClock(c.positive)
+	if err != nil {
+		return "", err
+	}
+	neg, err := encodeVectorClock(c.negative)
+	if err != nil {
+		return "", err
+	}
+	return string(appendLengthPrefixed(nil, pos)) + neg, nil
+}
+
+func parsePNCounter(data string) (*PNCounter, error) {
+	pos, neg, err := readLengthPrefixed(data)
+	if err != nil {
+		return nil, err
+	}
+	c := &PNCounter{}
+	if c.positive, err = parseVectorClock(pos); err != nil {
+		return nil, err
+	}
+	if c.negative, err = parseVectorClock(neg); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func validatePNCounter(data string) error {
+	_, err := parsePNCounter(data)
+	return err
+}
+
+func updatePNCounter(db *Db, key string, fn func(c *PNCounter) error) error {
+	_, err := db.updateEntry(key, func(cur *Entry) (*Entry, error) {
+		c := &PNCounter{positive: map[string]uint64{}, negative: map[string]uint64{}}
+		if cur != nil {
+			if cur.valueType != PNCOUNTER_TYPE {
+				return nil, fmt.Errorf("wrong type of value")
+			}
+			var err error
+			if c, err = parsePNCounter(cur.value); err != nil {
+				return nil, err
+			}
+		}
+		if err := fn(c); err != nil {
+			return nil, err
+		}
+		value, err := c.encode()
+		if err != nil {
+			return nil, err
+		}
+		if cur != nil && value == cur.value {
+			return nil, nil
+		}
+		return &Entry{valueType: PNCOUNTER_TYPE, value: value}, nil
+	})
+	return err
+}
+
+func addToNode(vector map[string]uint64, nodeID string, delta uint64) error {
+	if vector[nodeID] > math.MaxUint64-delta {
+		return fmt.Errorf("counter for node %s overflowed", nodeID)
+	}
+	vector[nodeID] += delta
+	return nil
+}
+
+func PNCounterIncrement(db *Db, key, nodeID string, delta uint64) error {
+	return updatePNCounter(db, key, func(c *PNCounter) error {
+		return addToNode(c.positive, nodeID, delta)
+	})
+}
+
+func PNCounterDecrement(db *Db, key, nodeID string, delta uint64) error {
+	return updatePNCounter(db, key, func(c *PNCounter) error {
+		return addToNode(c.negative, nodeID, delta)
+	})
+}
+
+// PNCounterValue returns the increments minus the decrements; a missing key
+// counts as zero.
+func PNCounterValue(db *Db, key string) (int64, error) {
+	c, err := PNCounterState(db, key)
+	if err != nil {
+		return 0, err
+	}
+	return c.Value(), nil
+}
+
+func PNCounterMerge(db *Db, key string, other *PNCounter) error {
+	return updatePNCounter(db, key, func(c *PNCounter) error {
+		c.positive = MergeClocks(c.positive, other.positive)
+		c.negative = MergeClocks(c.negative, other.negative)
+		return nil
+	})
+}
+
+func PNCounterState(db *Db, key string) (*PNCounter, error) {
+	e, err := db.getEntry(key)
+	if err == ErrNotFound {
+		return &PNCounter{positive: map[string]uint64{}, negative: map[string]uint64{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if e.valueType != PNCOUNTER_TYPE {
+		return nil, fmt.Errorf("wrong type of value")
+	}
+	return parsePNCounter(e.value)
+}
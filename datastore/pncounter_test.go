@@ -0,0 +1,59 @@
+package datastore
+
+import "testing"
+
+func TestPNCounter_MergeNodes(t *testing.T) {
+	a := newTestDb(t)
+	b := newTestDb(t)
+
+	steps := []struct {
+		db    *Db
+		node  string
+		delta int64
+	}{
+		{a, "a", 10}, {a, "a", -3}, {b, "b", 5}, {b, "b", -7}, {a, "a", 2}, {b, "b", 1},
+	}
+	var expected int64
+	for _, s := range steps {
+		var err error
+		if s.delta >= 0 {
+			err = PNCounterIncrement(s.db, "counter", s.node, uint64(s.delta))
+		} else {
+			err = PNCounterDecrement(s.db, "counter", s.node, uint64(-s.delta))
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected += s.delta
+	}
+
+	stateA, err := PNCounterState(a, "counter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateB, err := PNCounterState(b, "counter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// злиття ідемпотентне, тож повторне не змінює значення
+	for i := 0; i < 2; i++ {
+		if err := PNCounterMerge(a, "counter", stateB); err != nil {
+			t.Fatal(err)
+		}
+		if err := PNCounterMerge(b, "counter", stateA); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, db := range []*Db{a, b} {
+		if value, err := PNCounterValue(db, "counter"); err != nil || value != expected {
+			t.Errorf("Expected %d after merge, got %d, %v", expected, value, err)
+		}
+	}
+
+	if value, err := PNCounterValue(a, "missing"); err != nil || value != 0 {
+		t.Errorf("Expected missing counter to be 0, got %d, %v", value, err)
+	}
+	if err := PNCounterIncrement(a, "counter", "", 1); err == nil {
+		t.Error("Expected error for empty node id")
+	}
+}
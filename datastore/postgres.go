@@ -0,0 +1,177 @@
+package datastore
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// postgresDriver - ім'я драйвера lib/pq; для pgx застосунок ставить "pgx"
+var postgresDriver = "postgres"
+
+// parsePostgresArray розбирає одновимірний масив у текстовому вигляді, як
+// його віддають lib/pq і pgx: {a,"b c",NULL}
+func parsePostgresArray(s string) ([]interface{}, error) {
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return nil, fmt.Errorf("bad postgres array %q", s)
+	}
+	body := s[1 : len(s)-1]
+	res := []interface{}{}
+	if body == "" {
+		return res, nil
+	}
+	for i := 0; ; i++ {
+		var elem strings.Builder
+		quoted := i < len(body) && body[i] == '"'
+		if quoted {
+			for i++; ; i++ {
+				if i >= len(body) {
+					return nil, fmt.Errorf("bad postgres array %q", s)
+				}
+				if body[i] == '\\' && i+1 < len(body) {
+					i++
+				} else if body[i] == '"' {
+					i++
+					break
+				}
+				elem.WriteByte(body[i])
+			}
+		} else {
+			for ; i < len(body) && body[i] != ','; i++ {
+				if body[i] == '{' || body[i] == '"' {
+					return nil, fmt.Errorf("unsupported postgres array %q", s)
+				}
+				elem.WriteByte(body[i])
+			}
+		}
+		if !quoted && strings.EqualFold(elem.String(), "NULL") {
+			res = append(res, nil)
+		} else {
+			res = append(res, elem.String())
+		}
+		if i == len(body) {
+			return res, nil
+		}
+		if body[i] != ',' {
+			return nil, fmt.Errorf("bad postgres array %q", s)
+		}
+	}
+}
+
+// ImportPostgres runs query against the Postgres database at connStr and
+// writes keyCol, valueCol and typeCol of every row into db. A NULL type (or
+// an empty typeCol) means a string entry, except that array columns become
+// JSON array documents.
+func ImportPostgres(connStr, query string, keyCol, valueCol, typeCol string, db *Db) error {
+	conn, err := sql.Open(postgresDriver, connStr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	rows, err := conn.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	keyIdx, valueIdx, typeIdx := -1, -1, -1
+	for i, t := range types {
+		switch t.Name() {
+		case keyCol:
+			keyIdx = i
+		case valueCol:
+			valueIdx = i
+		}
+		if typeCol != "" && t.Name() == typeCol {
+			typeIdx = i
+		}
+	}
+	if keyIdx < 0 || valueIdx < 0 || typeCol != "" && typeIdx < 0 {
+		return fmt.Errorf("query does not return columns %q, %q and %q", keyCol, valueCol, typeCol)
+	}
+	//назви типів масивів у Postgres починаються з _: _TEXT, _INT4
+	isArray := strings.HasPrefix(types[valueIdx].DatabaseTypeName(), "_")
+
+	cols := make([]sql.NullString, len(types))
+	dest := make([]interface{}, len(types))
+	for i := range cols {
+		dest[i] = &cols[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		key, value := cols[keyIdx], cols[valueIdx]
+		if !key.Valid {
+			return fmt.Errorf("row with a NULL %s", keyCol)
+		}
+		if typeIdx >= 0 && cols[typeIdx].Valid {
+			if err := importEntry(db, key.String, cols[typeIdx].String, value.String); err != nil {
+				return err
+			}
+			continue
+		}
+		if !isArray || !value.Valid {
+			if err := db.Put(key.String, value.String); err != nil {
+				return err
+			}
+			continue
+		}
+		elems, err := parsePostgresArray(value.String)
+		if err != nil {
+			return fmt.Errorf("value of %q: %v", key.String, err)
+		}
+		e, err := NewDocumentEntry(key.String, elems)
+		if err != nil {
+			return err
+		}
+		if err := db.putEntry(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ExportPostgres writes every key of db under prefix into tableName of the
+// Postgres database at connStr as (key, value, type) rows, creating the
+// table if needed. Existing keys are updated; the export runs in one
+// transaction.
+func ExportPostgres(db *Db, prefix string, connStr, tableName string) error {
+	entries, err := db.entries()
+	if err != nil {
+		return err
+	}
+	conn, err := sql.Open(postgresDriver, connStr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	table := quoteSQLIdent(tableName)
+	if _, err := conn.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s ("key" TEXT PRIMARY KEY, "value" BYTEA, "type" TEXT NOT NULL)`, table)); err != nil {
+		return err
+	}
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(fmt.Sprintf(`INSERT INTO %s ("key", "value", "type") VALUES ($1, $2, $3) `+
+		`ON CONFLICT ("key") DO UPDATE SET "value" = EXCLUDED."value", "type" = EXCLUDED."type"`, table))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, e := range entries {
+		if !strings.HasPrefix(e.key, prefix) {
+			continue
+		}
+		if _, err := stmt.Exec(e.key, []byte(e.value), e.Type()); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
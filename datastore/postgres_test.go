@@ -0,0 +1,169 @@
+package datastore
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakePostgres віддає заготовлені рядки на будь-який запит і записує
+// аргументи всіх Exec, як це робив би sqlmock
+type fakePostgres struct {
+	mu      sync.Mutex
+	columns []string
+	types   []string
+	rows    [][]driver.Value
+	execs   []string
+	args    [][]driver.Value
+}
+
+var fakePostgresDb = &fakePostgres{}
+
+func init() {
+	for _, name := range sql.Drivers() {
+		if name == postgresDriver {
+			return
+		}
+	}
+	sql.Register(postgresDriver, fakePostgresDriver{})
+}
+
+type fakePostgresDriver struct{}
+
+func (fakePostgresDriver) Open(name string) (driver.Conn, error) { return fakePostgresConn{}, nil }
+
+type fakePostgresConn struct{}
+
+func (fakePostgresConn) Prepare(query string) (driver.Stmt, error) {
+	return fakePostgresStmt(query), nil
+}
+func (fakePostgresConn) Close() error              { return nil }
+func (fakePostgresConn) Begin() (driver.Tx, error) { return fakePostgresConn{}, nil }
+func (fakePostgresConn) Commit() error             { return nil }
+func (fakePostgresConn) Rollback() error           { return nil }
+
+type fakePostgresStmt string
+
+func (fakePostgresStmt) Close() error    { return nil }
+func (s fakePostgresStmt) NumInput() int { return strings.Count(string(s), "$") }
+
+func (s fakePostgresStmt) Exec(args []driver.Value) (driver.Result, error) {
+	fakePostgresDb.mu.Lock()
+	defer fakePostgresDb.mu.Unlock()
+	fakePostgresDb.execs = append(fakePostgresDb.execs, string(s))
+	if len(args) > 0 {
+		fakePostgresDb.args = append(fakePostgresDb.args, args)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakePostgresStmt) Query(args []driver.Value) (driver.Rows, error) {
+	fakePostgresDb.mu.Lock()
+	defer fakePostgresDb.mu.Unlock()
+	return &fakePostgresRows{fakePostgresDb.columns, fakePostgresDb.types, fakePostgresDb.rows}, nil
+}
+
+type fakePostgresRows struct {
+	columns []string
+	types   []string
+	rows    [][]driver.Value
+}
+
+func (r *fakePostgresRows) Columns() []string                       { return r.columns }
+func (r *fakePostgresRows) ColumnTypeDatabaseTypeName(i int) string { return r.types[i] }
+func (r *fakePostgresRows) Close() error                            { return nil }
+
+func (r *fakePostgresRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestParsePostgresArray(t *testing.T) {
+	elems, err := parsePostgresArray(`{a,"b c","q\"uote",NULL,"NULL"}`)
+	if err != nil || len(elems) != 5 || elems[1] != "b c" || elems[2] != `q"uote` || elems[3] != nil || elems[4] != "NULL" {
+		t.Errorf("Bad array: %#v, %v", elems, err)
+	}
+	if elems, err := parsePostgresArray("{}"); err != nil || len(elems) != 0 {
+		t.Errorf("Bad empty array: %#v, %v", elems, err)
+	}
+	for _, bad := range []string{"a,b", `{"a}`, `{"a"b}`, "{{1,2},{3,4}}"} {
+		if _, err := parsePostgresArray(bad); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func TestPostgresImportExport(t *testing.T) {
+	//драйвер зареєстровано один раз на процес, тож стан скидаємо для кожного запуску
+	fakePostgresDb.mu.Lock()
+	fakePostgresDb.rows, fakePostgresDb.execs, fakePostgresDb.args = nil, nil, nil
+	fakePostgresDb.columns = []string{"id", "name", "tags", "kind"}
+	fakePostgresDb.types = []string{"INT4", "TEXT", "_TEXT", "TEXT"}
+	fakePostgresDb.mu.Unlock()
+	for i := 0; i < 50; i++ {
+		var kind driver.Value
+		if i == 0 {
+			kind = "int64"
+		}
+		tags := fmt.Sprintf(`{tag%d,"with space"}`, i)
+		if i == 0 {
+			tags = "7"
+		}
+		fakePostgresDb.rows = append(fakePostgresDb.rows, []driver.Value{int64(i), fmt.Sprintf("cfg:%02d", i), []byte(tags), kind})
+	}
+
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := ImportPostgres("postgres://localhost/app", "SELECT * FROM settings", "name", "tags", "kind", db); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := db.entries()
+	if len(entries) != 50 {
+		t.Errorf("Expected 50 entries, got %d", len(entries))
+	}
+	if v, err := db.GetInt64("cfg:00"); err != nil || v != 7 {
+		t.Errorf("Bad typed row: %d, %v", v, err)
+	}
+	var tags []string
+	if err := getTestDocument(db, "cfg:42", &tags); err != nil || len(tags) != 2 || tags[0] != "tag42" || tags[1] != "with space" {
+		t.Errorf("Bad array row: %v, %v", tags, err)
+	}
+	if err := ImportPostgres("postgres://localhost/app", "SELECT * FROM settings", "name", "missing", "", db); err == nil {
+		t.Errorf("Expected an error for a missing column")
+	}
+
+	db.Put("other", "skipped")
+	if err := ExportPostgres(db, "cfg:", "postgres://localhost/app", "kv"); err != nil {
+		t.Fatal(err)
+	}
+	fakePostgresDb.mu.Lock()
+	defer fakePostgresDb.mu.Unlock()
+	if len(fakePostgresDb.execs) == 0 || !strings.HasPrefix(fakePostgresDb.execs[0], `CREATE TABLE IF NOT EXISTS "kv"`) {
+		t.Errorf("Expected the table to be created first: %v", fakePostgresDb.execs)
+	}
+	if len(fakePostgresDb.args) != 50 {
+		t.Fatalf("Expected 50 inserted rows, got %d", len(fakePostgresDb.args))
+	}
+	if row := fakePostgresDb.args[0]; row[0] != "cfg:00" || string(row[1].([]byte)) != "7" || row[2] != "int64" {
+		t.Errorf("Bad inserted row: %v", row)
+	}
+}
@@ -0,0 +1,169 @@
+package datastore
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+)
+
+// Повідомлення теми лежать під "topic:<тема>:<зсув>" із зсувом, доповненим
+// нулями до 20 цифр, а "topic:<тема>" зберігає наступний вільний зсув.
+// Підписка "sub:<тема>:<id>" зберігає зсув наступного непрочитаного
+// повідомлення.
+
+const (
+	topicKeyPrefix        = "topic:"
+	subscriptionKeyPrefix = "sub:"
+	// оригінальний ключ опублікованого запису
+	pubsubKeyAnnotation = "pubsub.key"
+)
+
+func topicMessageKey(topic string, offset uint64) string {
+	return fmt.Sprintf("%s%s:%020d", topicKeyPrefix, topic, offset)
+}
+
+// subscriptionOperator, як і lamportOperator, зберігає рівно 8 байт зсуву
+type subscriptionOperator struct{}
+
+func (s subscriptionOperator) Encode(e *Entry, dst []byte) []byte {
+	n, err := strconv.ParseUint(e.value, 10, 64)
+	if err != nil {
+		panic(err)
+	}
+	res, offset := encodeKeyInto(e, 4, dst)
+	res[offset] = SUBSCRIPTION_TYPE
+	binary.LittleEndian.PutUint64(res[offset+TYPE_SIZE:], n)
+	return res
+}
+
+func (s subscriptionOperator) EncodeInto(e *Entry, dst []byte) ([]byte, error) {
+	n, err := strconv.ParseUint(e.value, 10, 64)
+	if err != nil {
+		return dst, err
+	}
+	dst = appendKey(dst, e, 4)
+	dst = append(dst, SUBSCRIPTION_TYPE)
+	return binary.LittleEndian.AppendUint64(dst, n), nil
+}
+
+func (s subscriptionOperator) Decode(input []byte, e *Entry) {
+	kl := len(e.key)
+	e.value = strconv.FormatUint(binary.LittleEndian.Uint64(input[kl+TYPE_SIZE+8:]), 10)
+}
+
+func (s subscriptionOperator) Read(in *bufio.Reader) (string, error) {
+	data, err := in.Peek(8)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(binary.LittleEndian.Uint64(data), 10), nil
+}
+
+// topicHead читає наступний вільний зсув теми; викликається під db.mu
+func (db *Db) topicHead(topic string) (uint64, error) {
+	e, err := db.latestEntry(topicKeyPrefix + topic)
+	if err == ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if e.valueType != INT64_TYPE {
+		return 0, fmt.Errorf("wrong type of value")
+	}
+	return strconv.ParseUint(e.value, 10, 64)
+}
+
+// Publish appends a copy of message to topic. The message is written before
+// the topic head, so a crash in between loses the message rather than
+// leaving a gap that subscribers would stall on.
+func Publish(db *Db, topic string, message *Entry) error {
+	db.mu.Lock()
+	written, err := db.publish(topic, message)
+	db.unlockWrite()
+	if err != nil {
+		return err
+	}
+	for _, e := range written {
+		db.emit(e)
+	}
+	return nil
+}
+
+func (db *Db) publish(topic string, message *Entry) ([]*Entry, error) {
+	offset, err := db.topicHead(topic)
+	if err != nil {
+		return nil, err
+	}
+	stored := *message
+	stored.key = topicMessageKey(topic, offset)
+	stored.Annotate(pubsubKeyAnnotation, message.key)
+	head := &Entry{key: topicKeyPrefix + topic, valueType: INT64_TYPE, value: strconv.FormatUint(offset+1, 10)}
+	if err := db.appendEntry(&stored); err != nil {
+		return nil, err
+	}
+	if err := db.appendEntry(head); err != nil {
+		return []*Entry{&stored}, err
+	}
+	return []*Entry{&stored, head}, nil
+}
+
+// Subscription reads a topic from a durable offset stored in the Db.
+type Subscription struct {
+	db    *Db
+	topic string
+	key   string
+}
+
+// Subscribe opens the subscription subID on topic. A new subscription starts
+// at the end of the topic; an existing one resumes where it stopped, so
+// messages published while the subscriber was away are not lost.
+func Subscribe(db *Db, topic, subID string) (*Subscription, error) {
+	s := &Subscription{db: db, topic: topic, key: subscriptionKeyPrefix + topic + ":" + subID}
+	db.mu.RLock()
+	head, err := db.topicHead(topic)
+	db.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	_, err = db.updateEntry(s.key, func(cur *Entry) (*Entry, error) {
+		if cur != nil {
+			if cur.valueType != SUBSCRIPTION_TYPE {
+				return nil, fmt.Errorf("wrong type of value")
+			}
+			return nil, nil
+		}
+		return &Entry{valueType: SUBSCRIPTION_TYPE, value: strconv.FormatUint(head, 10)}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Next returns the next unread message and advances the stored offset. It
+// returns ErrNotFound when the subscriber has read everything.
+func (s *Subscription) Next() (*Entry, error) {
+	var message *Entry
+	_, err := s.db.updateEntry(s.key, func(cur *Entry) (*Entry, error) {
+		if cur == nil || cur.valueType != SUBSCRIPTION_TYPE {
+			return nil, fmt.Errorf("subscription %s is missing", s.key)
+		}
+		offset, err := strconv.ParseUint(cur.value, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if message, err = s.db.getEntry(topicMessageKey(s.topic, offset)); err != nil {
+			return nil, err
+		}
+		return &Entry{valueType: SUBSCRIPTION_TYPE, value: strconv.FormatUint(offset+1, 10)}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if key, ok := message.Annotation(pubsubKeyAnnotation); ok {
+		message.key = key
+	}
+	return message, nil
+}
@@ -0,0 +1,83 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestPubSub(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publish := func(from, to int) {
+		for i := from; i < to; i++ {
+			if err := Publish(db, "orders", &Entry{key: fmt.Sprintf("order%d", i), valueType: STRING_TYPE, value: fmt.Sprintf("payload%d", i)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	publish(0, 2)
+	sub, err := Subscribe(db, "orders", "billing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sub.Next(); err != ErrNotFound {
+		t.Errorf("Expected a new subscription to skip earlier messages, got %v", err)
+	}
+	publish(2, 5)
+	for i := 2; i < 4; i++ {
+		e, err := sub.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.key != fmt.Sprintf("order%d", i) || e.value != fmt.Sprintf("payload%d", i) {
+			t.Errorf("Bad message %d: %s=%s", i, e.key, e.value)
+		}
+	}
+
+	//підписник відключається, поки публікуються нові повідомлення, а база перевідкривається
+	publish(5, 8)
+	db.Close()
+	db, err = NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	sub, err = Subscribe(db, "orders", "billing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 4; i < 8; i++ {
+		e, err := sub.Next()
+		if err != nil {
+			t.Fatalf("Missed message %d: %v", i, err)
+		}
+		if e.key != fmt.Sprintf("order%d", i) {
+			t.Errorf("Expected order%d, got %s", i, e.key)
+		}
+	}
+	if _, err := sub.Next(); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound after the last message, got %v", err)
+	}
+
+	other, err := Subscribe(db, "orders", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	publish(8, 9)
+	if e, err := other.Next(); err != nil || e.key != "order8" {
+		t.Errorf("Expected order8 for the second subscriber, got %v, %v", e, err)
+	}
+	if e, err := sub.Next(); err != nil || e.key != "order8" {
+		t.Errorf("Expected order8 for the first subscriber, got %v, %v", e, err)
+	}
+}
@@ -0,0 +1,397 @@
+package datastore
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type Query struct {
+	filters []func(*Entry) bool
+	fields  []string
+	orderBy string
+	asc     bool
+	limit   int
+	err     error
+}
+
+func NewQuery() *Query {
+	return &Query{limit: -1}
+}
+
+func validField(field string) bool {
+	return field == "key" || field == "type" || field == "value"
+}
+
+func (q *Query) Select(fields ...string) *Query {
+	for _, field := range fields {
+		if !validField(field) {
+			q.err = fmt.Errorf("unknown field %q", field)
+		}
+	}
+	q.fields = fields
+	return q
+}
+
+func (q *Query) Where(fn func(*Entry) bool) *Query {
+	q.filters = append(q.filters, fn)
+	return q
+}
+
+func (q *Query) OrderBy(field string, asc bool) *Query {
+	if !validField(field) {
+		q.err = fmt.Errorf("unknown field %q", field)
+	}
+	q.orderBy = field
+	q.asc = asc
+	return q
+}
+
+func (q *Query) Limit(n int) *Query {
+	q.limit = n
+	return q
+}
+
+func compareValues(a, b *Entry) int {
+	if a.valueType == INT64_TYPE && b.valueType == INT64_TYPE {
+		x, errX := strconv.ParseInt(a.value, 10, 64)
+		y, errY := strconv.ParseInt(b.value, 10, 64)
+		if errX == nil && errY == nil {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	if a.valueType == TIMESTAMP_TYPE && b.valueType == TIMESTAMP_TYPE {
+		//текст RFC 3339 без кінцевих нулів не сортується як рядок
+		x, errX := parseTimestamp(a.value)
+		y, errY := parseTimestamp(b.value)
+		if errX == nil && errY == nil {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(a.value, b.value)
+}
+
+func compareField(a, b *Entry, field string) int {
+	switch field {
+	case "key":
+		return strings.Compare(a.key, b.key)
+	case "type":
+		return strings.Compare(a.Type(), b.Type())
+	}
+	return compareValues(a, b)
+}
+
+func (q *Query) Execute(db *Db) ([]*Entry, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	entries, err := db.entries()
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*Entry
+	for _, e := range entries {
+		matched := true
+		for _, fn := range q.filters {
+			if !fn(e) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			res = append(res, e)
+		}
+	}
+
+	if q.orderBy != "" {
+		sort.SliceStable(res, func(i, j int) bool {
+			c := compareField(res[i], res[j], q.orderBy)
+			if q.asc {
+				return c < 0
+			}
+			return c > 0
+		})
+	}
+	if q.limit >= 0 && q.limit < len(res) {
+		res = res[:q.limit]
+	}
+
+	//тип лишаємо завжди, без нього значення не інтерпретувати
+	if len(q.fields) != 0 {
+		var withKey, withValue bool
+		for _, field := range q.fields {
+			withKey = withKey || field == "key"
+			withValue = withValue || field == "value"
+		}
+		for _, e := range res {
+			if !withKey {
+				e.key = ""
+			}
+			if !withValue {
+				e.value = ""
+			}
+		}
+	}
+	return res, nil
+}
+
+type sqlToken struct {
+	text   string
+	quoted bool
+}
+
+func tokenizeSQL(sql string) ([]sqlToken, error) {
+	var tokens []sqlToken
+	runes := []rune(sql)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == ',' || r == '*' || r == '=':
+			tokens = append(tokens, sqlToken{text: string(r)})
+			i++
+		case r == '<' || r == '>' || r == '!':
+			if i+1 < len(runes) && (runes[i+1] == '=' || (r == '<' && runes[i+1] == '>')) {
+				tokens = append(tokens, sqlToken{text: string(runes[i : i+2])})
+				i += 2
+			} else if r == '!' {
+				return nil, fmt.Errorf("unexpected character %q", r)
+			} else {
+				tokens = append(tokens, sqlToken{text: string(r)})
+				i++
+			}
+		case r == '\'':
+			var sb strings.Builder
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("unterminated string literal")
+				}
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						sb.WriteRune('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, sqlToken{text: sb.String(), quoted: true})
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '-') {
+				i++
+			}
+			tokens = append(tokens, sqlToken{text: string(runes[start:i])})
+		default:
+			return nil, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+	return tokens, nil
+}
+
+type sqlParser struct {
+	tokens []sqlToken
+	pos    int
+}
+
+func (p *sqlParser) peek() (sqlToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return sqlToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *sqlParser) next() (sqlToken, error) {
+	t, ok := p.peek()
+	if !ok {
+		return sqlToken{}, fmt.Errorf("unexpected end of query")
+	}
+	p.pos++
+	return t, nil
+}
+
+func (p *sqlParser) keyword(kw string) bool {
+	t, ok := p.peek()
+	if ok && !t.quoted && strings.EqualFold(t.text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) expect(kw string) error {
+	if !p.keyword(kw) {
+		return fmt.Errorf("expected %s", strings.ToUpper(kw))
+	}
+	return nil
+}
+
+func (p *sqlParser) field() (string, error) {
+	t, err := p.next()
+	if err != nil {
+		return "", err
+	}
+	field := strings.ToLower(t.text)
+	if t.quoted || !validField(field) {
+		return "", fmt.Errorf("unknown field %q", t.text)
+	}
+	return field, nil
+}
+
+func (p *sqlParser) condition() (func(*Entry) bool, error) {
+	field, err := p.field()
+	if err != nil {
+		return nil, err
+	}
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	switch op.text {
+	case "=", "!=", "<>", "<", "<=", ">", ">=":
+	default:
+		return nil, fmt.Errorf("unsupported operator %q", op.text)
+	}
+	if op.quoted {
+		return nil, fmt.Errorf("unsupported operator %q", op.text)
+	}
+	literal, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	operand := &Entry{valueType: STRING_TYPE}
+	switch field {
+	case "key":
+		operand.key = literal.text
+	case "type":
+		operand.valueType = ToByte(literal.text)
+		if ToType(operand.valueType) != literal.text {
+			return nil, fmt.Errorf("unknown type %q", literal.text)
+		}
+	case "value":
+		operand.value = literal.text
+		if _, err := strconv.ParseInt(literal.text, 10, 64); err == nil && !literal.quoted {
+			operand.valueType = INT64_TYPE
+		}
+	}
+
+	return func(e *Entry) bool {
+		c := compareField(e, operand, field)
+		switch op.text {
+		case "=":
+			return c == 0
+		case "!=", "<>":
+			return c != 0
+		case "<":
+			return c < 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		}
+		return c >= 0
+	}, nil
+}
+
+func ParseQuery(sql string) (*Query, error) {
+	tokens, err := tokenizeSQL(sql)
+	if err != nil {
+		return nil, err
+	}
+	p := &sqlParser{tokens: tokens}
+	q := NewQuery()
+
+	if err := p.expect("select"); err != nil {
+		return nil, err
+	}
+	if t, ok := p.peek(); ok && t.text == "*" {
+		p.pos++
+	} else {
+		for {
+			field, err := p.field()
+			if err != nil {
+				return nil, err
+			}
+			q.Select(append(q.fields, field)...)
+			if t, ok := p.peek(); !ok || t.text != "," {
+				break
+			}
+			p.pos++
+		}
+	}
+
+	if err := p.expect("from"); err != nil {
+		return nil, err
+	}
+	if err := p.expect("entries"); err != nil {
+		return nil, err
+	}
+
+	if p.keyword("where") {
+		for {
+			cond, err := p.condition()
+			if err != nil {
+				return nil, err
+			}
+			q.Where(cond)
+			if !p.keyword("and") {
+				break
+			}
+		}
+	}
+
+	if p.keyword("order") {
+		if err := p.expect("by"); err != nil {
+			return nil, err
+		}
+		field, err := p.field()
+		if err != nil {
+			return nil, err
+		}
+		asc := true
+		if p.keyword("desc") {
+			asc = false
+		} else {
+			p.keyword("asc")
+		}
+		q.OrderBy(field, asc)
+	}
+
+	if p.keyword("limit") {
+		t, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.Atoi(t.text)
+		if err != nil || t.quoted || n < 0 {
+			return nil, fmt.Errorf("bad limit %q", t.text)
+		}
+		q.Limit(n)
+	}
+
+	if t, ok := p.peek(); ok {
+		return nil, fmt.Errorf("unexpected token %q", t.text)
+	}
+	return q, nil
+}
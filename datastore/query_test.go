@@ -0,0 +1,161 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func newQueryTestDb(t *testing.T) (*Db, func()) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := db.Put(fmt.Sprintf("s%d", i), fmt.Sprintf("value%d", 9-i)); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.PutInt64(fmt.Sprintf("i%d", i), int64(i*i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func keysOf(entries []*Entry) []string {
+	var keys []string
+	for _, e := range entries {
+		keys = append(keys, e.Key())
+	}
+	return keys
+}
+
+func TestQuery(t *testing.T) {
+	db, cleanup := newQueryTestDb(t)
+	defer cleanup()
+
+	t.Run("where", func(t *testing.T) {
+		res, err := NewQuery().Where(func(e *Entry) bool { return e.Type() == "int64" }).Execute(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res) != 10 {
+			t.Errorf("Expected 10 entries, got %d", len(res))
+		}
+	})
+
+	t.Run("order by value desc with limit", func(t *testing.T) {
+		res, err := NewQuery().
+			Where(func(e *Entry) bool { return e.Type() == "int64" }).
+			OrderBy("value", false).
+			Limit(3).
+			Execute(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys := fmt.Sprint(keysOf(res))
+		if keys != "[i9 i8 i7]" {
+			t.Errorf("Unexpected result %s", keys)
+		}
+	})
+
+	t.Run("order by value asc", func(t *testing.T) {
+		res, err := NewQuery().
+			Where(func(e *Entry) bool { return e.Type() == "string" }).
+			OrderBy("value", true).
+			Limit(2).
+			Execute(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys := fmt.Sprint(keysOf(res))
+		if keys != "[s9 s8]" {
+			t.Errorf("Unexpected result %s", keys)
+		}
+	})
+
+	t.Run("limit zero", func(t *testing.T) {
+		res, err := NewQuery().Limit(0).Execute(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res) != 0 {
+			t.Errorf("Expected no entries, got %d", len(res))
+		}
+	})
+
+	t.Run("unknown order field", func(t *testing.T) {
+		_, err := NewQuery().OrderBy("size", true).Execute(db)
+		if err == nil {
+			t.Error("Expected error for unknown field")
+		}
+	})
+}
+
+func TestParseQuery(t *testing.T) {
+	db, cleanup := newQueryTestDb(t)
+	defer cleanup()
+
+	cases := []struct {
+		sql  string
+		keys string
+	}{
+		{"SELECT key, value FROM entries WHERE type = 'int64' ORDER BY key LIMIT 100", "[i0 i1 i2 i3 i4 i5 i6 i7 i8 i9]"},
+		{"select * from entries where type = 'int64' and value >= 25 order by value desc limit 2", "[i9 i8]"},
+		{"SELECT key FROM entries WHERE key < 's2' AND key >= 's0'", "[s0 s1]"},
+		{"SELECT * FROM entries WHERE value = 'value0'", "[s9]"},
+		{"SELECT * FROM entries ORDER BY key DESC LIMIT 1", "[s9]"},
+	}
+	for _, c := range cases {
+		q, err := ParseQuery(c.sql)
+		if err != nil {
+			t.Errorf("Cannot parse %q: %s", c.sql, err)
+			continue
+		}
+		res, err := q.Execute(db)
+		if err != nil {
+			t.Errorf("Cannot execute %q: %s", c.sql, err)
+			continue
+		}
+		if keys := fmt.Sprint(keysOf(res)); keys != c.keys {
+			t.Errorf("Unexpected result for %q: expected %s, got %s", c.sql, c.keys, keys)
+		}
+	}
+
+	q, err := ParseQuery("SELECT key FROM entries WHERE key = 's1'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := q.Execute(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].Value() != "" {
+		t.Errorf("Expected value to be projected out, got %v", res)
+	}
+
+	bad := []string{
+		"",
+		"DELETE FROM entries",
+		"SELECT size FROM entries",
+		"SELECT * FROM other",
+		"SELECT * FROM entries WHERE type = 'float'",
+		"SELECT * FROM entries WHERE key LIKE 'a'",
+		"SELECT * FROM entries LIMIT -1",
+		"SELECT * FROM entries WHERE key = 'unterminated",
+		"SELECT * FROM entries LIMIT 1 extra",
+	}
+	for _, sql := range bad {
+		if _, err := ParseQuery(sql); err == nil {
+			t.Errorf("Expected error for %q", sql)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package datastore
+
+import (
+	"context"
+	"fmt"
+)
+
+var ErrQuorumFailed = fmt.Errorf("write was not confirmed by a quorum of peers")
+
+// QuorumWriter writes every entry to all peers in parallel and confirms it
+// once quorum of them have it on disk. Peers that fail or lag are not
+// repaired: a later write of the key simply overwrites them.
+type QuorumWriter struct {
+	peers  []*Db
+	quorum int
+}
+
+func NewQuorumWriter(peers []*Db, quorum int) *QuorumWriter {
+	return &QuorumWriter{peers: peers, quorum: quorum}
+}
+
+// WriteEntry returns once quorum peers have written e, or ErrQuorumFailed
+// when enough of them failed or ctx is done first. The remaining writes keep
+// running in the background.
+func (q *QuorumWriter) WriteEntry(ctx context.Context, e *Entry) error {
+	if q.quorum < 1 || q.quorum > len(q.peers) {
+		return fmt.Errorf("quorum %d is out of range for %d peers", q.quorum, len(q.peers))
+	}
+	//буфер на всіх, щоб запізнілі горутини не зависали після виходу
+	results := make(chan error, len(q.peers))
+	for _, peer := range q.peers {
+		go func(peer *Db) {
+			results <- peer.putEntry(e)
+		}(peer)
+	}
+	confirmed, failed := 0, 0
+	for confirmed < q.quorum {
+		select {
+		case err := <-results:
+			if err != nil {
+				failed++
+				if len(q.peers)-failed < q.quorum {
+					return ErrQuorumFailed
+				}
+				continue
+			}
+			confirmed++
+		case <-ctx.Done():
+			return ErrQuorumFailed
+		}
+	}
+	return nil
+}
+
+func (q *QuorumWriter) Put(ctx context.Context, key, value string) error {
+	return q.WriteEntry(ctx, &Entry{key: key, valueType: STRING_TYPE, value: value})
+}
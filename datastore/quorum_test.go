@@ -0,0 +1,60 @@
+package datastore
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestQuorumWriter(t *testing.T) {
+	var peers []*Db
+	for i := 0; i < 3; i++ {
+		dir, err := ioutil.TempDir("", "test-db")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		db, err := NewDb(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		peers = append(peers, db)
+	}
+
+	//відмова одного вузла: він відхиляє всі записи
+	peers[2].mu.Lock()
+	peers[2].standby = true
+	peers[2].mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := NewQuorumWriter(peers, 2).Put(ctx, "key", "value"); err != nil {
+		t.Fatalf("Expected the write to reach a quorum: %v", err)
+	}
+	for _, db := range peers[:2] {
+		if v, err := db.Get("key"); err != nil || v != "value" {
+			t.Errorf("Bad value on a healthy peer: %q, %v", v, err)
+		}
+	}
+	if err := NewQuorumWriter(peers, 3).Put(ctx, "key", "value"); err != ErrQuorumFailed {
+		t.Errorf("Expected ErrQuorumFailed with a failed peer, got %v", err)
+	}
+	if err := NewQuorumWriter(peers, 4).Put(ctx, "key", "value"); err == nil {
+		t.Errorf("Expected an error for a quorum larger than the peers")
+	}
+
+	//вузол, що не відповідає до дедлайну
+	peers[2].mu.Lock()
+	peers[2].standby = false
+	peers[1].mu.Lock()
+	short, cancelShort := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelShort()
+	err := NewQuorumWriter(peers, 2).Put(short, "slow", "value")
+	peers[1].mu.Unlock()
+	peers[2].mu.Unlock()
+	if err != ErrQuorumFailed {
+		t.Errorf("Expected ErrQuorumFailed after the deadline, got %v", err)
+	}
+}
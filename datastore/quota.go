@@ -0,0 +1,71 @@
+package datastore
+
+import "fmt"
+
+var ErrQuotaExceeded = fmt.Errorf("disk quota exceeded")
+
+// CurrentSizeBytes returns the total size of the segment files of the database.
+func (db *Db) CurrentSizeBytes() (int64, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.diskSize()
+}
+
+func (db *Db) diskSize() (int64, error) {
+	var total int64
+	for _, b := range db.blocks {
+		//знімок чекпоінту лежить поза базою і квоту не займає
+		if b.snapshot {
+			continue
+		}
+		size, err := b.size()
+		if err != nil {
+			return 0, err
+		}
+		total += size
+	}
+	return total, nil
+}
+
+// RegisterQuotaWarning adds fn to the callbacks called when a write brings
+// the database to QuotaWarningThreshold of MaxDiskBytes.
+func (db *Db) RegisterQuotaWarning(fn func(used, limit int64)) {
+	db.hooksMu.Lock()
+	defer db.hooksMu.Unlock()
+	db.quotaHooks = append(db.quotaHooks, fn)
+}
+
+// checkQuota викликається під db.mu перед записом e; повертає розмір бази
+// після запису, якщо він перетне поріг попередження, інакше 0
+func (db *Db) checkQuota(e *Entry) (int64, error) {
+	if db.maxDiskBytes <= 0 {
+		return 0, nil
+	}
+	data, err := EncodeInto(e, nil)
+	if err != nil {
+		return 0, err
+	}
+	used, err := db.diskSize()
+	if err != nil {
+		return 0, err
+	}
+	after := used + int64(len(data))
+	//видалення пропускаємо понад квоту: інакше повну базу не звільнити
+	if after > db.maxDiskBytes && e.valueType != TOMBSTONE_TYPE {
+		return 0, ErrQuotaExceeded
+	}
+	warnAt := int64(db.quotaWarningThreshold * float64(db.maxDiskBytes))
+	if db.quotaWarningThreshold > 0 && used < warnAt && after >= warnAt {
+		return after, nil
+	}
+	return 0, nil
+}
+
+func (db *Db) runQuotaHooks(used int64) {
+	db.hooksMu.Lock()
+	hooks := append(db.quotaHooks[:0:0], db.quotaHooks...)
+	db.hooksMu.Unlock()
+	for _, hook := range hooks {
+		hook(used, db.maxDiskBytes)
+	}
+}
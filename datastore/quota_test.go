@@ -0,0 +1,135 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDb_Quota(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewDb(dir, WithMaxDiskBytes(10*1024), WithQuotaWarningThreshold(0.8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var warnings []int64
+	db.RegisterQuotaWarning(func(used, limit int64) {
+		if limit != 10*1024 {
+			t.Errorf("Bad quota limit %d", limit)
+		}
+		warnings = append(warnings, used)
+	})
+
+	//ключ і значення підібрані так, щоб кожен запис займав рівно 1 KB
+	value := strings.Repeat("v", 1024-6-TYPE_SIZE-12)
+	data, err := EncodeInto(&Entry{key: "key-00", valueType: STRING_TYPE, value: value}, nil)
+	if err != nil || len(data) != 1024 {
+		t.Fatalf("Expected a 1 KB entry, got %d bytes: %v", len(data), err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := db.Put(fmt.Sprintf("key-%02d", i), value); err != nil {
+			t.Fatalf("write %d: %v", i+1, err)
+		}
+		if i == 6 && len(warnings) != 0 {
+			t.Errorf("Expected no warning below the threshold, got %v", warnings)
+		}
+	}
+	if err := db.Put("key-10", value); err != ErrQuotaExceeded {
+		t.Errorf("Expected ErrQuotaExceeded on the 11th write, got %v", err)
+	}
+	if len(warnings) != 1 || warnings[0] != 8*1024 {
+		t.Errorf("Expected one warning at 8 KB, got %v", warnings)
+	}
+
+	size, err := db.CurrentSizeBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 10*1024 {
+		t.Errorf("Expected 10 KB on disk, got %d", size)
+	}
+	if _, err := db.Get("key-10"); err != ErrNotFound {
+		t.Errorf("Expected the rejected write to be missing, got %v", err)
+	}
+	if got, err := db.Get("key-09"); err != nil || got != value {
+		t.Errorf("Cannot read the last accepted write: %v", err)
+	}
+}
+
+func TestDb_QuotaDelete(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewDb(dir, WithMaxDiskBytes(10*1024))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	value := strings.Repeat("v", 1024-6-TYPE_SIZE-12)
+	for i := 0; i < 10; i++ {
+		if err := db.Put(fmt.Sprintf("key-%02d", i), value); err != nil {
+			t.Fatalf("write %d: %v", i+1, err)
+		}
+	}
+	if err := db.Put("key-10", value); err != ErrQuotaExceeded {
+		t.Fatalf("Expected ErrQuotaExceeded on a full database, got %v", err)
+	}
+
+	//повну базу все одно можна звільнити
+	if deleted, err := db.deleteExisting("key-00"); err != nil || !deleted {
+		t.Errorf("Cannot delete from a full database: %v, %v", deleted, err)
+	}
+	if err := db.putTombstone("key-01"); err != nil {
+		t.Errorf("Cannot write a tombstone to a full database: %v", err)
+	}
+	if _, err := db.Get("key-00"); err != ErrNotFound {
+		t.Errorf("Expected key-00 to be deleted, got %v", err)
+	}
+	if err := db.SoftDelete("key-02", 0); err != ErrQuotaExceeded {
+		t.Errorf("Expected a soft delete to stay under the quota, got %v", err)
+	}
+}
+
+func TestDb_QuotaWarningAfterWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewDb(dir, WithMaxDiskBytes(10*1024), WithQuotaWarningThreshold(0.8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var warnings []int64
+	db.RegisterQuotaWarning(func(used, limit int64) {
+		warnings = append(warnings, used)
+	})
+
+	value := strings.Repeat("v", 1024-6-TYPE_SIZE-12)
+	for i := 0; i < 7; i++ {
+		if err := db.Put(fmt.Sprintf("key-%02d", i), value); err != nil {
+			t.Fatalf("write %d: %v", i+1, err)
+		}
+	}
+	//восьмий запис перетнув би поріг, але до файлу не потрапить
+	db.blocks[len(db.blocks)-1].segment.Close()
+	if err := db.Put("key-07", value); err == nil {
+		t.Fatal("Expected the write to a closed segment to fail")
+	}
+	if len(warnings) != 0 {
+		t.Errorf("Expected no warning for a failed write, got %v", warnings)
+	}
+	db.Close()
+}
@@ -0,0 +1,315 @@
+package datastore
+
+import "fmt"
+
+// Вузли й ребра лежать у сторінках, а не в окремих алокаціях: вузол займає
+// 20 байт, а ребро - це лише зсув і довжина в спільному масиві байтів, тож
+// розщеплене ребро ділить байти з обома половинами.
+
+const (
+	radixNodePageBits = 12
+	radixNodePageSize = 1 << radixNodePageBits
+	radixEdgePageBits = 16
+	radixEdgePageSize = 1 << radixEdgePageBits
+	radixMaxEdge      = radixEdgePageSize - 1
+	//старший біт offsetHi позначає вузол зі значенням
+	radixHasValue  = 1 << 15
+	radixMaxOffset = 1<<47 - 1
+)
+
+type radixNode struct {
+	edge     uint32
+	edgeLen  uint16
+	offsetHi uint16
+	offsetLo uint32
+	//перший нащадок і наступний брат; 0 - немає, бо корінь нічиїм нащадком не буває
+	child, next int32
+}
+
+func (n *radixNode) hasValue() bool {
+	return n.offsetHi&radixHasValue != 0
+}
+
+func (n *radixNode) offset() int64 {
+	return int64(n.offsetHi&^radixHasValue)<<32 | int64(n.offsetLo)
+}
+
+func (n *radixNode) setOffset(offset int64) {
+	n.offsetHi = uint16(offset>>32) | radixHasValue
+	n.offsetLo = uint32(offset)
+}
+
+var _ Index = (*RadixTree)(nil)
+
+// RadixTree is a path-compressed trie mapping keys to offsets. Keys sharing
+// a prefix store it once, so on keys with long common prefixes it takes much
+// less memory than a map. Offsets must be in [0, 2^47). Like the default
+// index it is not safe for concurrent use.
+type RadixTree struct {
+	nodes [][]radixNode
+	edges [][]byte
+	//звільнені вузли, зв'язані через next
+	free int32
+	len  int
+}
+
+func NewRadixTree() *RadixTree {
+	t := &RadixTree{}
+	t.alloc()
+	return t
+}
+
+func (t *RadixTree) Len() int {
+	return t.len
+}
+
+func (t *RadixTree) node(i int32) *radixNode {
+	return &t.nodes[i>>radixNodePageBits][i&(radixNodePageSize-1)]
+}
+
+func (t *RadixTree) alloc() int32 {
+	if t.free != 0 {
+		i := t.free
+		t.free = t.node(i).next
+		*t.node(i) = radixNode{}
+		return i
+	}
+	last := len(t.nodes) - 1
+	if last < 0 || len(t.nodes[last]) == radixNodePageSize {
+		t.nodes = append(t.nodes, make([]radixNode, 0, radixNodePageSize))
+		last++
+	}
+	t.nodes[last] = append(t.nodes[last], radixNode{})
+	return int32(last<<radixNodePageBits + len(t.nodes[last]) - 1)
+}
+
+func (t *RadixTree) edgeOf(n *radixNode) []byte {
+	if n.edgeLen == 0 {
+		return nil
+	}
+	page := t.edges[n.edge>>radixEdgePageBits]
+	start := n.edge & (radixEdgePageSize - 1)
+	return page[start : start+uint32(n.edgeLen)]
+}
+
+// appendEdge копіює s у масив ребер; ребро не перетинає межу сторінки
+func (t *RadixTree) appendEdge(s string) uint32 {
+	last := len(t.edges) - 1
+	if last < 0 || len(t.edges[last])+len(s) > radixEdgePageSize {
+		t.edges = append(t.edges, make([]byte, 0, radixEdgePageSize))
+		last++
+	}
+	start := len(t.edges[last])
+	t.edges[last] = append(t.edges[last], s...)
+	return uint32(last<<radixEdgePageBits + start)
+}
+
+// child шукає серед нащадків n ребро, що починається з c; нащадки впорядковані
+// за першим байтом, тож prev - брат, після якого вставляти новий
+func (t *RadixTree) child(n int32, c byte) (prev, found int32) {
+	for i := t.node(n).child; i != 0; i = t.node(i).next {
+		first := t.edgeOf(t.node(i))[0]
+		if first == c {
+			return prev, i
+		}
+		if first > c {
+			break
+		}
+		prev = i
+	}
+	return prev, 0
+}
+
+// link ставить i серед нащадків parent після prev (0 - на початок)
+func (t *RadixTree) link(parent, prev, i int32) {
+	if prev == 0 {
+		t.node(i).next = t.node(parent).child
+		t.node(parent).child = i
+	} else {
+		t.node(i).next = t.node(prev).next
+		t.node(prev).next = i
+	}
+}
+
+// replace ставить i на місце old серед нащадків parent
+func (t *RadixTree) replace(parent, prev, old, i int32) {
+	t.node(i).next = t.node(old).next
+	if prev == 0 {
+		t.node(parent).child = i
+	} else {
+		t.node(prev).next = i
+	}
+}
+
+func radixCommonPrefix(key string, edge []byte) int {
+	i := 0
+	for i < len(key) && i < len(edge) && key[i] == edge[i] {
+		i++
+	}
+	return i
+}
+
+func (t *RadixTree) Insert(key string, offset int64) {
+	if offset < 0 || offset > radixMaxOffset {
+		panic(fmt.Sprintf("radix tree offset %d out of range", offset))
+	}
+	n := int32(0)
+	for {
+		if key == "" {
+			if !t.node(n).hasValue() {
+				t.len++
+			}
+			t.node(n).setOffset(offset)
+			return
+		}
+		prev, c := t.child(n, key[0])
+		if c == 0 {
+			//довгий суфікс ділимо на ребра, що вміщаються в uint16
+			l := min(len(key), radixMaxEdge)
+			leaf := t.alloc()
+			t.node(leaf).edge = t.appendEdge(key[:l])
+			t.node(leaf).edgeLen = uint16(l)
+			t.link(n, prev, leaf)
+			n, key = leaf, key[l:]
+			continue
+		}
+
+		edge := t.edgeOf(t.node(c))
+		l := radixCommonPrefix(key, edge)
+		if l < len(edge) {
+			//розщеплюємо ребро; обидві половини дивляться в ті самі байти
+			split := t.alloc()
+			s, cn := t.node(split), t.node(c)
+			s.edge, s.edgeLen = cn.edge, uint16(l)
+			s.child = c
+			cn.edge += uint32(l)
+			cn.edgeLen -= uint16(l)
+			t.replace(n, prev, c, split)
+			t.node(c).next = 0
+			c = split
+		}
+		n, key = c, key[l:]
+	}
+}
+
+// find повертає вузол, до якого веде key, і шлях до нього від кореня
+func (t *RadixTree) find(key string, path []int32) (int32, []int32) {
+	n := int32(0)
+	for key != "" {
+		_, c := t.child(n, key[0])
+		if c == 0 {
+			return -1, path
+		}
+		edge := t.edgeOf(t.node(c))
+		if len(key) < len(edge) || radixCommonPrefix(key, edge) != len(edge) {
+			return -1, path
+		}
+		path = append(path, n)
+		n, key = c, key[len(edge):]
+	}
+	return n, path
+}
+
+func (t *RadixTree) Find(key string) (int64, bool) {
+	n, _ := t.find(key, nil)
+	if n < 0 || !t.node(n).hasValue() {
+		return 0, false
+	}
+	return t.node(n).offset(), true
+}
+
+func (t *RadixTree) collect(n int32, res []int64) []int64 {
+	if t.node(n).hasValue() {
+		res = append(res, t.node(n).offset())
+	}
+	for c := t.node(n).child; c != 0; c = t.node(c).next {
+		res = t.collect(c, res)
+	}
+	return res
+}
+
+// FindPrefix returns the offsets of all keys starting with prefix, in key
+// order.
+func (t *RadixTree) FindPrefix(prefix string) []int64 {
+	n := int32(0)
+	for prefix != "" {
+		_, c := t.child(n, prefix[0])
+		if c == 0 {
+			return nil
+		}
+		edge := t.edgeOf(t.node(c))
+		l := radixCommonPrefix(prefix, edge)
+		if l < len(prefix) && l < len(edge) {
+			return nil
+		}
+		n, prefix = c, prefix[l:]
+	}
+	return t.collect(n, nil)
+}
+
+func (t *RadixTree) Delete(key string) {
+	n, path := t.find(key, nil)
+	if n < 0 || !t.node(n).hasValue() {
+		return
+	}
+	t.node(n).offsetHi, t.node(n).offsetLo = 0, 0
+	t.len--
+
+	//прибираємо порожні листки; байти ребер лишаються до перебудови індексу
+	for len(path) > 0 {
+		parent := path[len(path)-1]
+		path = path[:len(path)-1]
+		cn := t.node(n)
+		if cn.hasValue() || cn.child != 0 {
+			t.mergeChild(parent, n)
+			return
+		}
+		prev, _ := t.child(parent, t.edgeOf(cn)[0])
+		if prev == 0 {
+			t.node(parent).child = cn.next
+		} else {
+			t.node(prev).next = cn.next
+		}
+		cn.next = t.free
+		t.free = n
+		n = parent
+	}
+}
+
+// mergeChild склеює n без значення з єдиним нащадком, якщо їхні ребра
+// лежать поруч, як після розщеплення
+func (t *RadixTree) mergeChild(parent, n int32) {
+	cn := t.node(n)
+	if cn.hasValue() || cn.child == 0 || t.node(cn.child).next != 0 {
+		return
+	}
+	c := cn.child
+	ch := t.node(c)
+	if cn.edge+uint32(cn.edgeLen) != ch.edge || int(cn.edgeLen)+int(ch.edgeLen) > radixMaxEdge {
+		return
+	}
+	prev, _ := t.child(parent, t.edgeOf(cn)[0])
+	ch.edge, ch.edgeLen = cn.edge, cn.edgeLen+ch.edgeLen
+	t.replace(parent, prev, n, c)
+	cn.next = t.free
+	t.free = n
+}
+
+func (t *RadixTree) walk(n int32, key []byte, fn func(key string, offset int64) bool) bool {
+	cn := t.node(n)
+	key = append(key, t.edgeOf(cn)...)
+	if cn.hasValue() && !fn(string(key), cn.offset()) {
+		return false
+	}
+	for c := cn.child; c != 0; c = t.node(c).next {
+		if !t.walk(c, key, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// Range calls fn for every key in key order until fn returns false.
+func (t *RadixTree) Range(fn func(key string, offset int64) bool) {
+	t.walk(0, nil, fn)
+}
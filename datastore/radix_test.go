@@ -0,0 +1,192 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRadixTree(t *testing.T) {
+	tree := NewRadixTree()
+	expected := make(map[string]int64)
+	r := rand.New(rand.NewSource(1))
+	words := []string{"", "a", "ab", "abc", "abd", "b", "ba", "user:", "user:1", "user:10", "user:2"}
+	for i := 0; i < 3000; i++ {
+		key := words[r.Intn(len(words))] + fmt.Sprint(r.Intn(50))
+		if i < len(words) {
+			key = words[i]
+		}
+		if r.Intn(3) == 0 {
+			tree.Delete(key)
+			delete(expected, key)
+		} else {
+			tree.Insert(key, int64(i))
+			expected[key] = int64(i)
+		}
+	}
+
+	if tree.Len() != len(expected) {
+		t.Errorf("Expected length %d, got %d", len(expected), tree.Len())
+	}
+	for key, offset := range expected {
+		got, ok := tree.Find(key)
+		if !ok || got != offset {
+			t.Errorf("Find(%q) = %d, %v; expected %d", key, got, ok, offset)
+		}
+	}
+	if _, ok := tree.Find("missing"); ok {
+		t.Error("Found a missing key")
+	}
+
+	for _, prefix := range []string{"", "a", "ab", "user:1", "user", "us", "zzz"} {
+		var keys []string
+		for key := range expected {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		var offsets []int64
+		for _, key := range keys {
+			offsets = append(offsets, expected[key])
+		}
+		if got := tree.FindPrefix(prefix); fmt.Sprint(got) != fmt.Sprint(offsets) {
+			t.Errorf("FindPrefix(%q): expected %v, got %v", prefix, offsets, got)
+		}
+	}
+
+	var keys []string
+	for key := range expected {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var ranged []string
+	tree.Range(func(key string, offset int64) bool {
+		if offset != expected[key] {
+			t.Errorf("Range(%q) = %d; expected %d", key, offset, expected[key])
+		}
+		ranged = append(ranged, key)
+		return true
+	})
+	if fmt.Sprint(ranged) != fmt.Sprint(keys) {
+		t.Errorf("Range: expected keys %v, got %v", keys, ranged)
+	}
+
+	for key := range expected {
+		tree.Delete(key)
+	}
+	if tree.Len() != 0 || tree.node(0).child != 0 {
+		t.Errorf("Expected empty tree, got %d keys", tree.Len())
+	}
+}
+
+func TestRadixTreeLongKeys(t *testing.T) {
+	tree := NewRadixTree()
+	long := strings.Repeat("k", 70000)
+	tree.Insert(long, 1)
+	tree.Insert(long+"x", 1<<40)
+	tree.Insert(long[:65535], 3)
+	for key, offset := range map[string]int64{long: 1, long + "x": 1 << 40, long[:65535]: 3} {
+		if got, ok := tree.Find(key); !ok || got != offset {
+			t.Errorf("Find(%d bytes) = %d, %v; expected %d", len(key), got, ok, offset)
+		}
+	}
+	if _, ok := tree.Find(long[:65536]); ok {
+		t.Error("Found a key that was never inserted")
+	}
+	tree.Delete(long)
+	if got, ok := tree.Find(long + "x"); !ok || got != 1<<40 {
+		t.Errorf("Lost a longer key after deleting its prefix: %d, %v", got, ok)
+	}
+}
+
+func TestDb_RadixIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	opts := []Option{WithMaxFileSize(4096), WithIndex(func() Index { return NewRadixTree() })}
+	db, err := NewDb(dir, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 500; i++ {
+		if err := db.Put(fmt.Sprintf("/api/v1/u/%d", i%200), fmt.Sprint(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, b := range db.blocks {
+		if _, ok := b.index.(*RadixTree); !ok {
+			t.Fatalf("Expected radix tree indexes, got %T", b.index)
+		}
+	}
+	db.Close()
+
+	//після перевідкриття індекси будуються з сегментів заново
+	db, err = NewDb(dir, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for i := 300; i < 500; i++ {
+		if v, err := db.Get(fmt.Sprintf("/api/v1/u/%d", i%200)); err != nil || v != fmt.Sprint(i) {
+			t.Errorf("Bad value of key %d: %q, %v", i%200, v, err)
+		}
+	}
+	if entries, err := db.entries(); err != nil || len(entries) != 200 {
+		t.Errorf("Expected 200 entries, got %d, %v", len(entries), err)
+	}
+}
+
+func radixBenchmarkKeys(n int) []string {
+	r := rand.New(rand.NewSource(1))
+	keys := make([]string, n)
+	for i := range keys {
+		if r.Intn(10) < 7 {
+			keys[i] = fmt.Sprintf("/api/v1/u/%d/profile", i)
+		} else {
+			keys[i] = fmt.Sprintf("%x/%d", r.Int63(), i)
+		}
+	}
+	return keys
+}
+
+func heapInUse() uint64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapInuse
+}
+
+func BenchmarkRadixTreeMemory(b *testing.B) {
+	keys := radixBenchmarkKeys(1000000)
+	b.Run("map", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			before := heapInUse()
+			m := make(map[string]int64)
+			for j, key := range keys {
+				m[strings.Clone(key)] = int64(j)
+			}
+			b.ReportMetric(float64(heapInUse()-before)/float64(len(keys)), "bytes/key")
+			runtime.KeepAlive(m)
+		}
+	})
+	b.Run("RadixTree", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			before := heapInUse()
+			tree := NewRadixTree()
+			for j, key := range keys {
+				tree.Insert(key, int64(j))
+			}
+			b.ReportMetric(float64(heapInUse()-before)/float64(len(keys)), "bytes/key")
+			runtime.KeepAlive(tree)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Стан відра токенів - 32 байти: [токени f64][останнє поповнення UnixNano i64]
+// [швидкість за секунду f64][місткість f64].
+
+type rateBucket struct {
+	tokens       float64
+	lastRefillNs int64
+	ratePerSec   float64
+	burst        float64
+}
+
+func (b *rateBucket) encode() string {
+	res := binary.LittleEndian.AppendUint64(nil, math.Float64bits(b.tokens))
+	res = binary.LittleEndian.AppendUint64(res, uint64(b.lastRefillNs))
+	res = binary.LittleEndian.AppendUint64(res, math.Float64bits(b.ratePerSec))
+	return string(binary.LittleEndian.AppendUint64(res, math.Float64bits(b.burst)))
+}
+
+func parseRateBucket(data string) (*rateBucket, error) {
+	if len(data) != 32 {
+		return nil, fmt.Errorf("corrupted rate bucket")
+	}
+	raw := []byte(data)
+	b := &rateBucket{
+		tokens:       math.Float64frombits(binary.LittleEndian.Uint64(raw)),
+		lastRefillNs: int64(binary.LittleEndian.Uint64(raw[8:])),
+		ratePerSec:   math.Float64frombits(binary.LittleEndian.Uint64(raw[16:])),
+		burst:        math.Float64frombits(binary.LittleEndian.Uint64(raw[24:])),
+	}
+	if !(b.ratePerSec >= 0) || !(b.burst > 0) || !(b.tokens >= 0 && b.tokens <= b.burst) {
+		return nil, fmt.Errorf("corrupted rate bucket")
+	}
+	return b, nil
+}
+
+func validateRateBucket(data string) error {
+	_, err := parseRateBucket(data)
+	return err
+}
+
+// refill додає токени за час, що минув, але не більше за місткість
+func (b *rateBucket) refill(nowNs int64) {
+	if elapsed := nowNs - b.lastRefillNs; elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+float64(elapsed)/1e9*b.ratePerSec)
+		b.lastRefillNs = nowNs
+	}
+}
+
+// NewRateBucket stores a full token bucket at key that refills ratePerSec
+// tokens per second up to burst.
+func NewRateBucket(db *Db, key string, ratePerSec, burst float64) error {
+	if !(ratePerSec >= 0) || !(burst > 0) || math.IsInf(burst, 0) {
+		return fmt.Errorf("bad rate bucket %v/s, burst %v", ratePerSec, burst)
+	}
+	b := &rateBucket{tokens: burst, lastRefillNs: timeNow().UnixNano(), ratePerSec: ratePerSec, burst: burst}
+	return db.putEntry(&Entry{key: key, valueType: RATE_BUCKET_TYPE, value: b.encode()})
+}
+
+// Allow takes n tokens from the bucket at key if it has them. The refilled
+// state is written back with compareAndSwap, so concurrent callers never
+// spend the same tokens twice; a denied call writes nothing.
+func Allow(db *Db, key string, n float64) (bool, error) {
+	if !(n >= 0) {
+		return false, fmt.Errorf("bad token count %v", n)
+	}
+	allowed := false
+	_, err := db.updateEntry(key, func(cur *Entry) (*Entry, error) {
+		if cur == nil {
+			return nil, ErrNotFound
+		}
+		if cur.valueType != RATE_BUCKET_TYPE {
+			return nil, fmt.Errorf("wrong type of value")
+		}
+		b, err := parseRateBucket(cur.value)
+		if err != nil {
+			return nil, err
+		}
+		b.refill(timeNow().UnixNano())
+		if allowed = b.tokens >= n; !allowed {
+			return nil, nil
+		}
+		b.tokens -= n
+		return &Entry{valueType: RATE_BUCKET_TYPE, value: b.encode()}, nil
+	})
+	return allowed && err == nil, err
+}
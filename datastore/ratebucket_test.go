@@ -0,0 +1,65 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateBucket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := Allow(db, "api", 1); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound for a missing bucket, got %v", err)
+	}
+	//годинник рухає тест, а не стіна: під -race виклики йдуть у рази повільніше
+	var nowNs int64 = time.Now().UnixNano()
+	timeNow = func() time.Time { return time.Unix(0, atomic.LoadInt64(&nowNs)) }
+	defer func() { timeNow = time.Now }()
+	if err := NewRateBucket(db, "api", 100, 10); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := Allow(db, "api", 11); err != nil || ok {
+		t.Errorf("Expected a request above burst to be denied, got %v, %v", ok, err)
+	}
+
+	//100 кроків по 10мс, на кожному 20 горутин просять більше, ніж є
+	var allowed int64
+	for step := 0; step <= 100; step++ {
+		if step > 0 {
+			atomic.AddInt64(&nowNs, int64(10*time.Millisecond))
+		}
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ok, err := Allow(db, "api", 1)
+				if err != nil {
+					t.Error(err)
+				}
+				if ok {
+					atomic.AddInt64(&allowed, 1)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+	//місткість 10 плюс 100 за секунду
+	if expected := 10 + 100.0; math.Abs(float64(allowed)-expected) > 1 {
+		t.Errorf("Expected about %.0f allowed requests, got %d", expected, allowed)
+	}
+}
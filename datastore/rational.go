@@ -0,0 +1,180 @@
+package datastore
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// rationalOperator зберігає чисельник і знаменник як два int64 (16 байт);
+// у Entry.value дріб лежить як "чисельник/знаменник"
+type rationalOperator struct{}
+
+var errRationalOverflow = fmt.Errorf("rational overflow")
+
+func formatRational(num, denom int64) string {
+	return strconv.FormatInt(num, 10) + "/" + strconv.FormatInt(denom, 10)
+}
+
+func parseRational(s string) (int64, int64, error) {
+	numText, denomText, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("bad rational %q", s)
+	}
+	num, err := strconv.ParseInt(numText, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("bad rational %q: %v", s, err)
+	}
+	denom, err := strconv.ParseInt(denomText, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("bad rational %q: %v", s, err)
+	}
+	if denom == 0 {
+		return 0, 0, fmt.Errorf("rational %q has zero denominator", s)
+	}
+	return num, denom, nil
+}
+
+func (s rationalOperator) Encode(e *Entry, dst []byte) []byte {
+	num, denom, err := parseRational(e.value)
+	if err != nil {
+		panic(err)
+	}
+	res, offset := encodeKeyInto(e, 12, dst)
+	res[offset] = RATIONAL_TYPE
+	binary.LittleEndian.PutUint64(res[offset+TYPE_SIZE:], uint64(num))
+	binary.LittleEndian.PutUint64(res[offset+TYPE_SIZE+8:], uint64(denom))
+	return res
+}
+
+func (s rationalOperator) EncodeInto(e *Entry, dst []byte) ([]byte, error) {
+	num, denom, err := parseRational(e.value)
+	if err != nil {
+		return dst, err
+	}
+	dst = appendKey(dst, e, 12)
+	dst = append(dst, RATIONAL_TYPE)
+	dst = binary.LittleEndian.AppendUint64(dst, uint64(num))
+	return binary.LittleEndian.AppendUint64(dst, uint64(denom)), nil
+}
+
+func decodeRational(data []byte) string {
+	return formatRational(int64(binary.LittleEndian.Uint64(data)), int64(binary.LittleEndian.Uint64(data[8:])))
+}
+
+func (s rationalOperator) Decode(input []byte, e *Entry) {
+	kl := len(e.key)
+	e.value = decodeRational(input[kl+TYPE_SIZE+8:])
+}
+
+func (s rationalOperator) Read(in *bufio.Reader) (string, error) {
+	data, err := in.Peek(16)
+	if err != nil {
+		return "", err
+	}
+	return decodeRational(data), nil
+}
+
+func NewRationalEntry(key string, num, denom int64) (*Entry, error) {
+	if denom == 0 {
+		return nil, fmt.Errorf("rational has zero denominator")
+	}
+	return &Entry{key: key, valueType: RATIONAL_TYPE, value: formatRational(num, denom)}, nil
+}
+
+func (e *Entry) GetRational() (num, denom int64, err error) {
+	if e.valueType != RATIONAL_TYPE {
+		return 0, 0, fmt.Errorf("wrong type of value")
+	}
+	return parseRational(e.value)
+}
+
+func (e *Entry) ToFloat64() (float64, error) {
+	num, denom, err := e.GetRational()
+	if err != nil {
+		return 0, err
+	}
+	return float64(num) / float64(denom), nil
+}
+
+func mulInt64(a, b int64) (int64, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	res := a * b
+	if res/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, errRationalOverflow
+	}
+	return res, nil
+}
+
+// RationalAdd returns n1/d1 + n2/d2 over the common denominator d1*d2,
+// without reducing it; see RationalReduce.
+func RationalAdd(n1, d1, n2, d2 int64) (int64, int64, error) {
+	if d1 == 0 || d2 == 0 {
+		return 0, 0, fmt.Errorf("rational has zero denominator")
+	}
+	a, err := mulInt64(n1, d2)
+	if err != nil {
+		return 0, 0, err
+	}
+	b, err := mulInt64(n2, d1)
+	if err != nil {
+		return 0, 0, err
+	}
+	denom, err := mulInt64(d1, d2)
+	if err != nil {
+		return 0, 0, err
+	}
+	num := a + b
+	if (b > 0 && num < a) || (b < 0 && num > a) {
+		return 0, 0, errRationalOverflow
+	}
+	return num, denom, nil
+}
+
+// RationalMul returns (n1*n2)/(d1*d2) without reducing it.
+func RationalMul(n1, d1, n2, d2 int64) (int64, int64, error) {
+	if d1 == 0 || d2 == 0 {
+		return 0, 0, fmt.Errorf("rational has zero denominator")
+	}
+	num, err := mulInt64(n1, n2)
+	if err != nil {
+		return 0, 0, err
+	}
+	denom, err := mulInt64(d1, d2)
+	if err != nil {
+		return 0, 0, err
+	}
+	return num, denom, nil
+}
+
+// RationalReduce divides both parts by their GCD and moves the sign to the
+// numerator, so equal fractions reduce to the same pair.
+func RationalReduce(num, denom int64) (int64, int64, error) {
+	if denom == 0 {
+		return 0, 0, fmt.Errorf("rational has zero denominator")
+	}
+	a, b := num, denom
+	for b != 0 {
+		a, b = b, a%b
+	}
+	//gcd може бути від'ємним через знак залишку
+	if a < 0 {
+		if a == math.MinInt64 {
+			return 0, 0, errRationalOverflow
+		}
+		a = -a
+	}
+	num, denom = num/a, denom/a
+	if denom < 0 {
+		if num == math.MinInt64 || denom == math.MinInt64 {
+			return 0, 0, errRationalOverflow
+		}
+		num, denom = -num, -denom
+	}
+	return num, denom, nil
+}
@@ -0,0 +1,48 @@
+package datastore
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRational(t *testing.T) {
+	if _, err := NewRationalEntry("r", 1, 0); err == nil {
+		t.Errorf("Expected an error for a zero denominator")
+	}
+	e, err := NewRationalEntry("r", -2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Entry
+	decoded.Decode(e.Encode())
+	if num, denom, err := decoded.GetRational(); err != nil || num != -2 || denom != 3 {
+		t.Errorf("Bad rational round trip: %d/%d, %v", num, denom, err)
+	}
+	if f, _ := decoded.ToFloat64(); f != -2.0/3 {
+		t.Errorf("Bad float value: %v", f)
+	}
+	if _, err := EncodeInto(&Entry{key: "r", valueType: RATIONAL_TYPE, value: "1/0"}, nil); err == nil {
+		t.Errorf("Expected an error for a zero denominator on write")
+	}
+
+	num, denom, err := RationalAdd(1, 3, 1, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if num, denom, _ = RationalReduce(num, denom); num != 1 || denom != 2 {
+		t.Errorf("Expected 1/3 + 1/6 = 1/2, got %d/%d", num, denom)
+	}
+	num, denom, _ = RationalMul(2, 3, 3, -4)
+	if num, denom, _ = RationalReduce(num, denom); num != -1 || denom != 2 {
+		t.Errorf("Expected 2/3 * 3/-4 = -1/2, got %d/%d", num, denom)
+	}
+	if num, denom, _ := RationalReduce(0, -5); num != 0 || denom != 1 {
+		t.Errorf("Expected 0/1, got %d/%d", num, denom)
+	}
+	if _, _, err := RationalAdd(math.MaxInt64, 1, 1, 1); err == nil {
+		t.Errorf("Expected an overflow error")
+	}
+	if _, _, err := RationalMul(math.MaxInt64, 1, 2, 1); err == nil {
+		t.Errorf("Expected an overflow error")
+	}
+}
@@ -0,0 +1,68 @@
+package datastore
+
+import (
+	"fmt"
+	"time"
+)
+
+var ErrLoadTimeout = fmt.Errorf("loader timed out")
+
+// ReadThroughDb serves reads from db and falls back to Loader on a miss,
+// storing what it loads so the next lookup is local.
+type ReadThroughDb struct {
+	db          *Db
+	Loader      func(key string) (*Entry, error)
+	LoadTimeout time.Duration
+}
+
+func NewReadThroughDb(db *Db, loader func(key string) (*Entry, error)) *ReadThroughDb {
+	return &ReadThroughDb{db: db, Loader: loader}
+}
+
+func (r *ReadThroughDb) LookupKey(key string) (*Entry, error) {
+	e, err := r.db.getEntry(key)
+	if err == nil && e.valueType != SOFT_DELETED_TYPE {
+		return e, nil
+	}
+	if err != nil && err != ErrNotFound {
+		return nil, err
+	}
+
+	e, err = r.load(key)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrNotFound
+	}
+	loaded := *e
+	loaded.key = key
+	if err := r.db.putEntry(&loaded); err != nil {
+		return nil, err
+	}
+	return &loaded, nil
+}
+
+func (r *ReadThroughDb) load(key string) (*Entry, error) {
+	if r.LoadTimeout <= 0 {
+		return r.Loader(key)
+	}
+	type result struct {
+		e   *Entry
+		err error
+	}
+	//буферизований канал, щоб горутина завантажувача завершилась і після тайм-ауту
+	done := make(chan result, 1)
+	go func() {
+		e, err := r.Loader(key)
+		done <- result{e, err}
+	}()
+	timer := time.NewTimer(r.LoadTimeout)
+	defer timer.Stop()
+	select {
+	case res := <-done:
+		return res.e, res.err
+	case <-timer.C:
+		return nil, ErrLoadTimeout
+	}
+}
@@ -0,0 +1,86 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReadThroughDb(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	calls := map[string]int{}
+	r := NewReadThroughDb(db, func(key string) (*Entry, error) {
+		calls[key]++
+		if key == "missing" {
+			return nil, nil
+		}
+		return &Entry{valueType: STRING_TYPE, value: "remote " + key}, nil
+	})
+
+	if err := db.Put("local", "value"); err != nil {
+		t.Fatal(err)
+	}
+	e, err := r.LookupKey("local")
+	if err != nil || e.Value() != "value" || calls["local"] != 0 {
+		t.Errorf("Bad local lookup: %v, %v, %d loader calls", e, err, calls["local"])
+	}
+
+	for i := 0; i < 2; i++ {
+		e, err = r.LookupKey("key")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Key() != "key" || e.Value() != "remote key" {
+			t.Errorf("Bad entry loaded: %v", e)
+		}
+	}
+	if calls["key"] != 1 {
+		t.Errorf("Expected 1 loader call, got %d", calls["key"])
+	}
+	if val, err := db.Get("key"); err != nil || val != "remote key" {
+		t.Errorf("Expected loaded entry to be stored, got %q, %v", val, err)
+	}
+
+	if _, err := r.LookupKey("missing"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestReadThroughDb_LoadTimeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	release := make(chan struct{})
+	defer close(release)
+	r := NewReadThroughDb(db, func(key string) (*Entry, error) {
+		<-release
+		return &Entry{valueType: STRING_TYPE, value: "late"}, nil
+	})
+	r.LoadTimeout = 10 * time.Millisecond
+
+	if _, err := r.LookupKey("key"); err != ErrLoadTimeout {
+		t.Errorf("Expected ErrLoadTimeout, got %v", err)
+	}
+	if _, err := db.Get("key"); err != ErrNotFound {
+		t.Errorf("Expected nothing stored after timeout, got %v", err)
+	}
+}
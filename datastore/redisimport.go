@@ -0,0 +1,258 @@
+package datastore
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RedisClient is a minimal RESP client, enough to migrate keys out of and
+// into a Redis server.
+type RedisClient struct {
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// NewRedisClient connects to the Redis server at addr and authenticates
+// with password unless it is empty.
+func NewRedisClient(addr, password string) (*RedisClient, error) {
+	conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	c := &RedisClient{conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}
+	if password != "" {
+		if _, err := c.do("AUTH", password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *RedisClient) Close() error {
+	return c.conn.Close()
+}
+
+func (c *RedisClient) do(args ...string) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(c.w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		writeRESPBulk(c.w, arg)
+	}
+	if err := c.w.Flush(); err != nil {
+		return nil, err
+	}
+	return readRESPValue(c.r, 0)
+}
+
+// readRESPValue читає відповідь: рядок, int64, []interface{} або nil;
+// помилка сервера повертається як redisError
+func readRESPValue(r *bufio.Reader, depth int) (interface{}, error) {
+	line, err := readRESPLine(r)
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	if line == "" || depth > 8 {
+		return nil, fmt.Errorf("protocol error: bad reply %q", line)
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("protocol error: bad integer %q", line)
+		}
+		return n, nil
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < -1 || size > maxRESPBulkLen {
+			return nil, fmt.Errorf("protocol error: invalid bulk length")
+		}
+		if size == -1 {
+			return nil, nil
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < -1 || n > maxRESPBulkLen {
+			return nil, fmt.Errorf("protocol error: invalid multibulk length")
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		res := make([]interface{}, n)
+		for i := range res {
+			if res[i], err = readRESPValue(r, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("protocol error: bad reply %q", line)
+}
+
+func (c *RedisClient) strings(args ...string) ([]string, error) {
+	reply, err := c.do(args...)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected reply to %s", args[0])
+	}
+	res := make([]string, len(items))
+	for i, item := range items {
+		if res[i], ok = item.(string); !ok {
+			return nil, fmt.Errorf("unexpected reply to %s", args[0])
+		}
+	}
+	return res, nil
+}
+
+// redisEntry читає значення key уже як запис потрібного типу: рядок -
+// STRING, хеш і список - документ, множина - GSET, sorted set - SORTEDSET
+func (c *RedisClient) redisEntry(key string) (*Entry, error) {
+	kind, err := c.do("TYPE", key)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case "none":
+		//ключ зник між SCAN і TYPE
+		return nil, nil
+	case "string":
+		reply, err := c.do("GET", key)
+		if err != nil || reply == nil {
+			return nil, err
+		}
+		value, ok := reply.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected reply to GET %q", key)
+		}
+		return &Entry{key: key, valueType: STRING_TYPE, value: value}, nil
+	case "hash":
+		pairs, err := c.strings("HGETALL", key)
+		if err != nil {
+			return nil, err
+		}
+		doc := make(map[string]interface{}, len(pairs)/2)
+		for i := 0; i+1 < len(pairs); i += 2 {
+			doc[pairs[i]] = pairs[i+1]
+		}
+		return NewDocumentEntry(key, doc)
+	case "list":
+		items, err := c.strings("LRANGE", key, "0", "-1")
+		if err != nil {
+			return nil, err
+		}
+		doc := make([]interface{}, len(items))
+		for i, item := range items {
+			doc[i] = item
+		}
+		return NewDocumentEntry(key, doc)
+	case "set":
+		members, err := c.strings("SMEMBERS", key)
+		if err != nil {
+			return nil, err
+		}
+		return &Entry{key: key, valueType: GSET_TYPE, value: encodeStringSet(members)}, nil
+	case "zset":
+		pairs, err := c.strings("ZRANGE", key, "0", "-1", "WITHSCORES")
+		if err != nil {
+			return nil, err
+		}
+		s := newSortedSet()
+		for i := 0; i+1 < len(pairs); i += 2 {
+			score, err := strconv.ParseFloat(pairs[i+1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("bad score of %q in %q", pairs[i], key)
+			}
+			s.add(pairs[i], score)
+		}
+		return &Entry{key: key, valueType: SORTEDSET_TYPE, value: s.encode()}, nil
+	}
+	return nil, fmt.Errorf("unsupported redis type %v of %q", kind, key)
+}
+
+// ImportRedis copies every Redis key matching pattern (SCAN MATCH syntax)
+// into db. Strings become string entries, hashes and lists documents, sets
+// GSET entries and sorted sets SORTEDSET entries.
+func ImportRedis(client *RedisClient, pattern string, db *Db) error {
+	cursor := "0"
+	for {
+		reply, err := client.do("SCAN", cursor, "MATCH", pattern, "COUNT", "100")
+		if err != nil {
+			return err
+		}
+		page, ok := reply.([]interface{})
+		if !ok || len(page) != 2 {
+			return fmt.Errorf("unexpected reply to SCAN")
+		}
+		next, ok := page[0].(string)
+		keys, okKeys := page[1].([]interface{})
+		if !ok || !okKeys {
+			return fmt.Errorf("unexpected reply to SCAN")
+		}
+		for _, k := range keys {
+			key, ok := k.(string)
+			if !ok {
+				return fmt.Errorf("unexpected reply to SCAN")
+			}
+			e, err := client.redisEntry(key)
+			if err != nil {
+				return err
+			}
+			if e == nil {
+				continue
+			}
+			if err := db.putEntry(e); err != nil {
+				return err
+			}
+		}
+		//SCAN може повернути ключ двічі, але повторний запис того самого значення нешкідливий
+		if cursor = next; cursor == "0" {
+			return nil
+		}
+	}
+}
+
+// ExportRedis writes every string and int64 key of db under prefix to Redis
+// with SET. Keys of other types are left out.
+func ExportRedis(db *Db, prefix string, client *RedisClient) error {
+	entries, err := db.entries()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !strings.HasPrefix(e.key, prefix) || (e.valueType != STRING_TYPE && e.valueType != INT64_TYPE) {
+			continue
+		}
+		if _, err := client.do("SET", e.key, e.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package datastore
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis тримає рядки й списки і відповідає на команди, потрібні
+// ImportRedis, ExportRedis і RedisDistributedLock; SCAN віддає ключі
+// сторінками по 16
+type fakeRedis struct {
+	mu      sync.Mutex
+	strings map[string]string
+	lists   map[string][]string
+	hashes  map[string]map[string]string
+	expires map[string]time.Time
+}
+
+// expire прибирає ключ, якому минув PX
+func (f *fakeRedis) expire(key string) {
+	if deadline, ok := f.expires[key]; ok && !time.Now().Before(deadline) {
+		delete(f.strings, key)
+		delete(f.expires, key)
+	}
+}
+
+func (f *fakeRedis) serve(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+			authed := false
+			for {
+				args, err := readRESPCommand(r)
+				if err != nil {
+					return
+				}
+				f.mu.Lock()
+				if args[0] == "AUTH" {
+					authed = args[1] == "pass"
+				}
+				if !authed {
+					w.WriteString("-NOAUTH Authentication required.\r\n")
+				} else {
+					f.execute(w, args)
+				}
+				f.mu.Unlock()
+				w.Flush()
+			}
+		}()
+	}
+}
+
+func writeRESPArray(w *bufio.Writer, items []string) {
+	fmt.Fprintf(w, "*%d\r\n", len(items))
+	for _, item := range items {
+		writeRESPBulk(w, item)
+	}
+}
+
+func (f *fakeRedis) execute(w *bufio.Writer, args []string) {
+	switch args[0] {
+	case "AUTH":
+		w.WriteString("+OK\r\n")
+	case "SET":
+		f.expire(args[1])
+		var deadline time.Time
+		for i := 3; i < len(args); i++ {
+			switch args[i] {
+			case "NX":
+				if _, ok := f.strings[args[1]]; ok {
+					w.WriteString("$-1\r\n")
+					return
+				}
+			case "PX":
+				ms, _ := strconv.Atoi(args[i+1])
+				deadline = time.Now().Add(time.Duration(ms) * time.Millisecond)
+				i++
+			}
+		}
+		f.strings[args[1]] = args[2]
+		if !deadline.IsZero() {
+			if f.expires == nil {
+				f.expires = make(map[string]time.Time)
+			}
+			f.expires[args[1]] = deadline
+		}
+		w.WriteString("+OK\r\n")
+	case "EVAL":
+		//уміє лише releaseScript
+		f.expire(args[3])
+		if args[1] != releaseScript || f.strings[args[3]] != args[4] {
+			w.WriteString(":0\r\n")
+			return
+		}
+		delete(f.strings, args[3])
+		delete(f.expires, args[3])
+		w.WriteString(":1\r\n")
+	case "SCAN":
+		var keys []string
+		for k := range f.strings {
+			keys = append(keys, k)
+		}
+		for k := range f.lists {
+			keys = append(keys, k)
+		}
+		for k := range f.hashes {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		cursor, _ := strconv.Atoi(args[1])
+		end := cursor + 16
+		if end >= len(keys) {
+			end = len(keys)
+		}
+		var page []string
+		for _, k := range keys[cursor:end] {
+			if ok, _ := path.Match(args[3], k); ok {
+				page = append(page, k)
+			}
+		}
+		next := strconv.Itoa(end)
+		if end == len(keys) {
+			next = "0"
+		}
+		w.WriteString("*2\r\n")
+		writeRESPBulk(w, next)
+		writeRESPArray(w, page)
+	case "TYPE":
+		switch {
+		case f.strings[args[1]] != "":
+			w.WriteString("+string\r\n")
+		case f.lists[args[1]] != nil:
+			w.WriteString("+list\r\n")
+		case f.hashes[args[1]] != nil:
+			w.WriteString("+hash\r\n")
+		default:
+			w.WriteString("+none\r\n")
+		}
+	case "GET":
+		writeRESPBulk(w, f.strings[args[1]])
+	case "LRANGE":
+		writeRESPArray(w, f.lists[args[1]])
+	case "HGETALL":
+		var pairs []string
+		for k, v := range f.hashes[args[1]] {
+			pairs = append(pairs, k, v)
+		}
+		writeRESPArray(w, pairs)
+	default:
+		fmt.Fprintf(w, "-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+func getTestDocument(db *Db, key string, dst interface{}) error {
+	e, err := db.getEntry(key)
+	if err != nil {
+		return err
+	}
+	return e.GetDocument(dst)
+}
+
+func TestRedisImportExport(t *testing.T) {
+	fake := &fakeRedis{
+		strings: map[string]string{"other:key": "skipped"},
+		lists:   map[string][]string{},
+		hashes:  map[string]map[string]string{"app:user:1": {"name": "alice", "age": "30"}},
+	}
+	for i := 0; i < 50; i++ {
+		fake.strings[fmt.Sprintf("app:str:%02d", i)] = fmt.Sprintf("value %d", i)
+	}
+	for i := 0; i < 10; i++ {
+		fake.lists[fmt.Sprintf("app:list:%d", i)] = []string{"a", strconv.Itoa(i), "c"}
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go fake.serve(l)
+
+	if _, err := NewRedisClient(l.Addr().String(), "wrong"); err == nil {
+		t.Errorf("Expected an error for a bad password")
+	}
+	client, err := NewRedisClient(l.Addr().String(), "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := ImportRedis(client, "app:*", db); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := db.entries()
+	if len(entries) != 61 {
+		t.Errorf("Expected 61 imported keys, got %d", len(entries))
+	}
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("app:str:%02d", i)
+		if v, err := db.Get(key); err != nil || v != fmt.Sprintf("value %d", i) {
+			t.Errorf("Bad %s: %q, %v", key, v, err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		var list []string
+		if err := getTestDocument(db, fmt.Sprintf("app:list:%d", i), &list); err != nil || len(list) != 3 || list[1] != strconv.Itoa(i) {
+			t.Errorf("Bad list %d: %v, %v", i, list, err)
+		}
+	}
+	var user map[string]string
+	if err := getTestDocument(db, "app:user:1", &user); err != nil || user["name"] != "alice" || user["age"] != "30" {
+		t.Errorf("Bad hash: %v, %v", user, err)
+	}
+
+	if err := db.Put("app:str:00", "changed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.PutInt64("app:counter", 42); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportRedis(db, "app:", client); err != nil {
+		t.Fatal(err)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if fake.strings["app:str:00"] != "changed" || fake.strings["app:counter"] != "42" {
+		t.Errorf("Bad export: %q, %q", fake.strings["app:str:00"], fake.strings["app:counter"])
+	}
+	if _, ok := fake.strings["app:list:0"]; ok {
+		t.Errorf("Expected documents to stay out of the export")
+	}
+}
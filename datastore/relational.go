@@ -0,0 +1,125 @@
+package datastore
+
+import (
+	"fmt"
+	"sort"
+)
+
+type JoinMode int
+
+const (
+	INNER_JOIN JoinMode = iota
+	LEFT_JOIN
+	RIGHT_JOIN
+	FULL_OUTER_JOIN
+)
+
+type EntryPair struct {
+	Left  *Entry
+	Right *Entry
+}
+
+func Join(a, b *Db, mode JoinMode) ([]*EntryPair, error) {
+	if mode < INNER_JOIN || mode > FULL_OUTER_JOIN {
+		return nil, fmt.Errorf("unknown join mode %d", mode)
+	}
+	left, err := a.entries()
+	if err != nil {
+		return nil, err
+	}
+	right, err := b.entries()
+	if err != nil {
+		return nil, err
+	}
+
+	//хеш-таблицю будуємо по меншій стороні
+	build, probe := left, right
+	swapped := false
+	if len(right) < len(left) {
+		build, probe = right, left
+		swapped = true
+	}
+	table := make(map[string]*Entry, len(build))
+	for _, e := range build {
+		table[e.key] = e
+	}
+
+	keepLeft := mode == LEFT_JOIN || mode == FULL_OUTER_JOIN
+	keepRight := mode == RIGHT_JOIN || mode == FULL_OUTER_JOIN
+	keepProbe, keepBuild := keepRight, keepLeft
+	if swapped {
+		keepProbe, keepBuild = keepLeft, keepRight
+	}
+
+	var res []*EntryPair
+	pair := func(p, q *Entry) *EntryPair {
+		if swapped {
+			return &EntryPair{Left: p, Right: q}
+		}
+		return &EntryPair{Left: q, Right: p}
+	}
+	matched := make(map[string]bool)
+	for _, e := range probe {
+		other, ok := table[e.key]
+		if ok {
+			matched[e.key] = true
+			res = append(res, pair(e, other))
+		} else if keepProbe {
+			res = append(res, pair(e, nil))
+		}
+	}
+	if keepBuild {
+		for _, e := range build {
+			if !matched[e.key] {
+				res = append(res, pair(nil, e))
+			}
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].key() < res[j].key() })
+	return res, nil
+}
+
+func (p *EntryPair) key() string {
+	if p.Left != nil {
+		return p.Left.key
+	}
+	return p.Right.key
+}
+
+func Union(dbs []*Db, dedupFn func(entries []*Entry) *Entry) ([]Entry, error) {
+	if dedupFn == nil {
+		dedupFn = func(entries []*Entry) *Entry {
+			return entries[len(entries)-1]
+		}
+	}
+
+	byKey := make(map[string][]*Entry)
+	var keys []string
+	for _, db := range dbs {
+		entries, err := db.entries()
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if _, ok := byKey[e.key]; !ok {
+				keys = append(keys, e.key)
+			}
+			byKey[e.key] = append(byKey[e.key], e)
+		}
+	}
+	sort.Strings(keys)
+
+	res := make([]Entry, 0, len(keys))
+	for _, key := range keys {
+		entries := byKey[key]
+		e := entries[0]
+		if len(entries) > 1 {
+			e = dedupFn(entries)
+		}
+		if e != nil {
+			res = append(res, *e)
+		}
+	}
+	return res, nil
+}
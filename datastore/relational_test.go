@@ -0,0 +1,139 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func newRelationalTestDb(t *testing.T, keys []string) *Db {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	for _, key := range keys {
+		if err := db.Put(key, dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func keyRange(prefix string, from, to int) []string {
+	var keys []string
+	for i := from; i < to; i++ {
+		keys = append(keys, fmt.Sprintf("%s%03d", prefix, i))
+	}
+	return keys
+}
+
+func TestJoin(t *testing.T) {
+	a := newRelationalTestDb(t, keyRange("key", 0, 200))
+	b := newRelationalTestDb(t, keyRange("key", 100, 250))
+
+	cases := []struct {
+		mode                       JoinMode
+		pairs, leftOnly, rightOnly int
+	}{
+		{INNER_JOIN, 100, 0, 0},
+		{LEFT_JOIN, 200, 100, 0},
+		{RIGHT_JOIN, 150, 0, 50},
+		{FULL_OUTER_JOIN, 250, 100, 50},
+	}
+	for _, c := range cases {
+		for _, swap := range []bool{false, true} {
+			left, right := a, b
+			leftOnly, rightOnly := c.leftOnly, c.rightOnly
+			mode := c.mode
+			if swap {
+				left, right = b, a
+				leftOnly, rightOnly = rightOnly, leftOnly
+				switch mode {
+				case LEFT_JOIN:
+					mode = RIGHT_JOIN
+				case RIGHT_JOIN:
+					mode = LEFT_JOIN
+				}
+			}
+
+			pairs, err := Join(left, right, mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pairs) != c.pairs {
+				t.Errorf("Mode %d: expected %d pairs, got %d", mode, c.pairs, len(pairs))
+			}
+			var gotLeftOnly, gotRightOnly int
+			for _, p := range pairs {
+				switch {
+				case p.Right == nil:
+					gotLeftOnly++
+				case p.Left == nil:
+					gotRightOnly++
+				case p.Left.Key() != p.Right.Key():
+					t.Errorf("Mode %d: mismatched pair %s/%s", mode, p.Left.Key(), p.Right.Key())
+				case p.Left.Value() != left.dir || p.Right.Value() != right.dir:
+					t.Errorf("Mode %d: pair sides swapped for %s", mode, p.Left.Key())
+				}
+			}
+			if gotLeftOnly != leftOnly || gotRightOnly != rightOnly {
+				t.Errorf("Mode %d: expected %d/%d unmatched, got %d/%d", mode, leftOnly, rightOnly, gotLeftOnly, gotRightOnly)
+			}
+		}
+	}
+
+	if _, err := Join(a, b, JoinMode(42)); err == nil {
+		t.Error("Expected error for unknown join mode")
+	}
+}
+
+func TestUnion(t *testing.T) {
+	// ключі key080..key099 є і в першій, і в другій базі
+	dbs := []*Db{
+		newRelationalTestDb(t, keyRange("key", 0, 100)),
+		newRelationalTestDb(t, keyRange("key", 80, 180)),
+		newRelationalTestDb(t, keyRange("other", 0, 100)),
+	}
+
+	res, err := Union(dbs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 280 {
+		t.Errorf("Expected 280 entries, got %d", len(res))
+	}
+	for _, e := range res {
+		if e.Key() == "key085" && e.Value() != dbs[1].dir {
+			t.Errorf("Expected last write to win for %s", e.Key())
+		}
+	}
+
+	calls := 0
+	res, err = Union(dbs, func(entries []*Entry) *Entry {
+		calls++
+		if len(entries) != 2 {
+			t.Errorf("Expected 2 versions of %s, got %d", entries[0].Key(), len(entries))
+		}
+		return entries[0]
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 20 {
+		t.Errorf("Expected 20 dedup calls, got %d", calls)
+	}
+	for _, e := range res {
+		if e.Key() == "key085" && e.Value() != dbs[0].dir {
+			t.Errorf("Expected dedupFn choice to be kept for %s", e.Key())
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package datastore
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type replication struct {
+	secondary *Db
+	//mu захищає поля нижче для читачів; змінюються вони ще й під db.mu
+	//первинної бази, тож записи рахуються в порядку їх фіксації
+	mu      sync.Mutex
+	stopped bool
+	//offset рахує записи первинної бази, applied - скільки з них уже на копії;
+	//після першої помилки копія відстала назавжди і applied не росте
+	offset  int64
+	applied int64
+	err     error
+}
+
+// Replicate copies every key of db to secondary and then applies each new
+// write of db to secondary while the write still holds db's lock, so the
+// secondary sees writes in the order db committed them. Until Failover the
+// secondary serves reads and rejects other writes with ErrReadOnly; cancel
+// stops the replication and leaves it in that state.
+func (db *Db) Replicate(secondary *Db) (cancel func(), err error) {
+	if secondary == db || secondary.readOnly {
+		return nil, fmt.Errorf("secondary must be another writable database")
+	}
+	r := &replication{secondary: secondary}
+	//копіюємо під db.mu: нові записи чекають, доки копія не стане повною
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.replica != nil {
+		return nil, fmt.Errorf("database is already replicated to %s", db.replica.secondary.dir)
+	}
+
+	secondary.mu.Lock()
+	secondary.standby = true
+	secondary.mu.Unlock()
+	err = db.scanLatest(func(key string, e *Entry) error {
+		if e == nil {
+			return nil
+		}
+		return secondary.replicateEntry(e)
+	})
+	if err != nil {
+		secondary.mu.Lock()
+		secondary.standby = false
+		secondary.mu.Unlock()
+		return nil, err
+	}
+	db.hooksMu.Lock()
+	db.replica = r
+	db.hooksMu.Unlock()
+	return func() { db.stopReplication(r) }, nil
+}
+
+// replicate застосовує e до копії; викликається під db.mu після запису
+func (db *Db) replicate(e *Entry) {
+	r := db.replica
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.offset++
+	if err := r.secondary.replicateEntry(e); err != nil {
+		if r.err == nil {
+			r.err = fmt.Errorf("replication to %s failed at offset %d: %v", r.secondary.dir, r.offset, err)
+		}
+		if db.logger != nil {
+			db.logger.Error("replication failed", "secondary", r.secondary.dir, "key", e.key, "error", err)
+		}
+	} else if r.err == nil {
+		r.applied = r.offset
+	}
+}
+
+func (db *Db) stopReplication(r *replication) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	r.mu.Lock()
+	r.stopped = true
+	r.mu.Unlock()
+	db.hooksMu.Lock()
+	if db.replica == r {
+		db.replica = nil
+	}
+	db.hooksMu.Unlock()
+}
+
+// replicateEntry пише e в обхід standby
+func (db *Db) replicateEntry(e *Entry) error {
+	db.mu.Lock()
+	err := db.writeEntry(e)
+	db.unlockWrite()
+	if err != nil {
+		return err
+	}
+	db.emit(e)
+	return nil
+}
+
+// Failover stops replicating db to secondary and makes secondary writable,
+// so it can take over as the primary.
+func (db *Db) Failover(secondary *Db) error {
+	db.hooksMu.Lock()
+	r := db.replica
+	db.hooksMu.Unlock()
+	if r == nil || r.secondary != secondary {
+		return fmt.Errorf("database is not replicated to %s", secondary.dir)
+	}
+	db.stopReplication(r)
+	secondary.mu.Lock()
+	secondary.standby = false
+	secondary.mu.Unlock()
+	return nil
+}
+
+// Replica follows the progress of a replication started by Replicate.
+// Offsets count the writes of the primary since replication started.
+type Replica struct {
+	r *replication
+}
+
+// Replica returns the replication of db, or nil if db is not replicated.
+func (db *Db) Replica() *Replica {
+	db.hooksMu.Lock()
+	defer db.hooksMu.Unlock()
+	if db.replica == nil {
+		return nil
+	}
+	return &Replica{db.replica}
+}
+
+// Offset returns the offset of the latest write of the primary.
+func (rep *Replica) Offset() int64 {
+	rep.r.mu.Lock()
+	defer rep.r.mu.Unlock()
+	return rep.r.offset
+}
+
+// WaitForOffset waits up to timeout until the replica has applied the write
+// at offset. It fails at once if replication stopped or a write to the
+// replica failed, since the replica won't catch up then.
+func (rep *Replica) WaitForOffset(offset int64, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		rep.r.mu.Lock()
+		applied, stopped, err := rep.r.applied, rep.r.stopped, rep.r.err
+		rep.r.mu.Unlock()
+		switch {
+		case applied >= offset:
+			return nil
+		case err != nil:
+			return err
+		case stopped:
+			return fmt.Errorf("replication to %s stopped before offset %d", rep.r.secondary.dir, offset)
+		case !time.Now().Before(deadline):
+			return fmt.Errorf("replica did not reach offset %d within %v", offset, timeout)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// WriteWithSync writes e and waits up to timeout until the replica has it,
+// so a read from the replica right after it returns sees e.
+func (db *Db) WriteWithSync(e *Entry, timeout time.Duration) error {
+	db.mu.Lock()
+	r := db.replica
+	if r == nil {
+		db.mu.Unlock()
+		return fmt.Errorf("database is not replicated")
+	}
+	err := db.appendEntry(e)
+	//під db.mu зміщення останнього запису - це саме наш запис
+	r.mu.Lock()
+	offset := r.offset
+	r.mu.Unlock()
+	db.unlockWrite()
+	if err != nil {
+		return err
+	}
+	db.emit(e)
+	return (&Replica{r}).WaitForOffset(offset, timeout)
+}
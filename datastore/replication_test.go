@@ -0,0 +1,182 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReplicateAndFailover(t *testing.T) {
+	newTestDb := func() *Db {
+		dir, err := ioutil.TempDir("", "test-db")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.RemoveAll(dir) })
+		db, err := NewDb(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+	primary, secondary := newTestDb(), newTestDb()
+	if err := primary.Put("before", "replication"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := primary.Replicate(primary); err == nil {
+		t.Errorf("Expected an error replicating a database to itself")
+	}
+	if _, err := primary.Replicate(secondary); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := primary.Replicate(newTestDb()); err == nil {
+		t.Errorf("Expected an error for a second replica")
+	}
+	for i := 0; i < 500; i++ {
+		if err := primary.Put("key"+strconv.Itoa(i), strconv.Itoa(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := primary.putTombstone("key0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := secondary.Put("direct", "write"); err != ErrReadOnly {
+		t.Errorf("Expected the standby to reject writes, got %v", err)
+	}
+
+	entries, err := secondary.entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 500 {
+		t.Errorf("Expected 500 keys on the secondary, got %d", len(entries))
+	}
+	if v, err := secondary.Get("before"); err != nil || v != "replication" {
+		t.Errorf("Expected the initial copy on the secondary, got %q, %v", v, err)
+	}
+	if _, err := secondary.Get("key0"); err != ErrNotFound {
+		t.Errorf("Expected the delete to be replicated, got %v", err)
+	}
+
+	if err := primary.Failover(newTestDb()); err == nil {
+		t.Errorf("Expected an error failing over to a database that is not a replica")
+	}
+	if err := primary.Failover(secondary); err != nil {
+		t.Fatal(err)
+	}
+	for i := 500; i < 600; i++ {
+		if err := secondary.Put("key"+strconv.Itoa(i), strconv.Itoa(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := primary.Put("stale", "write"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := secondary.Get("stale"); err != ErrNotFound {
+		t.Errorf("Expected replication to stop after failover, got %v", err)
+	}
+	if entries, _ := secondary.entries(); len(entries) != 600 {
+		t.Errorf("Expected 600 keys on the new primary, got %d", len(entries))
+	}
+}
+
+func TestWriteWithSync(t *testing.T) {
+	newTestDb := func() *Db {
+		dir, err := ioutil.TempDir("", "test-db")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.RemoveAll(dir) })
+		db, err := NewDb(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+	primary, secondary := newTestDb(), newTestDb()
+	e := &Entry{key: "key", valueType: STRING_TYPE, value: "value"}
+	if err := primary.WriteWithSync(e, 100*time.Millisecond); err == nil {
+		t.Errorf("Expected an error without a replica")
+	}
+	cancel, err := primary.Replicate(secondary)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := primary.WriteWithSync(e, 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := secondary.Get("key"); err != nil || v != "value" {
+		t.Errorf("Expected the write on the replica, got %q, %v", v, err)
+	}
+	rep := primary.Replica()
+	if rep.Offset() != 1 {
+		t.Errorf("Expected offset 1, got %d", rep.Offset())
+	}
+	if err := rep.WaitForOffset(2, 10*time.Millisecond); err == nil {
+		t.Errorf("Expected a timeout for an offset not written yet")
+	}
+
+	//копія без місця відстає, і чекати на неї марно
+	secondary.mu.Lock()
+	secondary.maxDiskBytes = 1
+	secondary.mu.Unlock()
+	if err := primary.WriteWithSync(&Entry{key: "lost", valueType: STRING_TYPE, value: "value"}, time.Second); err == nil {
+		t.Errorf("Expected an error when the replica can't apply the write")
+	}
+	cancel()
+	if err := rep.WaitForOffset(2, 10*time.Millisecond); err == nil {
+		t.Errorf("Expected the failed replica to stay failed")
+	}
+}
+
+func TestReplicateConcurrentWrites(t *testing.T) {
+	newTestDb := func() *Db {
+		dir, err := ioutil.TempDir("", "test-db")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.RemoveAll(dir) })
+		db, err := NewDb(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+	primary, secondary := newTestDb(), newTestDb()
+	//повільний спостерігач розводить запис і подію в часі
+	primary.Watch(func(e *Entry) { time.Sleep(time.Duration(len(e.value)) * 50 * time.Microsecond) })
+	if _, err := primary.Replicate(secondary); err != nil {
+		t.Fatal(err)
+	}
+	for round := 0; round < 50; round++ {
+		var wg sync.WaitGroup
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < 5; i++ {
+					if err := primary.Put("hot", strconv.Itoa(w*10+i)); err != nil {
+						t.Error(err)
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+		p, _ := primary.Get("hot")
+		s, _ := secondary.Get("hot")
+		if p != s {
+			t.Fatalf("Round %d: primary has %q, secondary %q", round, p, s)
+		}
+	}
+	if rep := primary.Replica(); rep.Offset() != 1000 {
+		t.Errorf("Expected offset 1000, got %d", rep.Offset())
+	}
+}
@@ -0,0 +1,184 @@
+package datastore
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxRESPArgs    = 1024
+	maxRESPBulkLen = 64 << 20
+)
+
+// RESPServer serves a subset of the Redis protocol (GET, SET, DEL, EXISTS,
+// TYPE, PING) on top of a Db.
+type RESPServer struct {
+	tcpServer
+	db *Db
+}
+
+// кількість аргументів разом з назвою; від'ємна - мінімальна кількість
+var respArity = map[string]int{"PING": -1, "GET": 2, "SET": 3, "DEL": -2, "EXISTS": -2, "TYPE": 2}
+
+func NewRESPServer(db *Db, addr string) (*RESPServer, error) {
+	s := &RESPServer{db: db}
+	if err := s.listen(addr, s.handle); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *RESPServer) handle(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		args, err := readRESPCommand(r)
+		if err != nil {
+			if err != io.EOF {
+				fmt.Fprintf(w, "-ERR %s\r\n", err)
+				w.Flush()
+			}
+			return
+		}
+		if len(args) != 0 {
+			s.execute(w, args)
+		}
+		//при конвеєризації відповідаємо одним записом на всі вже прочитані команди
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *RESPServer) execute(w *bufio.Writer, args []string) {
+	name := strings.ToUpper(args[0])
+	n, ok := respArity[name]
+	if !ok {
+		fmt.Fprintf(w, "-ERR unknown command '%s'\r\n", args[0])
+		return
+	}
+	if (n > 0 && len(args) != n) || (n < 0 && len(args) < -n) {
+		fmt.Fprintf(w, "-ERR wrong number of arguments for '%s' command\r\n", strings.ToLower(name))
+		return
+	}
+
+	//Db сама впорядковує записи, тож з'єднання не чекають одне на одного
+	switch name {
+	case "PING":
+		if len(args) > 1 {
+			writeRESPBulk(w, args[1])
+		} else {
+			w.WriteString("+PONG\r\n")
+		}
+	case "GET":
+		val, _, err := s.db.getType(args[1])
+		if err == ErrNotFound {
+			w.WriteString("$-1\r\n")
+		} else if err != nil {
+			writeRESPError(w, err)
+		} else {
+			writeRESPBulk(w, val)
+		}
+	case "SET":
+		if err := s.db.Put(args[1], args[2]); err != nil {
+			writeRESPError(w, err)
+		} else {
+			w.WriteString("+OK\r\n")
+		}
+	case "DEL", "EXISTS":
+		count := 0
+		for _, key := range args[1:] {
+			var found bool
+			var err error
+			if name == "DEL" {
+				found, err = s.db.deleteExisting(key)
+			} else if _, _, err = s.db.getType(key); err == nil {
+				found = true
+			} else if err == ErrNotFound {
+				err = nil
+			}
+			if err != nil {
+				writeRESPError(w, err)
+				return
+			}
+			if found {
+				count++
+			}
+		}
+		fmt.Fprintf(w, ":%d\r\n", count)
+	case "TYPE":
+		_, vType, err := s.db.getType(args[1])
+		if err == ErrNotFound {
+			w.WriteString("+none\r\n")
+		} else if err != nil {
+			writeRESPError(w, err)
+		} else {
+			fmt.Fprintf(w, "+%s\r\n", vType)
+		}
+	}
+}
+
+func writeRESPBulk(w *bufio.Writer, s string) {
+	fmt.Fprintf(w, "$%d\r\n%s\r\n", len(s), s)
+}
+
+func writeRESPError(w *bufio.Writer, err error) {
+	msg := strings.NewReplacer("\r", " ", "\n", " ").Replace(err.Error())
+	fmt.Fprintf(w, "-ERR %s\r\n", msg)
+}
+
+func readRESPLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		if err == io.EOF && line != "" {
+			return "", io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+func readRESPCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readRESPLine(r)
+	if err != nil {
+		return nil, err
+	}
+	//inline-команди, як від telnet чи redis-cli без RESP
+	if !strings.HasPrefix(line, "*") {
+		return strings.Fields(line), nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n > maxRESPArgs {
+		return nil, fmt.Errorf("protocol error: invalid multibulk length")
+	}
+	args := make([]string, 0, max(n, 0))
+	for i := 0; i < n; i++ {
+		line, err := readRESPLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(line, "$") {
+			return nil, fmt.Errorf("protocol error: expected '$', got '%.1s'", line)
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 || size > maxRESPBulkLen {
+			return nil, fmt.Errorf("protocol error: invalid bulk length")
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		if string(data[size:]) != "\r\n" {
+			return nil, fmt.Errorf("protocol error: bulk string not terminated")
+		}
+		args = append(args, string(data[:size]))
+	}
+	return args, nil
+}
@@ -0,0 +1,118 @@
+package datastore
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"testing"
+)
+
+// readRESPReply reads one reply and renders it in the form redis-cli prints.
+func readRESPReply(r *bufio.Reader) (string, error) {
+	line, err := readRESPLine(r)
+	if err != nil {
+		return "", err
+	}
+	if line == "$-1" {
+		return "(nil)", nil
+	}
+	if strings.HasPrefix(line, "$") {
+		value, err := readRESPLine(r)
+		return `"` + value + `"`, err
+	}
+	return line, nil
+}
+
+func TestRESPServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.PutInt64("counter", 7); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewRESPServer(db, "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	conn, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	commands := [][]string{
+		{"SET", "key", "hello world"},
+		{"GET", "key"},
+		{"EXISTS", "key", "missing", "counter"},
+		{"TYPE", "key"},
+		{"TYPE", "counter"},
+		{"TYPE", "missing"},
+		{"GET", "counter"},
+		{"DEL", "key", "missing"},
+		{"GET", "key"},
+		{"EXISTS", "key"},
+		{"get", "missing"},
+		{"SET", "key"},
+		{"FLUSHALL"},
+	}
+	expected := []string{
+		"+OK",
+		`"hello world"`,
+		":2",
+		"+string",
+		"+int64",
+		"+none",
+		`"7"`,
+		":1",
+		"(nil)",
+		":0",
+		"(nil)",
+		"-ERR wrong number of arguments for 'set' command",
+		"-ERR unknown command 'FLUSHALL'",
+	}
+
+	//усі команди надсилаємо одним записом, щоб перевірити конвеєризацію
+	var req strings.Builder
+	for _, args := range commands {
+		fmt.Fprintf(&req, "*%d\r\n", len(args))
+		for _, arg := range args {
+			fmt.Fprintf(&req, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+	}
+	req.WriteString("PING\r\n")
+	expected = append(expected, "+PONG")
+	if _, err := conn.Write([]byte(req.String())); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range expected {
+		got, err := readRESPReply(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Command %d: expected %s, got %s", i, want, got)
+		}
+	}
+
+	if _, err := db.Get("key"); err != ErrNotFound {
+		t.Errorf("Expected deleted key to be gone from Db, got %v", err)
+	}
+
+	conn.Write([]byte("*1\r\n$x\r\n"))
+	if got, _ := readRESPReply(r); !strings.HasPrefix(got, "-ERR protocol error") {
+		t.Errorf("Expected protocol error, got %s", got)
+	}
+}
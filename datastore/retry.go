@@ -0,0 +1,97 @@
+package datastore
+
+import (
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+var _ Store = (*RetryStore)(nil)
+
+type RetryStore struct {
+	store        Store
+	MaxRetries   int
+	InitialDelay time.Duration
+	Multiplier   float64
+	Jitter       bool
+	IsRetryable  func(error) bool
+
+	attempts int64
+	sleep    func(time.Duration)
+}
+
+func NewRetryStore(store Store, maxRetries int, initialDelay time.Duration, multiplier float64) *RetryStore {
+	return &RetryStore{
+		store:        store,
+		MaxRetries:   maxRetries,
+		InitialDelay: initialDelay,
+		Multiplier:   multiplier,
+		sleep:        time.Sleep,
+	}
+}
+
+func defaultRetryable(err error) bool {
+	return err != ErrNotFound && err != ErrReadOnly
+}
+
+func (r *RetryStore) Attempts() int64 {
+	return atomic.LoadInt64(&r.attempts)
+}
+
+func (r *RetryStore) do(op func() error) error {
+	retryable := r.IsRetryable
+	if retryable == nil {
+		retryable = defaultRetryable
+	}
+
+	delay := r.InitialDelay
+	for retry := 0; ; retry++ {
+		atomic.AddInt64(&r.attempts, 1)
+		err := op()
+		if err == nil || retry >= r.MaxRetries || !retryable(err) {
+			return err
+		}
+
+		wait := delay
+		//повний jitter, щоб клієнти не повторювали запити синхронно
+		if r.Jitter && wait > 0 {
+			wait = time.Duration(rand.Int63n(int64(wait) + 1))
+		}
+		r.sleep(wait)
+		if r.Multiplier > 0 {
+			delay = time.Duration(float64(delay) * r.Multiplier)
+		}
+	}
+}
+
+func (r *RetryStore) Get(key string) (string, error) {
+	var val string
+	err := r.do(func() error {
+		var err error
+		val, err = r.store.Get(key)
+		return err
+	})
+	return val, err
+}
+
+func (r *RetryStore) Put(key, value string) error {
+	return r.do(func() error {
+		return r.store.Put(key, value)
+	})
+}
+
+func (r *RetryStore) GetInt64(key string) (int64, error) {
+	var val int64
+	err := r.do(func() error {
+		var err error
+		val, err = r.store.GetInt64(key)
+		return err
+	})
+	return val, err
+}
+
+func (r *RetryStore) PutInt64(key string, value int64) error {
+	return r.do(func() error {
+		return r.store.PutInt64(key, value)
+	})
+}
@@ -0,0 +1,71 @@
+package datastore
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetryStore(t *testing.T) {
+	transient := fmt.Errorf("throttled")
+	store := &mockStore{fail: func(call int) error {
+		if call <= 3 {
+			return transient
+		}
+		return nil
+	}}
+
+	var delays []time.Duration
+	r := NewRetryStore(store, 5, 10*time.Millisecond, 2)
+	r.sleep = func(d time.Duration) { delays = append(delays, d) }
+
+	if err := r.Put("key", "value"); err != nil {
+		t.Fatalf("Expected success after retries, got %v", err)
+	}
+	if r.Attempts() != 4 {
+		t.Errorf("Expected 4 attempts, got %d", r.Attempts())
+	}
+	expected := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond}
+	if fmt.Sprint(delays) != fmt.Sprint(expected) {
+		t.Errorf("Expected delays %v, got %v", expected, delays)
+	}
+	if val, err := r.Get("key"); err != nil || val != "value" {
+		t.Errorf("Bad value read: %q, %v", val, err)
+	}
+}
+
+func TestRetryStore_GivesUp(t *testing.T) {
+	broken := fmt.Errorf("down")
+	store := &mockStore{fail: func(int) error { return broken }}
+	r := NewRetryStore(store, 2, time.Second, 2)
+	r.Jitter = true
+	r.sleep = func(d time.Duration) {
+		if d > 4*time.Second {
+			t.Errorf("Jittered delay %v exceeds backoff", d)
+		}
+	}
+
+	if err := r.PutInt64("key", 1); err != broken {
+		t.Errorf("Expected last error, got %v", err)
+	}
+	if store.calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", store.calls)
+	}
+
+	store.fail = nil
+	store.calls = 0
+	if _, err := r.Get("missing"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if store.calls != 1 {
+		t.Errorf("ErrNotFound must not be retried, got %d calls", store.calls)
+	}
+
+	r.IsRetryable = func(error) bool { return false }
+	store.fail = func(int) error { return broken }
+	store.calls = 0
+	r.Put("key", "value")
+	if store.calls != 1 {
+		t.Errorf("Expected IsRetryable to stop retries, got %d calls", store.calls)
+	}
+}
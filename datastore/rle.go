@@ -0,0 +1,91 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Серія однакових рядків зберігається одним записом з ключем першого:
+// [count u32][значення len u32][ключі зі спільним префіксом, див. series.go].
+
+func encodeRLERun(keys []string, value string) string {
+	res := binary.LittleEndian.AppendUint32(nil, uint32(len(keys)))
+	res = appendLengthPrefixed(res, value)
+	return string(appendSharedPrefixKeys(res, keys))
+}
+
+func parseRLERun(data string) ([]string, string, error) {
+	if len(data) < 4 {
+		return nil, "", fmt.Errorf("corrupted run length")
+	}
+	count := binary.LittleEndian.Uint32([]byte(data[:4]))
+	value, rest, err := readLengthPrefixed(data[4:])
+	if err != nil {
+		return nil, "", err
+	}
+	//кожен ключ займає щонайменше 2 байти
+	if count == 0 || uint64(count) > uint64(len(rest)) {
+		return nil, "", fmt.Errorf("corrupted run length %d", count)
+	}
+	keys, tail, err := readSharedPrefixKeys([]byte(rest), int(count))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(tail) != 0 {
+		return nil, "", fmt.Errorf("unexpected %d trailing bytes in run", len(tail))
+	}
+	return keys, value, nil
+}
+
+func validateRLERun(data string) error {
+	_, _, err := parseRLERun(data)
+	return err
+}
+
+// EncodeRLE replaces every run of consecutive string entries with the same
+// value by one RLE_STRING_TYPE entry. Other entries, including annotated
+// ones, are kept as they are.
+func EncodeRLE(entries []*Entry) ([]*Entry, error) {
+	var res []*Entry
+	for start := 0; start < len(entries); {
+		first := entries[start]
+		end := start + 1
+		if first.valueType == STRING_TYPE && first.annotations == "" {
+			for end < len(entries) && entries[end].valueType == STRING_TYPE &&
+				entries[end].annotations == "" && entries[end].value == first.value {
+				end++
+			}
+		}
+		if end-start == 1 {
+			res = append(res, first)
+			start = end
+			continue
+		}
+		keys := make([]string, end-start)
+		for i, e := range entries[start:end] {
+			keys[i] = e.key
+		}
+		res = append(res, &Entry{key: first.key, valueType: RLE_STRING_TYPE, value: encodeRLERun(keys, first.value)})
+		start = end
+	}
+	return res, nil
+}
+
+// DecodeRLE expands the entries written by EncodeRLE back into string entries.
+func DecodeRLE(entries []*Entry) ([]*Entry, error) {
+	var res []*Entry
+	for _, e := range entries {
+		if e.valueType != RLE_STRING_TYPE {
+			res = append(res, e)
+			continue
+		}
+		keys, value, err := parseRLERun(e.value)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			res = append(res, &Entry{key: key, valueType: STRING_TYPE, value: value})
+		}
+	}
+	return res, nil
+}
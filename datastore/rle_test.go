@@ -0,0 +1,97 @@
+package datastore
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRLE(t *testing.T) {
+	annotated := &Entry{key: "c", valueType: STRING_TYPE, value: "OK"}
+	annotated.Annotate("source", "probe")
+	entries := []*Entry{
+		{key: "a", valueType: STRING_TYPE, value: "OK"},
+		{key: "b", valueType: STRING_TYPE, value: "OK"},
+		annotated,
+		{key: "d", valueType: STRING_TYPE, value: "OK"},
+		{key: "e", valueType: INT64_TYPE, value: "1"},
+		{key: "f", valueType: STRING_TYPE, value: "FAIL"},
+		{key: "g", valueType: STRING_TYPE, value: ""},
+		{key: "h", valueType: STRING_TYPE, value: ""},
+		{key: "i", valueType: STRING_TYPE, value: ""},
+	}
+	encoded, err := EncodeRLE(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(encoded) != 6 {
+		t.Errorf("Expected 6 entries after encoding, got %d", len(encoded))
+	}
+	if encoded[0].valueType != RLE_STRING_TYPE || encoded[5].valueType != RLE_STRING_TYPE {
+		t.Errorf("Expected runs to be encoded: %v", encoded)
+	}
+
+	//записи мають пережити кодування на диск
+	var data []byte
+	for _, e := range encoded {
+		if data, err = EncodeInto(e, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stored, err := DecodeMany(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeRLE(stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != len(entries) {
+		t.Fatalf("Expected %d entries, got %d", len(entries), len(decoded))
+	}
+	for i := range entries {
+		if *decoded[i] != *entries[i] {
+			t.Errorf("Bad entry %d: expected %v, got %v", i, entries[i], decoded[i])
+		}
+	}
+
+	if _, err := DecodeRLE([]*Entry{{key: "x", valueType: RLE_STRING_TYPE, value: encodeRLERun([]string{"x", "y"}, "v")[:10]}}); err == nil {
+		t.Errorf("Expected an error for a truncated run")
+	}
+}
+
+func rleBenchEntries() []*Entry {
+	//100 000 показань датчиків зі 100 різними статусами, що йдуть підряд
+	entries := make([]*Entry, 100000)
+	for i := range entries {
+		entries[i] = &Entry{key: fmt.Sprintf("sensor:%06d", i), valueType: STRING_TYPE, value: fmt.Sprintf("status=OK;zone=%d", i/1000)}
+	}
+	return entries
+}
+
+func encodedSize(b *testing.B, entries []*Entry) int {
+	var data []byte
+	size := 0
+	for _, e := range entries {
+		var err error
+		if data, err = EncodeInto(e, data[:0]); err != nil {
+			b.Fatal(err)
+		}
+		size += len(data)
+	}
+	return size
+}
+
+func BenchmarkEncodeRLE(b *testing.B) {
+	entries := rleBenchEntries()
+	var encoded []*Entry
+	for i := 0; i < b.N; i++ {
+		var err error
+		if encoded, err = EncodeRLE(entries); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	raw, rle := encodedSize(b, entries), encodedSize(b, encoded)
+	b.ReportMetric(float64(raw)/float64(rle), "x-smaller")
+	b.ReportMetric(float64(rle), "rle-bytes")
+}
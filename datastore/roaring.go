@@ -0,0 +1,300 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+// Roaring-бітмапа ділить uint64 на старші 48 біт (ключ контейнера) і молодші
+// 16. Контейнер до 4096 елементів - відсортований масив uint16, більший -
+// бітмапа на 65536 біт. Розмітка: [контейнерів u32], далі для кожного
+// [ключ u64][кількість u32] і масив u16 або 1024 слова u64.
+
+const (
+	roaringArrayMax    = 4096
+	roaringBitmapWords = 1 << 16 / 64
+)
+
+type roaringContainer struct {
+	high   uint64
+	array  []uint16
+	bitmap []uint64
+}
+
+type roaringBitmap struct {
+	containers []roaringContainer
+}
+
+func (c *roaringContainer) cardinality() int {
+	if c.bitmap == nil {
+		return len(c.array)
+	}
+	n := 0
+	for _, w := range c.bitmap {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+func (c *roaringContainer) contains(low uint16) bool {
+	if c.bitmap != nil {
+		return c.bitmap[low/64]&(1<<(low%64)) != 0
+	}
+	i := sort.Search(len(c.array), func(i int) bool { return c.array[i] >= low })
+	return i < len(c.array) && c.array[i] == low
+}
+
+func (c *roaringContainer) add(low uint16) {
+	if c.bitmap != nil {
+		c.bitmap[low/64] |= 1 << (low % 64)
+		return
+	}
+	i := sort.Search(len(c.array), func(i int) bool { return c.array[i] >= low })
+	if i < len(c.array) && c.array[i] == low {
+		return
+	}
+	c.array = append(c.array, 0)
+	copy(c.array[i+1:], c.array[i:])
+	c.array[i] = low
+	if len(c.array) > roaringArrayMax {
+		*c = containerFromWords(c.high, c.words())
+	}
+}
+
+func (c *roaringContainer) words() []uint64 {
+	if c.bitmap != nil {
+		return c.bitmap
+	}
+	words := make([]uint64, roaringBitmapWords)
+	for _, low := range c.array {
+		words[low/64] |= 1 << (low % 64)
+	}
+	return words
+}
+
+// containerFromWords вибирає масив або бітмапу за кількістю елементів
+func containerFromWords(high uint64, words []uint64) roaringContainer {
+	n := 0
+	for _, w := range words {
+		n += bits.OnesCount64(w)
+	}
+	if n > roaringArrayMax {
+		return roaringContainer{high: high, bitmap: words}
+	}
+	array := make([]uint16, 0, n)
+	for i, w := range words {
+		for w != 0 {
+			array = append(array, uint16(i*64+bits.TrailingZeros64(w)))
+			w &= w - 1
+		}
+	}
+	return roaringContainer{high: high, array: array}
+}
+
+func (b *roaringBitmap) find(high uint64) (int, bool) {
+	i := sort.Search(len(b.containers), func(i int) bool { return b.containers[i].high >= high })
+	return i, i < len(b.containers) && b.containers[i].high == high
+}
+
+func (b *roaringBitmap) add(x uint64) {
+	i, ok := b.find(x >> 16)
+	if !ok {
+		b.containers = append(b.containers, roaringContainer{})
+		copy(b.containers[i+1:], b.containers[i:])
+		b.containers[i] = roaringContainer{high: x >> 16}
+	}
+	b.containers[i].add(uint16(x))
+}
+
+func (b *roaringBitmap) contains(x uint64) bool {
+	i, ok := b.find(x >> 16)
+	return ok && b.containers[i].contains(uint16(x))
+}
+
+func (b *roaringBitmap) cardinality() uint64 {
+	var n uint64
+	for i := range b.containers {
+		n += uint64(b.containers[i].cardinality())
+	}
+	return n
+}
+
+func roaringAnd(x, y *roaringBitmap) *roaringBitmap {
+	res := &roaringBitmap{}
+	for i := range x.containers {
+		j, ok := y.find(x.containers[i].high)
+		if !ok {
+			continue
+		}
+		a, b := x.containers[i].words(), y.containers[j].words()
+		words := make([]uint64, roaringBitmapWords)
+		for k := range words {
+			words[k] = a[k] & b[k]
+		}
+		if c := containerFromWords(x.containers[i].high, words); c.cardinality() > 0 {
+			res.containers = append(res.containers, c)
+		}
+	}
+	return res
+}
+
+func roaringOr(x, y *roaringBitmap) *roaringBitmap {
+	res := &roaringBitmap{}
+	i, j := 0, 0
+	for i < len(x.containers) || j < len(y.containers) {
+		switch {
+		case j == len(y.containers) || (i < len(x.containers) && x.containers[i].high < y.containers[j].high):
+			res.containers = append(res.containers, x.containers[i])
+			i++
+		case i == len(x.containers) || y.containers[j].high < x.containers[i].high:
+			res.containers = append(res.containers, y.containers[j])
+			j++
+		default:
+			a, b := x.containers[i].words(), y.containers[j].words()
+			words := make([]uint64, roaringBitmapWords)
+			for k := range words {
+				words[k] = a[k] | b[k]
+			}
+			res.containers = append(res.containers, containerFromWords(x.containers[i].high, words))
+			i++
+			j++
+		}
+	}
+	return res
+}
+
+func (b *roaringBitmap) encode() string {
+	res := binary.LittleEndian.AppendUint32(nil, uint32(len(b.containers)))
+	for i := range b.containers {
+		c := &b.containers[i]
+		res = binary.LittleEndian.AppendUint64(res, c.high)
+		res = binary.LittleEndian.AppendUint32(res, uint32(c.cardinality()))
+		if c.bitmap != nil {
+			for _, w := range c.bitmap {
+				res = binary.LittleEndian.AppendUint64(res, w)
+			}
+			continue
+		}
+		for _, low := range c.array {
+			res = binary.LittleEndian.AppendUint16(res, low)
+		}
+	}
+	return string(res)
+}
+
+func parseRoaring(data string) (*roaringBitmap, error) {
+	raw := []byte(data)
+	if len(raw) < 4 {
+		return nil, fmt.Errorf("corrupted roaring bitmap")
+	}
+	n := int(binary.LittleEndian.Uint32(raw))
+	raw = raw[4:]
+	b := &roaringBitmap{}
+	for i := 0; i < n; i++ {
+		if len(raw) < 12 {
+			return nil, fmt.Errorf("corrupted roaring bitmap")
+		}
+		c := roaringContainer{high: binary.LittleEndian.Uint64(raw)}
+		card := int(binary.LittleEndian.Uint32(raw[8:]))
+		raw = raw[12:]
+		if c.high >= 1<<48 || card < 1 || card > 1<<16 || (i > 0 && c.high <= b.containers[i-1].high) {
+			return nil, fmt.Errorf("corrupted roaring container %d", i)
+		}
+		if card > roaringArrayMax {
+			if len(raw) < 8*roaringBitmapWords {
+				return nil, fmt.Errorf("corrupted roaring bitmap")
+			}
+			c.bitmap = make([]uint64, roaringBitmapWords)
+			for k := range c.bitmap {
+				c.bitmap[k] = binary.LittleEndian.Uint64(raw[8*k:])
+			}
+			raw = raw[8*roaringBitmapWords:]
+			if c.cardinality() != card {
+				return nil, fmt.Errorf("corrupted roaring container %d", i)
+			}
+		} else {
+			if len(raw) < 2*card {
+				return nil, fmt.Errorf("corrupted roaring bitmap")
+			}
+			c.array = make([]uint16, card)
+			for k := range c.array {
+				c.array[k] = binary.LittleEndian.Uint16(raw[2*k:])
+				if k > 0 && c.array[k] <= c.array[k-1] {
+					return nil, fmt.Errorf("corrupted roaring container %d", i)
+				}
+			}
+			raw = raw[2*card:]
+		}
+		b.containers = append(b.containers, c)
+	}
+	if len(raw) != 0 {
+		return nil, fmt.Errorf("corrupted roaring bitmap")
+	}
+	return b, nil
+}
+
+func validateRoaring(data string) error {
+	_, err := parseRoaring(data)
+	return err
+}
+
+func (e *Entry) roaring() (*roaringBitmap, error) {
+	if e.valueType != ROARING_TYPE {
+		return nil, fmt.Errorf("wrong type of value")
+	}
+	return parseRoaring(e.value)
+}
+
+func NewRoaringEntry(key string) *Entry {
+	return &Entry{key: key, valueType: ROARING_TYPE, value: (&roaringBitmap{}).encode()}
+}
+
+func (e *Entry) RoaringAdd(i uint64) error {
+	b, err := e.roaring()
+	if err != nil {
+		return err
+	}
+	b.add(i)
+	e.value = b.encode()
+	return nil
+}
+
+func (e *Entry) RoaringContains(i uint64) (bool, error) {
+	b, err := e.roaring()
+	if err != nil {
+		return false, err
+	}
+	return b.contains(i), nil
+}
+
+func (e *Entry) RoaringCardinality() (uint64, error) {
+	b, err := e.roaring()
+	if err != nil {
+		return 0, err
+	}
+	return b.cardinality(), nil
+}
+
+// RoaringAnd and RoaringOr return the intersection and the union under the
+// key of e.
+func (e *Entry) RoaringAnd(other *Entry) (*Entry, error) {
+	return e.roaringCombine(other, roaringAnd)
+}
+
+func (e *Entry) RoaringOr(other *Entry) (*Entry, error) {
+	return e.roaringCombine(other, roaringOr)
+}
+
+func (e *Entry) roaringCombine(other *Entry, op func(x, y *roaringBitmap) *roaringBitmap) (*Entry, error) {
+	x, err := e.roaring()
+	if err != nil {
+		return nil, err
+	}
+	y, err := other.roaring()
+	if err != nil {
+		return nil, err
+	}
+	return &Entry{key: e.key, valueType: ROARING_TYPE, value: op(x, y).encode()}, nil
+}
@@ -0,0 +1,67 @@
+package datastore
+
+import "testing"
+
+func roaringOf(t *testing.T, key string, values ...uint64) *Entry {
+	e := NewRoaringEntry(key)
+	for _, v := range values {
+		if err := e.RoaringAdd(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return e
+}
+
+func TestRoaring(t *testing.T) {
+	a := roaringOf(t, "a", 1, 2, 3)
+	b := roaringOf(t, "b", 2, 3, 4)
+	and, err := a.RoaringAnd(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if and.value != roaringOf(t, "x", 2, 3).value {
+		t.Errorf("Expected {1,2,3} AND {2,3,4} = {2,3}")
+	}
+	or, err := a.RoaringOr(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := or.RoaringCardinality(); n != 4 {
+		t.Errorf("Expected 4 elements in the union, got %d", n)
+	}
+
+	//великий контейнер стає бітмапою, а елементи далеко один від одного - окремими контейнерами
+	big := NewRoaringEntry("big")
+	for i := uint64(0); i < 10000; i += 2 {
+		big.RoaringAdd(i)
+	}
+	big.RoaringAdd(1 << 40)
+	big.RoaringAdd(1<<64 - 1)
+	var decoded Entry
+	decoded.Decode(big.Encode())
+	if n, err := decoded.RoaringCardinality(); err != nil || n != 5002 {
+		t.Errorf("Expected 5002 elements, got %d, %v", n, err)
+	}
+	for _, c := range []struct {
+		v  uint64
+		ok bool
+	}{{0, true}, {1, false}, {9998, true}, {10000, false}, {1 << 40, true}, {1<<64 - 1, true}, {1<<64 - 2, false}} {
+		if ok, _ := decoded.RoaringContains(c.v); ok != c.ok {
+			t.Errorf("Contains(%d): expected %v", c.v, c.ok)
+		}
+	}
+	odd := NewRoaringEntry("odd")
+	for i := uint64(1); i < 10000; i += 2 {
+		odd.RoaringAdd(i)
+	}
+	if none, _ := big.RoaringAnd(odd); none.value != NewRoaringEntry("x").value {
+		t.Errorf("Expected an empty intersection of evens and odds")
+	}
+	all, _ := big.RoaringOr(odd)
+	if n, _ := all.RoaringCardinality(); n != 10002 {
+		t.Errorf("Expected 10002 elements in the union, got %d", n)
+	}
+	if _, err := EncodeInto(&Entry{key: "k", valueType: ROARING_TYPE, value: "\x01\x00\x00\x00"}, nil); err == nil {
+		t.Errorf("Expected an error for a truncated bitmap")
+	}
+}
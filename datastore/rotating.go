@@ -0,0 +1,247 @@
+package datastore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type RotationOptions struct {
+	//0 вимикає відповідне обмеження
+	MaxSizeBytes int64
+	MaxAge       time.Duration
+	//скільки поколінь зберігати, включно з поточним
+	MaxFiles int
+	DbOptions
+}
+
+type rotation struct {
+	db      *Db
+	path    string
+	number  int
+	created time.Time
+}
+
+// RotatingDb writes to basePath until it reaches MaxSizeBytes or MaxAge and
+// then switches to a new database at basePath.1, basePath.2 and so on.
+// Reads go from the newest rotation to the oldest; rotations beyond MaxFiles
+// are deleted together with their data.
+type RotatingDb struct {
+	mu        sync.RWMutex
+	basePath  string
+	opts      RotationOptions
+	rotations []*rotation
+}
+
+func NewRotatingDb(basePath string, opts RotationOptions) (*RotatingDb, error) {
+	if opts.MaxFiles < 0 || opts.MaxSizeBytes < 0 || opts.MaxAge < 0 {
+		return nil, fmt.Errorf("rotation limits must not be negative")
+	}
+	r := &RotatingDb{basePath: basePath, opts: opts}
+	numbers, err := r.existingRotations()
+	if err != nil {
+		return nil, err
+	}
+	if len(numbers) == 0 {
+		numbers = []int{0}
+	}
+	for _, n := range numbers {
+		if err := r.open(n); err != nil {
+			r.Close()
+			return nil, err
+		}
+	}
+	return r, r.prune()
+}
+
+func (r *RotatingDb) rotationPath(n int) string {
+	if n == 0 {
+		return r.basePath
+	}
+	return r.basePath + "." + strconv.Itoa(n)
+}
+
+func (r *RotatingDb) existingRotations() ([]int, error) {
+	var numbers []int
+	if _, err := os.Stat(r.basePath); err == nil {
+		numbers = append(numbers, 0)
+	}
+	matches, err := filepath.Glob(r.basePath + ".*")
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range matches {
+		n, err := strconv.Atoi(strings.TrimPrefix(path, r.basePath+"."))
+		if err == nil && n > 0 {
+			numbers = append(numbers, n)
+		}
+	}
+	sort.Ints(numbers)
+	return numbers, nil
+}
+
+func (r *RotatingDb) open(n int) error {
+	path := r.rotationPath(n)
+	//вік відкритої заново ротації рахуємо від останньої зміни директорії
+	created := timeNow()
+	if info, err := os.Stat(path); err == nil {
+		created = info.ModTime()
+	}
+	db, err := NewDb(path, WithOptions(r.opts.DbOptions))
+	if err != nil {
+		return err
+	}
+	r.rotations = append(r.rotations, &rotation{db: db, path: path, number: n, created: created})
+	return nil
+}
+
+func (r *RotatingDb) current() *rotation {
+	return r.rotations[len(r.rotations)-1]
+}
+
+// rotateIfNeeded викликається під r.mu
+func (r *RotatingDb) rotateIfNeeded() error {
+	cur := r.current()
+	full := false
+	if r.opts.MaxSizeBytes > 0 {
+		size, err := cur.db.CurrentSizeBytes()
+		if err != nil {
+			return err
+		}
+		full = size >= r.opts.MaxSizeBytes
+	}
+	if r.opts.MaxAge > 0 && timeNow().Sub(cur.created) >= r.opts.MaxAge {
+		full = true
+	}
+	if !full {
+		return nil
+	}
+	if err := r.open(cur.number + 1); err != nil {
+		return err
+	}
+	return r.prune()
+}
+
+func (r *RotatingDb) prune() error {
+	if r.opts.MaxFiles <= 0 {
+		return nil
+	}
+	for len(r.rotations) > r.opts.MaxFiles {
+		oldest := r.rotations[0]
+		r.rotations = r.rotations[1:]
+		oldest.db.Close()
+		if err := os.RemoveAll(oldest.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *RotatingDb) Put(key, value string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.rotateIfNeeded(); err != nil {
+		return err
+	}
+	return r.current().db.Put(key, value)
+}
+
+func (r *RotatingDb) Get(key string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for j := len(r.rotations) - 1; j >= 0; j-- {
+		e, err := r.rotations[j].db.getEntry(key)
+		if err == ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if e.valueType != STRING_TYPE {
+			return "", fmt.Errorf("wrong type of value")
+		}
+		return e.value, nil
+	}
+	return "", ErrNotFound
+}
+
+// Paths returns the directories of the kept rotations, oldest first.
+func (r *RotatingDb) Paths() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	res := make([]string, len(r.rotations))
+	for i, rot := range r.rotations {
+		res[i] = rot.path
+	}
+	return res
+}
+
+func (r *RotatingDb) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rot := range r.rotations {
+		rot.db.Close()
+	}
+	return nil
+}
+
+// CompactRotations merges every rotation of basePath except the newest into
+// a single database at archivePath, newer writes winning, and then deletes
+// the merged rotations. An existing archive is treated as older than all of
+// them. The RotatingDb for basePath must not be open.
+func CompactRotations(basePath string, archivePath string) error {
+	r := &RotatingDb{basePath: basePath}
+	numbers, err := r.existingRotations()
+	if err != nil {
+		return err
+	}
+	if len(numbers) < 2 {
+		return nil
+	}
+	numbers = numbers[:len(numbers)-1]
+
+	archive, err := NewDb(archivePath)
+	if err != nil {
+		return err
+	}
+	//від найстарішої ротації до найновішої, тож пізніші записи перекривають ранні
+	for _, n := range numbers {
+		if err := copyLatestVersions(r.rotationPath(n), archive); err != nil {
+			archive.Close()
+			return err
+		}
+	}
+	//мердж прибирає дублікати ключів і надгробки
+	if err := archive.Compact(); err != nil {
+		archive.Close()
+		return err
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	for _, n := range numbers {
+		if err := os.RemoveAll(r.rotationPath(n)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyLatestVersions(path string, dst *Db) error {
+	src, err := NewDb(path, WithReadOnly())
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return src.scanVersions(func(key string, e *Entry) error {
+		if e == nil {
+			return dst.putTombstone(key)
+		}
+		return dst.putEntry(e)
+	})
+}
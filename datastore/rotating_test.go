@@ -0,0 +1,183 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotatingDb_SizeRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	base := filepath.Join(dir, "data")
+
+	r, err := NewRotatingDb(base, RotationOptions{MaxSizeBytes: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		if err := r.Put(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Put("key0", "updated"); err != nil {
+		t.Fatal(err)
+	}
+	paths := r.Paths()
+	if len(paths) < 2 || paths[0] != base || paths[1] != base+".1" {
+		t.Fatalf("Expected rotation to %s.1, got %v", base, paths)
+	}
+	for i := 1; i < 20; i++ {
+		if got, err := r.Get(fmt.Sprintf("key%d", i)); err != nil || got != fmt.Sprintf("value%d", i) {
+			t.Errorf("Bad value for key%d: %q, %v", i, got, err)
+		}
+	}
+	if got, err := r.Get("key0"); err != nil || got != "updated" {
+		t.Errorf("Expected the newest rotation to win, got %q, %v", got, err)
+	}
+	r.Close()
+
+	//кожна ротація — звичайна база зі своїми записами
+	old, err := NewDb(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer old.Close()
+	if got, err := old.Get("key0"); err != nil || got != "value0" {
+		t.Errorf("Bad value in the first rotation: %q, %v", got, err)
+	}
+	if _, err := old.Get("key19"); err != ErrNotFound {
+		t.Errorf("Expected key19 to be written after rotation, got %v", err)
+	}
+	latest, err := NewDb(paths[len(paths)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer latest.Close()
+	if got, err := latest.Get("key19"); err != nil || got != "value19" {
+		t.Errorf("Bad value in the latest rotation: %q, %v", got, err)
+	}
+}
+
+func TestRotatingDb_AgeAndMaxFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	base := filepath.Join(dir, "data")
+
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	r, err := NewRotatingDb(base, RotationOptions{MaxAge: time.Hour, MaxFiles: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := r.Put(fmt.Sprintf("key%d", i), "value"); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(time.Hour)
+	}
+	paths := r.Paths()
+	if len(paths) != 2 || paths[0] != base+".1" || paths[1] != base+".2" {
+		t.Errorf("Expected two rotations by age, got %v", paths)
+	}
+	if _, err := os.Stat(base); !os.IsNotExist(err) {
+		t.Errorf("Expected the oldest rotation to be deleted, got %v", err)
+	}
+	if _, err := r.Get("key0"); err != ErrNotFound {
+		t.Errorf("Expected key0 to be deleted with its rotation, got %v", err)
+	}
+	if got, err := r.Get("key2"); err != nil || got != "value" {
+		t.Errorf("Bad value for key2: %q, %v", got, err)
+	}
+	r.Close()
+
+	reopened, err := NewRotatingDb(base, RotationOptions{MaxAge: time.Hour, MaxFiles: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if got, err := reopened.Get("key1"); err != nil || got != "value" {
+		t.Errorf("Bad value after reopening: %q, %v", got, err)
+	}
+}
+
+func TestCompactRotations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	base := filepath.Join(dir, "data")
+	archivePath := filepath.Join(dir, "archive")
+
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	r, err := NewRotatingDb(base, RotationOptions{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	//5 ротацій по 200 ключів; сусідні мають 25 спільних, разом 100
+	for g := 0; g < 5; g++ {
+		for i := g * 175; i < g*175+200; i++ {
+			if err := r.Put(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", g)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		now = now.Add(time.Hour)
+	}
+	if err := r.Put("current", "value"); err != nil {
+		t.Fatal(err)
+	}
+	paths := r.Paths()
+	if len(paths) != 6 {
+		t.Fatalf("Expected 6 rotations, got %v", paths)
+	}
+	r.Close()
+
+	if err := CompactRotations(base, archivePath); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths[:5] {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be deleted, got %v", path, err)
+		}
+	}
+	if _, err := os.Stat(paths[5]); err != nil {
+		t.Errorf("Expected the current rotation to be kept: %v", err)
+	}
+
+	archive, err := NewDb(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	entries, err := archive.entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 900 {
+		t.Errorf("Expected 900 unique keys in the archive, got %d", len(entries))
+	}
+	if got, err := archive.Get("key175"); err != nil || got != "value1" {
+		t.Errorf("Expected the newer rotation to win, got %q, %v", got, err)
+	}
+	if got, err := archive.Get("key0"); err != nil || got != "value0" {
+		t.Errorf("Bad value for key0: %q, %v", got, err)
+	}
+	if _, err := archive.Get("current"); err != ErrNotFound {
+		t.Errorf("Expected the current rotation to stay out of the archive, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package datastore
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Схема типів лежить поруч із директорією, як і .ckpt: сегменти читаються з
+// нульового зміщення, і заголовок там сприйнявся б як обірваний запис.
+
+// schemaMagic відрізняє файл схеми від випадкового файлу з такою назвою
+const schemaMagic = "GLSC"
+
+func typeSchemaPath(dir string) string {
+	return strings.TrimRight(dir, string(os.PathSeparator)) + ".types"
+}
+
+// WriteSchemaHeader records which type byte stands for each of typeNames in
+// a .types file next to the database directory, so the data can be decoded
+// by a process whose type registry differs, e.g. one without the plugins
+// that defined custom types.
+func WriteSchemaHeader(db *Db, typeNames []string) error {
+	if db.readOnly {
+		return ErrReadOnly
+	}
+	types := make([]byte, len(typeNames))
+	for i, name := range typeNames {
+		valueType, ok := typeToByte[name]
+		if !ok {
+			return fmt.Errorf("unknown value type %q", name)
+		}
+		types[i] = valueType
+	}
+	return writeFileAtomic(typeSchemaPath(db.dir), func(w *bufio.Writer) error {
+		var n [4]byte
+		binary.LittleEndian.PutUint32(n[:], uint32(len(typeNames)))
+		w.WriteString(schemaMagic)
+		if _, err := w.Write(n[:]); err != nil {
+			return err
+		}
+		for i, name := range typeNames {
+			w.WriteByte(types[i])
+			if err := writeString(w, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ReadSchemaHeader returns the type names recorded by WriteSchemaHeader,
+// keyed by type byte, or nil if db has no schema.
+func ReadSchemaHeader(db *Db) (map[byte]string, error) {
+	return readSchema(db.dir)
+}
+
+func readSchema(dir string) (map[byte]string, error) {
+	f, err := os.Open(typeSchemaPath(dir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("corrupted schema: %v", err)
+	}
+	if string(header[:4]) != schemaMagic {
+		return nil, fmt.Errorf("corrupted schema: bad magic")
+	}
+	count := binary.LittleEndian.Uint32(header[4:])
+	if count > 256 {
+		return nil, fmt.Errorf("corrupted schema: %d types", count)
+	}
+	schema := make(map[byte]string, count)
+	for i := uint32(0); i < count; i++ {
+		valueType, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("corrupted schema: %v", err)
+		}
+		name, err := readString(r)
+		if err != nil {
+			return nil, fmt.Errorf("corrupted schema: %v", err)
+		}
+		schema[valueType] = name
+	}
+	return schema, nil
+}
@@ -0,0 +1,85 @@
+package datastore
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestSchemaHeader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Remove(typeSchemaPath(dir))
+
+	if err := RegisterType(0x72, "hex-v1", hexCodec{}); err != nil {
+		t.Fatal(err)
+	}
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema, err := ReadSchemaHeader(db); err != nil || schema != nil {
+		t.Errorf("Expected no schema, got %v, %v", schema, err)
+	}
+	if err := db.putEntry(&Entry{key: "blob", valueType: 0x72, value: "cafe"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put("name", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteSchemaHeader(db, []string{"unknown"}); err == nil {
+		t.Errorf("Expected an error for an unknown type")
+	}
+	if err := WriteSchemaHeader(db, []string{"string", "int64", "hex-v1"}); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	//інший процес знає той самий байт під іншою назвою
+	UnloadTypePlugin("hex-v1")
+	if err := RegisterType(0x72, "hex-v2", hexCodec{}); err != nil {
+		t.Fatal(err)
+	}
+	defer UnloadTypePlugin("hex-v2")
+
+	db, err = NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := ReadSchemaHeader(db)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[byte]string{STRING_TYPE: "string", INT64_TYPE: "int64", 0x72: "hex-v1"}
+	if len(schema) != len(expected) {
+		t.Errorf("Bad schema: %v", schema)
+	}
+	for valueType, name := range expected {
+		if schema[valueType] != name {
+			t.Errorf("Expected %q for type %d, got %q", name, valueType, schema[valueType])
+		}
+	}
+
+	var out bytes.Buffer
+	if err := ExportJSON(dir, &out); err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(&out)
+	types := make(map[string]string)
+	for dec.More() {
+		var record exportRecord
+		if err := dec.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+		types[record.Key] = record.Type
+	}
+	if types["blob"] != "hex-v1" || types["name"] != "string" {
+		t.Errorf("Bad exported types: %v", types)
+	}
+}
@@ -0,0 +1,187 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Серія int64 зберігається одним записом з ключем першого елемента:
+// [n uvarint][перше значення i64][n ключів: спільний префікс з попереднім
+// uvarint, довжина суфікса uvarint, суфікс][n-1 дельт: zigzag u32].
+// Дельта, що не влазить в int32, починає новий запис.
+
+func zigzag32(d int32) uint32 {
+	return uint32(d<<1) ^ uint32(d>>31)
+}
+
+func unzigzag32(u uint32) int32 {
+	return int32(u>>1) ^ -int32(u&1)
+}
+
+func encodeInt64Series(keys []string, values []int64) string {
+	res := binary.AppendUvarint(nil, uint64(len(values)))
+	res = binary.LittleEndian.AppendUint64(res, uint64(values[0]))
+	res = appendSharedPrefixKeys(res, keys)
+	for i := 1; i < len(values); i++ {
+		res = binary.LittleEndian.AppendUint32(res, zigzag32(int32(values[i]-values[i-1])))
+	}
+	return string(res)
+}
+
+func parseInt64Series(data string) ([]string, []int64, error) {
+	b := []byte(data)
+	n, l := binary.Uvarint(b)
+	if l <= 0 || n == 0 || len(b)-l < 8 {
+		return nil, nil, fmt.Errorf("corrupted series header")
+	}
+	b = b[l:]
+	//кожен елемент займає щонайменше 2 байти ключа і 4 байти дельти
+	if n > uint64(len(b)) {
+		return nil, nil, fmt.Errorf("corrupted series length %d", n)
+	}
+	values := make([]int64, n)
+	values[0] = int64(binary.LittleEndian.Uint64(b))
+	b = b[8:]
+
+	keys, b, err := readSharedPrefixKeys(b, int(n))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(b) != 4*(len(values)-1) {
+		return nil, nil, fmt.Errorf("corrupted series deltas")
+	}
+	for i := 1; i < len(values); i++ {
+		values[i] = values[i-1] + int64(unzigzag32(binary.LittleEndian.Uint32(b)))
+		b = b[4:]
+	}
+	return keys, values, nil
+}
+
+func commonPrefixLen(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// ключі послідовних записів зазвичай мають спільний префікс, тож кожен
+// пишеться як [спільне з попереднім uvarint][довжина решти uvarint][решта]
+func appendSharedPrefixKeys(dst []byte, keys []string) []byte {
+	prev := ""
+	for _, key := range keys {
+		shared := commonPrefixLen(prev, key)
+		dst = binary.AppendUvarint(dst, uint64(shared))
+		dst = binary.AppendUvarint(dst, uint64(len(key)-shared))
+		dst = append(dst, key[shared:]...)
+		prev = key
+	}
+	return dst
+}
+
+func readSharedPrefixKeys(b []byte, n int) ([]string, []byte, error) {
+	keys := make([]string, n)
+	prev := ""
+	for i := range keys {
+		shared, l1 := binary.Uvarint(b)
+		if l1 <= 0 || shared > uint64(len(prev)) {
+			return nil, nil, fmt.Errorf("corrupted key %d", i)
+		}
+		suffix, l2 := binary.Uvarint(b[l1:])
+		if l2 <= 0 || suffix > uint64(len(b)-l1-l2) {
+			return nil, nil, fmt.Errorf("corrupted key %d", i)
+		}
+		b = b[l1+l2:]
+		keys[i] = prev[:shared] + string(b[:suffix])
+		b = b[suffix:]
+		prev = keys[i]
+	}
+	return keys, b, nil
+}
+
+func validateInt64Series(data string) error {
+	_, _, err := parseInt64Series(data)
+	return err
+}
+
+// DeltaEncodeInt64Series writes values, each under the key at the same index,
+// to w as delta-encoded records. A delta that does not fit in int32 starts a
+// new record.
+func DeltaEncodeInt64Series(keys []string, values []int64, w io.Writer) error {
+	if len(keys) != len(values) {
+		return fmt.Errorf("got %d keys for %d values", len(keys), len(values))
+	}
+	var buf []byte
+	for start := 0; start < len(values); {
+		end := start + 1
+		for end < len(values) {
+			d := values[end] - values[end-1]
+			//різниця сама могла переповнитися
+			if d < math.MinInt32 || d > math.MaxInt32 || (values[end] > values[end-1]) != (d > 0) {
+				break
+			}
+			end++
+		}
+		e := &Entry{key: keys[start], valueType: DELTA_INT64_TYPE, value: encodeInt64Series(keys[start:end], values[start:end])}
+		var err error
+		if buf, err = EncodeInto(e, buf[:0]); err != nil {
+			return err
+		}
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+		start = end
+	}
+	return nil
+}
+
+func readInt64Series(r io.Reader) ([]string, []int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	entries, err := DecodeMany(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		keys   []string
+		values []int64
+	)
+	for _, e := range entries {
+		if e.valueType != DELTA_INT64_TYPE {
+			return nil, nil, fmt.Errorf("wrong type of value")
+		}
+		k, v, err := parseInt64Series(e.value)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, k...)
+		values = append(values, v...)
+	}
+	return keys, values, nil
+}
+
+// DeltaDecodeInt64Series reads every value written by DeltaEncodeInt64Series.
+func DeltaDecodeInt64Series(r io.Reader) ([]int64, error) {
+	_, values, err := readInt64Series(r)
+	return values, err
+}
+
+// DeltaDecodeInt64SeriesEntries expands the series into int64 entries, e.g.
+// for loading it into a Db.
+func DeltaDecodeInt64SeriesEntries(r io.Reader) ([]*Entry, error) {
+	keys, values, err := readInt64Series(r)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*Entry, len(keys))
+	for i, key := range keys {
+		res[i] = &Entry{key: key, valueType: INT64_TYPE, value: strconv.FormatInt(values[i], 10)}
+	}
+	return res, nil
+}
@@ -0,0 +1,113 @@
+package datastore
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func seriesKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("cpu:%08d", i)
+	}
+	return keys
+}
+
+func TestDeltaInt64Series(t *testing.T) {
+	values := []int64{1000, 1001, 1002, 990, math.MaxInt64, math.MinInt64, 5, 5, -1 << 40}
+	keys := seriesKeys(len(values))
+	var buf bytes.Buffer
+	if err := DeltaEncodeInt64Series(keys, values, &buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	got, err := DeltaDecodeInt64Series(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, values) {
+		t.Errorf("Bad values decoded: expected %v, got %v", values, got)
+	}
+	entries, err := DeltaDecodeInt64SeriesEntries(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, e := range entries {
+		if e.key != keys[i] || e.valueType != INT64_TYPE || e.value != strconv.FormatInt(values[i], 10) {
+			t.Errorf("Bad entry %d: %v", i, e)
+		}
+	}
+	//великі стрибки розбивають серію на окремі записи
+	records, err := DecodeMany(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 5 {
+		t.Errorf("Expected 5 records, got %d", len(records))
+	}
+
+	if err := DeltaEncodeInt64Series(keys[:1], values, &buf); err == nil {
+		t.Errorf("Expected an error for mismatched keys and values")
+	}
+	corrupted := append([]byte(nil), data[:len(data)-1]...)
+	if _, err := DeltaDecodeInt64Series(bytes.NewReader(corrupted)); err == nil {
+		t.Errorf("Expected an error for a truncated series")
+	}
+}
+
+func TestDeltaInt64Series_Size(t *testing.T) {
+	const n = 1000
+	keys := seriesKeys(n)
+	values := make([]int64, n)
+	var separate []byte
+	for i := range values {
+		values[i] = 1700000000 + int64(i)
+		var err error
+		separate, err = EncodeInto(&Entry{key: keys[i], valueType: INT64_TYPE, value: strconv.FormatInt(values[i], 10)}, separate)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var series bytes.Buffer
+	if err := DeltaEncodeInt64Series(keys, values, &series); err != nil {
+		t.Fatal(err)
+	}
+	if series.Len()*2 > len(separate) {
+		t.Errorf("Expected at least 50%% reduction, got %d bytes vs %d", series.Len(), len(separate))
+	}
+}
+
+func BenchmarkDeltaInt64Series(b *testing.B) {
+	const n = 10000
+	keys := seriesKeys(n)
+	values := make([]int64, n)
+	for i := range values {
+		values[i] = 1700000000 + int64(i)
+	}
+
+	b.Run("separate int64 entries", func(b *testing.B) {
+		var buf []byte
+		for i := 0; i < b.N; i++ {
+			buf = buf[:0]
+			for j := range values {
+				buf, _ = EncodeInto(&Entry{key: keys[j], valueType: INT64_TYPE, value: strconv.FormatInt(values[j], 10)}, buf)
+			}
+		}
+		b.ReportMetric(float64(len(buf))/n, "bytes/value")
+	})
+	b.Run("delta series", func(b *testing.B) {
+		var buf bytes.Buffer
+		for i := 0; i < b.N; i++ {
+			buf.Reset()
+			if err := DeltaEncodeInt64Series(keys, values, &buf); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(buf.Len())/n, "bytes/value")
+	})
+}
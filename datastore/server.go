@@ -0,0 +1,66 @@
+package datastore
+
+import (
+	"net"
+	"sync"
+)
+
+// tcpServer приймає з'єднання і закриває їх усі разом із сервером
+type tcpServer struct {
+	listener net.Listener
+
+	mu    sync.Mutex
+	conns map[net.Conn]bool
+	wg    sync.WaitGroup
+}
+
+func (s *tcpServer) listen(addr string, handle func(net.Conn)) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.listener = l
+	s.conns = make(map[net.Conn]bool)
+	s.wg.Add(1)
+	go s.serve(handle)
+	return nil
+}
+
+func (s *tcpServer) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+func (s *tcpServer) Close() error {
+	err := s.listener.Close()
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+func (s *tcpServer) serve(handle func(net.Conn)) {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = true
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+				conn.Close()
+			}()
+			handle(conn)
+		}()
+	}
+}
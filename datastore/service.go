@@ -0,0 +1,132 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const serviceKeyPrefix = "service:"
+
+// ServiceInstance is one registered instance of a service. ExpiresAt is the
+// end of its TTL; the instance has to register again before then to stay
+// discoverable.
+type ServiceInstance struct {
+	ServiceName string
+	InstanceID  string
+	Addr        string
+	Meta        map[string]string
+	ExpiresAt   time.Time
+}
+
+// розмітка: [адреса][пар u32][ключ][значення]... з префіксами довжини;
+// термін життя лежить в анотації ttl.hard, як у PutWithTTL
+func encodeServiceInstance(addr string, meta map[string]string) string {
+	res := appendLengthPrefixed(nil, addr)
+	keys := make([]string, 0, len(meta))
+	for k := range meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	res = binary.LittleEndian.AppendUint32(res, uint32(len(keys)))
+	for _, k := range keys {
+		res = appendLengthPrefixed(res, k)
+		res = appendLengthPrefixed(res, meta[k])
+	}
+	return string(res)
+}
+
+func parseServiceInstance(data string) (string, map[string]string, error) {
+	addr, data, err := readLengthPrefixed(data)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(data) < 4 {
+		return "", nil, fmt.Errorf("corrupted service instance")
+	}
+	n := int(binary.LittleEndian.Uint32([]byte(data[:4])))
+	data = data[4:]
+	if n > len(data)/8 {
+		return "", nil, fmt.Errorf("corrupted service instance")
+	}
+	meta := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		var k, v string
+		if k, data, err = readLengthPrefixed(data); err != nil {
+			return "", nil, err
+		}
+		if v, data, err = readLengthPrefixed(data); err != nil {
+			return "", nil, err
+		}
+		meta[k] = v
+	}
+	if len(data) != 0 {
+		return "", nil, fmt.Errorf("corrupted service instance")
+	}
+	return addr, meta, nil
+}
+
+func validateServiceInstance(data string) error {
+	_, _, err := parseServiceInstance(data)
+	return err
+}
+
+func serviceKey(serviceName, instanceID string) string {
+	return serviceKeyPrefix + serviceName + "/" + instanceID
+}
+
+// RegisterService records instanceID of serviceName at addr for ttl.
+// Registering the same instance again replaces its address and metadata and
+// restarts the TTL, so live instances re-register as a heartbeat.
+func RegisterService(db *Db, serviceName, instanceID, addr string, meta map[string]string, ttl time.Duration) error {
+	if serviceName == "" || strings.Contains(serviceName, "/") {
+		return fmt.Errorf("bad service name %q", serviceName)
+	}
+	if instanceID == "" {
+		return fmt.Errorf("instance id must not be empty")
+	}
+	if ttl <= 0 {
+		return fmt.Errorf("service ttl must be positive")
+	}
+	e := &Entry{key: serviceKey(serviceName, instanceID), valueType: SERVICE_TYPE, value: encodeServiceInstance(addr, meta)}
+	e.SetTTL(0, ttl)
+	return db.putEntry(e)
+}
+
+// DeregisterService removes instanceID of serviceName before its TTL lapses.
+func DeregisterService(db *Db, serviceName, instanceID string) error {
+	return db.putTombstone(serviceKey(serviceName, instanceID))
+}
+
+// DiscoverService returns the live instances of serviceName ordered by
+// instance ID. Instances past their TTL are left out.
+func DiscoverService(db *Db, serviceName string) ([]*ServiceInstance, error) {
+	prefix := serviceKey(serviceName, "")
+	var res []*ServiceInstance
+	//прострочені записи scanVersions віддає як nil
+	err := db.scanVersions(func(key string, e *Entry) error {
+		if e == nil || !strings.HasPrefix(key, prefix) || e.valueType != SERVICE_TYPE {
+			return nil
+		}
+		addr, meta, err := parseServiceInstance(e.value)
+		if err != nil {
+			return err
+		}
+		expiresAt, _ := e.HardExpiry()
+		res = append(res, &ServiceInstance{
+			ServiceName: serviceName,
+			InstanceID:  key[len(prefix):],
+			Addr:        addr,
+			Meta:        meta,
+			ExpiresAt:   expiresAt,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].InstanceID < res[j].InstanceID })
+	return res, nil
+}
@@ -0,0 +1,75 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestServiceDiscovery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for i := 0; i < 5; i++ {
+		ttl := time.Hour
+		if i%2 == 1 {
+			ttl = time.Minute
+		}
+		meta := map[string]string{"zone": fmt.Sprintf("z%d", i)}
+		if err := RegisterService(db, "api", fmt.Sprintf("i-%d", i), fmt.Sprintf("10.0.0.%d:80", i), meta, ttl); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := RegisterService(db, "worker", "w-0", "10.0.1.1:80", nil, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterService(db, "a/b", "x", "addr", nil, time.Hour); err == nil {
+		t.Errorf("Expected an error for a service name with a slash")
+	}
+
+	instances, err := DiscoverService(db, "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(instances) != 5 {
+		t.Fatalf("Expected 5 instances, got %d", len(instances))
+	}
+
+	//i-1 та i-3 живуть хвилину
+	now := time.Now().Add(2 * time.Minute)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+	instances, err = DiscoverService(db, "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(instances) != 3 {
+		t.Fatalf("Expected 3 live instances, got %d", len(instances))
+	}
+	for j, i := range []int{0, 2, 4} {
+		in := instances[j]
+		if in.InstanceID != fmt.Sprintf("i-%d", i) || in.Addr != fmt.Sprintf("10.0.0.%d:80", i) || in.Meta["zone"] != fmt.Sprintf("z%d", i) || in.ServiceName != "api" {
+			t.Errorf("Bad instance %d: %+v", j, in)
+		}
+		if !in.ExpiresAt.After(now) {
+			t.Errorf("Expected %s to expire after now, got %v", in.InstanceID, in.ExpiresAt)
+		}
+	}
+
+	if err := DeregisterService(db, "api", "i-0"); err != nil {
+		t.Fatal(err)
+	}
+	if instances, _ = DiscoverService(db, "api"); len(instances) != 2 {
+		t.Errorf("Expected 2 instances after deregistering, got %d", len(instances))
+	}
+}
@@ -0,0 +1,59 @@
+package datastore
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Flush forces the active segment to stable storage. Writes reach the file
+// before Put returns, so this is only about surviving a power loss.
+func (db *Db) Flush() error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if len(db.blocks) == 0 || db.readOnly {
+		return nil
+	}
+	return db.blocks[len(db.blocks)-1].segment.Sync()
+}
+
+// HandleShutdownSignals flushes and closes db on the first SIGTERM or SIGINT
+// and exits the process: with code 0 if both finished within timeout,
+// otherwise with code 1 after logging the error.
+func HandleShutdownSignals(db *Db, timeout time.Duration) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	go handleShutdown(db, timeout, signals, os.Exit)
+}
+
+// handleShutdown чекає на сигнал окремо від signal.Notify, щоб тест міг
+// подати сигнал і перехопити код виходу
+func handleShutdown(db *Db, timeout time.Duration, signals <-chan os.Signal, exit func(code int)) {
+	sig := <-signals
+	logger := db.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	done := make(chan error, 1)
+	go func() {
+		err := db.Flush()
+		if closeErr := db.Close(); err == nil {
+			err = closeErr
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			logger.Error("shutdown failed", "signal", sig.String(), "error", err)
+			exit(1)
+			return
+		}
+		exit(0)
+	case <-time.After(timeout):
+		logger.Error("shutdown timed out", "signal", sig.String(), "timeout", timeout)
+		exit(1)
+	}
+}
@@ -0,0 +1,77 @@
+package datastore
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestHandleShutdown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put("key", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	signals := make(chan os.Signal, 1)
+	codes := make(chan int, 1)
+	go handleShutdown(db, time.Second, signals, func(code int) { codes <- code })
+	signals <- syscall.SIGTERM
+	select {
+	case code := <-codes:
+		if code != 0 {
+			t.Errorf("Expected exit code 0, got %d", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the process to exit")
+	}
+
+	reopened, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if v, err := reopened.Get("key"); err != nil || v != "value" {
+		t.Errorf("Bad value after shutdown: %q, %v", v, err)
+	}
+}
+
+func TestHandleShutdownTimeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var logs bytes.Buffer
+	db, err := NewDb(dir, WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//запис, що триває, не дає Flush узяти блокування
+	db.mu.Lock()
+	signals := make(chan os.Signal, 1)
+	codes := make(chan int, 1)
+	go handleShutdown(db, 50*time.Millisecond, signals, func(code int) { codes <- code })
+	signals <- syscall.SIGINT
+	code := <-codes
+	db.mu.Unlock()
+	if code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(logs.String(), "shutdown timed out") {
+		t.Errorf("Expected the timeout to be logged, got %q", logs.String())
+	}
+}
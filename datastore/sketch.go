@@ -0,0 +1,142 @@
+package datastore
+
+import (
+	"hash/fnv"
+	"sort"
+	"sync"
+)
+
+type CountMinSketch struct {
+	width, depth int
+	counts       [][]uint64
+}
+
+func NewCountMinSketch(width, depth int) *CountMinSketch {
+	if width < 1 {
+		width = 1
+	}
+	if depth < 1 {
+		depth = 1
+	}
+	counts := make([][]uint64, depth)
+	for i := range counts {
+		counts[i] = make([]uint64, width)
+	}
+	return &CountMinSketch{width: width, depth: depth, counts: counts}
+}
+
+// рядки індексуються як h1 + i*h2 (Kirsch–Mitzenmacher) з одного fnv-хешу
+func (s *CountMinSketch) positions(key string, fn func(row, col int)) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	sum := h.Sum64()
+	h1, h2 := uint32(sum), uint32(sum>>32)|1
+	for i := 0; i < s.depth; i++ {
+		fn(i, int((h1+uint32(i)*h2)%uint32(s.width)))
+	}
+}
+
+func (s *CountMinSketch) Add(key string) {
+	s.positions(key, func(row, col int) {
+		s.counts[row][col]++
+	})
+}
+
+func (s *CountMinSketch) Estimate(key string) uint64 {
+	var res uint64
+	first := true
+	s.positions(key, func(row, col int) {
+		if c := s.counts[row][col]; first || c < res {
+			res = c
+			first = false
+		}
+	})
+	return res
+}
+
+func (s *CountMinSketch) Reset() {
+	for _, row := range s.counts {
+		for i := range row {
+			row[i] = 0
+		}
+	}
+}
+
+const hotKeyCandidates = 1024
+
+type hotKeyTracker struct {
+	mu         sync.Mutex
+	sketch     *CountMinSketch
+	candidates map[string]uint64
+}
+
+func (t *hotKeyTracker) record(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sketch.Add(key)
+	estimate := t.sketch.Estimate(key)
+	if _, ok := t.candidates[key]; ok || len(t.candidates) < hotKeyCandidates {
+		t.candidates[key] = estimate
+		return
+	}
+
+	//витісняємо найхолоднішого кандидата, якщо новий ключ гарячіший
+	var coldest string
+	var min uint64
+	first := true
+	for k, c := range t.candidates {
+		if first || c < min {
+			coldest, min, first = k, c, false
+		}
+	}
+	if estimate > min {
+		delete(t.candidates, coldest)
+		t.candidates[key] = estimate
+	}
+}
+
+func (db *Db) EnableHotKeyTracking(width, depth int) {
+	db.hotKeys = &hotKeyTracker{
+		sketch:     NewCountMinSketch(width, depth),
+		candidates: make(map[string]uint64),
+	}
+}
+
+func (db *Db) HotKeyReport(topN int) []string {
+	t := db.hotKeys
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	keys := make([]string, 0, len(t.candidates))
+	for key := range t.candidates {
+		keys = append(keys, key)
+	}
+	estimates := make(map[string]uint64, len(keys))
+	for _, key := range keys {
+		estimates[key] = t.sketch.Estimate(key)
+	}
+	t.mu.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if estimates[keys[i]] != estimates[keys[j]] {
+			return estimates[keys[i]] > estimates[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if topN < len(keys) {
+		keys = keys[:topN]
+	}
+	return keys
+}
+
+func (db *Db) ResetHotKeys() {
+	t := db.hotKeys
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sketch.Reset()
+	t.candidates = make(map[string]uint64)
+}
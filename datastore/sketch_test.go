@@ -0,0 +1,74 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCountMinSketch(t *testing.T) {
+	s := NewCountMinSketch(1000, 4)
+	for i := 0; i < 1000; i++ {
+		s.Add("hot")
+	}
+	s.Add("cold")
+	for i := 0; i < 500; i++ {
+		s.Add(fmt.Sprintf("noise%d", i))
+	}
+
+	hot, cold := s.Estimate("hot"), s.Estimate("cold")
+	if hot < 1000 {
+		t.Errorf("Estimate must not undercount: got %d for hot", hot)
+	}
+	if hot <= 100*cold {
+		t.Errorf("Expected hot (%d) > 100 * cold (%d)", hot, cold)
+	}
+
+	s.Reset()
+	if s.Estimate("hot") != 0 {
+		t.Error("Expected zero estimate after reset")
+	}
+}
+
+func TestDb_HotKeyReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if db.HotKeyReport(3) != nil {
+		t.Error("Expected no report while tracking is disabled")
+	}
+	db.EnableHotKeyTracking(1000, 4)
+
+	for i := 0; i < 10; i++ {
+		if err := db.Put(fmt.Sprintf("key%d", i), "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		for j := 0; j < (i+1)*10; j++ {
+			if _, err := db.Get(fmt.Sprintf("key%d", i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if got := db.HotKeyReport(3); !reflect.DeepEqual(got, []string{"key9", "key8", "key7"}) {
+		t.Errorf("Unexpected hot keys %v", got)
+	}
+
+	db.ResetHotKeys()
+	if got := db.HotKeyReport(3); len(got) != 0 {
+		t.Errorf("Expected empty report after reset, got %v", got)
+	}
+}
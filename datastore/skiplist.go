@@ -0,0 +1,105 @@
+package datastore
+
+import "math/rand"
+
+const skipListMaxLevel = 32
+
+type skipNode struct {
+	key    string
+	offset int64
+	next   []*skipNode
+}
+
+type SkipList struct {
+	head  *skipNode
+	level int
+	len   int
+	rnd   *rand.Rand
+}
+
+func NewSkipList() *SkipList {
+	return &SkipList{
+		head:  &skipNode{next: make([]*skipNode, skipListMaxLevel)},
+		level: 1,
+		rnd:   rand.New(rand.NewSource(1)),
+	}
+}
+
+func (s *SkipList) Len() int {
+	return s.len
+}
+
+func (s *SkipList) randomLevel() int {
+	level := 1
+	for level < skipListMaxLevel && s.rnd.Intn(4) == 0 {
+		level++
+	}
+	return level
+}
+
+// update[i] — останній вузол на рівні i з ключем, меншим за key
+func (s *SkipList) findPrev(key string, update []*skipNode) *skipNode {
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && x.next[i].key < key {
+			x = x.next[i]
+		}
+		if update != nil {
+			update[i] = x
+		}
+	}
+	return x.next[0]
+}
+
+func (s *SkipList) Insert(key string, offset int64) {
+	var update [skipListMaxLevel]*skipNode
+	if x := s.findPrev(key, update[:]); x != nil && x.key == key {
+		x.offset = offset
+		return
+	}
+
+	level := s.randomLevel()
+	if level > s.level {
+		for i := s.level; i < level; i++ {
+			update[i] = s.head
+		}
+		s.level = level
+	}
+	node := &skipNode{key: key, offset: offset, next: make([]*skipNode, level)}
+	for i := 0; i < level; i++ {
+		node.next[i] = update[i].next[i]
+		update[i].next[i] = node
+	}
+	s.len++
+}
+
+func (s *SkipList) Delete(key string) {
+	var update [skipListMaxLevel]*skipNode
+	x := s.findPrev(key, update[:])
+	if x == nil || x.key != key {
+		return
+	}
+	for i := 0; i < len(x.next); i++ {
+		update[i].next[i] = x.next[i]
+	}
+	for s.level > 1 && s.head.next[s.level-1] == nil {
+		s.level--
+	}
+	s.len--
+}
+
+func (s *SkipList) Find(key string) (int64, bool) {
+	x := s.findPrev(key, nil)
+	if x == nil || x.key != key {
+		return 0, false
+	}
+	return x.offset, true
+}
+
+func (s *SkipList) Range(lo, hi string, fn func(key string, offset int64) bool) {
+	for x := s.findPrev(lo, nil); x != nil && x.key < hi; x = x.next[0] {
+		if !fn(x.key, x.offset) {
+			return
+		}
+	}
+}
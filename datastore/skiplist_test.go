@@ -0,0 +1,125 @@
+package datastore
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSkipList(t *testing.T) {
+	s := NewSkipList()
+	expected := make(map[string]int64)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		key := fmt.Sprintf("key%04d", r.Intn(2000))
+		if r.Intn(4) == 0 {
+			s.Delete(key)
+			delete(expected, key)
+		} else {
+			s.Insert(key, int64(i))
+			expected[key] = int64(i)
+		}
+	}
+
+	if s.Len() != len(expected) {
+		t.Errorf("Expected length %d, got %d", len(expected), s.Len())
+	}
+	for key, offset := range expected {
+		got, ok := s.Find(key)
+		if !ok || got != offset {
+			t.Errorf("Find(%s) = %d, %v; expected %d", key, got, ok, offset)
+		}
+	}
+	if _, ok := s.Find("missing"); ok {
+		t.Error("Found a missing key")
+	}
+
+	var keys []string
+	for key := range expected {
+		if key >= "key0500" && key < "key1500" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var got []string
+	s.Range("key0500", "key1500", func(key string, offset int64) bool {
+		if offset != expected[key] {
+			t.Errorf("Bad offset for %s in range", key)
+		}
+		got = append(got, key)
+		return true
+	})
+	if fmt.Sprint(got) != fmt.Sprint(keys) {
+		t.Errorf("Range returned %d keys, expected %d in order", len(got), len(keys))
+	}
+
+	n := 0
+	s.Range("", "~", func(string, int64) bool {
+		n++
+		return n < 3
+	})
+	if n != 3 {
+		t.Errorf("Expected Range to stop after 3 keys, got %d", n)
+	}
+}
+
+type sortedSlice struct {
+	keys    []string
+	offsets []int64
+}
+
+func (s *sortedSlice) insert(key string, offset int64) {
+	i := sort.SearchStrings(s.keys, key)
+	if i < len(s.keys) && s.keys[i] == key {
+		s.offsets[i] = offset
+		return
+	}
+	s.keys = append(s.keys, "")
+	s.offsets = append(s.offsets, 0)
+	copy(s.keys[i+1:], s.keys[i:])
+	copy(s.offsets[i+1:], s.offsets[i:])
+	s.keys[i] = key
+	s.offsets[i] = offset
+}
+
+func (s *sortedSlice) rangeKeys(lo, hi string, fn func(string, int64) bool) {
+	for i := sort.SearchStrings(s.keys, lo); i < len(s.keys) && s.keys[i] < hi; i++ {
+		if !fn(s.keys[i], s.offsets[i]) {
+			return
+		}
+	}
+}
+
+func BenchmarkOrderedIndex(b *testing.B) {
+	const n = 100000
+	keys := make([]string, n)
+	r := rand.New(rand.NewSource(1))
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%08d", r.Intn(n*10))
+	}
+	count := func(string, int64) bool { return true }
+
+	b.Run("SkipList", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			s := NewSkipList()
+			for j, key := range keys {
+				s.Insert(key, int64(j))
+				if j%100 == 0 {
+					s.Range(key, key+"~", count)
+				}
+			}
+		}
+	})
+	b.Run("sorted slice", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			s := &sortedSlice{}
+			for j, key := range keys {
+				s.insert(key, int64(j))
+				if j%100 == 0 {
+					s.rangeKeys(key, key+"~", count)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,245 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Впорядкована множина зберігається як вихідний рівень skip-list: [членів u32],
+// далі [бал f64][член з префіксом довжини] за зростанням (бал, член). При
+// читанні список відбудовується в SkipList з ключами sortedSetKey.
+
+type sortedSet struct {
+	list   *SkipList
+	scores map[string]float64
+}
+
+// sortedSetKey кодує бал так, щоб байтовий порядок ключів збігався з числовим
+func sortedSetKey(score float64, member string) string {
+	bits := math.Float64bits(score)
+	if bits>>63 != 0 {
+		bits = ^bits
+	} else {
+		bits |= 1 << 63
+	}
+	return string(binary.BigEndian.AppendUint64(nil, bits)) + member
+}
+
+// більше за ключ будь-якого балу, крім NaN, який не зберігається
+const sortedSetEnd = "\xff\xff\xff\xff\xff\xff\xff\xff\xff"
+
+func newSortedSet() *sortedSet {
+	return &sortedSet{list: NewSkipList(), scores: make(map[string]float64)}
+}
+
+func (z *sortedSet) add(member string, score float64) {
+	if old, ok := z.scores[member]; ok {
+		z.list.Delete(sortedSetKey(old, member))
+	}
+	z.scores[member] = score
+	z.list.Insert(sortedSetKey(score, member), 0)
+}
+
+func (z *sortedSet) remove(member string) bool {
+	score, ok := z.scores[member]
+	if !ok {
+		return false
+	}
+	delete(z.scores, member)
+	z.list.Delete(sortedSetKey(score, member))
+	return true
+}
+
+// each обходить члени за зростанням балу
+func (z *sortedSet) each(fn func(member string, score float64) bool) {
+	z.list.Range("", sortedSetEnd, func(key string, _ int64) bool {
+		member := key[8:]
+		return fn(member, z.scores[member])
+	})
+}
+
+func (z *sortedSet) encode() string {
+	res := binary.LittleEndian.AppendUint32(nil, uint32(len(z.scores)))
+	z.each(func(member string, score float64) bool {
+		res = binary.LittleEndian.AppendUint64(res, math.Float64bits(score))
+		res = appendLengthPrefixed(res, member)
+		return true
+	})
+	return string(res)
+}
+
+func parseSortedSet(data string) (*sortedSet, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("corrupted sorted set")
+	}
+	n := int(binary.LittleEndian.Uint32([]byte(data[:4])))
+	if n > (len(data)-4)/12 {
+		return nil, fmt.Errorf("corrupted sorted set")
+	}
+	data = data[4:]
+	z := newSortedSet()
+	prev := ""
+	for i := 0; i < n; i++ {
+		if len(data) < 8 {
+			return nil, fmt.Errorf("corrupted sorted set")
+		}
+		score := math.Float64frombits(binary.LittleEndian.Uint64([]byte(data[:8])))
+		member, rest, err := readLengthPrefixed(data[8:])
+		if err != nil {
+			return nil, err
+		}
+		key := sortedSetKey(score, member)
+		if math.IsNaN(score) || (i > 0 && key <= prev) {
+			return nil, fmt.Errorf("corrupted sorted set")
+		}
+		if _, ok := z.scores[member]; ok {
+			return nil, fmt.Errorf("duplicate sorted set member %q", member)
+		}
+		z.add(member, score)
+		prev, data = key, rest
+	}
+	if data != "" {
+		return nil, fmt.Errorf("corrupted sorted set")
+	}
+	return z, nil
+}
+
+func validateSortedSet(data string) error {
+	_, err := parseSortedSet(data)
+	return err
+}
+
+func getSortedSet(db *Db, key string) (*sortedSet, error) {
+	e, err := db.getEntry(key)
+	if err != nil {
+		return nil, err
+	}
+	if e.valueType != SORTEDSET_TYPE {
+		return nil, fmt.Errorf("wrong type of value")
+	}
+	return parseSortedSet(e.value)
+}
+
+func updateSortedSet(db *Db, key string, fn func(z *sortedSet) (bool, error)) error {
+	_, err := db.updateEntry(key, func(cur *Entry) (*Entry, error) {
+		z := newSortedSet()
+		if cur != nil {
+			if cur.valueType != SORTEDSET_TYPE {
+				return nil, fmt.Errorf("wrong type of value")
+			}
+			var err error
+			if z, err = parseSortedSet(cur.value); err != nil {
+				return nil, err
+			}
+		}
+		changed, err := fn(z)
+		if err != nil || !changed {
+			return nil, err
+		}
+		return &Entry{valueType: SORTEDSET_TYPE, value: z.encode()}, nil
+	})
+	return err
+}
+
+// ZAdd sets the score of member, adding it if needed. Members with equal
+// scores are ordered by member.
+func ZAdd(db *Db, key, member string, score float64) error {
+	if math.IsNaN(score) {
+		return fmt.Errorf("score must not be NaN")
+	}
+	//-0 і 0 мають один бал
+	if score == 0 {
+		score = 0
+	}
+	return updateSortedSet(db, key, func(z *sortedSet) (bool, error) {
+		if old, ok := z.scores[member]; ok && old == score {
+			return false, nil
+		}
+		z.add(member, score)
+		return true, nil
+	})
+}
+
+func ZRem(db *Db, key, member string) error {
+	return updateSortedSet(db, key, func(z *sortedSet) (bool, error) {
+		if !z.remove(member) {
+			return false, ErrNotFound
+		}
+		return true, nil
+	})
+}
+
+// ZRank returns the 0-based position of member by ascending score. The
+// skip list keeps no spans, so this walks the set.
+func ZRank(db *Db, key, member string) (int, error) {
+	z, err := getSortedSet(db, key)
+	if err != nil {
+		return 0, err
+	}
+	if _, ok := z.scores[member]; !ok {
+		return 0, ErrNotFound
+	}
+	rank := -1
+	i := 0
+	z.each(func(m string, _ float64) bool {
+		if m == member {
+			rank = i
+			return false
+		}
+		i++
+		return true
+	})
+	return rank, nil
+}
+
+// ZRange returns the members ranked lo..hi inclusive; negative indexes count
+// from the end, so ZRange(db, key, 0, -1) returns the whole set.
+func ZRange(db *Db, key string, lo, hi int) ([]string, error) {
+	z, err := getSortedSet(db, key)
+	if err != nil {
+		return nil, err
+	}
+	n := len(z.scores)
+	if lo < 0 {
+		lo += n
+	}
+	if hi < 0 {
+		hi += n
+	}
+	if lo < 0 {
+		lo = 0
+	}
+	var res []string
+	i := 0
+	z.each(func(member string, _ float64) bool {
+		if i > hi {
+			return false
+		}
+		if i >= lo {
+			res = append(res, member)
+		}
+		i++
+		return true
+	})
+	return res, nil
+}
+
+// ZRangeByScore returns the members with min <= score <= max in ascending
+// order.
+func ZRangeByScore(db *Db, key string, min, max float64) ([]string, error) {
+	z, err := getSortedSet(db, key)
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	z.list.Range(sortedSetKey(min, ""), sortedSetEnd, func(k string, _ int64) bool {
+		member := k[8:]
+		if z.scores[member] > max {
+			return false
+		}
+		res = append(res, member)
+		return true
+	})
+	return res, nil
+}
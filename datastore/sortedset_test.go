@@ -0,0 +1,94 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"testing"
+)
+
+func TestSortedSet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rnd := rand.New(rand.NewSource(1))
+	scores := make(map[string]float64)
+	for i := 0; i < 100; i++ {
+		member := fmt.Sprintf("player%02d", i)
+		//від'ємні бали і повтори, щоб перевірити порядок за членом
+		scores[member] = float64(rnd.Intn(200)-100) / 4
+		if err := ZAdd(db, "board", member, scores[member]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	members := make([]string, 0, len(scores))
+	for m := range scores {
+		members = append(members, m)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if scores[members[i]] != scores[members[j]] {
+			return scores[members[i]] < scores[members[j]]
+		}
+		return members[i] < members[j]
+	})
+
+	all, err := ZRangeByScore(db, "board", math.Inf(-1), math.Inf(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(all) != fmt.Sprint(members) {
+		t.Errorf("Bad full score range order")
+	}
+	var expected []string
+	for _, m := range members {
+		if scores[m] >= -5 && scores[m] <= 5 {
+			expected = append(expected, m)
+		}
+	}
+	if got, _ := ZRangeByScore(db, "board", -5, 5); fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("Bad score range [-5, 5]: expected %v, got %v", expected, got)
+	}
+	if got, _ := ZRange(db, "board", 0, -1); fmt.Sprint(got) != fmt.Sprint(members) {
+		t.Errorf("Bad full rank range")
+	}
+	if got, _ := ZRange(db, "board", -3, -1); fmt.Sprint(got) != fmt.Sprint(members[97:]) {
+		t.Errorf("Bad top 3: %v", got)
+	}
+	if rank, err := ZRank(db, "board", members[42]); err != nil || rank != 42 {
+		t.Errorf("Expected rank 42, got %d, %v", rank, err)
+	}
+
+	//новий бал переміщує члена
+	if err := ZAdd(db, "board", members[0], 1000); err != nil {
+		t.Fatal(err)
+	}
+	if rank, _ := ZRank(db, "board", members[0]); rank != 99 {
+		t.Errorf("Expected the updated member last, got rank %d", rank)
+	}
+	if err := ZRem(db, "board", members[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ZRank(db, "board", members[0]); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound for a removed member, got %v", err)
+	}
+	if err := ZRem(db, "board", members[0]); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound removing twice, got %v", err)
+	}
+	if got, _ := ZRange(db, "board", 0, -1); len(got) != 99 {
+		t.Errorf("Expected 99 members, got %d", len(got))
+	}
+	if err := ZAdd(db, "board", "x", math.NaN()); err == nil {
+		t.Errorf("Expected an error for a NaN score")
+	}
+}
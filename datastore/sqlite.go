@@ -0,0 +1,91 @@
+package datastore
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// sqliteDriver - ім'я, під яким реєструється modernc.org/sqlite; сам
+// драйвер підключає застосунок через import _ "modernc.org/sqlite"
+var sqliteDriver = "sqlite"
+
+// quoteSQLIdent бере ідентифікатор у подвійні лапки, як цього чекають і
+// SQLite, і Postgres
+func quoteSQLIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// ImportSQLite reads keyCol, valueCol and typeCol of every row of tableName
+// in the SQLite database at dbPath and writes them into db. A NULL type (or
+// an empty typeCol) means a string entry, a NULL value an empty one.
+func ImportSQLite(dbPath, tableName, keyCol, valueCol, typeCol string, db *Db) error {
+	conn, err := sql.Open(sqliteDriver, dbPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	typeExpr := "NULL"
+	if typeCol != "" {
+		typeExpr = quoteSQLIdent(typeCol)
+	}
+	rows, err := conn.Query(fmt.Sprintf("SELECT %s, %s, %s FROM %s",
+		quoteSQLIdent(keyCol), quoteSQLIdent(valueCol), typeExpr, quoteSQLIdent(tableName)))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key, valueType sql.NullString
+		var value []byte
+		if err := rows.Scan(&key, &value, &valueType); err != nil {
+			return err
+		}
+		if !key.Valid {
+			return fmt.Errorf("row of %s with a NULL key", tableName)
+		}
+		if !valueType.Valid {
+			valueType.String = "string"
+		}
+		if err := importEntry(db, key.String, valueType.String, string(value)); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ExportSQLite writes every key of db into tableName of the SQLite database
+// at dbPath as (key, value, type) rows, creating the table if needed. Rows
+// with the same key are replaced; the export runs in one transaction.
+func ExportSQLite(db *Db, dbPath, tableName string) error {
+	entries, err := db.entries()
+	if err != nil {
+		return err
+	}
+	conn, err := sql.Open(sqliteDriver, dbPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	table := quoteSQLIdent(tableName)
+	if _, err := conn.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s ("key" TEXT PRIMARY KEY, "value" BLOB, "type" TEXT)`, table)); err != nil {
+		return err
+	}
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(fmt.Sprintf(`INSERT OR REPLACE INTO %s ("key", "value", "type") VALUES (?, ?, ?)`, table))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, e := range entries {
+		if _, err := stmt.Exec(e.key, []byte(e.value), e.Type()); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
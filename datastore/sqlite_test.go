@@ -0,0 +1,252 @@
+package datastore
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSQLite розуміє рівно ті запити, які шлють ImportSQLite і ExportSQLite;
+// справжній modernc.org/sqlite до дерева не підключений
+type fakeSQLite struct {
+	mu     sync.Mutex
+	tables map[string]*fakeSQLiteTable
+}
+
+type fakeSQLiteTable struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+var (
+	fakeSQLiteDb     = &fakeSQLite{tables: map[string]*fakeSQLiteTable{}}
+	fakeSQLiteCreate = regexp.MustCompile(`^CREATE TABLE IF NOT EXISTS "([^"]+)" \((.*)\)$`)
+	fakeSQLiteInsert = regexp.MustCompile(`^INSERT OR REPLACE INTO "([^"]+)" \(([^)]*)\) VALUES \(\?, \?, \?\)$`)
+	fakeSQLiteSelect = regexp.MustCompile(`^SELECT (.*) FROM "([^"]+)"$`)
+)
+
+func init() {
+	for _, name := range sql.Drivers() {
+		if name == sqliteDriver {
+			return
+		}
+	}
+	sql.Register(sqliteDriver, fakeSQLiteDriver{})
+}
+
+type fakeSQLiteDriver struct{}
+
+func (fakeSQLiteDriver) Open(name string) (driver.Conn, error) {
+	return &fakeSQLiteConn{path: name}, nil
+}
+
+type fakeSQLiteConn struct {
+	path string
+}
+
+func (c *fakeSQLiteConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeSQLiteStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeSQLiteConn) Close() error              { return nil }
+func (c *fakeSQLiteConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *fakeSQLiteConn) Commit() error             { return nil }
+func (c *fakeSQLiteConn) Rollback() error           { return nil }
+
+type fakeSQLiteStmt struct {
+	conn  *fakeSQLiteConn
+	query string
+}
+
+func (s *fakeSQLiteStmt) Close() error  { return nil }
+func (s *fakeSQLiteStmt) NumInput() int { return strings.Count(s.query, "?") }
+
+func unquoteFakeSQLite(s string) string {
+	return strings.Trim(strings.Fields(strings.TrimSpace(s))[0], `"`)
+}
+
+func (s *fakeSQLiteStmt) Exec(args []driver.Value) (driver.Result, error) {
+	fakeSQLiteDb.mu.Lock()
+	defer fakeSQLiteDb.mu.Unlock()
+	if m := fakeSQLiteCreate.FindStringSubmatch(s.query); m != nil {
+		name := s.conn.path + "/" + m[1]
+		if fakeSQLiteDb.tables[name] == nil {
+			table := &fakeSQLiteTable{}
+			for _, col := range strings.Split(m[2], ",") {
+				table.columns = append(table.columns, unquoteFakeSQLite(col))
+			}
+			fakeSQLiteDb.tables[name] = table
+		}
+		return driver.RowsAffected(0), nil
+	}
+	if m := fakeSQLiteInsert.FindStringSubmatch(s.query); m != nil {
+		table := fakeSQLiteDb.tables[s.conn.path+"/"+m[1]]
+		if table == nil {
+			return nil, fmt.Errorf("no such table: %s", m[1])
+		}
+		row := make([]driver.Value, len(table.columns))
+		for i, col := range strings.Split(m[2], ",") {
+			for j, name := range table.columns {
+				if name == unquoteFakeSQLite(col) {
+					if b, ok := args[i].([]byte); ok {
+						args[i] = append([]byte(nil), b...)
+					}
+					row[j] = args[i]
+				}
+			}
+		}
+		//перший стовпець - первинний ключ
+		for i, old := range table.rows {
+			if old[0] == row[0] {
+				table.rows[i] = row
+				return driver.RowsAffected(1), nil
+			}
+		}
+		table.rows = append(table.rows, row)
+		return driver.RowsAffected(1), nil
+	}
+	return nil, fmt.Errorf("unsupported statement %q", s.query)
+}
+
+func (s *fakeSQLiteStmt) Query(args []driver.Value) (driver.Rows, error) {
+	fakeSQLiteDb.mu.Lock()
+	defer fakeSQLiteDb.mu.Unlock()
+	m := fakeSQLiteSelect.FindStringSubmatch(s.query)
+	if m == nil {
+		return nil, fmt.Errorf("unsupported query %q", s.query)
+	}
+	table := fakeSQLiteDb.tables[s.conn.path+"/"+m[2]]
+	if table == nil {
+		return nil, fmt.Errorf("no such table: %s", m[2])
+	}
+	res := &fakeSQLiteRows{}
+	var index []int
+	for _, col := range strings.Split(m[1], ",") {
+		res.columns = append(res.columns, strings.TrimSpace(col))
+		found := -1
+		for j, name := range table.columns {
+			if strings.TrimSpace(col) == `"`+name+`"` {
+				found = j
+			}
+		}
+		if found < 0 && strings.TrimSpace(col) != "NULL" {
+			return nil, fmt.Errorf("no such column: %s", col)
+		}
+		index = append(index, found)
+	}
+	for _, row := range table.rows {
+		out := make([]driver.Value, len(index))
+		for i, j := range index {
+			if j >= 0 {
+				out[i] = row[j]
+			}
+		}
+		res.rows = append(res.rows, out)
+	}
+	return res, nil
+}
+
+type fakeSQLiteRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeSQLiteRows) Columns() []string { return r.columns }
+func (r *fakeSQLiteRows) Close() error      { return nil }
+
+func (r *fakeSQLiteRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestSQLiteImportExport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "legacy.sqlite")
+
+	conn, err := sql.Open(sqliteDriver, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec(`CREATE TABLE IF NOT EXISTS "settings" (name TEXT PRIMARY KEY, data TEXT, kind TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		var kind interface{}
+		value := fmt.Sprintf("value-%d", i)
+		if i%10 == 0 {
+			kind, value = "int64", fmt.Sprint(i)
+		} else if i%2 == 0 {
+			kind = "string"
+		}
+		if _, err := conn.Exec(`INSERT OR REPLACE INTO "settings" ("name", "data", "kind") VALUES (?, ?, ?)`, fmt.Sprintf("key-%03d", i), value, kind); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conn.Close()
+
+	db, err := NewDb(filepath.Join(dir, "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := ImportSQLite(src, "settings", "name", "data", "kind", db); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := db.entries()
+	if len(entries) != 100 {
+		t.Errorf("Expected 100 entries, got %d", len(entries))
+	}
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%03d", i)
+		if i%10 == 0 {
+			if v, err := db.GetInt64(key); err != nil || v != int64(i) {
+				t.Errorf("Bad %s: %d, %v", key, v, err)
+			}
+		} else if v, err := db.Get(key); err != nil || v != fmt.Sprintf("value-%d", i) {
+			t.Errorf("Bad %s: %q, %v", key, v, err)
+		}
+	}
+	if err := ImportSQLite(src, "settings", "name", "missing", "", db); err == nil {
+		t.Errorf("Expected an error for a missing column")
+	}
+
+	dst := filepath.Join(dir, "export.sqlite")
+	if err := ExportSQLite(db, dst, "kv"); err != nil {
+		t.Fatal(err)
+	}
+	//повторний експорт замінює рядки, а не дублює їх
+	if err := ExportSQLite(db, dst, "kv"); err != nil {
+		t.Fatal(err)
+	}
+	copied, err := NewDb(filepath.Join(dir, "copy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer copied.Close()
+	if err := ImportSQLite(dst, "kv", "key", "value", "type", copied); err != nil {
+		t.Fatal(err)
+	}
+	copiedEntries, _ := copied.entries()
+	if len(copiedEntries) != 100 {
+		t.Errorf("Expected 100 exported rows, got %d", len(copiedEntries))
+	}
+	if v, err := copied.GetInt64("key-050"); err != nil || v != 50 {
+		t.Errorf("Bad exported int64: %d, %v", v, err)
+	}
+}
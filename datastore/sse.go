@@ -0,0 +1,78 @@
+package datastore
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// sseKeepalive - як часто слати коментар, щоб проксі не рвали тихе з'єднання
+var sseKeepalive = 15 * time.Second
+
+// скільки подій клієнт може відставати, перш ніж ми закриємо його потік
+const watchBufferSize = 256
+
+type sseHandler struct {
+	db *Db
+}
+
+// SSEHandler streams every write to db as server-sent events named
+// entryChanged, each carrying the entry as a JSON object in the ExportJSON
+// format. Every client gets its own stream from the moment it connects. A
+// client that falls too far behind is disconnected, so that its
+// EventSource reconnects instead of silently missing events.
+func SSEHandler(db *Db) http.Handler {
+	return &sseHandler{db: db}
+}
+
+func (h *sseHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	events := make(chan *Entry, watchBufferSize)
+	lagged := make(chan struct{})
+	var once sync.Once
+	unwatch := h.db.Watch(func(e *Entry) {
+		select {
+		case events <- e:
+		default:
+			once.Do(func() { close(lagged) })
+		}
+	})
+	defer unwatch()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(sseKeepalive)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-lagged:
+			return
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		case e := <-events:
+			data, err := json.Marshal(newExportRecord(e))
+			if err != nil {
+				return
+			}
+			//JSON без відступів не містить переносів, тож вистачає одного рядка data:
+			if _, err := fmt.Fprintf(w, "event: entryChanged\ndata: %s\n\n", data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
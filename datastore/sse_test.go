@@ -0,0 +1,81 @@
+package datastore
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSEHandler(t *testing.T) {
+	sseKeepalive = 50 * time.Millisecond
+	defer func() { sseKeepalive = 15 * time.Second }()
+
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	srv := httptest.NewServer(SSEHandler(db))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Bad content type %q", ct)
+	}
+
+	go func() {
+		for i := 0; i < 10; i++ {
+			db.Put(fmt.Sprintf("key-%d", i), fmt.Sprintf("value %d", i))
+		}
+	}()
+
+	r := bufio.NewReader(resp.Body)
+	var records []exportRecord
+	keepalives := 0
+	deadline := time.AfterFunc(5*time.Second, func() { resp.Body.Close() })
+	defer deadline.Stop()
+	event := ""
+	for len(records) < 10 || keepalives == 0 {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Stream ended after %d events: %v", len(records), err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == ": keepalive":
+			keepalives++
+		case strings.HasPrefix(line, "event: "):
+			event = line[len("event: "):]
+		case strings.HasPrefix(line, "data: "):
+			if event != "entryChanged" {
+				t.Errorf("Bad event name %q", event)
+			}
+			var record exportRecord
+			if err := json.Unmarshal([]byte(line[len("data: "):]), &record); err != nil {
+				t.Fatal(err)
+			}
+			records = append(records, record)
+		}
+	}
+	for i, record := range records {
+		if record.Key != fmt.Sprintf("key-%d", i) || record.Type != "string" || record.Value != fmt.Sprintf("value %d", i) {
+			t.Errorf("Bad event %d: %+v", i, record)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package datastore
+
+type Store interface {
+	Get(key string) (string, error)
+	Put(key, value string) error
+	GetInt64(key string) (int64, error)
+	PutInt64(key string, value int64) error
+}
+
+var _ Store = (*Db)(nil)
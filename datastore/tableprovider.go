@@ -0,0 +1,188 @@
+package datastore
+
+import (
+	"context"
+	"fmt"
+)
+
+// Go-біндингів DataFusion немає, тож інтерфейс повторює форму його
+// TableProvider на власних типах: рушій запитів отримує схему, а Scan
+// віддає потрібні стовпці пакетами й одразу застосовує прості фільтри.
+
+// TableField describes one column; every column of the entries table is
+// Utf8.
+type TableField struct {
+	Name string
+	Type string
+}
+
+type TableSchema struct {
+	Fields []TableField
+}
+
+// Expr is a filter pushed down to Scan: Column Op Value, where Op is one of
+// = != < <= > >= and values compare as strings.
+type Expr struct {
+	Column string
+	Op     string
+	Value  string
+}
+
+// RecordBatch holds NumRows rows as one string slice per scanned column.
+type RecordBatch struct {
+	Schema  TableSchema
+	Columns [][]string
+	NumRows int
+}
+
+// RecordBatchReader reads batches like arrow's RecordReader: call Next until
+// it returns false, then check Err.
+type RecordBatchReader interface {
+	Schema() TableSchema
+	Next() bool
+	Record() *RecordBatch
+	Err() error
+}
+
+type TableProvider interface {
+	Schema() TableSchema
+	Scan(ctx context.Context, projection []int, filters []Expr) (RecordBatchReader, error)
+}
+
+var entriesTableSchema = TableSchema{Fields: []TableField{{"key", "Utf8"}, {"type", "Utf8"}, {"value", "Utf8"}}}
+
+type entriesTable struct {
+	db        *Db
+	batchSize int
+}
+
+// NewEntriesTable exposes the latest version of every key of db as a table
+// of key, type and value columns, scanned batchSize rows at a time.
+func NewEntriesTable(db *Db, batchSize int) (TableProvider, error) {
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("batch size must be positive")
+	}
+	return &entriesTable{db: db, batchSize: batchSize}, nil
+}
+
+func (t *entriesTable) Schema() TableSchema {
+	return entriesTableSchema
+}
+
+func entryColumn(e *Entry, column int) string {
+	switch column {
+	case 0:
+		return e.key
+	case 1:
+		return e.Type()
+	}
+	return e.value
+}
+
+func columnIndex(name string) int {
+	for i, f := range entriesTableSchema.Fields {
+		if f.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f Expr) matches(value string) bool {
+	switch f.Op {
+	case "=":
+		return value == f.Value
+	case "!=":
+		return value != f.Value
+	case "<":
+		return value < f.Value
+	case "<=":
+		return value <= f.Value
+	case ">":
+		return value > f.Value
+	}
+	return value >= f.Value
+}
+
+// Scan reads the table as of the call; a nil projection means all columns.
+func (t *entriesTable) Scan(ctx context.Context, projection []int, filters []Expr) (RecordBatchReader, error) {
+	if projection == nil {
+		projection = []int{0, 1, 2}
+	}
+	schema := TableSchema{}
+	for _, c := range projection {
+		if c < 0 || c >= len(entriesTableSchema.Fields) {
+			return nil, fmt.Errorf("no column %d", c)
+		}
+		schema.Fields = append(schema.Fields, entriesTableSchema.Fields[c])
+	}
+	filterColumns := make([]int, len(filters))
+	for i, f := range filters {
+		if filterColumns[i] = columnIndex(f.Column); filterColumns[i] < 0 {
+			return nil, fmt.Errorf("no column %q", f.Column)
+		}
+		switch f.Op {
+		case "=", "!=", "<", "<=", ">", ">=":
+		default:
+			return nil, fmt.Errorf("unsupported filter operator %q", f.Op)
+		}
+	}
+	entries, err := t.db.entries()
+	if err != nil {
+		return nil, err
+	}
+	var rows []*Entry
+	for _, e := range entries {
+		ok := true
+		for i, f := range filters {
+			ok = ok && f.matches(entryColumn(e, filterColumns[i]))
+		}
+		if ok {
+			rows = append(rows, e)
+		}
+	}
+	return &entriesReader{ctx: ctx, schema: schema, projection: projection, rows: rows, batchSize: t.batchSize}, nil
+}
+
+type entriesReader struct {
+	ctx        context.Context
+	schema     TableSchema
+	projection []int
+	rows       []*Entry
+	batchSize  int
+	batch      *RecordBatch
+	err        error
+}
+
+func (r *entriesReader) Schema() TableSchema {
+	return r.schema
+}
+
+func (r *entriesReader) Next() bool {
+	r.batch = nil
+	if r.err = r.ctx.Err(); r.err != nil || len(r.rows) == 0 {
+		return false
+	}
+	n := r.batchSize
+	if n > len(r.rows) {
+		n = len(r.rows)
+	}
+	batch := &RecordBatch{Schema: r.schema, Columns: make([][]string, len(r.projection)), NumRows: n}
+	for i, c := range r.projection {
+		batch.Columns[i] = make([]string, n)
+		for j, e := range r.rows[:n] {
+			batch.Columns[i][j] = entryColumn(e, c)
+		}
+	}
+	r.rows = r.rows[n:]
+	r.batch = batch
+	return true
+}
+
+func (r *entriesReader) Record() *RecordBatch {
+	return r.batch
+}
+
+func (r *entriesReader) Err() error {
+	return r.err
+}
@@ -0,0 +1,77 @@
+package datastore
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestEntriesTableScan(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for i := 0; i < 25; i++ {
+		if i%2 == 0 {
+			err = db.PutInt64(fmt.Sprintf("n%02d", i), int64(i))
+		} else {
+			err = db.Put(fmt.Sprintf("s%02d", i), "text")
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	table, err := NewEntriesTable(db, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//SELECT key, value FROM entries WHERE type = 'int64'
+	reader, err := table.Scan(context.Background(), []int{0, 2}, []Expr{{Column: "type", Op: "=", Value: "int64"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := reader.Schema(); len(s.Fields) != 2 || s.Fields[0].Name != "key" || s.Fields[1].Name != "value" {
+		t.Errorf("Bad projected schema: %v", s)
+	}
+	var rows, batches int
+	for reader.Next() {
+		batch := reader.Record()
+		batches++
+		for i := 0; i < batch.NumRows; i++ {
+			key, value := batch.Columns[0][i], batch.Columns[1][i]
+			if key != fmt.Sprintf("n%02d", rows*2) || value != fmt.Sprint(rows*2) {
+				t.Errorf("Bad row %d: %s = %s", rows, key, value)
+			}
+			rows++
+		}
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 13 || batches != 3 {
+		t.Errorf("Expected 13 rows in 3 batches, got %d in %d", rows, batches)
+	}
+
+	if _, err := table.Scan(context.Background(), []int{3}, nil); err == nil {
+		t.Errorf("Expected an error for a bad projection")
+	}
+	if _, err := table.Scan(context.Background(), nil, []Expr{{Column: "type", Op: "LIKE", Value: "int%"}}); err == nil {
+		t.Errorf("Expected an error for an unsupported operator")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reader, _ = table.Scan(ctx, nil, nil)
+	cancel()
+	if reader.Next() || reader.Err() == nil {
+		t.Errorf("Expected a cancelled scan to stop")
+	}
+}
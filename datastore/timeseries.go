@@ -0,0 +1,269 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// Часовий ряд зберігається у стисненні Gorilla: [кількість u16][перша мітка
+// i64][перше значення f64], а далі бітовий потік. Для міток пишеться дельта
+// дельт (0, 10+7, 110+9, 1110+12 або 1111+64 біти), для значень - XOR з
+// попереднім (0 - те саме значення, 10 - значущі біти у вікні попереднього
+// XOR, 11 - 5 біт нулів спереду, 6 біт довжини і самі значущі біти).
+
+const maxTimeSeriesSamples = 1024
+
+type bitWriter struct {
+	buf []byte
+	n   uint
+}
+
+func (w *bitWriter) writeBits(v uint64, count uint) {
+	for i := count; i > 0; i-- {
+		if w.n%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		if v>>(i-1)&1 != 0 {
+			w.buf[len(w.buf)-1] |= 0x80 >> (w.n % 8)
+		}
+		w.n++
+	}
+}
+
+type bitReader struct {
+	buf []byte
+	n   uint
+}
+
+func (r *bitReader) readBits(count uint) (uint64, error) {
+	if r.n+count > uint(len(r.buf))*8 {
+		return 0, fmt.Errorf("corrupted time series")
+	}
+	var v uint64
+	for ; count > 0; count-- {
+		v = v<<1 | uint64(r.buf[r.n/8]>>(7-r.n%8)&1)
+		r.n++
+	}
+	return v, nil
+}
+
+// діапазони дельти дельт: префікс, його довжина і ширина поля
+var dodBuckets = []struct {
+	prefix, prefixBits, bits uint64
+}{
+	{0b10, 2, 7},
+	{0b110, 3, 9},
+	{0b1110, 4, 12},
+}
+
+func encodeTimeSeries(ts []int64, values []float64) string {
+	res := binary.LittleEndian.AppendUint16(nil, uint16(len(ts)))
+	if len(ts) == 0 {
+		return string(res)
+	}
+	res = binary.LittleEndian.AppendUint64(res, uint64(ts[0]))
+	res = binary.LittleEndian.AppendUint64(res, math.Float64bits(values[0]))
+	w := &bitWriter{}
+	var prevDelta int64
+	prevLeading, prevTrailing := uint(64), uint(0)
+	for i := 1; i < len(ts); i++ {
+		delta := ts[i] - ts[i-1]
+		dod := delta - prevDelta
+		prevDelta = delta
+		if dod == 0 {
+			w.writeBits(0, 1)
+		} else {
+			written := false
+			for _, b := range dodBuckets {
+				if half := int64(1) << (b.bits - 1); dod >= -half+1 && dod <= half {
+					w.writeBits(b.prefix, uint(b.prefixBits))
+					w.writeBits(uint64(dod+half-1), uint(b.bits))
+					written = true
+					break
+				}
+			}
+			if !written {
+				w.writeBits(0b1111, 4)
+				w.writeBits(uint64(dod), 64)
+			}
+		}
+
+		xor := math.Float64bits(values[i]) ^ math.Float64bits(values[i-1])
+		if xor == 0 {
+			w.writeBits(0, 1)
+			continue
+		}
+		leading, trailing := uint(bits.LeadingZeros64(xor)), uint(bits.TrailingZeros64(xor))
+		if leading > 31 {
+			leading = 31
+		}
+		if prevLeading != 64 && leading >= prevLeading && trailing >= prevTrailing {
+			w.writeBits(0b10, 2)
+			w.writeBits(xor>>prevTrailing, 64-prevLeading-prevTrailing)
+			continue
+		}
+		meaningful := 64 - leading - trailing
+		w.writeBits(0b11, 2)
+		w.writeBits(uint64(leading), 5)
+		//довжина 64 не влазить у 6 біт і пишеться як 0
+		w.writeBits(uint64(meaningful%64), 6)
+		w.writeBits(xor>>trailing, meaningful)
+		prevLeading, prevTrailing = leading, trailing
+	}
+	return string(append(res, w.buf...))
+}
+
+func parseTimeSeries(data string) ([]int64, []float64, error) {
+	raw := []byte(data)
+	if len(raw) < 2 {
+		return nil, nil, fmt.Errorf("corrupted time series")
+	}
+	n := int(binary.LittleEndian.Uint16(raw))
+	if n > maxTimeSeriesSamples {
+		return nil, nil, fmt.Errorf("corrupted time series")
+	}
+	if n == 0 {
+		if len(raw) != 2 {
+			return nil, nil, fmt.Errorf("corrupted time series")
+		}
+		return nil, nil, nil
+	}
+	if len(raw) < 18 {
+		return nil, nil, fmt.Errorf("corrupted time series")
+	}
+	ts := []int64{int64(binary.LittleEndian.Uint64(raw[2:]))}
+	values := []float64{math.Float64frombits(binary.LittleEndian.Uint64(raw[10:]))}
+	r := &bitReader{buf: raw[18:]}
+	var prevDelta int64
+	var prevLeading, prevTrailing uint
+	window := false
+	for i := 1; i < n; i++ {
+		dod, err := readDeltaOfDelta(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		prevDelta += dod
+		if ts[i-1]+prevDelta <= ts[i-1] {
+			return nil, nil, fmt.Errorf("time series timestamps must increase")
+		}
+		ts = append(ts, ts[i-1]+prevDelta)
+
+		prev := math.Float64bits(values[i-1])
+		bit, err := r.readBits(1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if bit == 0 {
+			values = append(values, values[i-1])
+			continue
+		}
+		if bit, err = r.readBits(1); err != nil {
+			return nil, nil, err
+		}
+		if bit == 1 {
+			leading, err := r.readBits(5)
+			if err != nil {
+				return nil, nil, err
+			}
+			meaningful, err := r.readBits(6)
+			if err != nil {
+				return nil, nil, err
+			}
+			if meaningful == 0 {
+				meaningful = 64
+			}
+			if leading+meaningful > 64 {
+				return nil, nil, fmt.Errorf("corrupted time series")
+			}
+			prevLeading, prevTrailing = uint(leading), uint(64-leading-meaningful)
+			window = true
+		} else if !window {
+			return nil, nil, fmt.Errorf("corrupted time series")
+		}
+		xor, err := r.readBits(64 - prevLeading - prevTrailing)
+		if err != nil {
+			return nil, nil, err
+		}
+		values = append(values, math.Float64frombits(prev^xor<<prevTrailing))
+	}
+	//потік доповнюється нулями лише до цілого байта
+	if uint(len(r.buf))*8-r.n >= 8 {
+		return nil, nil, fmt.Errorf("corrupted time series")
+	}
+	return ts, values, nil
+}
+
+func readDeltaOfDelta(r *bitReader) (int64, error) {
+	for i := 0; i < 4; i++ {
+		bit, err := r.readBits(1)
+		if err != nil {
+			return 0, err
+		}
+		if bit == 0 {
+			if i == 0 {
+				return 0, nil
+			}
+			b := dodBuckets[i-1]
+			v, err := r.readBits(uint(b.bits))
+			if err != nil {
+				return 0, err
+			}
+			return int64(v) - (int64(1) << (b.bits - 1)) + 1, nil
+		}
+	}
+	v, err := r.readBits(64)
+	return int64(v), err
+}
+
+func validateTimeSeries(data string) error {
+	_, _, err := parseTimeSeries(data)
+	return err
+}
+
+func (e *Entry) timeSeries() ([]int64, []float64, error) {
+	if e.valueType != TIME_SERIES_TYPE {
+		return nil, nil, fmt.Errorf("wrong type of value")
+	}
+	return parseTimeSeries(e.value)
+}
+
+func NewTimeSeriesEntry(key string) *Entry {
+	return &Entry{key: key, valueType: TIME_SERIES_TYPE, value: encodeTimeSeries(nil, nil)}
+}
+
+// TSAppend adds a sample; timestamps must strictly increase and an entry
+// holds at most 1024 samples.
+func (e *Entry) TSAppend(ts int64, value float64) error {
+	times, values, err := e.timeSeries()
+	if err != nil {
+		return err
+	}
+	if len(times) == maxTimeSeriesSamples {
+		return fmt.Errorf("time series is full (%d samples)", maxTimeSeriesSamples)
+	}
+	//дельта має вміститися в int64
+	if last := len(times) - 1; last >= 0 && (ts <= times[last] || ts-times[last] < 0) {
+		return fmt.Errorf("timestamp %d is not after %d", ts, times[last])
+	}
+	e.value = encodeTimeSeries(append(times, ts), append(values, value))
+	return nil
+}
+
+// TSScan returns the samples with from <= ts <= to.
+func (e *Entry) TSScan(from, to int64) ([]int64, []float64, error) {
+	times, values, err := e.timeSeries()
+	if err != nil {
+		return nil, nil, err
+	}
+	var resTimes []int64
+	var resValues []float64
+	for i, ts := range times {
+		if ts >= from && ts <= to {
+			resTimes = append(resTimes, ts)
+			resValues = append(resValues, values[i])
+		}
+	}
+	return resTimes, resValues, nil
+}
@@ -0,0 +1,110 @@
+package datastore
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestTimeSeries(t *testing.T) {
+	e := NewTimeSeriesEntry("cpu")
+	rnd := rand.New(rand.NewSource(1))
+	var times []int64
+	var values []float64
+	ts := int64(1700000000000)
+	for i := 0; i < maxTimeSeriesSamples; i++ {
+		//рівні інтервали з тремтінням, іноді пропуск, і кілька особливих значень
+		ts += 10000 + int64(rnd.Intn(5)) - 2
+		if i%100 == 99 {
+			ts += 1 << 40
+		}
+		v := math.Round(rnd.Float64()*1000) / 10
+		switch i {
+		case 10, 11:
+			v = values[len(values)-1]
+		case 500:
+			v = math.Inf(-1)
+		case 501:
+			v = math.Copysign(0, -1)
+		case 502:
+			v = math.MaxFloat64
+		}
+		times, values = append(times, ts), append(values, v)
+		if err := e.TSAppend(ts, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.TSAppend(ts+1, 0); err == nil {
+		t.Errorf("Expected an error for a full series")
+	}
+
+	var decoded Entry
+	decoded.Decode(e.Encode())
+	gotTimes, gotValues, err := decoded.TSScan(math.MinInt64, math.MaxInt64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gotTimes) != len(times) {
+		t.Fatalf("Expected %d samples, got %d", len(times), len(gotTimes))
+	}
+	for i := range times {
+		if gotTimes[i] != times[i] || math.Float64bits(gotValues[i]) != math.Float64bits(values[i]) {
+			t.Errorf("Bad sample %d: (%d, %v), expected (%d, %v)", i, gotTimes[i], gotValues[i], times[i], values[i])
+		}
+	}
+	gotTimes, gotValues, _ = decoded.TSScan(times[100], times[199])
+	if len(gotTimes) != 100 || gotTimes[0] != times[100] || gotValues[99] != values[199] {
+		t.Errorf("Bad range scan: %d samples", len(gotTimes))
+	}
+
+	small := NewTimeSeriesEntry("s")
+	small.TSAppend(5, 1)
+	if err := small.TSAppend(5, 2); err == nil {
+		t.Errorf("Expected an error for a repeated timestamp")
+	}
+	if err := small.TSAppend(math.MinInt64, 2); err == nil {
+		t.Errorf("Expected an error for an earlier timestamp")
+	}
+	negative := NewTimeSeriesEntry("n")
+	negative.TSAppend(-5, 1)
+	if err := negative.TSAppend(math.MaxInt64, 2); err == nil {
+		t.Errorf("Expected an error for a delta past int64")
+	}
+	if _, err := EncodeInto(&Entry{key: "k", valueType: TIME_SERIES_TYPE, value: e.value[:len(e.value)-10]}, nil); err == nil {
+		t.Errorf("Expected an error for a truncated series")
+	}
+}
+
+func BenchmarkTimeSeries(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	times := make([]int64, maxTimeSeriesSamples)
+	values := make([]float64, maxTimeSeriesSamples)
+	ts, v := int64(1700000000), 50.0
+	for i := range times {
+		ts += 10
+		if rnd.Intn(4) == 0 {
+			v += float64(rnd.Intn(11)-5) / 10
+		}
+		times[i], values[i] = ts, v
+	}
+
+	//найщільніший окремий запис float64 у дереві - 8 байт бітів як int64
+	b.Run("separate float64 entries", func(b *testing.B) {
+		var buf []byte
+		for i := 0; i < b.N; i++ {
+			buf = buf[:0]
+			for j := range times {
+				buf, _ = EncodeInto(&Entry{key: fmt.Sprintf("cpu:%d", times[j]), valueType: INT64_TYPE, value: fmt.Sprint(int64(math.Float64bits(values[j])))}, buf)
+			}
+		}
+		b.ReportMetric(float64(len(buf))/maxTimeSeriesSamples, "bytes/sample")
+	})
+	b.Run("gorilla series", func(b *testing.B) {
+		var buf []byte
+		for i := 0; i < b.N; i++ {
+			buf, _ = EncodeInto(&Entry{key: "cpu", valueType: TIME_SERIES_TYPE, value: encodeTimeSeries(times, values)}, buf[:0])
+		}
+		b.ReportMetric(float64(len(buf))/maxTimeSeriesSamples, "bytes/sample")
+	})
+}
@@ -0,0 +1,89 @@
+package datastore
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// timestampOperator зберігає рівно 8 байт Unix-часу в наносекундах;
+// у Entry.value час лежить у форматі RFC 3339 з наносекундами, в UTC
+type timestampOperator struct{}
+
+func parseTimestamp(s string) (int64, error) {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return 0, err
+	}
+	//UnixNano не визначений поза 1678..2262 роками
+	if t.Before(time.Unix(0, -1<<63)) || t.After(time.Unix(0, 1<<63-1)) {
+		return 0, fmt.Errorf("timestamp %s out of range", s)
+	}
+	return t.UnixNano(), nil
+}
+
+func formatTimestamp(ns int64) string {
+	return time.Unix(0, ns).UTC().Format(time.RFC3339Nano)
+}
+
+func (s timestampOperator) Encode(e *Entry, dst []byte) []byte {
+	ns, err := parseTimestamp(e.value)
+	if err != nil {
+		panic(err)
+	}
+	res, offset := encodeKeyInto(e, 4, dst)
+	res[offset] = TIMESTAMP_TYPE
+	binary.LittleEndian.PutUint64(res[offset+TYPE_SIZE:], uint64(ns))
+	return res
+}
+
+func (s timestampOperator) EncodeInto(e *Entry, dst []byte) ([]byte, error) {
+	ns, err := parseTimestamp(e.value)
+	if err != nil {
+		return dst, err
+	}
+	dst = appendKey(dst, e, 4)
+	dst = append(dst, TIMESTAMP_TYPE)
+	return binary.LittleEndian.AppendUint64(dst, uint64(ns)), nil
+}
+
+func (s timestampOperator) Decode(input []byte, e *Entry) {
+	kl := len(e.key)
+	e.value = formatTimestamp(int64(binary.LittleEndian.Uint64(input[kl+TYPE_SIZE+8:])))
+}
+
+func (s timestampOperator) Read(in *bufio.Reader) (string, error) {
+	data, err := in.Peek(8)
+	if err != nil {
+		return "", err
+	}
+	return formatTimestamp(int64(binary.LittleEndian.Uint64(data))), nil
+}
+
+func NewTimestampEntry(key string, t time.Time) *Entry {
+	return &Entry{key: key, valueType: TIMESTAMP_TYPE, value: formatTimestamp(t.UnixNano())}
+}
+
+func (e *Entry) GetTime() (time.Time, error) {
+	if e.valueType != TIMESTAMP_TYPE {
+		return time.Time{}, fmt.Errorf("wrong type of value")
+	}
+	ns, err := parseTimestamp(e.value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, ns).UTC(), nil
+}
+
+func (db *Db) PutTime(key string, t time.Time) error {
+	return db.putEntry(NewTimestampEntry(key, t))
+}
+
+func (db *Db) GetTime(key string) (time.Time, error) {
+	e, err := db.getEntry(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return e.GetTime()
+}
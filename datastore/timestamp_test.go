@@ -0,0 +1,68 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTimestamp(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.FixedZone("EEST", 3*3600))
+	e := NewTimestampEntry("created", ts)
+	if e.value != "2024-05-01T09:30:00.123456789Z" {
+		t.Errorf("Bad timestamp text: %s", e.value)
+	}
+	var decoded Entry
+	decoded.Decode(e.Encode())
+	got, err := decoded.GetTime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(ts) || got.Nanosecond() != 123456789 {
+		t.Errorf("Timestamp lost precision: %v", got)
+	}
+	for _, bad := range []string{"", "2024-05-01", "yesterday", "1500-01-01T00:00:00Z"} {
+		if _, err := EncodeInto(&Entry{key: "k", valueType: TIMESTAMP_TYPE, value: bad}, nil); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.PutTime("created", ts); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := db.GetTime("created"); err != nil || !got.Equal(ts) {
+		t.Errorf("Bad time read: %v, %v", got, err)
+	}
+
+	t.Run("order by value", func(t *testing.T) {
+		base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		//як рядки "…:00.5Z" > "…:00Z" > "…:00.45Z" не впорядковуються
+		for key, offset := range map[string]time.Duration{"b": 500 * time.Millisecond, "a": 0, "c": 450 * time.Millisecond, "d": time.Hour} {
+			if err := db.PutTime(key, base.Add(offset)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		res, err := NewQuery().Where(func(e *Entry) bool { return e.key != "created" }).OrderBy("value", true).Execute(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var order string
+		for _, e := range res {
+			order += e.key
+		}
+		if order != "acbd" {
+			t.Errorf("Expected numeric timestamp order acbd, got %s", order)
+		}
+	})
+}
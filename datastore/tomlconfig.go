@@ -0,0 +1,252 @@
+package datastore
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Як і з YAML, бібліотеки TOML у дереві немає. Читаємо масив таблиць
+// [[entries]] з полями key, type, value (або value_base64); значення -
+// базові чи літеральні рядки або цілі числа.
+
+// ImportTOML writes the [[entries]] tables of the TOML file at tomlPath into
+// db. The whole file is parsed before the first write.
+func ImportTOML(tomlPath string, db *Db) error {
+	data, err := os.ReadFile(tomlPath)
+	if err != nil {
+		return err
+	}
+	records, err := parseTOMLEntries(string(data))
+	if err != nil {
+		return fmt.Errorf("%s: %v", tomlPath, err)
+	}
+	for _, record := range records {
+		if err := importRecord(db, record.Key, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportTOML writes every key starting with prefix to the file dst as
+// [[entries]] tables, in key order.
+func ExportTOML(db *Db, prefix string, dst string) error {
+	entries, err := db.entries()
+	if err != nil {
+		return err
+	}
+	var sb strings.Builder
+	for _, e := range entries {
+		if !strings.HasPrefix(e.key, prefix) {
+			continue
+		}
+		if sb.Len() != 0 {
+			sb.WriteByte('\n')
+		}
+		fmt.Fprintf(&sb, "[[entries]]\nkey = %s\ntype = %s\n", tomlQuote(e.key), tomlQuote(e.Type()))
+		if utf8.ValidString(e.value) {
+			fmt.Fprintf(&sb, "value = %s\n", tomlQuote(e.value))
+		} else {
+			fmt.Fprintf(&sb, "value_base64 = %q\n", base64.StdEncoding.EncodeToString([]byte(e.value)))
+		}
+	}
+	return os.WriteFile(dst, []byte(sb.String()), 0o644)
+}
+
+// tomlQuote пише базовий рядок TOML; strconv.Quote не годиться, бо TOML
+// не знає \x, \a і \v
+func tomlQuote(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '\r':
+			sb.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&sb, `\u%04X`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// readTOMLString читає рядок на початку s і повертає його разом з рештою s
+func readTOMLString(s string) (string, string, error) {
+	if strings.HasPrefix(s, `"""`) || strings.HasPrefix(s, "'''") {
+		return "", "", fmt.Errorf("multi-line strings are not supported")
+	}
+	if s[0] == '\'' {
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated string")
+		}
+		return s[1 : end+1], s[end+2:], nil
+	}
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			return sb.String(), s[i+1:], nil
+		case c < 0x20 && c != '\t' || c == 0x7f:
+			return "", "", fmt.Errorf("control character in string")
+		case c != '\\':
+			sb.WriteByte(c)
+		case i+1 == len(s):
+			return "", "", fmt.Errorf("unterminated string")
+		default:
+			i++
+			switch s[i] {
+			case 'b':
+				sb.WriteByte('\b')
+			case 't':
+				sb.WriteByte('\t')
+			case 'n':
+				sb.WriteByte('\n')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'r':
+				sb.WriteByte('\r')
+			case '"', '\\':
+				sb.WriteByte(s[i])
+			case 'u', 'U':
+				size := 4
+				if s[i] == 'U' {
+					size = 8
+				}
+				if i+size >= len(s) {
+					return "", "", fmt.Errorf("bad unicode escape")
+				}
+				code, err := strconv.ParseUint(s[i+1:i+1+size], 16, 32)
+				if err != nil || !utf8.ValidRune(rune(code)) {
+					return "", "", fmt.Errorf("bad unicode escape")
+				}
+				sb.WriteRune(rune(code))
+				i += size
+			default:
+				return "", "", fmt.Errorf("bad escape \\%c", s[i])
+			}
+		}
+	}
+	return "", "", fmt.Errorf("unterminated string")
+}
+
+func isBareTOMLKey(s string) bool {
+	for _, c := range []byte(s) {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// parseTOMLPair розбирає рядок key = value разом з коментарем у кінці
+func parseTOMLPair(line string) (string, string, error) {
+	var key, rest string
+	var err error
+	if line[0] == '"' || line[0] == '\'' {
+		if key, rest, err = readTOMLString(line); err != nil {
+			return "", "", err
+		}
+	} else {
+		eq := strings.IndexByte(line, '=')
+		if eq < 0 {
+			return "", "", fmt.Errorf("expected key = value, got %q", line)
+		}
+		key, rest = strings.TrimRight(line[:eq], " \t"), line[eq:]
+		if !isBareTOMLKey(key) {
+			return "", "", fmt.Errorf("bad key %q", key)
+		}
+	}
+	rest = strings.TrimLeft(rest, " \t")
+	if !strings.HasPrefix(rest, "=") {
+		return "", "", fmt.Errorf("expected = after %q", key)
+	}
+	rest = strings.TrimLeft(rest[1:], " \t")
+	if rest == "" {
+		return "", "", fmt.Errorf("missing value of %q", key)
+	}
+
+	var value string
+	if rest[0] == '"' || rest[0] == '\'' {
+		if value, rest, err = readTOMLString(rest); err != nil {
+			return "", "", err
+		}
+	} else {
+		//ціле число: знак, цифри і підкреслення між ними
+		end := strings.IndexAny(rest, " \t#")
+		if end < 0 {
+			end = len(rest)
+		}
+		value, rest = rest[:end], rest[end:]
+		n, err := strconv.ParseInt(value, 0, 64)
+		if err != nil || strings.HasPrefix(value, "0") && len(value) > 1 && value[1] >= '0' && value[1] <= '9' {
+			return "", "", fmt.Errorf("unsupported value %q of %q", value, key)
+		}
+		value = strconv.FormatInt(n, 10)
+	}
+	if rest = strings.TrimLeft(rest, " \t"); rest != "" && rest[0] != '#' {
+		return "", "", fmt.Errorf("unexpected %q after the value of %q", rest, key)
+	}
+	return key, value, nil
+}
+
+func parseTOMLEntries(src string) ([]exportRecord, error) {
+	var records []exportRecord
+	var seen map[string]bool
+	for n, line := range strings.Split(src, "\n") {
+		line = strings.Trim(line, " \t\r")
+		var err error
+		switch {
+		case line == "" || line[0] == '#':
+		case line[0] == '[':
+			header := line
+			if i := strings.IndexByte(line, '#'); i >= 0 {
+				header = strings.TrimRight(line[:i], " \t")
+			}
+			if !strings.HasPrefix(header, "[[") || !strings.HasSuffix(header, "]]") || strings.TrimSpace(header[2:len(header)-2]) != "entries" {
+				err = fmt.Errorf("unsupported table %s", header)
+				break
+			}
+			records = append(records, exportRecord{})
+			seen = make(map[string]bool)
+		case len(records) == 0:
+			err = fmt.Errorf("expected [[entries]]")
+		default:
+			var k, v string
+			if k, v, err = parseTOMLPair(line); err != nil {
+				break
+			}
+			if seen[k] {
+				err = fmt.Errorf("duplicate key %q", k)
+				break
+			}
+			seen[k] = true
+			err = setRecordField(&records[len(records)-1], k, v)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n+1, err)
+		}
+	}
+	return records, nil
+}
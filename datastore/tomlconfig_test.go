@@ -0,0 +1,109 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImportExportTOML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src, err := NewDb(filepath.Join(dir, "src"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	values := map[string]string{
+		"app/eq":       "a = b == c",
+		"app/brackets": "[[entries]]\n[entries]\nkey = \"x\"",
+		"app/quotes":   `say "hi" \ # not a comment`,
+		"app/[[key]]":  "key = value",
+		"app/control":  "tab\tbell\a\x7f\r\n",
+		"app/unicode":  "привіт ✓",
+		"app/empty":    "",
+	}
+	for key, value := range values {
+		if err := src.Put(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := src.PutInt64("app/workers", -16); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.putEntry(&Entry{key: "app/tags", valueType: GSET_TYPE, value: encodeStringSet([]string{"a", "b"})}); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.Put("other", "skipped"); err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(dir, "export.toml")
+	if err := ExportTOML(src, "app/", file); err != nil {
+		t.Fatal(err)
+	}
+	dst, err := NewDb(filepath.Join(dir, "dst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+	if err := ImportTOML(file, dst); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := src.entries()
+	got, _ := dst.entries()
+	if len(got) != len(want)-1 {
+		t.Fatalf("Expected %d entries, got %d", len(want)-1, len(got))
+	}
+	for i, e := range got {
+		if *e != *want[i] {
+			t.Errorf("Bad round trip: expected %v, got %v", want[i], e)
+		}
+	}
+
+	handWritten := `# сервіс
+[[entries]]
+key = 'C:\path'  # літеральний рядок без екранування
+type = "string"
+value = '=[[x]]='
+
+[[ entries ]]
+"key" = "limit"
+type = "int64"
+value = 1_000
+`
+	if err := os.WriteFile(file, []byte(handWritten), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportTOML(file, dst); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := dst.Get(`C:\path`); err != nil || v != "=[[x]]=" {
+		t.Errorf("Bad literal string import: %q, %v", v, err)
+	}
+	if v, err := dst.GetInt64("limit"); err != nil || v != 1000 {
+		t.Errorf("Bad integer import: %d, %v", v, err)
+	}
+
+	for _, bad := range []string{
+		"key = \"a\"\n",
+		"[entries]\nkey = \"a\"\n",
+		"[[entries]]\nkey = \"a\"\nkey = \"b\"\n",
+		"[[entries]]\nkey = \"a\" type = \"string\"\n",
+		"[[entries]]\nkey = \"a\nvalue = \"b\"\n",
+		"[[entries]]\nkey = \"a\"\nvalue = true\n",
+		"[[entries]]\nkey = \"a\"\ncolour = \"red\"\n",
+	} {
+		if err := os.WriteFile(file, []byte(bad), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := ImportTOML(file, dst); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
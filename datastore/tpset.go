@@ -0,0 +1,125 @@
+package datastore
+
+import "fmt"
+
+// TwoPSet is a two-phase set: a grow-only add set plus a grow-only remove
+// set. An element is present while it is added and not removed; once
+// removed it can never come back.
+type TwoPSet struct {
+	adds    []string
+	removes []string
+}
+
+func (s *TwoPSet) Contains(elem string) bool {
+	return setContains(s.adds, elem) && !setContains(s.removes, elem)
+}
+
+func (s *TwoPSet) Elements() []string {
+	var elems []string
+	for _, elem := range s.adds {
+		if !setContains(s.removes, elem) {
+			elems = append(elems, elem)
+		}
+	}
+	return elems
+}
+
+// розмітка: [len u32][множина доданих][множина видалених]
+func (s *TwoPSet) encode() string {
+	res := appendLengthPrefixed(nil, encodeStringSet(s.adds))
+	return string(res) + encodeStringSet(s.removes)
+}
+
+func parseTwoPSet(data string) (*TwoPSet, error) {
+	adds, rest, err := readLengthPrefixed(data)
+	if err != nil {
+		return nil, err
+	}
+	s := &TwoPSet{}
+	if s.adds, err = parseStringSet(adds); err != nil {
+		return nil, err
+	}
+	if s.removes, err = parseStringSet(rest); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func validateTwoPSet(data string) error {
+	_, err := parseTwoPSet(data)
+	return err
+}
+
+func updateTwoPSet(db *Db, key string, fn func(s *TwoPSet) error) error {
+	_, err := db.updateEntry(key, func(cur *Entry) (*Entry, error) {
+		s := &TwoPSet{}
+		if cur != nil {
+			if cur.valueType != TPSET_TYPE {
+				return nil, fmt.Errorf("wrong type of value")
+			}
+			var err error
+			if s, err = parseTwoPSet(cur.value); err != nil {
+				return nil, err
+			}
+		}
+		if err := fn(s); err != nil {
+			return nil, err
+		}
+		value := s.encode()
+		if cur != nil && value == cur.value {
+			return nil, nil
+		}
+		return &Entry{valueType: TPSET_TYPE, value: value}, nil
+	})
+	return err
+}
+
+func TwoPSetAdd(db *Db, key, elem string) error {
+	return updateTwoPSet(db, key, func(s *TwoPSet) error {
+		s.adds = append(s.adds, elem)
+		return nil
+	})
+}
+
+// TwoPSetRemove removes elem for good. Like in the 2P-Set spec, only an
+// element that is present can be removed; otherwise it returns ErrNotFound.
+func TwoPSetRemove(db *Db, key, elem string) error {
+	return updateTwoPSet(db, key, func(s *TwoPSet) error {
+		if !s.Contains(elem) {
+			return ErrNotFound
+		}
+		s.removes = append(s.removes, elem)
+		return nil
+	})
+}
+
+// TwoPSetMerge merges another replica's state into the set at key.
+func TwoPSetMerge(db *Db, key string, other *TwoPSet) error {
+	return updateTwoPSet(db, key, func(s *TwoPSet) error {
+		s.adds = append(s.adds, other.adds...)
+		s.removes = append(s.removes, other.removes...)
+		return nil
+	})
+}
+
+func TwoPSetContains(db *Db, key, elem string) (bool, error) {
+	s, err := TwoPSetState(db, key)
+	if err != nil {
+		return false, err
+	}
+	return s.Contains(elem), nil
+}
+
+func TwoPSetState(db *Db, key string) (*TwoPSet, error) {
+	e, err := db.getEntry(key)
+	if err == ErrNotFound {
+		return &TwoPSet{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if e.valueType != TPSET_TYPE {
+		return nil, fmt.Errorf("wrong type of value")
+	}
+	return parseTwoPSet(e.value)
+}
@@ -0,0 +1,68 @@
+package datastore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTwoPSet(t *testing.T) {
+	a := newTestDb(t)
+	b := newTestDb(t)
+
+	for _, elem := range []string{"x", "y"} {
+		if err := TwoPSetAdd(a, "set", elem); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := TwoPSetRemove(a, "set", "x"); err != nil {
+		t.Fatal(err)
+	}
+	// видалене назавжди лишається видаленим
+	if err := TwoPSetAdd(a, "set", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := TwoPSetContains(a, "set", "x"); err != nil || ok {
+		t.Errorf("Expected re-added x to stay removed, got %v, %v", ok, err)
+	}
+	if err := TwoPSetRemove(a, "set", "x"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound removing a removed element, got %v", err)
+	}
+	if err := TwoPSetRemove(a, "set", "missing"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound removing a missing element, got %v", err)
+	}
+
+	// b додає x і z незалежно, поки a вже видалила x
+	if err := TwoPSetAdd(b, "set", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := TwoPSetAdd(b, "set", "z"); err != nil {
+		t.Fatal(err)
+	}
+	stateA, err := TwoPSetState(a, "set")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateB, err := TwoPSetState(b, "set")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := TwoPSetMerge(a, "set", stateB); err != nil {
+		t.Fatal(err)
+	}
+	if err := TwoPSetMerge(b, "set", stateA); err != nil {
+		t.Fatal(err)
+	}
+	for _, db := range []*Db{a, b} {
+		s, err := TwoPSetState(db, "set")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(s.Elements(), []string{"y", "z"}) {
+			t.Errorf("Expected remove to win over concurrent add, got %q", s.Elements())
+		}
+	}
+
+	if _, err := parseTwoPSet("\x01\x00"); err == nil {
+		t.Error("Expected error for corrupted 2p-set")
+	}
+}
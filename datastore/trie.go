@@ -0,0 +1,185 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// Trie зберігається плоским масивом вузлів у прямому порядку обходу
+// (діти - за зростанням байта ребра): [вузлів u32], корінь як [прапорець],
+// а кожен інший вузол як [батько u32][байт ребра][прапорець]. Якщо
+// прапорець 1, далі йде значення з префіксом довжини. Батько завжди
+// стоїть раніше за дитину, тож дерево відновлюється за один прохід.
+
+type trieNode struct {
+	edge     byte
+	hasValue bool
+	value    string
+	children []*trieNode
+}
+
+func (n *trieNode) child(edge byte, create bool) *trieNode {
+	i := sort.Search(len(n.children), func(i int) bool { return n.children[i].edge >= edge })
+	if i < len(n.children) && n.children[i].edge == edge {
+		return n.children[i]
+	}
+	if !create {
+		return nil
+	}
+	c := &trieNode{edge: edge}
+	n.children = append(n.children, nil)
+	copy(n.children[i+1:], n.children[i:])
+	n.children[i] = c
+	return c
+}
+
+func (n *trieNode) find(word string) *trieNode {
+	for i := 0; i < len(word) && n != nil; i++ {
+		n = n.child(word[i], false)
+	}
+	return n
+}
+
+func appendTrieValue(dst []byte, n *trieNode) []byte {
+	if !n.hasValue {
+		return append(dst, 0)
+	}
+	return appendLengthPrefixed(append(dst, 1), n.value)
+}
+
+func (n *trieNode) encode() string {
+	body := appendTrieValue(nil, n)
+	count := 1
+	var walk func(p *trieNode, index int)
+	walk = func(p *trieNode, index int) {
+		for _, c := range p.children {
+			body = binary.LittleEndian.AppendUint32(body, uint32(index))
+			body = appendTrieValue(append(body, c.edge), c)
+			count++
+			walk(c, count-1)
+		}
+	}
+	walk(n, 0)
+	return string(binary.LittleEndian.AppendUint32(nil, uint32(count))) + string(body)
+}
+
+func readTrieValue(data string, n *trieNode) (string, error) {
+	if len(data) < 1 || data[0] > 1 {
+		return "", fmt.Errorf("corrupted trie node")
+	}
+	if data[0] == 0 {
+		return data[1:], nil
+	}
+	n.hasValue = true
+	value, rest, err := readLengthPrefixed(data[1:])
+	n.value = value
+	return rest, err
+}
+
+func parseTrie(data string) (*trieNode, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("corrupted trie")
+	}
+	count := int(binary.LittleEndian.Uint32([]byte(data[:4])))
+	if count < 1 || count > len(data) {
+		return nil, fmt.Errorf("corrupted trie")
+	}
+	nodes := make([]*trieNode, 0, count)
+	root := &trieNode{}
+	rest, err := readTrieValue(data[4:], root)
+	if err != nil {
+		return nil, err
+	}
+	nodes = append(nodes, root)
+	for i := 1; i < count; i++ {
+		if len(rest) < 5 {
+			return nil, fmt.Errorf("corrupted trie node %d", i)
+		}
+		parent := int(binary.LittleEndian.Uint32([]byte(rest[:4])))
+		n := &trieNode{edge: rest[4]}
+		if parent >= i {
+			return nil, fmt.Errorf("corrupted trie node %d", i)
+		}
+		//діти одного батька мають іти за зростанням ребра
+		p := nodes[parent]
+		if len(p.children) > 0 && p.children[len(p.children)-1].edge >= n.edge {
+			return nil, fmt.Errorf("corrupted trie node %d", i)
+		}
+		if rest, err = readTrieValue(rest[5:], n); err != nil {
+			return nil, err
+		}
+		p.children = append(p.children, n)
+		nodes = append(nodes, n)
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("corrupted trie")
+	}
+	return root, nil
+}
+
+func validateTrie(data string) error {
+	_, err := parseTrie(data)
+	return err
+}
+
+func (e *Entry) trie() (*trieNode, error) {
+	if e.valueType != TRIE_TYPE {
+		return nil, fmt.Errorf("wrong type of value")
+	}
+	return parseTrie(e.value)
+}
+
+func NewTrieEntry(key string) *Entry {
+	return &Entry{key: key, valueType: TRIE_TYPE, value: (&trieNode{}).encode()}
+}
+
+// TrieInsert sets the value for word, replacing an existing one.
+func (e *Entry) TrieInsert(word, value string) error {
+	root, err := e.trie()
+	if err != nil {
+		return err
+	}
+	n := root
+	for i := 0; i < len(word); i++ {
+		n = n.child(word[i], true)
+	}
+	n.hasValue, n.value = true, value
+	e.value = root.encode()
+	return nil
+}
+
+func (e *Entry) TrieLookup(word string) (string, bool, error) {
+	root, err := e.trie()
+	if err != nil {
+		return "", false, err
+	}
+	n := root.find(word)
+	if n == nil || !n.hasValue {
+		return "", false, nil
+	}
+	return n.value, true, nil
+}
+
+// TriePrefixSearch returns the stored words starting with prefix in
+// lexicographic byte order.
+func (e *Entry) TriePrefixSearch(prefix string) ([]string, error) {
+	root, err := e.trie()
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	var walk func(n *trieNode, word []byte)
+	walk = func(n *trieNode, word []byte) {
+		if n.hasValue {
+			res = append(res, string(word))
+		}
+		for _, c := range n.children {
+			walk(c, append(word, c.edge))
+		}
+	}
+	if n := root.find(prefix); n != nil {
+		walk(n, []byte(prefix))
+	}
+	return res, nil
+}
@@ -0,0 +1,63 @@
+package datastore
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestTrie(t *testing.T) {
+	e := NewTrieEntry("dict")
+	rnd := rand.New(rand.NewSource(1))
+	words := make(map[string]string)
+	for len(words) < 1000 {
+		var sb strings.Builder
+		for n := 1 + rnd.Intn(6); n > 0; n-- {
+			sb.WriteByte("abcd"[rnd.Intn(4)])
+		}
+		word := sb.String()
+		words[word] = fmt.Sprint(len(words))
+		if err := e.TrieInsert(word, words[word]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.TrieInsert("", "empty"); err != nil {
+		t.Fatal(err)
+	}
+	words[""] = "empty"
+
+	var decoded Entry
+	decoded.Decode(e.Encode())
+	for word, value := range words {
+		if got, ok, err := decoded.TrieLookup(word); err != nil || !ok || got != value {
+			t.Errorf("Bad lookup of %q: %q, %v, %v", word, got, ok, err)
+		}
+	}
+	if _, ok, _ := decoded.TrieLookup("abcde"); ok {
+		t.Errorf("Expected a missing word")
+	}
+
+	for _, prefix := range []string{"", "a", "db", "cab", "abcdab", "e"} {
+		var expected []string
+		for word := range words {
+			if strings.HasPrefix(word, prefix) {
+				expected = append(expected, word)
+			}
+		}
+		sort.Strings(expected)
+		got, err := decoded.TriePrefixSearch(prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(expected) {
+			t.Errorf("Bad prefix search %q: expected %d words, got %d", prefix, len(expected), len(got))
+		}
+	}
+
+	//батько після дитини
+	if _, err := EncodeInto(&Entry{key: "k", valueType: TRIE_TYPE, value: "\x02\x00\x00\x00\x00\x01\x00\x00\x00a\x00"}, nil); err == nil {
+		t.Errorf("Expected an error for a forward parent pointer")
+	}
+}
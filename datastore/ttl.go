@@ -0,0 +1,82 @@
+package datastore
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	softExpiryAnnotation = "ttl.soft"
+	hardExpiryAnnotation = "ttl.hard"
+)
+
+// підміняється в тестах
+var timeNow = time.Now
+
+// SetTTL stores expiry times relative to now in the entry annotations. After
+// softTTL the entry is stale; after hardTTL it is no longer returned at all.
+// A zero duration leaves that expiry unset.
+func (e *Entry) SetTTL(softTTL, hardTTL time.Duration) {
+	now := timeNow()
+	if softTTL > 0 {
+		e.Annotate(softExpiryAnnotation, strconv.FormatInt(now.Add(softTTL).UnixNano(), 10))
+	}
+	if hardTTL > 0 {
+		e.Annotate(hardExpiryAnnotation, strconv.FormatInt(now.Add(hardTTL).UnixNano(), 10))
+	}
+}
+
+func (e *Entry) expiry(name string) (time.Time, bool) {
+	if e.annotations == "" {
+		return time.Time{}, false
+	}
+	value, ok := e.Annotation(name)
+	if !ok {
+		return time.Time{}, false
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, n), true
+}
+
+func (e *Entry) SoftExpiry() (time.Time, bool) {
+	return e.expiry(softExpiryAnnotation)
+}
+
+func (e *Entry) HardExpiry() (time.Time, bool) {
+	return e.expiry(hardExpiryAnnotation)
+}
+
+func (e *Entry) IsSoftExpired() bool {
+	t, ok := e.SoftExpiry()
+	return ok && !timeNow().Before(t)
+}
+
+func (e *Entry) IsHardExpired() bool {
+	t, ok := e.HardExpiry()
+	return ok && !timeNow().Before(t)
+}
+
+func (db *Db) PutWithTTL(key, value string, softTTL, hardTTL time.Duration) error {
+	e := &Entry{key: key, valueType: STRING_TYPE, value: value}
+	e.SetTTL(softTTL, hardTTL)
+	return db.putEntry(e)
+}
+
+// GetWithStale returns the latest entry for key and whether it is past its
+// soft expiry. Entries past their hard expiry are reported as ErrNotFound.
+func (db *Db) GetWithStale(key string) (*Entry, bool, error) {
+	if db.hotKeys != nil {
+		db.hotKeys.record(key)
+	}
+	e, err := db.getEntry(key)
+	if err != nil {
+		return nil, false, err
+	}
+	if e.valueType == SOFT_DELETED_TYPE {
+		return nil, false, ErrNotFound
+	}
+	return e, e.IsSoftExpired(), nil
+}
@@ -0,0 +1,111 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDb_GetWithStale(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Unix(1700000000, 0)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := db.Put("key", "old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.PutWithTTL("key", "value", time.Minute, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	e, stale, err := db.GetWithStale("key")
+	if err != nil || stale || e.Value() != "value" {
+		t.Errorf("Expected fresh entry, got %v, %v, %v", e, stale, err)
+	}
+
+	now = now.Add(30 * time.Minute)
+	e, stale, err = db.GetWithStale("key")
+	if err != nil || !stale || e.Value() != "value" {
+		t.Errorf("Expected stale entry, got %v, %v, %v", e, stale, err)
+	}
+	if !e.IsSoftExpired() || e.IsHardExpired() {
+		t.Error("Expected entry to be past soft TTL only")
+	}
+	if value, err := db.Get("key"); err != nil || value != "value" {
+		t.Errorf("Expected Get to serve stale value, got %q, %v", value, err)
+	}
+
+	// після жорсткого TTL стара версія ключа теж не повертається
+	now = now.Add(time.Hour)
+	if _, _, err := db.GetWithStale("key"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound after hard TTL, got %v", err)
+	}
+	if _, err := db.Get("key"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound from Get after hard TTL, got %v", err)
+	}
+	if entries, err := db.entries(); err != nil || len(entries) != 0 {
+		t.Errorf("Expected no live entries, got %v, %v", entries, err)
+	}
+}
+
+func TestDb_TTLMerge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Unix(1700000000, 0)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	db, err := NewDb(dir, WithMaxFileSize(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := db.Put("expired", "old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.PutWithTTL("expired", "value", 0, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.PutWithTTL("live", "value", time.Minute, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(10 * time.Minute)
+	for _, key := range []string{"a", "b", "c", "d", "e", "f"} {
+		if err := db.Put(key, "filler value to roll segments"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "segment-0")); err != nil {
+		t.Fatalf("Expected segments to be merged: %v", err)
+	}
+	if _, err := db.Get("expired"); err != ErrNotFound {
+		t.Errorf("Expected expired key to stay hidden after merge, got %v", err)
+	}
+	e, stale, err := db.GetWithStale("live")
+	if err != nil || !stale {
+		t.Fatalf("Expected merged entry to keep its TTL, got %v, %v", stale, err)
+	}
+	if _, ok := e.HardExpiry(); !ok {
+		t.Error("Expected hard expiry to survive the merge")
+	}
+}
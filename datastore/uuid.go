@@ -0,0 +1,88 @@
+package datastore
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+)
+
+// uuidOperator зберігає рівно 16 сирих байт; у Entry.value UUID лежить
+// у звичному вигляді xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
+type uuidOperator struct{}
+
+func formatUUID(id [16]byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:])
+}
+
+func parseUUID(s string) ([16]byte, error) {
+	var id [16]byte
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return id, fmt.Errorf("bad uuid %q", s)
+	}
+	digits := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	if _, err := hex.Decode(id[:], []byte(digits)); err != nil {
+		return id, fmt.Errorf("bad uuid %q: %v", s, err)
+	}
+	return id, nil
+}
+
+func (s uuidOperator) Encode(e *Entry, dst []byte) []byte {
+	id, err := parseUUID(e.value)
+	if err != nil {
+		panic(err)
+	}
+	res, offset := encodeKeyInto(e, 12, dst)
+	res[offset] = UUID_TYPE
+	copy(res[offset+TYPE_SIZE:], id[:])
+	return res
+}
+
+func (s uuidOperator) EncodeInto(e *Entry, dst []byte) ([]byte, error) {
+	id, err := parseUUID(e.value)
+	if err != nil {
+		return dst, err
+	}
+	dst = appendKey(dst, e, 12)
+	dst = append(dst, UUID_TYPE)
+	return append(dst, id[:]...), nil
+}
+
+func (s uuidOperator) Decode(input []byte, e *Entry) {
+	kl := len(e.key)
+	var id [16]byte
+	copy(id[:], input[kl+TYPE_SIZE+8:])
+	e.value = formatUUID(id)
+}
+
+func (s uuidOperator) Read(in *bufio.Reader) (string, error) {
+	data, err := in.Peek(16)
+	if err != nil {
+		return "", err
+	}
+	var id [16]byte
+	copy(id[:], data)
+	return formatUUID(id), nil
+}
+
+func NewUUIDEntry(key string, id [16]byte) *Entry {
+	return &Entry{key: key, valueType: UUID_TYPE, value: formatUUID(id)}
+}
+
+func (e *Entry) GetUUID() ([16]byte, error) {
+	if e.valueType != UUID_TYPE {
+		return [16]byte{}, fmt.Errorf("wrong type of value")
+	}
+	return parseUUID(e.value)
+}
+
+func (db *Db) PutUUID(key string, id [16]byte) error {
+	return db.putEntry(NewUUIDEntry(key, id))
+}
+
+func (db *Db) GetUUID(key string) ([16]byte, error) {
+	e, err := db.getEntry(key)
+	if err != nil {
+		return [16]byte{}, err
+	}
+	return e.GetUUID()
+}
@@ -0,0 +1,85 @@
+package datastore
+
+import (
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestUUID(t *testing.T) {
+	id := [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+	e := NewUUIDEntry("id", id)
+	if e.value != "123e4567-e89b-12d3-a456-426614174000" {
+		t.Errorf("Bad uuid text: %s", e.value)
+	}
+	data := e.Encode()
+	if len(data) != 8+len("id")+TYPE_SIZE+16 {
+		t.Errorf("Expected 16 bytes of value, got a %d byte record", len(data))
+	}
+	var decoded Entry
+	decoded.Decode(data)
+	if got, err := decoded.GetUUID(); err != nil || got != id {
+		t.Errorf("Bad uuid decoded: %x, %v", got, err)
+	}
+
+	upper := &Entry{key: "id", valueType: UUID_TYPE, value: strings.ToUpper(e.value)}
+	if got, err := upper.GetUUID(); err != nil || got != id {
+		t.Errorf("Expected upper case uuids to parse: %x, %v", got, err)
+	}
+	for _, bad := range []string{"", "123e4567e89b12d3a456426614174000", "123e4567-e89b-12d3-a456-42661417400g", "123e4567-e89b-12d3-a456_426614174000"} {
+		if _, err := EncodeInto(&Entry{key: "id", valueType: UUID_TYPE, value: bad}, nil); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.PutUUID("user", id); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := db.GetUUID("user"); err != nil || got != id {
+		t.Errorf("Bad uuid read: %x, %v", got, err)
+	}
+	if val, vType, err := db.GetValue("user"); err != nil || vType != "uuid" || val != e.value {
+		t.Errorf("Bad uuid value: %s %s %v", val, vType, err)
+	}
+}
+
+func BenchmarkUUIDStorage(b *testing.B) {
+	const n = 1000000
+	ids := make([][16]byte, 1000)
+	for i := range ids {
+		rand.Read(ids[i][:])
+	}
+	run := func(b *testing.B, entry func(id [16]byte) *Entry) {
+		size := 0
+		var buf []byte
+		for i := 0; i < b.N; i++ {
+			size = 0
+			for j := 0; j < n; j++ {
+				var err error
+				if buf, err = EncodeInto(entry(ids[j%len(ids)]), buf[:0]); err != nil {
+					b.Fatal(err)
+				}
+				size += len(buf)
+			}
+		}
+		b.ReportMetric(float64(size)/n, "bytes/entry")
+	}
+	b.Run("string", func(b *testing.B) {
+		run(b, func(id [16]byte) *Entry { return &Entry{key: "u", valueType: STRING_TYPE, value: formatUUID(id)} })
+	})
+	b.Run("uuid", func(b *testing.B) {
+		run(b, func(id [16]byte) *Entry { return NewUUIDEntry("u", id) })
+	})
+}
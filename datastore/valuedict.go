@@ -0,0 +1,134 @@
+package datastore
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// dictStringOperator зберігає рівно 2 байти номера значення у словнику
+type dictStringOperator struct{}
+
+func (s dictStringOperator) Encode(e *Entry, dst []byte) []byte {
+	n, err := strconv.ParseUint(e.value, 10, 16)
+	if err != nil {
+		panic(err)
+	}
+	res, offset := encodeKeyInto(e, -2, dst)
+	res[offset] = DICT_STRING_TYPE
+	binary.LittleEndian.PutUint16(res[offset+TYPE_SIZE:], uint16(n))
+	return res
+}
+
+func (s dictStringOperator) EncodeInto(e *Entry, dst []byte) ([]byte, error) {
+	n, err := strconv.ParseUint(e.value, 10, 16)
+	if err != nil {
+		return dst, err
+	}
+	dst = appendKey(dst, e, -2)
+	dst = append(dst, DICT_STRING_TYPE)
+	return binary.LittleEndian.AppendUint16(dst, uint16(n)), nil
+}
+
+func (s dictStringOperator) Decode(input []byte, e *Entry) {
+	kl := len(e.key)
+	e.value = strconv.FormatUint(uint64(binary.LittleEndian.Uint16(input[kl+TYPE_SIZE+8:])), 10)
+}
+
+func (s dictStringOperator) Read(in *bufio.Reader) (string, error) {
+	data, err := in.Peek(2)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(uint64(binary.LittleEndian.Uint16(data)), 10), nil
+}
+
+// ValueDictionary maps up to 65536 distinct string values to uint16 codes.
+type ValueDictionary struct {
+	values []string
+	codes  map[string]uint16
+}
+
+func NewValueDictionary(values []string) (*ValueDictionary, error) {
+	if len(values) > math.MaxUint16+1 {
+		return nil, fmt.Errorf("too many values for a dictionary: %d", len(values))
+	}
+	d := &ValueDictionary{values: append([]string(nil), values...), codes: make(map[string]uint16, len(values))}
+	for i, v := range d.values {
+		if _, ok := d.codes[v]; ok {
+			return nil, fmt.Errorf("duplicate dictionary value %q", v)
+		}
+		d.codes[v] = uint16(i)
+	}
+	return d, nil
+}
+
+// BuildValueDictionary collects the distinct string values stored in db,
+// sorted, and fails if there are more than a dictionary can hold.
+func BuildValueDictionary(db *Db) (*ValueDictionary, error) {
+	entries, err := db.entries()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var values []string
+	for _, e := range entries {
+		if e.valueType == STRING_TYPE && !seen[e.value] {
+			seen[e.value] = true
+			values = append(values, e.value)
+		}
+	}
+	sort.Strings(values)
+	return NewValueDictionary(values)
+}
+
+func (d *ValueDictionary) Len() int {
+	return len(d.values)
+}
+
+// Values returns the values in code order, e.g. to store the dictionary.
+func (d *ValueDictionary) Values() []string {
+	return append([]string(nil), d.values...)
+}
+
+// DictionaryDb stores string values found in the dictionary as their
+// DICT_STRING_TYPE code and any other value as a plain string.
+type DictionaryDb struct {
+	db   *Db
+	dict *ValueDictionary
+}
+
+func NewDictionaryDb(db *Db, d *ValueDictionary) *DictionaryDb {
+	return &DictionaryDb{db: db, dict: d}
+}
+
+func (d *DictionaryDb) Put(key, value string) error {
+	if code, ok := d.dict.codes[value]; ok {
+		return d.db.putEntry(&Entry{key: key, valueType: DICT_STRING_TYPE, value: strconv.Itoa(int(code))})
+	}
+	return d.db.Put(key, value)
+}
+
+func (d *DictionaryDb) Get(key string) (string, error) {
+	val, vType, err := d.db.getType(key)
+	if err != nil {
+		return "", err
+	}
+	switch vType {
+	case "string":
+		return val, nil
+	case "dictstring":
+		code, err := strconv.Atoi(val)
+		if err != nil {
+			return "", err
+		}
+		if code >= len(d.dict.values) {
+			return "", fmt.Errorf("unknown dictionary code %d", code)
+		}
+		return d.dict.values[code], nil
+	}
+	return "", fmt.Errorf("wrong type of value")
+}
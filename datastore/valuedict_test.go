@@ -0,0 +1,111 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDictionaryDb(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	countries := []string{"UA", "PL", "DE", "UA", "PL", "UA"}
+	for i, c := range countries {
+		if err := db.Put(fmt.Sprintf("user%d", i), c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.PutInt64("count", 6); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := BuildValueDictionary(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(d.Values(), ","); got != "DE,PL,UA" {
+		t.Errorf("Bad dictionary values: %s", got)
+	}
+
+	ddb := NewDictionaryDb(db, d)
+	if err := ddb.Put("user100", "PL"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ddb.Put("user101", "FR"); err != nil {
+		t.Fatal(err)
+	}
+	if _, vType, err := db.GetValue("user100"); err != nil || vType != "dictstring" {
+		t.Errorf("Expected a dictionary code to be stored, got %s, %v", vType, err)
+	}
+	for key, want := range map[string]string{"user100": "PL", "user101": "FR", "user0": "UA"} {
+		if got, err := ddb.Get(key); err != nil || got != want {
+			t.Errorf("Bad value for %s: %q, %v", key, got, err)
+		}
+	}
+	if _, err := ddb.Get("count"); err == nil {
+		t.Errorf("Expected an error for an int64 value")
+	}
+	if _, err := ddb.Get("missing"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if _, err := NewValueDictionary([]string{"a", "a"}); err == nil {
+		t.Errorf("Expected an error for duplicate values")
+	}
+	if _, err := NewValueDictionary(make([]string, 65537)); err == nil {
+		t.Errorf("Expected an error for too many values")
+	}
+	if _, err := EncodeInto(&Entry{key: "k", valueType: DICT_STRING_TYPE, value: "65536"}, nil); err == nil {
+		t.Errorf("Expected an error for a code out of range")
+	}
+}
+
+func BenchmarkDictionaryEncoding(b *testing.B) {
+	const n = 1000000
+	values := make([]string, 100)
+	for i := range values {
+		values[i] = fmt.Sprintf("status-value-%07d", i)
+	}
+	d, err := NewValueDictionary(values)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("string", func(b *testing.B) {
+		size := 0
+		var buf []byte
+		for i := 0; i < b.N; i++ {
+			size = 0
+			for j := 0; j < n; j++ {
+				buf, _ = EncodeInto(&Entry{key: "k" + strconv.Itoa(j), valueType: STRING_TYPE, value: values[j%len(values)]}, buf[:0])
+				size += len(buf)
+			}
+		}
+		b.ReportMetric(float64(size)/n, "bytes/entry")
+	})
+	b.Run("dictionary", func(b *testing.B) {
+		size := 0
+		var buf []byte
+		for i := 0; i < b.N; i++ {
+			size = 0
+			for j := 0; j < n; j++ {
+				code := d.codes[values[j%len(values)]]
+				buf, _ = EncodeInto(&Entry{key: "k" + strconv.Itoa(j), valueType: DICT_STRING_TYPE, value: strconv.Itoa(int(code))}, buf[:0])
+				size += len(buf)
+			}
+		}
+		b.ReportMetric(float64(size)/n, "bytes/entry")
+	})
+}
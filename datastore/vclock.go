@@ -0,0 +1,120 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// Векторний годинник зберігається як відсортовані пари
+// [len u32][id вузла][лічильник u64] у розмітці рядка.
+
+func encodeVectorClock(clock map[string]uint64) (string, error) {
+	nodes := make([]string, 0, len(clock))
+	size := 0
+	for node := range clock {
+		if node == "" {
+			return "", fmt.Errorf("empty node id in vector clock")
+		}
+		nodes = append(nodes, node)
+		size += 12 + len(node)
+	}
+	sort.Strings(nodes)
+	res := make([]byte, 0, size)
+	for _, node := range nodes {
+		res = binary.LittleEndian.AppendUint32(res, uint32(len(node)))
+		res = append(res, node...)
+		res = binary.LittleEndian.AppendUint64(res, clock[node])
+	}
+	return string(res), nil
+}
+
+func parseVectorClock(data string) (map[string]uint64, error) {
+	clock := make(map[string]uint64)
+	prev := ""
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, fmt.Errorf("corrupted vector clock")
+		}
+		n := int(binary.LittleEndian.Uint32([]byte(data[:4])))
+		if n == 0 || n > len(data)-12 {
+			return nil, fmt.Errorf("corrupted vector clock")
+		}
+		node := data[4 : 4+n]
+		//порядок вузлів робить кодування канонічним
+		if node <= prev {
+			return nil, fmt.Errorf("vector clock nodes out of order")
+		}
+		clock[node] = binary.LittleEndian.Uint64([]byte(data[4+n : 12+n]))
+		prev = node
+		data = data[12+n:]
+	}
+	return clock, nil
+}
+
+func validateVectorClock(data string) error {
+	_, err := parseVectorClock(data)
+	return err
+}
+
+func NewVectorClockEntry(key string, clock map[string]uint64) (*Entry, error) {
+	value, err := encodeVectorClock(clock)
+	if err != nil {
+		return nil, err
+	}
+	return &Entry{key: key, valueType: VECTOR_CLOCK_TYPE, value: value}, nil
+}
+
+func (e *Entry) GetVectorClock() (map[string]uint64, error) {
+	if e.valueType != VECTOR_CLOCK_TYPE {
+		return nil, fmt.Errorf("wrong type of value")
+	}
+	return parseVectorClock(e.value)
+}
+
+func (db *Db) PutVectorClock(key string, clock map[string]uint64) error {
+	e, err := NewVectorClockEntry(key, clock)
+	if err != nil {
+		return err
+	}
+	return db.putEntry(e)
+}
+
+func (db *Db) GetVectorClock(key string) (map[string]uint64, error) {
+	e, err := db.getEntry(key)
+	if err != nil {
+		return nil, err
+	}
+	return e.GetVectorClock()
+}
+
+// MergeClocks returns the element-wise maximum of a and b.
+func MergeClocks(a, b map[string]uint64) map[string]uint64 {
+	res := make(map[string]uint64, len(a))
+	for node, n := range a {
+		res[node] = n
+	}
+	for node, n := range b {
+		if n > res[node] {
+			res[node] = n
+		}
+	}
+	return res
+}
+
+// HappensBefore reports whether a causally precedes b. When neither clock
+// happens before the other and they differ, the events are concurrent.
+func HappensBefore(a, b map[string]uint64) bool {
+	for node, n := range a {
+		if n > b[node] {
+			return false
+		}
+	}
+	//відсутній вузол рівнозначний нулю
+	for node, n := range b {
+		if n > a[node] {
+			return true
+		}
+	}
+	return false
+}
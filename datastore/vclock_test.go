@@ -0,0 +1,84 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestHappensBefore(t *testing.T) {
+	cases := []struct {
+		a, b          map[string]uint64
+		before, after bool
+	}{
+		{map[string]uint64{"a": 1}, map[string]uint64{"a": 2}, true, false},
+		{map[string]uint64{"a": 1}, map[string]uint64{"a": 1, "b": 1}, true, false},
+		{map[string]uint64{"a": 2, "b": 1}, map[string]uint64{"a": 1, "b": 2}, false, false},
+		{map[string]uint64{"a": 1}, map[string]uint64{"b": 1}, false, false},
+		{map[string]uint64{"a": 1, "b": 0}, map[string]uint64{"a": 1}, false, false},
+		{nil, map[string]uint64{"a": 1}, true, false},
+	}
+	for i, c := range cases {
+		if got := HappensBefore(c.a, c.b); got != c.before {
+			t.Errorf("Case %d: expected HappensBefore(a, b) = %v", i, c.before)
+		}
+		if got := HappensBefore(c.b, c.a); got != c.after {
+			t.Errorf("Case %d: expected HappensBefore(b, a) = %v", i, c.after)
+		}
+	}
+
+	// два вузли пишуть паралельно, злиття бачить обидві події
+	a := map[string]uint64{"a": 2, "b": 1}
+	b := map[string]uint64{"a": 1, "b": 3}
+	merged := MergeClocks(a, b)
+	if !reflect.DeepEqual(merged, map[string]uint64{"a": 2, "b": 3}) {
+		t.Errorf("Bad merged clock: %v", merged)
+	}
+	if !HappensBefore(a, merged) || !HappensBefore(b, merged) {
+		t.Error("Expected both clocks to happen before their merge")
+	}
+}
+
+func TestVectorClockEntry(t *testing.T) {
+	clock := map[string]uint64{"node-b": 1 << 40, "node-a": 3, "c": 0}
+	e, err := NewVectorClockEntry("key", clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := DecodeMany(e.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := entries[0].GetVectorClock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, clock) || entries[0].Type() != "vclock" {
+		t.Errorf("Bad clock decoded: %v", decoded)
+	}
+	if _, err := NewVectorClockEntry("key", map[string]uint64{"": 1}); err == nil {
+		t.Error("Expected error for empty node id")
+	}
+	bad := Entry{key: "key", valueType: VECTOR_CLOCK_TYPE, value: "\x05\x00\x00\x00ab"}
+	if _, err := EncodeInto(&bad, nil); err == nil {
+		t.Error("Expected error for corrupted clock")
+	}
+
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.PutVectorClock("key", clock); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := db.GetVectorClock("key"); err != nil || !reflect.DeepEqual(got, clock) {
+		t.Errorf("Bad clock read: %v, %v", got, err)
+	}
+}
@@ -0,0 +1,80 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPITR(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	walPath := filepath.Join(dir, "wal")
+
+	w, err := NewWALWriter(walPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 100; i++ {
+		e := &Entry{key: "key" + strconv.Itoa(i), valueType: STRING_TYPE, value: "value" + strconv.Itoa(i)}
+		if err := w.WriteTimestampedWAL(e, start.Add(time.Duration(i)*100*time.Millisecond)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	//записи після цілі, дописані не по порядку часу, теж пропускаються
+	if err := w.WriteTimestampedWAL(&Entry{key: "key1", valueType: STRING_TYPE, value: "rewritten"}, start.Add(6*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteTimestampedWAL(&Entry{key: "key2", valueType: TOMBSTONE_TYPE}, start.Add(7*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	//обірваний останній запис не заважає відтворенню
+	f, err := os.OpenFile(walPath, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{1, 2, 3, 4, 5, 6, 7, 8, 100, 0})
+	f.Close()
+
+	dst := filepath.Join(dir, "restored")
+	n, err := PITR(walPath, start.Add(5*time.Second), dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 50 {
+		t.Errorf("Expected 50 entries replayed, got %d", n)
+	}
+	db, err := NewDb(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	entries, err := db.entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 50 {
+		t.Errorf("Expected 50 keys at the target time, got %d", len(entries))
+	}
+	if v, err := db.Get("key49"); err != nil || v != "value49" {
+		t.Errorf("Bad key49: %q, %v", v, err)
+	}
+	if _, err := db.Get("key50"); err != ErrNotFound {
+		t.Errorf("Expected key50 to be after the target, got %v", err)
+	}
+	if v, _ := db.Get("key1"); v != "value1" {
+		t.Errorf("Expected the rewrite after the target to be skipped, got %q", v)
+	}
+	if _, err := db.Get("key2"); err != nil {
+		t.Errorf("Expected the delete after the target to be skipped, got %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package datastore
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const webSessionKeyPrefix = "websession:"
+
+// WebSession is a server-side session for web applications. Unlike Session,
+// it has no goroutines: expiry is checked when the session is read, and
+// PruneWebSessions removes the expired ones.
+type WebSession struct {
+	ID         string
+	UserID     string
+	Data       map[string]string
+	CreatedAt  int64
+	LastSeenAt int64
+	ExpiresAt  int64
+}
+
+// розмітка: [id][користувач][пар u32][ключ][значення]... з префіксами довжини,
+// далі три i64 у UnixNano: створено, востаннє бачили, спливає
+func (s *WebSession) encode() string {
+	res := appendLengthPrefixed(nil, s.ID)
+	res = appendLengthPrefixed(res, s.UserID)
+	keys := make([]string, 0, len(s.Data))
+	for k := range s.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	res = binary.LittleEndian.AppendUint32(res, uint32(len(keys)))
+	for _, k := range keys {
+		res = appendLengthPrefixed(res, k)
+		res = appendLengthPrefixed(res, s.Data[k])
+	}
+	for _, t := range []int64{s.CreatedAt, s.LastSeenAt, s.ExpiresAt} {
+		res = binary.LittleEndian.AppendUint64(res, uint64(t))
+	}
+	return string(res)
+}
+
+func parseWebSession(data string) (*WebSession, error) {
+	s := &WebSession{Data: make(map[string]string)}
+	var err error
+	if s.ID, data, err = readLengthPrefixed(data); err != nil {
+		return nil, err
+	}
+	if s.UserID, data, err = readLengthPrefixed(data); err != nil {
+		return nil, err
+	}
+	if len(data) < 4 {
+		return nil, fmt.Errorf("corrupted session")
+	}
+	n := int(binary.LittleEndian.Uint32([]byte(data[:4])))
+	if n > (len(data)-4)/8 {
+		return nil, fmt.Errorf("corrupted session")
+	}
+	data = data[4:]
+	for i := 0; i < n; i++ {
+		var k, v string
+		if k, data, err = readLengthPrefixed(data); err != nil {
+			return nil, err
+		}
+		if v, data, err = readLengthPrefixed(data); err != nil {
+			return nil, err
+		}
+		s.Data[k] = v
+	}
+	if len(data) != 24 {
+		return nil, fmt.Errorf("corrupted session")
+	}
+	raw := []byte(data)
+	s.CreatedAt = int64(binary.LittleEndian.Uint64(raw))
+	s.LastSeenAt = int64(binary.LittleEndian.Uint64(raw[8:]))
+	s.ExpiresAt = int64(binary.LittleEndian.Uint64(raw[16:]))
+	return s, nil
+}
+
+func validateWebSession(data string) error {
+	_, err := parseWebSession(data)
+	return err
+}
+
+func (s *WebSession) Expired() bool {
+	return timeNow().UnixNano() >= s.ExpiresAt
+}
+
+// NewWebSession creates a session for userID that expires after ttl unless
+// touched, and returns its random 128-bit ID.
+func NewWebSession(db *Db, userID string, ttl time.Duration) (string, error) {
+	if ttl <= 0 {
+		return "", fmt.Errorf("session ttl must be positive")
+	}
+	var raw [16]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return "", err
+	}
+	now := timeNow().UnixNano()
+	s := &WebSession{ID: hex.EncodeToString(raw[:]), UserID: userID, Data: map[string]string{}, CreatedAt: now, LastSeenAt: now, ExpiresAt: now + int64(ttl)}
+	swapped, err := db.compareAndSwap(nil, &Entry{key: webSessionKeyPrefix + s.ID, valueType: SESSION_TYPE, value: s.encode()})
+	if err != nil {
+		return "", err
+	}
+	if !swapped {
+		return "", fmt.Errorf("session id collision")
+	}
+	return s.ID, nil
+}
+
+// GetWebSession returns ErrSessionExpired for a session past its expiry and
+// ErrNotFound for an unknown or deleted one.
+func GetWebSession(db *Db, sessionID string) (*WebSession, error) {
+	e, err := db.getEntry(webSessionKeyPrefix + sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if e.valueType != SESSION_TYPE {
+		return nil, fmt.Errorf("wrong type of value")
+	}
+	s, err := parseWebSession(e.value)
+	if err != nil {
+		return nil, err
+	}
+	if s.Expired() {
+		return nil, ErrSessionExpired
+	}
+	return s, nil
+}
+
+func updateWebSession(db *Db, sessionID string, fn func(s *WebSession)) error {
+	_, err := db.updateEntry(webSessionKeyPrefix+sessionID, func(cur *Entry) (*Entry, error) {
+		if cur == nil {
+			return nil, ErrNotFound
+		}
+		if cur.valueType != SESSION_TYPE {
+			return nil, fmt.Errorf("wrong type of value")
+		}
+		s, err := parseWebSession(cur.value)
+		if err != nil {
+			return nil, err
+		}
+		if s.Expired() {
+			return nil, ErrSessionExpired
+		}
+		fn(s)
+		return &Entry{valueType: SESSION_TYPE, value: s.encode()}, nil
+	})
+	return err
+}
+
+// TouchWebSession marks the session as seen now and pushes its expiry out by
+// the ttl it was created with.
+func TouchWebSession(db *Db, sessionID string) error {
+	return updateWebSession(db, sessionID, func(s *WebSession) {
+		now := timeNow().UnixNano()
+		s.ExpiresAt, s.LastSeenAt = now+s.ExpiresAt-s.LastSeenAt, now
+	})
+}
+
+func SetWebSessionValue(db *Db, sessionID, name, value string) error {
+	return updateWebSession(db, sessionID, func(s *WebSession) {
+		s.Data[name] = value
+	})
+}
+
+func DeleteWebSession(db *Db, sessionID string) error {
+	if _, err := db.getEntry(webSessionKeyPrefix + sessionID); err != nil {
+		return err
+	}
+	return db.putTombstone(webSessionKeyPrefix + sessionID)
+}
+
+// PruneWebSessions writes tombstones for expired sessions.
+func PruneWebSessions(db *Db) error {
+	entries, err := db.scanEntries()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.valueType != SESSION_TYPE || !strings.HasPrefix(e.key, webSessionKeyPrefix) {
+			continue
+		}
+		s, err := parseWebSession(e.value)
+		if err != nil {
+			return err
+		}
+		if s.Expired() {
+			if err := db.putTombstone(e.key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWebSession(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Unix(1700000000, 0)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	id, err := NewWebSession(db, "user:1", 30*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := NewWebSession(db, "user:1", 30*time.Minute)
+	if len(id) != 32 || id == other {
+		t.Errorf("Bad session ids: %s, %s", id, other)
+	}
+	if err := SetWebSessionValue(db, id, "cart", "3 items"); err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(20 * time.Minute)
+	if err := TouchWebSession(db, id); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(20 * time.Minute)
+	s, err := GetWebSession(db, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.UserID != "user:1" || s.Data["cart"] != "3 items" || s.ExpiresAt != now.Add(10*time.Minute).UnixNano() {
+		t.Errorf("Bad session: %+v", s)
+	}
+	if _, err := GetWebSession(db, other); err != ErrSessionExpired {
+		t.Errorf("Expected ErrSessionExpired for an untouched session, got %v", err)
+	}
+	if err := TouchWebSession(db, other); err != ErrSessionExpired {
+		t.Errorf("Expected ErrSessionExpired on touch, got %v", err)
+	}
+
+	now = now.Add(10 * time.Minute)
+	if _, err := GetWebSession(db, id); err != ErrSessionExpired {
+		t.Errorf("Expected ErrSessionExpired right at expiry, got %v", err)
+	}
+	if err := PruneWebSessions(db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetWebSession(db, id); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound after prune, got %v", err)
+	}
+
+	live, _ := NewWebSession(db, "user:2", time.Hour)
+	if err := DeleteWebSession(db, live); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetWebSession(db, live); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	if err := DeleteWebSession(db, live); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+}
@@ -0,0 +1,377 @@
+package datastore
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Мінімальна серверна частина RFC 6455: рукостискання, кадри з маскою від
+// клієнта, фрагментовані повідомлення, ping/pong і close. Розширень на
+// кшталт permessage-deflate немає. Сумісність перевіряє запис розмови з
+// клієнтом Node у testdata/websocket.
+
+const (
+	wsContinuation = 0
+	wsText         = 1
+	wsBinary       = 2
+	wsClose        = 8
+	wsPing         = 9
+	wsPong         = 10
+
+	wsCloseNormal        = 1000
+	wsCloseProtocolError = 1002
+	wsCloseUnsupported   = 1003
+	wsClosePolicy        = 1008
+	wsCloseTooBig        = 1009
+
+	//найбільше повідомлення, яке приймаємо від клієнта
+	maxWebSocketMessage = 1 << 20
+)
+
+const wsAcceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+type wsCloseError struct {
+	code   int
+	reason string
+}
+
+func (e *wsCloseError) Error() string {
+	return fmt.Sprintf("websocket closed: %d %s", e.code, e.reason)
+}
+
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sameOrigin пускає запити без Origin (не з браузера) і з того самого хоста,
+// як типова перевірка gorilla/websocket
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+type wsConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	//кадри пише лише одна горутина, решта передає повідомлення через канал
+	w *bufio.Writer
+}
+
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); r.Method != http.MethodGet || err != nil || len(decoded) != 16 ||
+		!headerHasToken(r.Header, "Connection", "upgrade") || !headerHasToken(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "not a websocket handshake", http.StatusBadRequest)
+		return nil, fmt.Errorf("not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusUpgradeRequired)
+		return nil, fmt.Errorf("unsupported websocket version")
+	}
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin websocket refused", http.StatusForbidden)
+		return nil, fmt.Errorf("cross-origin websocket refused")
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket is not supported", http.StatusInternalServerError)
+		return nil, fmt.Errorf("response writer cannot be hijacked")
+	}
+	conn, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha1.Sum([]byte(key + wsAcceptGUID))
+	brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	brw.WriteString("Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	if err := brw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{conn: conn, r: brw.Reader, w: brw.Writer}, nil
+}
+
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xffff:
+		header = binary.BigEndian.AppendUint16(append(header, 126), uint16(n))
+	default:
+		header = binary.BigEndian.AppendUint64(append(header, 127), uint64(n))
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	c.w.Write(header)
+	c.w.Write(payload)
+	return c.w.Flush()
+}
+
+func (c *wsConn) writeClose(code int, reason string) error {
+	return c.writeFrame(wsClose, append(binary.BigEndian.AppendUint16(nil, uint16(code)), reason...))
+}
+
+// readMessage повертає наступне текстове чи бінарне повідомлення; на ping
+// відповідає pong через pong, close перетворює на *wsCloseError
+func (c *wsConn) readMessage(pong func(payload []byte)) (byte, []byte, error) {
+	var message []byte
+	var messageType byte
+	for {
+		var head [2]byte
+		if _, err := io.ReadFull(c.r, head[:]); err != nil {
+			return 0, nil, err
+		}
+		fin, opcode := head[0]&0x80 != 0, head[0]&0x0f
+		if head[0]&0x70 != 0 {
+			return 0, nil, &wsCloseError{wsCloseProtocolError, "reserved bits set"}
+		}
+		if head[1]&0x80 == 0 {
+			return 0, nil, &wsCloseError{wsCloseProtocolError, "client frames must be masked"}
+		}
+		size := uint64(head[1] & 0x7f)
+		switch size {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+				return 0, nil, err
+			}
+			size = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+				return 0, nil, err
+			}
+			size = binary.BigEndian.Uint64(ext[:])
+		}
+		control := opcode >= wsClose
+		if control && (!fin || size > 125) {
+			return 0, nil, &wsCloseError{wsCloseProtocolError, "bad control frame"}
+		}
+		if size > uint64(maxWebSocketMessage-len(message)) {
+			return 0, nil, &wsCloseError{wsCloseTooBig, "message too big"}
+		}
+		var mask [4]byte
+		if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+			return 0, nil, err
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(c.r, payload); err != nil {
+			return 0, nil, err
+		}
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+
+		switch opcode {
+		case wsPing:
+			pong(payload)
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			code := wsCloseNormal
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+			}
+			return 0, nil, &wsCloseError{code, string(payload[min(2, len(payload)):])}
+		case wsText, wsBinary:
+			if messageType != 0 {
+				return 0, nil, &wsCloseError{wsCloseProtocolError, "expected a continuation frame"}
+			}
+			messageType = opcode
+		case wsContinuation:
+			if messageType == 0 {
+				return 0, nil, &wsCloseError{wsCloseProtocolError, "unexpected continuation frame"}
+			}
+		default:
+			return 0, nil, &wsCloseError{wsCloseProtocolError, "unknown opcode"}
+		}
+		message = append(message, payload...)
+		if fin {
+			return messageType, message, nil
+		}
+	}
+}
+
+// WebSocketRequest is a message from the client. Op is "set", "get" or
+// "delete"; set takes Type (string by default) and Value, or ValueBase64
+// for binary values. ID is echoed in the response.
+type WebSocketRequest struct {
+	ID          string `json:"id,omitempty"`
+	Op          string `json:"op"`
+	Key         string `json:"key"`
+	Type        string `json:"type,omitempty"`
+	Value       string `json:"value,omitempty"`
+	ValueBase64 string `json:"value_base64,omitempty"`
+}
+
+// WebSocketMessage is a message to the client: Kind "response" answers a
+// request, Kind "change" reports a write to the Db by anyone.
+type WebSocketMessage struct {
+	Kind    string        `json:"kind"`
+	ID      string        `json:"id,omitempty"`
+	Error   string        `json:"error,omitempty"`
+	Entry   *exportRecord `json:"entry,omitempty"`
+	Deleted bool          `json:"deleted,omitempty"`
+}
+
+type webSocketHandler struct {
+	db *Db
+}
+
+// WebSocketHandler accepts WebSocket connections that carry JSON
+// WebSocketRequest messages and receive WebSocketMessage responses, plus a
+// change notification for every write to db while connected. Browser
+// connections from another origin are refused. A client that falls too far
+// behind on notifications is disconnected with close code 1008.
+func WebSocketHandler(db *Db) http.Handler {
+	return &webSocketHandler{db: db}
+}
+
+func (h *webSocketHandler) execute(req WebSocketRequest) WebSocketMessage {
+	res := WebSocketMessage{Kind: "response", ID: req.ID}
+	var err error
+	switch req.Op {
+	case "get":
+		var e *Entry
+		if e, err = h.db.getEntry(req.Key); err == nil {
+			record := newExportRecord(e)
+			res.Entry = &record
+		} else if err == ErrNotFound {
+			err = nil
+		}
+	case "set":
+		record := exportRecord{Key: req.Key, Type: req.Type, Value: req.Value, ValueBase64: req.ValueBase64}
+		if record.Type == "" {
+			record.Type = "string"
+		}
+		var e *Entry
+		if e, err = recordEntry(req.Key, record); err == nil {
+			if err = h.db.putEntry(e); err == nil {
+				stored := newExportRecord(storedEntry(e))
+				res.Entry = &stored
+			}
+		}
+	case "delete":
+		res.Deleted, err = h.db.deleteExisting(req.Key)
+	default:
+		err = fmt.Errorf("unknown op %q", req.Op)
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res
+}
+
+func (h *webSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ws, err := upgradeWebSocket(w, r)
+	if err != nil {
+		return
+	}
+	defer ws.conn.Close()
+
+	type frame struct {
+		opcode  byte
+		payload []byte
+	}
+	//усі кадри пише одна горутина нижче
+	out := make(chan frame, 16)
+	changes := make(chan *Entry, watchBufferSize)
+	lagged := make(chan struct{})
+	done := make(chan struct{})
+	stop := make(chan struct{})
+	defer close(stop)
+	var once sync.Once
+	unwatch := h.db.Watch(func(e *Entry) {
+		select {
+		case changes <- e:
+		default:
+			once.Do(func() { close(lagged) })
+		}
+	})
+	defer unwatch()
+
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case f := <-out:
+				if ws.writeFrame(f.opcode, f.payload) != nil || f.opcode == wsClose {
+					return
+				}
+			case e := <-changes:
+				record := newExportRecord(e)
+				data, _ := json.Marshal(WebSocketMessage{Kind: "change", Entry: &record})
+				if ws.writeFrame(wsText, data) != nil {
+					return
+				}
+			case <-lagged:
+				ws.writeClose(wsClosePolicy, "client too slow")
+				return
+			case <-stop:
+				return
+			}
+		}
+	}()
+	send := func(opcode byte, payload []byte) bool {
+		select {
+		case out <- frame{opcode, payload}:
+			return true
+		case <-done:
+			return false
+		}
+	}
+	//після закриття записувача читання треба перервати
+	go func() {
+		<-done
+		ws.conn.SetReadDeadline(time.Now())
+	}()
+
+	for {
+		opcode, data, err := ws.readMessage(func(payload []byte) { send(wsPong, payload) })
+		var closeErr *wsCloseError
+		if errors.As(err, &closeErr) {
+			//на close клієнта відповідаємо його ж кодом, на порушення протоколу - своїм
+			send(wsClose, append(binary.BigEndian.AppendUint16(nil, uint16(closeErr.code)), closeErr.reason...))
+			<-done
+			return
+		}
+		if err != nil {
+			return
+		}
+		var req WebSocketRequest
+		if opcode != wsText || json.Unmarshal(data, &req) != nil {
+			send(wsClose, append(binary.BigEndian.AppendUint16(nil, wsCloseUnsupported), "expected a JSON text message"...))
+			<-done
+			return
+		}
+		res, _ := json.Marshal(h.execute(req))
+		if !send(wsText, res) {
+			return
+		}
+	}
+}
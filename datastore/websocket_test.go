@@ -0,0 +1,255 @@
+package datastore
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testWSClient - найпростіший клієнт: рукостискання, кадри з маскою, читання
+// кадрів сервера без маски
+type testWSClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialTestWS(t *testing.T, addr, origin string) (*testWSClient, *http.Response) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	key := make([]byte, 16)
+	rand.Read(key)
+	fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\n", addr, base64.StdEncoding.EncodeToString(key))
+	if origin != "" {
+		fmt.Fprintf(conn, "Origin: %s\r\n", origin)
+	}
+	fmt.Fprint(conn, "\r\n")
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testWSClient{conn: conn, r: r}, resp
+}
+
+func (c *testWSClient) write(opcode byte, payload []byte) {
+	frame := []byte{0x80 | opcode}
+	if len(payload) < 126 {
+		frame = append(frame, 0x80|byte(len(payload)))
+	} else {
+		frame = binary.BigEndian.AppendUint16(append(frame, 0x80|126), uint16(len(payload)))
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	c.conn.Write(frame)
+}
+
+func (c *testWSClient) read(t *testing.T) (byte, []byte) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.r, head[:]); err != nil {
+		t.Fatal(err)
+	}
+	size := int(head[1] & 0x7f)
+	if size == 126 {
+		var ext [2]byte
+		io.ReadFull(c.r, ext[:])
+		size = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		t.Fatal(err)
+	}
+	return head[0] & 0x0f, payload
+}
+
+func TestWebSocketHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	srv := httptest.NewServer(WebSocketHandler(db))
+	defer srv.Close()
+	addr := strings.TrimPrefix(srv.URL, "http://")
+
+	if _, resp := dialTestWS(t, addr, "http://evil.example"); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected a cross-origin handshake to be refused, got %s", resp.Status)
+	}
+	c, resp := dialTestWS(t, addr, "")
+	defer c.conn.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Bad handshake: %s", resp.Status)
+	}
+
+	//ping посеред розмови має дістати pong
+	c.write(wsPing, []byte("hi"))
+	if op, payload := c.read(t); op != wsPong || string(payload) != "hi" {
+		t.Errorf("Expected a pong, got %d %q", op, payload)
+	}
+
+	c.write(wsText, []byte(`{"id": "1", "op": "set", "key": "greeting", "value": "hello"}`))
+	var response, change *WebSocketMessage
+	for response == nil || change == nil {
+		op, payload := c.read(t)
+		if op != wsText {
+			t.Fatalf("Unexpected frame %d", op)
+		}
+		var msg WebSocketMessage
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			t.Fatal(err)
+		}
+		switch msg.Kind {
+		case "response":
+			response = &msg
+		case "change":
+			change = &msg
+		}
+	}
+	if response.ID != "1" || response.Error != "" || response.Entry == nil || response.Entry.Value != "hello" {
+		t.Errorf("Bad response: %+v", response)
+	}
+	if change.Entry == nil || change.Entry.Key != "greeting" || change.Entry.Type != "string" || change.Entry.Value != "hello" {
+		t.Errorf("Bad change notification: %+v", change)
+	}
+
+	c.write(wsText, []byte(`{"id": "2", "op": "get", "key": "greeting"}`))
+	if _, payload := c.read(t); !strings.Contains(string(payload), `"value":"hello"`) || !strings.Contains(string(payload), `"id":"2"`) {
+		t.Errorf("Bad get response: %s", payload)
+	}
+	c.write(wsText, []byte(`{"id": "3", "op": "rename", "key": "greeting"}`))
+	if _, payload := c.read(t); !strings.Contains(string(payload), "unknown op") {
+		t.Errorf("Expected an error for an unknown op: %s", payload)
+	}
+
+	c.write(wsClose, binary.BigEndian.AppendUint16(nil, wsCloseNormal))
+	if op, payload := c.read(t); op != wsClose || binary.BigEndian.Uint16(payload) != wsCloseNormal {
+		t.Errorf("Expected the close to be echoed, got %d %v", op, payload)
+	}
+}
+
+// clientFrames ділить записаний потік клієнта на кадри з маскою
+func clientFrames(t *testing.T, data []byte) [][]byte {
+	var frames [][]byte
+	for len(data) > 0 {
+		if len(data) < 2 || data[1]&0x80 == 0 {
+			t.Fatalf("Bad client frame % x", data)
+		}
+		size, head := int(data[1]&0x7f), 2
+		switch size {
+		case 126:
+			size, head = int(binary.BigEndian.Uint16(data[2:])), 4
+		case 127:
+			size, head = int(binary.BigEndian.Uint64(data[2:])), 10
+		}
+		n := head + 4 + size
+		frames = append(frames, data[:n])
+		data = data[n:]
+	}
+	return frames
+}
+
+// Записи в testdata/websocket зняті проксі між сервером і вбудованим
+// клієнтом WebSocket з Node 20 (undici): клієнт надсилає set на 170 байт,
+// після двох відповідей - get, після третьої закриває з'єднання кодом 1000.
+// Node прийняв саме ці кадри сервера, тож відтворена розмова мусить дати ті
+// самі байти.
+func TestWebSocketHandler_NodeClient(t *testing.T) {
+	client, err := os.ReadFile("testdata/websocket/node_client.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := os.ReadFile("testdata/websocket/node_server.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := &testWSClient{r: bufio.NewReader(bytes.NewReader(server))}
+	recordedResp, err := http.ReadResponse(recorded.r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	srv := httptest.NewServer(WebSocketHandler(db))
+	defer srv.Close()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	c := &testWSClient{conn: conn, r: bufio.NewReader(conn)}
+
+	end := bytes.Index(client, []byte("\r\n\r\n")) + 4
+	conn.Write(client[:end])
+	resp, err := http.ReadResponse(c.r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Bad handshake: %s", resp.Status)
+	}
+	//permessage-deflate, який пропонує Node, сервер не підтримує
+	if accept := resp.Header.Get("Sec-WebSocket-Accept"); accept != recordedResp.Header.Get("Sec-WebSocket-Accept") || resp.Header.Get("Sec-WebSocket-Extensions") != "" {
+		t.Errorf("Expected the handshake Node accepted, got %v", resp.Header)
+	}
+
+	frames := clientFrames(t, client[end:])
+	if len(frames) != 3 {
+		t.Fatalf("Expected 3 recorded client frames, got %d", len(frames))
+	}
+	//після кожного кадру клієнт чекав стільки відповідей
+	for i, replies := range []int{2, 1, 1} {
+		conn.Write(frames[i])
+		var got, want []string
+		for j := 0; j < replies; j++ {
+			op, payload := c.read(t)
+			got = append(got, fmt.Sprintf("%d %s", op, payload))
+			op, payload = recorded.read(t)
+			want = append(want, fmt.Sprintf("%d %s", op, payload))
+		}
+		//зміна й відповідь на set можуть прийти в будь-якому порядку
+		sort.Strings(got)
+		sort.Strings(want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Frame %d: expected %q, got %q", i, want, got)
+		}
+	}
+	if _, err := recorded.r.ReadByte(); err != io.EOF {
+		t.Errorf("Expected the recording to end after the close frame")
+	}
+}
@@ -0,0 +1,33 @@
+package datastore
+
+import "fmt"
+
+// WriteThrough writes e under key locally and then passes it to store. If
+// store fails, the local write is undone: the previous version is written
+// back, or a tombstone if key did not exist. If the undo fails as well, the
+// returned error says so, since the local copy then differs from store.
+func (db *Db) WriteThrough(key string, e *Entry, store func(*Entry) error) error {
+	previous, err := db.getEntry(key)
+	if err != nil && err != ErrNotFound {
+		return err
+	}
+
+	written := *e
+	written.key = key
+	if err := db.putEntry(&written); err != nil {
+		return err
+	}
+	if err := store(&written); err != nil {
+		var rollbackErr error
+		if previous != nil {
+			rollbackErr = db.putEntry(previous)
+		} else {
+			rollbackErr = db.putTombstone(key)
+		}
+		if rollbackErr != nil {
+			return fmt.Errorf("%v; rollback failed: %v", err, rollbackErr)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package datastore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWriteThrough(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upstream := map[string]string{}
+	store := func(e *Entry) error {
+		upstream[e.Key()] = e.Value()
+		return nil
+	}
+	failure := fmt.Errorf("upstream unavailable")
+	failing := func(e *Entry) error {
+		return failure
+	}
+
+	if err := db.WriteThrough("key", &Entry{valueType: STRING_TYPE, value: "value"}, store); err != nil {
+		t.Fatal(err)
+	}
+	if val, err := db.Get("key"); err != nil || val != "value" || upstream["key"] != "value" {
+		t.Errorf("Bad write-through: local %q (%v), upstream %q", val, err, upstream["key"])
+	}
+
+	if err := db.WriteThrough("new", &Entry{valueType: STRING_TYPE, value: "value"}, failing); err != failure {
+		t.Errorf("Expected store error, got %v", err)
+	}
+	if err := db.WriteThrough("key", &Entry{valueType: STRING_TYPE, value: "changed"}, failing); err != failure {
+		t.Errorf("Expected store error, got %v", err)
+	}
+	//відкат теж падає: база перейшла в standby, поки працював store
+	standby := func(e *Entry) error {
+		db.mu.Lock()
+		db.standby = true
+		db.mu.Unlock()
+		return failure
+	}
+	err = db.WriteThrough("other", &Entry{valueType: STRING_TYPE, value: "value"}, standby)
+	if err == nil || !strings.Contains(err.Error(), "rollback failed") {
+		t.Errorf("Expected the rollback error to be reported, got %v", err)
+	}
+	db.standby = false
+	if err := db.putTombstone("other"); err != nil {
+		t.Fatal(err)
+	}
+
+	db.Close()
+	db, err = NewDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Get("new"); err != ErrNotFound {
+		t.Errorf("Expected rolled back entry to be gone, got %v", err)
+	}
+	if val, err := db.Get("key"); err != nil || val != "value" {
+		t.Errorf("Expected previous value after rollback, got %q, %v", val, err)
+	}
+}
+
+func BenchmarkWriteThrough(b *testing.B) {
+	upstream := map[string]*Entry{}
+	store := func(e *Entry) error {
+		upstream[e.Key()] = e
+		return nil
+	}
+	for _, through := range []bool{false, true} {
+		name := "direct"
+		if through {
+			name = "write-through"
+		}
+		b.Run(name, func(b *testing.B) {
+			dir, err := ioutil.TempDir("", "test-db")
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			db, err := NewDb(dir)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer db.Close()
+
+			for i := 0; i < b.N; i++ {
+				key := fmt.Sprintf("key%d", i%1000)
+				e := &Entry{valueType: STRING_TYPE, value: "value"}
+				if through {
+					err = db.WriteThrough(key, e, store)
+				} else {
+					e.key = key
+					err = db.putEntry(e)
+				}
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
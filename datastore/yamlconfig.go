@@ -0,0 +1,281 @@
+package datastore
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Бібліотеки YAML у дереві немає, тож читаємо лише ту підмножину, якої
+// досить для конфігів: список entries з відображень key/type/value у
+// блоковому або потоковому стилі, скаляри без лапок, в одинарних чи
+// подвійних лапках і коментарі. Якорі, теги та багаторядкові скаляри
+// відкидаються з помилкою.
+
+func namespacedKey(namespace, key string) string {
+	if namespace == "" {
+		return key
+	}
+	return namespace + ":" + key
+}
+
+// ImportYAML writes the entries listed in the YAML file at yamlPath into db,
+// prefixing each key with "namespace:". The whole file is parsed before the
+// first write.
+func ImportYAML(yamlPath string, db *Db, namespace string) error {
+	data, err := os.ReadFile(yamlPath)
+	if err != nil {
+		return err
+	}
+	records, err := parseYAMLEntries(string(data))
+	if err != nil {
+		return fmt.Errorf("%s: %v", yamlPath, err)
+	}
+	for _, record := range records {
+		if err := importRecord(db, namespacedKey(namespace, record.Key), record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportYAML writes the keys of namespace to the file dst in the format read
+// by ImportYAML, with the namespace prefix removed.
+func ExportYAML(db *Db, namespace string, dst string) error {
+	entries, err := db.entries()
+	if err != nil {
+		return err
+	}
+	prefix := namespacedKey(namespace, "")
+	var sb strings.Builder
+	for _, e := range entries {
+		if !strings.HasPrefix(e.key, prefix) {
+			continue
+		}
+		fmt.Fprintf(&sb, "  - key: %s\n    type: %s\n", strconv.Quote(e.key[len(prefix):]), e.Type())
+		if utf8.ValidString(e.value) {
+			fmt.Fprintf(&sb, "    value: %s\n", strconv.Quote(e.value))
+		} else {
+			fmt.Fprintf(&sb, "    value_base64: %s\n", base64.StdEncoding.EncodeToString([]byte(e.value)))
+		}
+	}
+	if sb.Len() == 0 {
+		return os.WriteFile(dst, []byte("entries: []\n"), 0o644)
+	}
+	return os.WriteFile(dst, []byte("entries:\n"+sb.String()), 0o644)
+}
+
+// outsideYAMLQuotes викликає fn для кожного байта поза лапками, доки fn
+// повертає true; лапки відкривають рядок лише на початку скаляра, тож
+// апостроф у don't лапкою не вважається
+func outsideYAMLQuotes(s string, fn func(i int) bool) {
+	var quote, last byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '"':
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				quote, last = 0, c
+			}
+		case quote == '\'':
+			if c == '\'' && i+1 < len(s) && s[i+1] == '\'' {
+				i++
+			} else if c == '\'' {
+				quote, last = 0, c
+			}
+		case (c == '"' || c == '\'') && (last == 0 || strings.IndexByte(":-{,", last) >= 0):
+			quote = c
+		default:
+			if !fn(i) {
+				return
+			}
+			if c != ' ' && c != '\t' {
+				last = c
+			}
+		}
+	}
+}
+
+func stripYAMLComment(line string) string {
+	end := len(line)
+	outsideYAMLQuotes(line, func(i int) bool {
+		if line[i] == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t') {
+			end = i
+			return false
+		}
+		return true
+	})
+	return strings.TrimRight(line[:end], " \t")
+}
+
+func parseYAMLScalar(s string) (string, error) {
+	switch {
+	case s == "" || s == "~" || s == "null" || s == "Null" || s == "NULL":
+		return "", nil
+	case s[0] == '"':
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return "", fmt.Errorf("bad double-quoted scalar %s", s)
+		}
+		return v, nil
+	case s[0] == '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' || strings.Count(s[1:len(s)-1], "'")%2 != 0 {
+			return "", fmt.Errorf("bad single-quoted scalar %s", s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case strings.IndexByte("|>&*!%@`[{", s[0]) >= 0:
+		return "", fmt.Errorf("unsupported YAML scalar %s", s)
+	}
+	return s, nil
+}
+
+func splitYAMLPair(s string) (string, string, error) {
+	colon := -1
+	outsideYAMLQuotes(s, func(i int) bool {
+		if s[i] == ':' && (i+1 == len(s) || s[i+1] == ' ') {
+			colon = i
+			return false
+		}
+		return true
+	})
+	if colon < 0 {
+		return "", "", fmt.Errorf("expected key: value, got %q", s)
+	}
+	key, err := parseYAMLScalar(strings.TrimSpace(s[:colon]))
+	if err != nil {
+		return "", "", err
+	}
+	value, err := parseYAMLScalar(strings.TrimSpace(s[colon+1:]))
+	return key, value, err
+}
+
+func setYAMLField(record *exportRecord, pair string) error {
+	k, v, err := splitYAMLPair(pair)
+	if err != nil {
+		return err
+	}
+	return setRecordField(record, k, v)
+}
+
+func setRecordField(record *exportRecord, k, v string) error {
+	switch k {
+	case "key":
+		record.Key = v
+	case "type":
+		record.Type = v
+	case "value":
+		record.Value = v
+	case "value_base64":
+		record.ValueBase64 = v
+	default:
+		return fmt.Errorf("unknown entry field %q", k)
+	}
+	return nil
+}
+
+func setYAMLFlowFields(record *exportRecord, flow string) error {
+	if !strings.HasSuffix(flow, "}") {
+		return fmt.Errorf("unterminated flow mapping %q", flow)
+	}
+	inner := flow[1 : len(flow)-1]
+	start := 0
+	var pairs []string
+	outsideYAMLQuotes(inner, func(i int) bool {
+		if inner[i] == ',' {
+			pairs = append(pairs, inner[start:i])
+			start = i + 1
+		}
+		return true
+	})
+	pairs = append(pairs, inner[start:])
+	for i, pair := range pairs {
+		pair = strings.TrimSpace(pair)
+		if pair == "" && i == len(pairs)-1 {
+			continue
+		}
+		if err := setYAMLField(record, pair); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseYAMLEntries(src string) ([]exportRecord, error) {
+	var (
+		records   []exportRecord
+		cur       *exportRecord
+		inEntries bool
+		//відступ "-" елементів і полів поточного елемента; -1 ще не відомий
+		itemIndent, fieldIndent = -1, -1
+		//після потокового {...} елемент уже повний
+		flowItem bool
+	)
+	for n, line := range strings.Split(src, "\n") {
+		text := stripYAMLComment(strings.TrimRight(line, "\r"))
+		body := strings.TrimLeft(text, " ")
+		indent := len(text) - len(body)
+		if body == "" || (indent == 0 && (body == "---" || body == "...")) {
+			continue
+		}
+		if body[0] == '\t' {
+			return nil, fmt.Errorf("line %d: tabs are not allowed in indentation", n+1)
+		}
+		isItem := body == "-" || strings.HasPrefix(body, "- ")
+
+		var err error
+		switch {
+		case indent == 0 && !isItem:
+			if body != "entries:" && strings.ReplaceAll(body, " ", "") != "entries:[]" {
+				err = fmt.Errorf("expected entries: at the top level, got %q", body)
+			}
+			inEntries = true
+		case !inEntries:
+			err = fmt.Errorf("expected entries:")
+		case isItem:
+			if itemIndent < 0 {
+				itemIndent = indent
+			}
+			if indent != itemIndent {
+				err = fmt.Errorf("bad list indentation")
+				break
+			}
+			if cur != nil {
+				records = append(records, *cur)
+			}
+			cur, fieldIndent, flowItem = &exportRecord{}, -1, false
+			rest := strings.TrimLeft(body[1:], " ")
+			switch {
+			case rest == "":
+			case rest[0] == '{':
+				flowItem = true
+				err = setYAMLFlowFields(cur, rest)
+			default:
+				fieldIndent = indent + len(body) - len(rest)
+				err = setYAMLField(cur, rest)
+			}
+		case cur == nil || flowItem || indent <= itemIndent:
+			err = fmt.Errorf("unexpected %q", body)
+		default:
+			if fieldIndent < 0 {
+				fieldIndent = indent
+			}
+			if indent != fieldIndent {
+				err = fmt.Errorf("bad field indentation")
+				break
+			}
+			err = setYAMLField(cur, body)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n+1, err)
+		}
+	}
+	if cur != nil {
+		records = append(records, *cur)
+	}
+	return records, nil
+}
@@ -0,0 +1,137 @@
+package datastore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testConfigYAML = `# конфіг сервісу оплат
+---
+entries:
+  - key: name
+    type: string
+    value: payments # коментар після значення
+  - key: greeting
+    type: string
+    value: 'it''s #1: "ok"'
+  - {key: motd, type: string, value: "line one\nline two"}
+  - key: workers
+    type: int64
+    value: 16
+  - key: price
+    type: decimal
+    value: "19.99"
+  - key: instance
+    type: uuid
+    value: 123e4567-e89b-12d3-a456-426614174000
+  - key: launched
+    type: timestamp
+    value: 2024-05-01T12:30:00Z
+  -
+    key: timeout
+    type: duration
+    value: 1m30s
+  - key: office
+    type: geo
+    value: "50.4501,30.5234"
+  - {key: gateway, type: ipaddr, value: 10.0.0.1}
+`
+
+func TestImportExportYAML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := NewDb(filepath.Join(dir, "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	config := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(config, []byte(testConfigYAML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put("unrelated", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportYAML(config, db, "prod"); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{
+		"name":     "payments",
+		"greeting": `it's #1: "ok"`,
+		"motd":     "line one\nline two",
+		"workers":  "16",
+		"timeout":  "1m30s",
+		"gateway":  "10.0.0.1",
+	} {
+		e, err := db.getEntry("prod:" + key)
+		if err != nil || e.value != want {
+			t.Errorf("Bad value of %s: expected %q, got %v, %v", key, want, e, err)
+		}
+	}
+	if e, _ := db.getEntry("prod:timeout"); e.valueType != DURATION_TYPE {
+		t.Errorf("Expected prod:timeout to be a duration, got %s", e.Type())
+	}
+
+	//бінарне значення переноситься через value_base64
+	if err := db.putEntry(&Entry{key: "prod:tags", valueType: GSET_TYPE, value: encodeStringSet([]string{"a", "b"})}); err != nil {
+		t.Fatal(err)
+	}
+	exported := filepath.Join(dir, "export.yaml")
+	if err := ExportYAML(db, "prod", exported); err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportYAML(exported, db, "staging"); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := db.entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prod, staging := map[string]Entry{}, map[string]Entry{}
+	for _, e := range entries {
+		if key := strings.TrimPrefix(e.key, "prod:"); key != e.key {
+			e.key = key
+			prod[key] = *e
+		} else if key := strings.TrimPrefix(e.key, "staging:"); key != e.key {
+			e.key = key
+			staging[key] = *e
+		}
+	}
+	if len(prod) != 11 || len(staging) != len(prod) {
+		t.Fatalf("Expected 11 entries in both namespaces, got %d and %d", len(prod), len(staging))
+	}
+	for key, e := range prod {
+		if staging[key] != e {
+			t.Errorf("Bad round trip of %s: expected %v, got %v", key, e, staging[key])
+		}
+	}
+
+	if err := ExportYAML(db, "empty", exported); err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportYAML(exported, db, "empty"); err != nil {
+		t.Errorf("Expected an empty export to import, got %v", err)
+	}
+
+	for _, bad := range []string{
+		"entries:\n  - key: a\n    type: nosuchtype\n    value: b\n",
+		"entries:\n  - key: a\n    colour: red\n",
+		"entries:\n  - key: a\n      type: string\n",
+		"entries:\n  - key: &anchor a\n",
+		"settings:\n  - key: a\n",
+	} {
+		if err := os.WriteFile(config, []byte(bad), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := ImportYAML(config, db, "bad"); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+#!/bin/sh
+# Runs mutation testing against the record encoding code with go-mutesting
+# (go install github.com/zimmski/go-mutesting/cmd/go-mutesting@latest).
+# The targets are the size and offset arithmetic in entry.go (encodeKeyInto,
+# appendKey, the operators' Encode/Decode), entry_unsafe.go and the record
+# validation in decode.go; every surviving mutant is printed with its diff.
+set -e
+# datastore has no go.mod, so build it in GOPATH mode
+export GO111MODULE=off
+
+cd "$(dirname "$0")/datastore"
+exec go-mutesting --verbose "$@" entry.go entry_unsafe.go decode.go